@@ -27,6 +27,8 @@ var accessCmd = &cobra.Command{
 		role, _ := cmd.Flags().GetString("role")
 		duration, _ := cmd.Flags().GetString("duration")
 		reason, _ := cmd.Flags().GetString("reason")
+		breakGlass, _ := cmd.Flags().GetBool("break-glass")
+		params, _ := cmd.Flags().GetStringToString("param")
 
 		if len(resource) == 0 || len(role) == 0 || len(duration) == 0 || len(reason) == 0 {
 			fmt.Println("Error: --resource, --role, --duration, and --reason are required")
@@ -41,12 +43,22 @@ var accessCmd = &cobra.Command{
 			return
 		}
 
+		// Check templated roles locally before sending the request
+		if foundRole.IsTemplate() {
+			if _, err := foundRole.ApplyParameters(params); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+		}
+
 		err = MakeElevationRequest(&models.ElevateRequest{
 			Role:      foundRole,
 			Providers: []string{resource},
 			// Let the system pick the workflow based on role and provider
-			Reason:   reason,
-			Duration: duration,
+			Reason:     reason,
+			Duration:   duration,
+			BreakGlass: breakGlass,
+			Params:     params,
 		})
 
 		if err != nil {
@@ -66,5 +78,6 @@ func init() {
 	accessCmd.Flags().StringP("role", "o", "", "Role to assume (e.g., analyst, admin, readonly)")
 	accessCmd.Flags().StringP("duration", "d", "", "Duration of access (e.g., 1h, 4h, 8h)")
 	accessCmd.Flags().StringP("reason", "e", "", "Reason for access request (e.g., 'Need access for analysis')")
+	accessCmd.Flags().StringToStringP("param", "p", nil, "Parameters for templated roles (e.g., --param project=billing-prod)")
 
 }
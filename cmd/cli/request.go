@@ -45,6 +45,18 @@ var requestCmd = &cobra.Command{
 			return
 		}
 
+		breakGlass, _ := cmd.Flags().GetBool("break-glass")
+
+		// Break-glass requests are for a known role so skip generating one
+		if breakGlass {
+			role, _ := cmd.Flags().GetString("role")
+
+			if err := makeBreakGlassRequest(role, reason); err != nil {
+				fmt.Println(errorStyle.Render(err.Error()))
+			}
+			return
+		}
+
 		// This is an AI request so lets call the login server to generate our role
 
 		fmt.Println(successStyle.Render("Generating request .."))
@@ -118,6 +130,37 @@ var requestCmd = &cobra.Command{
 	},
 }
 
+// makeBreakGlassRequest requests emergency access to a break-glass role.
+// The server caps the duration, pages the on-call notifier and opens a
+// review of the elevation.
+func makeBreakGlassRequest(role string, reason string) error {
+
+	if len(role) == 0 {
+		return fmt.Errorf("--role is required with --break-glass")
+	}
+
+	foundRole, err := cfg.GetRoleByName(role)
+
+	if err != nil {
+		return err
+	}
+
+	if !foundRole.BreakGlass {
+		return fmt.Errorf("role %s is not a break-glass role", role)
+	}
+
+	fmt.Println(warningStyle.Render(
+		fmt.Sprintf("Requesting break-glass access to %s. This will be paged and reviewed.", foundRole.Name)))
+
+	return MakeElevationRequest(&models.ElevateRequest{
+		Role:       foundRole,
+		Providers:  foundRole.Providers,
+		Reason:     reason,
+		Duration:   "1h", // The server caps break-glass durations
+		BreakGlass: true,
+	})
+}
+
 func MakeElevationRequest(request *models.ElevateRequest) error {
 
 	if err := validateElevationRequest(request); err != nil {
@@ -366,4 +409,7 @@ func init() {
 	// Add subcommands
 	rootCmd.AddCommand(requestCmd) // Request without access uses the LLM to figure out the role
 
+	requestCmd.PersistentFlags().Bool("break-glass", false, "Request emergency access to a break-glass role. It skips approval and is reviewed afterwards")
+	requestCmd.Flags().StringP("role", "o", "", "Break-glass role to request (e.g., prod-emergency)")
+
 }
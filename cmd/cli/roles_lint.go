@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thand-io/agent/internal/config"
+)
+
+var rolesLintCmd = &cobra.Command{
+	Use:   "lint [paths...]",
+	Short: "Check role files for problems",
+	Long: `Check role files against the configured providers and workflows and the
+embedded permission catalogs, without a running server. Roles are read from
+the given files or directories, or from the configuration if none are given.
+
+Exits with a non-zero status if any errors are found, or warnings when --strict is set.`,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE:          runRolesLint,
+}
+
+func runRolesLint(cmd *cobra.Command, args []string) error {
+
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return fmt.Errorf("failed to get strict flag: %w", err)
+	}
+
+	report, err := cfg.LintRoles(args...)
+	if err != nil {
+		return fmt.Errorf("failed to lint roles: %w", err)
+	}
+
+	displayRolesLintReport(report)
+
+	if report.HasErrors() {
+		return fmt.Errorf("found %d errors in roles", report.Count(config.RoleLintError))
+	}
+
+	if strict && report.Count(config.RoleLintWarning) > 0 {
+		return fmt.Errorf("found %d warnings in roles", report.Count(config.RoleLintWarning))
+	}
+
+	return nil
+}
+
+func displayRolesLintReport(report *config.RoleLintReport) {
+
+	role := ""
+
+	for _, finding := range report.Findings {
+
+		if finding.Role != role {
+			if len(role) > 0 {
+				fmt.Println()
+			}
+			role = finding.Role
+			fmt.Println(headerStyle.Render(role))
+		}
+
+		switch finding.Severity {
+		case config.RoleLintError:
+			fmt.Printf("  %s %s\n", errorStyle.Render("error"), finding.Message)
+		case config.RoleLintWarning:
+			fmt.Printf("  %s %s\n", warningStyle.Render("warning"), finding.Message)
+		default:
+			fmt.Printf("  %s %s\n", infoStyle.Render("info"), finding.Message)
+		}
+	}
+
+	if len(report.Findings) > 0 {
+		fmt.Println()
+	}
+
+	fmt.Printf("Checked %d roles: %d errors, %d warnings\n",
+		report.Roles,
+		report.Count(config.RoleLintError),
+		report.Count(config.RoleLintWarning),
+	)
+}
+
+func init() {
+	rolesLintCmd.Flags().Bool("strict", false, "Treat warnings as errors")
+
+	rolesCmd.AddCommand(rolesLintCmd)
+}
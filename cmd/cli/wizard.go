@@ -45,14 +45,23 @@ func RunRequestWizard(config *config.Config) (*models.ElevateRequest, error) {
 
 	data.Role = foundRole
 
-	// Step 3: Select Duration
+	// Step 3: Fill in any role parameters
+	if foundRole.IsTemplate() {
+		params, err := selectParameters(foundRole)
+		if err != nil {
+			return nil, err
+		}
+		data.Params = params
+	}
+
+	// Step 4: Select Duration
 	duration, err := selectDuration()
 	if err != nil {
 		return nil, err
 	}
 	data.Duration = duration
 
-	// Step 4: Enter Reason
+	// Step 5: Enter Reason
 	reason, err := selectReason()
 	if err != nil {
 		return nil, err
@@ -151,7 +160,7 @@ func getProviderOptions(config *config.Config) []huh.Option[string] {
 			if !role.Enabled {
 				continue
 			}
-			if slices.Contains(role.Providers, providerKey) {
+			if slices.Contains(role.Providers, providerKey) || role.MatchesTemplatedProvider(providerKey) {
 				hasRoles = true
 			}
 			if hasRoles {
@@ -190,7 +199,7 @@ func getRoleOptionsForProvider(config *config.Config, providerKey string) []huh.
 		}
 
 		// Check if this role supports the selected provider
-		if slices.Contains(role.Providers, providerKey) {
+		if slices.Contains(role.Providers, providerKey) || role.MatchesTemplatedProvider(providerKey) {
 			// Build display name with inheritance info
 			displayName := buildRoleDisplayName(role, config.Roles.Definitions)
 
@@ -234,6 +243,52 @@ func buildRoleDisplayName(role models.Role, allRoles map[string]models.Role) str
 	return name
 }
 
+// selectParameters prompts for each parameter of a templated role
+func selectParameters(role *models.Role) (map[string]string, error) {
+
+	params := map[string]string{}
+
+	for _, name := range role.GetParameterNames() {
+
+		parameter := role.Parameters[name]
+		value := parameter.Default
+
+		description := parameter.Description
+		if len(parameter.Default) > 0 {
+			description = fmt.Sprintf("%s (default: %s)", description, parameter.Default)
+		}
+
+		var field huh.Field
+
+		if len(parameter.Allowed) > 0 {
+			field = huh.NewSelect[string]().
+				Title(fmt.Sprintf("Select %s:", name)).
+				Description(description).
+				Options(huh.NewOptions(parameter.Allowed...)...).
+				Value(&value)
+		} else {
+			field = huh.NewInput().
+				Title(fmt.Sprintf("Enter %s:", name)).
+				Description(description).
+				Value(&value).
+				Validate(func(val string) error {
+					if len(val) == 0 && len(parameter.Default) > 0 {
+						return nil
+					}
+					return parameter.ValidateParameterValue(val)
+				})
+		}
+
+		if err := huh.NewForm(huh.NewGroup(field)).Run(); err != nil {
+			return nil, fmt.Errorf("parameter input cancelled: %w", err)
+		}
+
+		params[name] = value
+	}
+
+	return params, nil
+}
+
 // selectDuration prompts for duration selection
 func selectDuration() (string, error) {
 	durationOptions := []huh.Option[string]{
@@ -343,6 +398,9 @@ func displaySummary(data *models.ElevateRequest) {
 
 	fmt.Printf("Providers: %s\n", data.Providers)
 	fmt.Printf("Role: %s\n", data.Role.Name)
+	for _, name := range data.Role.GetParameterNames() {
+		fmt.Printf("  %s: %s\n", name, data.Params[name])
+	}
 	fmt.Printf("Duration: %s\n", data.Duration)
 	fmt.Printf("Reason: %s\n", data.Reason)
 	fmt.Println()
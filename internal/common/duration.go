@@ -6,6 +6,7 @@ import (
 	"time"
 
 	iso8601 "github.com/senseyeio/duration"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
 )
 
 func ValidateDuration(duration string) (time.Duration, error) {
@@ -33,3 +34,22 @@ func validateDuration(duration string) (time.Duration, error) {
 
 	return 0, fmt.Errorf("invalid duration format: %s. Expect ISO 8601 or duration string", duration)
 }
+
+// ParseWorkflowDuration converts an inline or ISO 8601 workflow duration.
+// Unlike ValidateDuration there is no minimum.
+func ParseWorkflowDuration(duration *model.Duration) (time.Duration, error) {
+
+	if duration == nil {
+		return 0, fmt.Errorf("duration is nil")
+	}
+
+	if inline := duration.AsInline(); inline != nil {
+		return time.Duration(inline.Days)*24*time.Hour +
+			time.Duration(inline.Hours)*time.Hour +
+			time.Duration(inline.Minutes)*time.Minute +
+			time.Duration(inline.Seconds)*time.Second +
+			time.Duration(inline.Milliseconds)*time.Millisecond, nil
+	}
+
+	return validateDuration(duration.AsExpression())
+}
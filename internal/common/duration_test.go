@@ -3,6 +3,8 @@ package common
 import (
 	"testing"
 	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
 )
 
 func TestValidateDuration(t *testing.T) {
@@ -311,3 +313,20 @@ func BenchmarkValidateDurationInvalid(b *testing.B) {
 		_, _ = ValidateDuration("invalid")
 	}
 }
+
+func TestParseWorkflowDuration(t *testing.T) {
+
+	inline := &model.Duration{Value: model.DurationInline{Minutes: 1, Seconds: 30}}
+	if got, err := ParseWorkflowDuration(inline); err != nil || got != 90*time.Second {
+		t.Errorf("ParseWorkflowDuration(inline) = %v, %v", got, err)
+	}
+
+	expression := model.NewDurationExpr("PT5S")
+	if got, err := ParseWorkflowDuration(expression); err != nil || got != 5*time.Second {
+		t.Errorf("ParseWorkflowDuration(expression) = %v, %v", got, err)
+	}
+
+	if _, err := ParseWorkflowDuration(nil); err == nil {
+		t.Error("expected a nil duration to fail")
+	}
+}
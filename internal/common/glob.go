@@ -0,0 +1,52 @@
+package common
+
+import "strings"
+
+// MatchGlob reports whether value matches the glob pattern. A '*' matches
+// any run of characters, including separators such as '/' or ':', and a
+// '?' matches exactly one character. All other characters match literally.
+func MatchGlob(pattern, value string) bool {
+
+	p, v := []rune(pattern), []rune(value)
+
+	// Position to resume from after the last '*' when a match fails
+	starP, starV := -1, 0
+
+	pi, vi := 0, 0
+
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == v[vi]):
+			pi++
+			vi++
+		case pi < len(p) && p[pi] == '*':
+			starP, starV = pi, vi
+			pi++
+		case starP >= 0:
+			// Let the last '*' consume one more character
+			starV++
+			pi, vi = starP+1, starV
+		default:
+			return false
+		}
+	}
+
+	// Any trailing '*' can match the empty string
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+
+	return pi == len(p)
+}
+
+// MatchAnyGlob reports whether value matches any of the glob patterns,
+// ignoring case
+func MatchAnyGlob(patterns []string, value string) bool {
+	value = strings.ToLower(value)
+	for _, pattern := range patterns {
+		if MatchGlob(strings.ToLower(pattern), value) {
+			return true
+		}
+	}
+	return false
+}
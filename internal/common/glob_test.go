@@ -0,0 +1,43 @@
+package common
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+
+	tests := []struct {
+		pattern  string
+		value    string
+		expected bool
+	}{
+		{pattern: "alice@example.com", value: "alice@example.com", expected: true},
+		{pattern: "*@example.com", value: "alice@example.com", expected: true},
+		{pattern: "*@example.com", value: "alice@example.org", expected: false},
+		{pattern: "eng-*", value: "eng-platform", expected: true},
+		{pattern: "acme/*", value: "acme/platform/oncall", expected: true},
+		{pattern: "ec2:Describe*", value: "ec2:DescribeInstances", expected: true},
+		{pattern: "ec2:*Instances", value: "ec2:DescribeInstances", expected: true},
+		{pattern: "ec2:*Instances", value: "ec2:DescribeImages", expected: false},
+		{pattern: "user?", value: "user1", expected: true},
+		{pattern: "user?", value: "user12", expected: false},
+		{pattern: "*", value: "", expected: true},
+		{pattern: "a*b*c", value: "aXXbYYc", expected: true},
+		{pattern: "a*b*c", value: "aXXbYY", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"_"+tt.value, func(t *testing.T) {
+			if got := MatchGlob(tt.pattern, tt.value); got != tt.expected {
+				t.Errorf("MatchGlob(%q, %q) = %v, expected %v", tt.pattern, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestMatchAnyGlob_IgnoresCase(t *testing.T) {
+	if !MatchAnyGlob([]string{"intern-*", "*@Example.com"}, "Alice@example.COM") {
+		t.Error("expected case insensitive match")
+	}
+	if MatchAnyGlob(nil, "alice@example.com") {
+		t.Error("expected no match for empty patterns")
+	}
+}
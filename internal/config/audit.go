@@ -0,0 +1,27 @@
+package config
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/models"
+)
+
+// RecordAuditEvent writes an audit event to the log. Audit entries are
+// always logged at warning level so they are kept whatever the log level.
+func (c *Config) RecordAuditEvent(event models.AuditEvent) {
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"audit":       true,
+		"audit_type":  event.Type,
+		"audit_time":  event.Time,
+		"user":        event.User,
+		"role":        event.Role,
+		"workflow_id": event.WorkflowID,
+		"details":     event.Details,
+	}).Warnln(event.Message)
+}
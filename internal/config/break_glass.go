@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/models"
+)
+
+// PrepareBreakGlass applies the break-glass policy to a request for a
+// break-glass role. The duration is capped, the break-glass workflow is
+// used if one is configured, a review deadline is set and the notifier
+// is paged straight away.
+func (c *Config) PrepareBreakGlass(ctx context.Context, request *models.ElevateRequest, user *models.User) error {
+
+	if request.Role == nil || !request.Role.BreakGlass {
+		return fmt.Errorf("break-glass can only be used with a break-glass role")
+	}
+
+	maxDuration := c.GetBreakGlassMaxDuration()
+
+	if duration, err := request.AsDuration(); err != nil || duration > maxDuration {
+		request.Duration = maxDuration.String()
+	}
+
+	if len(c.BreakGlass.Workflow) > 0 {
+		request.Workflow = c.BreakGlass.Workflow
+	}
+
+	reviewWithin := c.GetBreakGlassReviewWithin()
+
+	if request.Metadata == nil {
+		request.Metadata = &models.ElevateRequestMetadata{}
+	}
+
+	reviewDueAt := time.Now().UTC().Add(reviewWithin)
+	request.Metadata.ReviewDueAt = &reviewDueAt
+
+	userEmail := ""
+	if user != nil {
+		userEmail = user.Email
+	}
+
+	message := fmt.Sprintf(
+		"Break-glass access to role %s was used by %s for %s. Reason: %s. It must be reviewed by %s.",
+		request.Role.Name, userEmail, request.Duration, request.Reason, reviewDueAt.Format(time.RFC3339))
+
+	c.RecordAuditEvent(models.AuditEvent{
+		Type:    models.AuditEventBreakGlass,
+		User:    userEmail,
+		Role:    request.Role.Name,
+		Message: message,
+		Details: map[string]any{
+			"providers":     request.Providers,
+			"duration":      request.Duration,
+			"reason":        request.Reason,
+			"review_due_at": reviewDueAt,
+		},
+	})
+
+	// Failing to page must not block emergency access
+	if err := c.pageBreakGlass(ctx, message); err != nil {
+		logrus.WithError(err).Errorln("Failed to page break-glass notifier")
+	}
+
+	return nil
+}
+
+// GetBreakGlassMaxDuration returns the longest a break-glass elevation can last
+func (c *Config) GetBreakGlassMaxDuration() time.Duration {
+	if c.BreakGlass.MaxDuration <= 0 {
+		return time.Hour
+	}
+	return c.BreakGlass.MaxDuration
+}
+
+// GetBreakGlassReviewWithin returns how long a break-glass elevation has
+// to be reviewed
+func (c *Config) GetBreakGlassReviewWithin() time.Duration {
+	if c.BreakGlass.ReviewWithin <= 0 {
+		return 24 * time.Hour
+	}
+	return c.BreakGlass.ReviewWithin
+}
+
+// ValidateBreakGlass checks a request for a break-glass role still has
+// the policy PrepareBreakGlass applied, however the workflow was started
+func (c *Config) ValidateBreakGlass(request *models.ElevateRequestInternal) error {
+
+	if request.Role == nil {
+		return nil
+	}
+
+	if request.BreakGlass != request.Role.BreakGlass {
+		return fmt.Errorf("role %s can only be requested with break-glass set to %t",
+			request.Role.Name, request.Role.BreakGlass)
+	}
+
+	if !request.BreakGlass {
+		return nil
+	}
+
+	// Only configured roles can be break-glass roles, never one the
+	// requester submitted
+	configured, err := c.GetRoleByName(request.Role.Name)
+	if err != nil || !configured.BreakGlass {
+		return fmt.Errorf("role %s is not a configured break-glass role", request.Role.Name)
+	}
+
+	duration, err := request.AsDuration()
+	if err != nil {
+		return fmt.Errorf("invalid break-glass duration: %w", err)
+	}
+
+	if maxDuration := c.GetBreakGlassMaxDuration(); duration > maxDuration {
+		return fmt.Errorf("break-glass elevation of %s exceeds the maximum of %s", duration, maxDuration)
+	}
+
+	if request.Metadata == nil || request.Metadata.ReviewDueAt == nil {
+		return fmt.Errorf("break-glass elevation has no review deadline")
+	}
+
+	return nil
+}
+
+// pageBreakGlass sends the break-glass message to the configured notifier
+func (c *Config) pageBreakGlass(ctx context.Context, message string) error {
+
+	notifier := c.BreakGlass.Notifier
+
+	if len(notifier.Provider) == 0 {
+		logrus.Warnln("No break-glass notifier is configured, nobody has been paged")
+		return nil
+	}
+
+	provider, err := c.Providers.GetProviderByName(notifier.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to get break-glass notifier: %w", err)
+	}
+
+	if provider.GetClient() == nil {
+		return fmt.Errorf("break-glass notifier %s is not initialized", notifier.Provider)
+	}
+
+	var notification models.NotificationRequest
+
+	switch provider.Provider {
+	case "slack":
+		notification = models.NotificationRequest{
+			"channel": notifier.To,
+			"text":    message,
+		}
+	case "email":
+		notification = models.NotificationRequest{
+			"To":      notifier.To,
+			"Subject": "Break-glass access used",
+			"Body": map[string]any{
+				"Text": message,
+				"HTML": message,
+			},
+		}
+	default:
+		return fmt.Errorf("unsupported break-glass notifier type: %s", provider.Provider)
+	}
+
+	if err := provider.GetClient().SendNotification(ctx, notification); err != nil {
+		return fmt.Errorf("failed to page break-glass notifier: %w", err)
+	}
+
+	return nil
+}
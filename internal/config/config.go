@@ -221,6 +221,10 @@ func readAndUnmarshalConfig(v *viper.Viper) (*Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Roles translate permission sets when they are granted
+	// so they must be known before any role is resolved
+	models.RegisterPermissionSets(config.PermissionSets)
+
 	return &config, nil
 }
 
@@ -268,7 +272,7 @@ func (c *Config) ReloadConfig() error {
 	// Load roles in parallel
 	if c.Roles.IsExternal() {
 		wg.Go(func() {
-			roles, err := c.LoadRoles()
+			roles, declared, err := c.LoadRoles()
 			if err != nil {
 				logrus.WithError(err).Errorln("Error loading roles")
 				mu.Lock()
@@ -278,6 +282,7 @@ func (c *Config) ReloadConfig() error {
 				logrus.Infoln("Loaded roles from external source:", len(roles))
 				mu.Lock()
 				c.Roles.Definitions = roles
+				c.Roles.Declared = declared
 				mu.Unlock()
 			} else {
 				logrus.Warningln("No roles loaded from external source")
@@ -328,6 +333,18 @@ func (c *Config) ReloadConfig() error {
 	// Wait for all goroutines to complete
 	wg.Wait()
 
+	// Roles defined inline in the config still need their inheritance resolved
+	if !c.Roles.IsExternal() && c.Roles.Declared == nil && len(c.Roles.Definitions) > 0 {
+		roles, err := models.FlattenRoles(c.Roles.Definitions)
+		if err != nil {
+			logrus.WithError(err).Errorln("Error resolving role inheritance")
+			errors = append(errors, fmt.Errorf("resolving roles: %w", err))
+		} else {
+			c.Roles.Declared = c.Roles.Definitions
+			c.Roles.Definitions = roles
+		}
+	}
+
 	// Return first error if any occurred
 	if len(errors) > 0 {
 		return errors[0]
@@ -589,6 +606,29 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("logging.format", "json")
 	v.SetDefault("logging.output", "stdout")
 
+	// Break-glass defaults
+	v.SetDefault("break_glass.max_duration", "1h")
+	v.SetDefault("break_glass.review_within", "24h")
+
+	// Script sandbox defaults
+	v.SetDefault("workflows.scripts.timeout", "30s")
+	v.SetDefault("workflows.scripts.memory_limit_mb", 64)
+
+	// Container sandbox defaults
+	v.SetDefault("workflows.containers.timeout", "120s")
+	v.SetDefault("workflows.containers.pull_policy", "ifNotPresent")
+	v.SetDefault("workflows.containers.memory_limit_mb", 512)
+	v.SetDefault("workflows.containers.cpus", 1)
+	v.SetDefault("workflows.containers.pids_limit", 256)
+	v.SetDefault("workflows.containers.network", "bridge")
+
+	// Durable workflow defaults
+	v.SetDefault("workflows.durable.enabled", false)
+	v.SetDefault("workflows.durable.path", "./data/workflows")
+	v.SetDefault("workflows.durable.poll_interval", "10s")
+	v.SetDefault("workflows.durable.min_wait", "1m")
+	v.SetDefault("workflows.durable.concurrency", 10)
+
 	// Where to load in roles and workflows from
 	v.SetDefault("workflows.path", "./examples/workflows") // load any json or yaml files from this directory
 	v.SetDefault("roles.path", "./examples/roles")         // load any json or yaml files from this directory
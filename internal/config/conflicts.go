@@ -0,0 +1,247 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/common"
+	"github.com/thand-io/agent/internal/models"
+	"github.com/thand-io/agent/internal/workflows/durable"
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+// GetConflictingRoles returns the roles that can't be held at the same time
+// as the given role. Conflicts apply both ways, so a role also conflicts with
+// every configured role that lists it in conflicts_with.
+func (c *Config) GetConflictingRoles(role *models.Role) []string {
+
+	if role == nil {
+		return nil
+	}
+
+	conflicts := slices.Clone(role.ConflictsWith)
+
+	for name, configured := range c.Roles.Definitions {
+		if slices.Contains(configured.ConflictsWith, role.Name) && !slices.Contains(conflicts, name) {
+			conflicts = append(conflicts, name)
+		}
+	}
+
+	return conflicts
+}
+
+// ValidateRoleConflicts checks that nobody receiving the role already holds
+// a conflicting role granted by another elevation. Temporal workflows are
+// searched when configured, otherwise the suspended stateless workflows.
+// The workflow making the request is ignored. Violations are recorded as
+// audit events.
+func (c *Config) ValidateRoleConflicts(
+	ctx context.Context,
+	request *models.ElevateRequestInternal,
+	workflowID string,
+) error {
+
+	conflicts := c.GetConflictingRoles(request.Role)
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	// The role is granted to the identities if any were requested
+	holders := request.GetHolders()
+
+	if len(holders) == 0 {
+		return fmt.Errorf("no user or identities to check role conflicts for role %s", request.Role.Name)
+	}
+
+	var activeWorkflows []string
+	var err error
+
+	if temporalService := c.GetServices().GetTemporal(); temporalService != nil && temporalService.HasClient() {
+		activeWorkflows, err = c.listGrantedTemporalElevations(ctx, conflicts, holders, workflowID)
+	} else if c.GetWorkflows().Durable.Enabled {
+		activeWorkflows, err = c.listGrantedSuspendedElevations(conflicts, holders, workflowID)
+	} else {
+		// Without a record of active elevations the check can't pass
+		return fmt.Errorf("role %s has conflicting roles but no temporal or durable workflow store is configured to track active elevations", request.Role.Name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(activeWorkflows) == 0 {
+		return nil
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"role":      request.Role.Name,
+		"conflicts": conflicts,
+		"workflows": activeWorkflows,
+	}).Warnln("Role conflicts with active elevations")
+
+	userEmail := ""
+	if request.User != nil {
+		userEmail = request.User.Email
+	}
+
+	err = fmt.Errorf("role %s conflicts with active elevations for %s: %s",
+		request.Role.Name, strings.Join(conflicts, ", "), strings.Join(activeWorkflows, ", "))
+
+	c.RecordAuditEvent(models.AuditEvent{
+		Type:       models.AuditEventRoleConflict,
+		User:       userEmail,
+		Role:       request.Role.Name,
+		WorkflowID: workflowID,
+		Message:    err.Error(),
+		Details: map[string]any{
+			"holders":          holders,
+			"conflicts":        conflicts,
+			"active_workflows": activeWorkflows,
+		},
+	})
+
+	return err
+}
+
+// listGrantedTemporalElevations returns the running workflows that have
+// granted a conflicting role to any of the holders. Workflows still
+// waiting for approval don't hold the role yet.
+func (c *Config) listGrantedTemporalElevations(
+	ctx context.Context,
+	conflicts []string,
+	holders []string,
+	workflowID string,
+) ([]string, error) {
+
+	temporalService := c.GetServices().GetTemporal()
+
+	query := fmt.Sprintf(
+		"TaskQueue=%s AND ExecutionStatus='Running' AND %s=true AND (%s) AND (%s OR %s IN (%s))",
+		quoteQueryValue(temporalService.GetTaskQueue()),
+		models.TypedSearchAttributeApproved.GetName(),
+		joinQueryValues(models.TypedSearchAttributeRole.GetName(), conflicts),
+		joinQueryValues(models.TypedSearchAttributeUser.GetName(), holders),
+		models.TypedSearchAttributeIdentities.GetName(),
+		strings.Join(quoteQueryValues(holders), ", "),
+	)
+
+	if len(workflowID) > 0 {
+		query = fmt.Sprintf("%s AND WorkflowId!=%s", query, quoteQueryValue(workflowID))
+	}
+
+	activeWorkflows := []string{}
+
+	var nextPageToken []byte
+
+	for {
+
+		resp, err := temporalService.GetClient().ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     temporalService.GetNamespace(),
+			PageSize:      100,
+			NextPageToken: nextPageToken,
+			Query:         query,
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to list active elevations: %w", err)
+		}
+
+		for _, execution := range resp.Executions {
+			activeWorkflows = append(activeWorkflows, execution.GetExecution().GetWorkflowId())
+		}
+
+		nextPageToken = resp.GetNextPageToken()
+
+		if len(nextPageToken) == 0 {
+			return activeWorkflows, nil
+		}
+	}
+}
+
+// listGrantedSuspendedElevations returns the suspended stateless workflows
+// that have granted a conflicting role to any of the holders and not yet
+// revoked it. A granted stateless elevation is suspended until it expires.
+func (c *Config) listGrantedSuspendedElevations(
+	conflicts []string,
+	holders []string,
+	workflowID string,
+) ([]string, error) {
+
+	store, err := durable.NewFileStore(c.GetWorkflows().Durable.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open durable workflow store: %w", err)
+	}
+
+	suspensions, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspended workflows: %w", err)
+	}
+
+	activeWorkflows := []string{}
+
+	for _, suspension := range suspensions {
+
+		if suspension.Workflow == workflowID {
+			continue
+		}
+
+		decoded, err := models.EncodingWrapper{}.DecodeAndDecrypt(
+			suspension.State, c.GetServices().GetEncryption())
+		if err != nil || decoded.Type != models.ENCODED_WORKFLOW_TASK {
+			logrus.WithError(err).WithField("workflow_id", suspension.Workflow).
+				Debug("Skipping suspended workflow that could not be decoded")
+			continue
+		}
+
+		data, ok := decoded.Data.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var workflowTask models.WorkflowTask
+		if err := common.ConvertMapToInterface(data, &workflowTask); err != nil {
+			continue
+		}
+
+		elevation, err := workflowTask.GetContextAsElevationRequest()
+		if err != nil || elevation == nil || elevation.Role == nil {
+			continue
+		}
+
+		if !elevation.IsGranted() || !slices.Contains(conflicts, elevation.Role.Name) {
+			continue
+		}
+
+		if slices.ContainsFunc(elevation.GetHolders(), func(holder string) bool {
+			return slices.Contains(holders, holder)
+		}) {
+			activeWorkflows = append(activeWorkflows, suspension.Workflow)
+		}
+	}
+
+	return activeWorkflows, nil
+}
+
+func joinQueryValues(attribute string, values []string) string {
+	clauses := make([]string, 0, len(values))
+	for _, value := range values {
+		clauses = append(clauses, fmt.Sprintf("%s=%s", attribute, quoteQueryValue(value)))
+	}
+	return strings.Join(clauses, " OR ")
+}
+
+func quoteQueryValues(values []string) []string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, quoteQueryValue(value))
+	}
+	return quoted
+}
+
+func quoteQueryValue(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "\\'") + "'"
+}
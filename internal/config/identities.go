@@ -0,0 +1,268 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/sirupsen/logrus"
+
+	"github.com/thand-io/agent/internal/common"
+	"github.com/thand-io/agent/internal/models"
+)
+
+// identitiesCacheTTL is how long identities listed from the
+// providers are kept before they are fetched again
+const identitiesCacheTTL = 5 * time.Minute
+
+// identitiesCache holds the identities from every identity
+// provider along with an index to search them
+type identitiesCache struct {
+	mu      sync.Mutex
+	current *identitiesSnapshot
+
+	// Held while the identities are reloaded so only one
+	// request lists them from the providers at a time
+	reload sync.Mutex
+
+	// Groups resolved for users whose session carried none
+	groups map[string]cachedGroups
+}
+
+// identitiesSnapshot is one load of the identities and their index.
+// A snapshot is never modified, a reload replaces it and closes the
+// old index once the searches using it have finished.
+type identitiesSnapshot struct {
+	identities []models.IdentityResponse
+	index      bleve.Index
+	loadedAt   time.Time
+	readers    sync.WaitGroup
+}
+
+type cachedGroups struct {
+	groups   []string
+	loadedAt time.Time
+}
+
+// SearchIdentities returns the users and groups across all identity
+// providers matching the query. Each term in the query is matched as
+// a prefix so partial input can be used for autocomplete.
+func (c *Config) SearchIdentities(ctx context.Context, query string) ([]models.IdentityResponse, error) {
+
+	snapshot, err := c.getIdentities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer snapshot.readers.Done()
+
+	filters := identityQueryTerms(query)
+
+	return common.BleveListSearch(ctx, snapshot.index, func(a *search.DocumentMatch, b models.IdentityResponse) bool {
+		return strings.Compare(a.ID, identityDocumentId(b)) == 0
+	}, snapshot.identities, filters...)
+}
+
+// getIdentities returns the cached identities, reloading them from the
+// providers once the cache has expired. The caller must call Done on the
+// snapshot's readers when it has finished searching it.
+func (c *Config) getIdentities(ctx context.Context) (*identitiesSnapshot, error) {
+
+	if snapshot := c.acquireIdentities(false); snapshot != nil {
+		return snapshot, nil
+	}
+
+	// Searches keep using the expired snapshot while another
+	// request is reloading it
+	if !c.identities.reload.TryLock() {
+		if snapshot := c.acquireIdentities(true); snapshot != nil {
+			return snapshot, nil
+		}
+		c.identities.reload.Lock()
+	}
+	defer c.identities.reload.Unlock()
+
+	// Another request may have reloaded while this one waited
+	if snapshot := c.acquireIdentities(false); snapshot != nil {
+		return snapshot, nil
+	}
+
+	// The providers are listed and indexed without holding the cache lock
+	identities := c.listProviderIdentities(ctx)
+
+	mapping := bleve.NewIndexMapping()
+	index, err := bleve.NewMemOnly(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	for _, identity := range identities {
+		if err := index.Index(identityDocumentId(identity), identity); err != nil {
+			index.Close()
+			return nil, fmt.Errorf("failed to index identity %s: %w", identity.ID, err)
+		}
+	}
+
+	snapshot := &identitiesSnapshot{
+		identities: identities,
+		index:      index,
+		loadedAt:   time.Now(),
+	}
+	snapshot.readers.Add(1)
+
+	c.identities.mu.Lock()
+	previous := c.identities.current
+	c.identities.current = snapshot
+	c.identities.mu.Unlock()
+
+	// No new searches can acquire the previous snapshot, close
+	// its index once the ones still running have finished
+	if previous != nil {
+		go func() {
+			previous.readers.Wait()
+			previous.index.Close()
+		}()
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"identities": len(identities),
+	}).Debug("Loaded and indexed identities")
+
+	return snapshot, nil
+}
+
+// acquireIdentities returns the current snapshot registered as being
+// read, or nil if there is none or it has expired and stale is false
+func (c *Config) acquireIdentities(stale bool) *identitiesSnapshot {
+
+	c.identities.mu.Lock()
+	defer c.identities.mu.Unlock()
+
+	snapshot := c.identities.current
+
+	if snapshot == nil || (!stale && time.Since(snapshot.loadedAt) >= identitiesCacheTTL) {
+		return nil
+	}
+
+	snapshot.readers.Add(1)
+
+	return snapshot
+}
+
+// listProviderIdentities lists users and groups from every identity provider.
+// A provider that fails is logged and skipped so the others are still usable.
+func (c *Config) listProviderIdentities(ctx context.Context) []models.IdentityResponse {
+
+	identities := []models.IdentityResponse{}
+
+	for name, provider := range c.GetProvidersByCapability(models.ProviderCapabilityIdentity) {
+
+		client := provider.GetClient()
+
+		users, err := client.ListUsers(ctx)
+		if err != nil {
+			logrus.WithError(err).WithField("provider", name).Warn("Failed to list users")
+		}
+
+		for _, user := range users {
+			identity := models.NewUserIdentity(&user)
+			identity.Provider = name
+			identities = append(identities, identity.AsResponse())
+		}
+
+		groups, err := client.ListGroups(ctx)
+		if err != nil {
+			logrus.WithError(err).WithField("provider", name).Warn("Failed to list groups")
+		}
+
+		for _, group := range groups {
+			identity := models.NewGroupIdentity(&group)
+			identity.Provider = name
+			identities = append(identities, identity.AsResponse())
+		}
+	}
+
+	return identities
+}
+
+// ResolveUserGroups populates the user's groups from the identity providers
+// when their session didn't include any, so role applicability can be
+// checked against them. Groups already on the session are kept as is.
+func (c *Config) ResolveUserGroups(ctx context.Context, user *models.User) {
+
+	if user == nil || len(user.Groups) > 0 {
+		return
+	}
+
+	key := strings.ToLower(user.Email)
+	if len(key) == 0 {
+		key = strings.ToLower(user.Username)
+	}
+	if len(key) == 0 {
+		return
+	}
+
+	c.identities.mu.Lock()
+	cached, ok := c.identities.groups[key]
+	c.identities.mu.Unlock()
+
+	if ok && time.Since(cached.loadedAt) < identitiesCacheTTL {
+		user.Groups = slices.Clone(cached.groups)
+		return
+	}
+
+	groups := []string{}
+
+	for name, provider := range c.GetProvidersByCapability(models.ProviderCapabilityIdentity) {
+
+		found, err := provider.GetClient().GetUser(ctx, key)
+		if err != nil {
+			logrus.WithError(err).WithField("provider", name).Debug("Failed to resolve user groups")
+			continue
+		}
+
+		for _, group := range found.Groups {
+			if !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	c.identities.mu.Lock()
+	if c.identities.groups == nil {
+		c.identities.groups = map[string]cachedGroups{}
+	}
+	c.identities.groups[key] = cachedGroups{
+		groups:   groups,
+		loadedAt: time.Now(),
+	}
+	c.identities.mu.Unlock()
+
+	user.Groups = slices.Clone(groups)
+}
+
+// identityQueryTerms converts free text into prefix queries, requiring
+// every word to match. Punctuation is dropped so input such as an email
+// address or a group: prefix cannot be parsed as query syntax.
+func identityQueryTerms(query string) []string {
+
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, "+"+word+"*")
+	}
+
+	return terms
+}
+
+func identityDocumentId(identity models.IdentityResponse) string {
+	return identity.Provider + "/" + identity.ID
+}
@@ -0,0 +1,465 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/thand-io/agent/internal/models"
+	"github.com/thand-io/agent/internal/providers"
+	"github.com/thand-io/agent/internal/providers/aws"
+	"github.com/thand-io/agent/internal/providers/gcp"
+)
+
+type RoleLintSeverity string
+
+const (
+	RoleLintError   RoleLintSeverity = "error"
+	RoleLintWarning RoleLintSeverity = "warning"
+	RoleLintInfo    RoleLintSeverity = "info"
+)
+
+// RoleLintFinding is a single problem or note about a role
+type RoleLintFinding struct {
+	Role     string           `json:"role"`
+	Severity RoleLintSeverity `json:"severity"`
+	Message  string           `json:"message"`
+}
+
+// RoleLintReport is the result of linting a set of roles
+type RoleLintReport struct {
+	Roles    int               `json:"roles"`
+	Findings []RoleLintFinding `json:"findings"`
+}
+
+// Count returns the number of findings with the given severity
+func (r *RoleLintReport) Count(severity RoleLintSeverity) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// HasErrors returns true if any role has a problem that would stop it
+// from being requested
+func (r *RoleLintReport) HasErrors() bool {
+	return r.Count(RoleLintError) > 0
+}
+
+func (r *RoleLintReport) add(role string, severity RoleLintSeverity, format string, args ...any) {
+	r.Findings = append(r.Findings, RoleLintFinding{
+		Role:     role,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// roleLintCatalog holds the permissions and roles a provider type can grant
+type roleLintCatalog struct {
+	permissions []models.ProviderPermission
+	roles       []models.ProviderRole
+	wildcards   models.PermissionWildcards
+}
+
+// roleLintCatalogs loads the embedded catalogs for each provider type. Only
+// provider types listed here have their permissions checked.
+var roleLintCatalogs = map[string]func() (*roleLintCatalog, error){
+	"aws": func() (*roleLintCatalog, error) {
+		permissions, err := aws.GetAwsPermissions()
+		if err != nil {
+			return nil, err
+		}
+		roles, err := aws.GetAwsRoles()
+		if err != nil {
+			return nil, err
+		}
+		return &roleLintCatalog{permissions: permissions, roles: roles}, nil
+	},
+	"gcp": func() (*roleLintCatalog, error) {
+		permissions, err := gcp.GetGcpPermissionsForStage(gcp.DefaultStage)
+		if err != nil {
+			return nil, err
+		}
+		roles, err := gcp.GetGcpRolesForStage(gcp.DefaultStage)
+		if err != nil {
+			return nil, err
+		}
+		return &roleLintCatalog{permissions: permissions, roles: roles}, nil
+	},
+}
+
+// Permissions that grant far more than a role should
+var roleLintBroadPermissions = []string{"*", "*:*", "iam:*", "iam.*"}
+
+// LintRoles checks roles against the configured providers and workflows
+// and the embedded permission catalogs, without initializing any providers.
+// Roles are loaded from the given paths, or from the configuration if none
+// are given.
+func (c *Config) LintRoles(paths ...string) (*RoleLintReport, error) {
+
+	declared, err := c.loadLintRoles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	providerDefinitions, err := c.loadLintProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	workflows, err := c.loadLintWorkflows()
+	if err != nil {
+		return nil, err
+	}
+
+	linter := &roleLinter{
+		roles:     declared,
+		providers: providerDefinitions,
+		workflows: workflows,
+		catalogs:  map[string]*roleLintCatalog{},
+		report:    &RoleLintReport{Roles: len(declared)},
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(declared)) {
+		if err := linter.lintRole(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return linter.report, nil
+}
+
+func (c *Config) loadLintRoles(paths []string) (map[string]models.Role, error) {
+
+	if len(paths) > 0 {
+
+		foundRoles := []*RoleDefinitions{}
+
+		for _, path := range paths {
+			found, err := loadDataFromSource(path, nil, "", RoleDefinitions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to load roles from %s: %w", path, err)
+			}
+			foundRoles = append(foundRoles, found...)
+		}
+
+		return collectDeclaredRoles(foundRoles), nil
+	}
+
+	if c.Roles.IsExternal() {
+		return c.loadDeclaredRoles()
+	}
+
+	if c.Roles.Declared != nil {
+		return c.Roles.Declared, nil
+	}
+
+	return c.Roles.Definitions, nil
+}
+
+func (c *Config) loadLintProviders() (map[string]models.Provider, error) {
+
+	if !c.Providers.IsExternal() {
+		return c.Providers.Definitions, nil
+	}
+
+	foundProviders, err := c.loadProviderDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	providerDefinitions := map[string]models.Provider{}
+
+	for _, definitions := range foundProviders {
+		for providerKey, provider := range definitions.Providers {
+			if _, exists := providerDefinitions[providerKey]; !exists {
+				providerDefinitions[providerKey] = provider
+			}
+		}
+	}
+
+	return providerDefinitions, nil
+}
+
+func (c *Config) loadLintWorkflows() (map[string]models.Workflow, error) {
+
+	if !c.Workflows.IsExternal() {
+		return c.Workflows.Definitions, nil
+	}
+
+	foundWorkflows, err := c.loadWorkflowDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	workflows := map[string]models.Workflow{}
+
+	for _, definitions := range foundWorkflows {
+		for workflowKey, workflow := range definitions.Workflows {
+			if _, exists := workflows[workflowKey]; !exists {
+				workflows[workflowKey] = workflow
+			}
+		}
+	}
+
+	return workflows, nil
+}
+
+type roleLinter struct {
+	roles     map[string]models.Role
+	providers map[string]models.Provider
+	workflows map[string]models.Workflow
+	catalogs  map[string]*roleLintCatalog
+	report    *RoleLintReport
+}
+
+func (l *roleLinter) lintRole(name string) error {
+
+	declared := l.roles[name]
+
+	role, err := models.ResolveRole(&declared, l.roles)
+	if err != nil {
+		l.report.add(name, RoleLintError, "%v", err)
+		return nil
+	}
+
+	if err := role.ValidateParameters(); err != nil {
+		l.report.add(name, RoleLintError, "%v", err)
+	}
+
+	if err := role.ValidateConditions(); err != nil {
+		l.report.add(name, RoleLintError, "%v", err)
+	}
+
+	// Disabled roles are only linted for what they pass on to other roles
+	if role.Enabled {
+		l.lintWorkflows(name, role)
+	}
+
+	providerTypes := l.lintProviders(name, role)
+
+	translatable := l.lintPermissionSets(name, role, providerTypes)
+
+	for _, providerType := range providerTypes {
+
+		// Permission sets are checked as the provider's own permissions
+		granted := role
+		if translatable {
+			granted, err = role.TranslatePermissionSets(providerType)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := l.lintCatalog(name, granted, providerType); err != nil {
+			return err
+		}
+	}
+
+	for _, permission := range role.Permissions.Allow {
+		if slices.Contains(roleLintBroadPermissions, strings.ToLower(permission)) {
+			l.report.add(name, RoleLintWarning, "permission %s grants broad access", permission)
+		}
+	}
+
+	if slices.Contains(role.Resources.Allow, "*") {
+		l.report.add(name, RoleLintWarning, "resource * grants access to every resource")
+	}
+
+	return nil
+}
+
+func (l *roleLinter) lintWorkflows(name string, role *models.Role) {
+
+	if len(role.Workflows) == 0 {
+		l.report.add(name, RoleLintError, "role has no workflows so it can't be requested")
+		return
+	}
+
+	for _, workflowName := range role.Workflows {
+
+		workflow, exists := l.workflows[workflowName]
+
+		if !exists {
+			l.report.add(name, RoleLintError, "workflow %s does not exist", workflowName)
+		} else if !workflow.Enabled {
+			l.report.add(name, RoleLintError, "workflow %s is disabled", workflowName)
+		}
+	}
+}
+
+// lintProviders checks the role's providers exist and are enabled, and
+// returns the provider types the role's permissions must be granted in
+func (l *roleLinter) lintProviders(name string, role *models.Role) []string {
+
+	if len(role.Providers) == 0 && role.Enabled {
+		l.report.add(name, RoleLintError, "role has no providers so it can't be requested")
+	}
+
+	providerTypes := []string{}
+
+	for _, providerName := range role.Providers {
+
+		// Templated providers are only known at request time
+		if models.IsTemplatedValue(providerName) {
+			continue
+		}
+
+		provider, exists := l.getProvider(providerName)
+
+		if !exists {
+			l.report.add(name, RoleLintError, "provider %s does not exist", providerName)
+			continue
+		}
+
+		if !provider.Enabled {
+			l.report.add(name, RoleLintError, "provider %s is disabled", providerName)
+		}
+
+		providerType := strings.ToLower(provider.Provider)
+
+		if !slices.Contains(providerTypes, providerType) {
+			providerTypes = append(providerTypes, providerType)
+		}
+	}
+
+	// Provider roles are checked against the catalog for their prefix
+	for _, inherit := range role.Inherits {
+
+		prefix, providerRole, _ := strings.Cut(inherit, ":")
+
+		providerType := strings.ToLower(prefix)
+		if provider, exists := l.getProvider(prefix); exists {
+			providerType = strings.ToLower(provider.Provider)
+		}
+
+		catalog, err := l.getCatalog(providerType)
+		if err != nil || catalog == nil || len(catalog.roles) == 0 {
+			continue
+		}
+
+		if !slices.ContainsFunc(catalog.roles, func(r models.ProviderRole) bool {
+			return strings.Compare(r.Name, providerRole) == 0
+		}) {
+			l.report.add(name, RoleLintError, "inherited %s role %s does not exist", providerType, providerRole)
+		}
+	}
+
+	return providerTypes
+}
+
+// lintPermissionSets checks the role's permission sets exist and can be
+// translated for every provider type the role is granted in
+func (l *roleLinter) lintPermissionSets(name string, role *models.Role, providerTypes []string) bool {
+
+	translatable := true
+
+	for _, setName := range role.PermissionSets {
+
+		set, err := models.GetPermissionSet(setName)
+		if err != nil {
+			l.report.add(name, RoleLintError, "%v", err)
+			translatable = false
+			continue
+		}
+
+		for _, providerType := range providerTypes {
+			if !set.SupportsProvider(providerType) {
+				l.report.add(name, RoleLintError, "permission set %s has no permissions for %s", setName, providerType)
+				translatable = false
+			}
+		}
+	}
+
+	return translatable
+}
+
+// lintCatalog checks every permission exists in the provider's catalog and
+// reports how many permissions each wildcard expands to
+func (l *roleLinter) lintCatalog(name string, role *models.Role, providerType string) error {
+
+	if len(role.Permissions.Allow) == 0 && len(role.Permissions.Deny) == 0 {
+		return nil
+	}
+
+	catalog, err := l.getCatalog(providerType)
+	if err != nil {
+		return err
+	}
+
+	if catalog == nil || len(catalog.permissions) == 0 {
+		l.report.add(name, RoleLintInfo, "no permission catalog for %s, permissions were not checked", providerType)
+		return nil
+	}
+
+	for _, permission := range slices.Concat(role.Permissions.Allow, role.Permissions.Deny) {
+
+		// Templated permissions are only known at request time
+		if models.IsTemplatedValue(permission) {
+			continue
+		}
+
+		if models.IsPermissionWildcard(permission) {
+
+			expanded := models.ExpandPermissionsWildcard(catalog.permissions, catalog.wildcards, permission)
+
+			if len(expanded) == 0 {
+				l.report.add(name, RoleLintError, "%s permission %s does not match any permissions", providerType, permission)
+			} else if catalog.wildcards.Native {
+				l.report.add(name, RoleLintInfo, "%s permission %s matches %d permissions and is kept as a wildcard", providerType, permission, len(expanded))
+			} else {
+				l.report.add(name, RoleLintInfo, "%s permission %s expands to %d permissions", providerType, permission, len(expanded))
+			}
+
+			continue
+		}
+
+		if !slices.ContainsFunc(catalog.permissions, func(p models.ProviderPermission) bool {
+			return catalog.wildcards.Match(permission, p.Name)
+		}) {
+			l.report.add(name, RoleLintError, "%s permission %s does not exist", providerType, permission)
+		}
+	}
+
+	return nil
+}
+
+func (l *roleLinter) getProvider(name string) (models.Provider, bool) {
+	for providerKey, provider := range l.providers {
+		if strings.EqualFold(providerKey, name) {
+			return provider, true
+		}
+	}
+	return models.Provider{}, false
+}
+
+func (l *roleLinter) getCatalog(providerType string) (*roleLintCatalog, error) {
+
+	if catalog, exists := l.catalogs[providerType]; exists {
+		return catalog, nil
+	}
+
+	loadCatalog, exists := roleLintCatalogs[providerType]
+	if !exists {
+		l.catalogs[providerType] = nil
+		return nil, nil
+	}
+
+	catalog, err := loadCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s permission catalog: %w", providerType, err)
+	}
+
+	// Permissions are matched the same way the provider matches them
+	if provider, err := providers.Get(providerType); err == nil {
+		catalog.wildcards = provider.GetPermissionWildcards()
+	}
+
+	l.catalogs[providerType] = catalog
+
+	return catalog, nil
+}
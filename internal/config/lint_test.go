@@ -0,0 +1,123 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/thand-io/agent/internal/models"
+)
+
+func TestLintRoles(t *testing.T) {
+
+	cfg := &Config{
+		Roles: RoleConfig{
+			Definitions: map[string]models.Role{
+				"operator": {
+					Name:      "operator",
+					Workflows: []string{"missing_workflow"},
+					Permissions: models.Permissions{
+						Allow: []string{"ec2:DescribeInstances", "ec2:DescribeEverything", "iam:*"},
+					},
+					Providers: []string{"aws-prod", "aws-dev"},
+					Enabled:   true,
+				},
+			},
+		},
+		Providers: ProviderConfig{
+			Definitions: map[string]models.Provider{
+				"aws-prod": {Name: "aws-prod", Provider: "aws", Enabled: true},
+				"aws-dev":  {Name: "aws-dev", Provider: "aws", Enabled: false},
+			},
+		},
+	}
+
+	report, err := cfg.LintRoles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages := []string{}
+	for _, finding := range report.Findings {
+		messages = append(messages, string(finding.Severity)+": "+finding.Message)
+	}
+
+	expected := []string{
+		"error: workflow missing_workflow does not exist",
+		"error: provider aws-dev is disabled",
+		"error: aws permission ec2:DescribeEverything does not exist",
+		"warning: permission iam:* grants broad access",
+	}
+
+	for _, want := range expected {
+		if !slices.Contains(messages, want) {
+			t.Errorf("expected finding %q, got: %v", want, messages)
+		}
+	}
+
+	if !report.HasErrors() {
+		t.Error("expected the report to have errors")
+	}
+}
+
+func TestLintRoles_PermissionSets(t *testing.T) {
+
+	cfg := &Config{
+		Roles: RoleConfig{
+			Definitions: map[string]models.Role{
+				"reader": {
+					Name:           "reader",
+					Workflows:      []string{"approval"},
+					PermissionSets: []string{"storage-read", "logs-read"},
+					Providers:      []string{"gcp-prod"},
+					Enabled:        true,
+				},
+				"broken": {
+					Name:           "broken",
+					Workflows:      []string{"approval"},
+					PermissionSets: []string{"storage-read", "missing-set"},
+					Providers:      []string{"gcp-prod", "github"},
+					Enabled:        true,
+				},
+			},
+		},
+		Workflows: WorkflowConfig{
+			Definitions: map[string]models.Workflow{
+				"approval": {Name: "approval", Enabled: true},
+			},
+		},
+		Providers: ProviderConfig{
+			Definitions: map[string]models.Provider{
+				"gcp-prod": {Name: "gcp-prod", Provider: "gcp", Enabled: true},
+				"github":   {Name: "github", Provider: "github", Enabled: true},
+			},
+		},
+	}
+
+	report, err := cfg.LintRoles()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages := map[string][]string{}
+	for _, finding := range report.Findings {
+		messages[finding.Role] = append(messages[finding.Role], string(finding.Severity)+": "+finding.Message)
+	}
+
+	for _, message := range messages["reader"] {
+		if strings.HasPrefix(message, "error") {
+			t.Errorf("expected the translated sets to be valid, got %q", message)
+		}
+	}
+
+	expected := []string{
+		"error: permission set not found: missing-set",
+		"error: permission set storage-read has no permissions for github",
+	}
+
+	for _, want := range expected {
+		if !slices.Contains(messages["broken"], want) {
+			t.Errorf("expected finding %q, got: %v", want, messages["broken"])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"net/http"
 	"net/url"
 	"slices"
@@ -54,6 +55,12 @@ type Config struct {
 	Workflows WorkflowConfig `mapstructure:"workflows"` // These are workflows to run for role associated workflows
 	Providers ProviderConfig `mapstructure:"providers"` // These are integration providers like AWS, GCP, etc.
 
+	// Emergency access for break-glass roles
+	BreakGlass BreakGlassConfig `mapstructure:"break_glass"`
+
+	// Provider neutral permission sets added to, or replacing, the built in sets
+	PermissionSets map[string]models.PermissionSet `mapstructure:"permission_sets"`
+
 	// Internal mode of operation
 	mode   Mode
 	logger thandLogger
@@ -61,6 +68,9 @@ type Config struct {
 	// Cached services client
 	initializeServiceClientOnce sync.Once
 	servicesClient              models.ServicesClientImpl
+
+	// Cached identities from identity providers
+	identities identitiesCache
 }
 
 func (c *Config) GetSecret() string {
@@ -186,6 +196,18 @@ func (c *Config) HasLargeLanguageModel() bool {
 	return c.GetServices().HasLargeLanguageModel()
 }
 
+type BreakGlassConfig struct {
+	Workflow     string                   `mapstructure:"workflow"`      // Workflow used instead of the role's. It should skip approvals and open a review
+	MaxDuration  time.Duration            `mapstructure:"max_duration"`  // Longest a break-glass elevation can last
+	ReviewWithin time.Duration            `mapstructure:"review_within"` // How long a second person has to review the elevation
+	Notifier     BreakGlassNotifierConfig `mapstructure:"notifier"`      // Who is paged when break-glass access is used
+}
+
+type BreakGlassNotifierConfig struct {
+	Provider string `mapstructure:"provider"`
+	To       string `mapstructure:"to"`
+}
+
 type ServerConfig struct {
 	Host     string             `mapstructure:"host"`
 	Port     int                `mapstructure:"port"`
@@ -240,6 +262,7 @@ type CORSConfig struct {
 type APIConfig struct {
 	Version   string          `mapstructure:"version" default:"v1"`
 	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Events    EventsAPIConfig `mapstructure:"events"`
 }
 
 func (api *APIConfig) GetVersion() string {
@@ -249,6 +272,45 @@ func (api *APIConfig) GetVersion() string {
 	return "v1"
 }
 
+// EventsAPIConfig lists the external systems that can send events to
+// the events endpoint. Events from any other source are rejected.
+type EventsAPIConfig struct {
+	Sources []EventSourceConfig `mapstructure:"sources"`
+}
+
+// EventSourceConfig authenticates the events from a CloudEvents source
+// with a bearer token, an HMAC signature of the request body, or both
+type EventSourceConfig struct {
+	Source          string   `mapstructure:"source"`           // CloudEvents source, a trailing * matches by prefix
+	Token           string   `mapstructure:"token"`            // Bearer token
+	Secret          string   `mapstructure:"secret"`           // HMAC-SHA256 key
+	SignatureHeader string   `mapstructure:"signature_header"` // Header with the sha256=<hex> signature
+	Types           []string `mapstructure:"types"`            // Event types the source can send, required
+}
+
+// GetEventSource returns the first source that matches the event's source
+func (e *EventsAPIConfig) GetEventSource(source string) (*EventSourceConfig, error) {
+
+	for _, eventSource := range e.Sources {
+
+		if prefix, found := strings.CutSuffix(eventSource.Source, "*"); found {
+			if strings.HasPrefix(source, prefix) {
+				return &eventSource, nil
+			}
+		} else if eventSource.Source == source {
+			return &eventSource, nil
+		}
+	}
+
+	return nil, fmt.Errorf("event source not configured: %s", source)
+}
+
+// AllowsType returns whether the source can send events of the type.
+// Sources must list their types, so a source without any sends nothing.
+func (e *EventSourceConfig) AllowsType(eventType string) bool {
+	return len(e.Types) > 0 && matchesEventType(e.Types, eventType)
+}
+
 type RateLimitConfig struct {
 	RequestsPerMinute int `mapstructure:"requests_per_minute"`
 	Burst             int `mapstructure:"burst"`
@@ -261,6 +323,9 @@ type RoleConfig struct {
 
 	// Store everything in memory
 	Definitions map[string]models.Role `mapstructure:",remain"`
+
+	// The roles as declared, before inheritance was flattened
+	Declared map[string]models.Role `mapstructure:"-"`
 }
 
 func (r *RoleConfig) IsExternal() bool {
@@ -274,6 +339,41 @@ func (r *RoleConfig) GetRoleByName(name string) (*models.Role, error) {
 	return nil, fmt.Errorf("role not found: %s", name)
 }
 
+// GetDeclaredRoleByName returns the role as it was declared, before
+// its local inheritance was flattened
+func (r *RoleConfig) GetDeclaredRoleByName(name string) (*models.Role, error) {
+	if role, exists := r.Declared[name]; exists {
+		return &role, nil
+	}
+	return r.GetRoleByName(name)
+}
+
+// ResolveRole flattens the local inheritance of a role that isn't
+// one of the configured roles
+func (r *RoleConfig) ResolveRole(role *models.Role) (*models.Role, error) {
+	declared := r.Declared
+	if declared == nil {
+		declared = r.Definitions
+	}
+	return models.ResolveRole(role, declared)
+}
+
+// ValidateInherits checks the user could request every role inherited by
+// a role that isn't one of the configured roles
+func (r *RoleConfig) ValidateInherits(role *models.Role, user *models.User, authenticator string) error {
+	if role == nil {
+		return nil
+	}
+	if _, err := r.GetRoleByName(role.Name); err == nil {
+		return nil
+	}
+	declared := r.Declared
+	if declared == nil {
+		declared = r.Definitions
+	}
+	return models.ValidateInherits(role, declared, user, authenticator)
+}
+
 type WorkflowConfig struct {
 	Path  string          `mapstructure:"path"`
 	URL   *model.Endpoint `mapstructure:"url"`
@@ -282,6 +382,18 @@ type WorkflowConfig struct {
 	// Load dynamic plugin registry for custom call tools
 	Plugins WorkflowPluginConfig `mapstructure:"plugins"`
 
+	// Limits for run.script tasks
+	Scripts WorkflowScriptConfig `mapstructure:"scripts"`
+
+	// Limits for run.container tasks
+	Containers WorkflowContainerConfig `mapstructure:"containers"`
+
+	// Where emit tasks publish their events
+	Events WorkflowEventsConfig `mapstructure:"events"`
+
+	// Persist waiting workflows when running without Temporal
+	Durable WorkflowDurableConfig `mapstructure:"durable"`
+
 	// Store everything in memory
 	Definitions map[string]models.Workflow `mapstructure:",remain"`
 }
@@ -297,10 +409,134 @@ func (p *WorkflowConfig) GetWorkflowByName(name string) (*models.Workflow, error
 	return nil, fmt.Errorf("workflow not found: %s", name)
 }
 
+// GetWorkflowByReference finds the workflow a run.workflow task refers to.
+// Workflows are matched on their document namespace, name and version,
+// falling back to the name they are configured under within the same
+// namespace. An empty or latest version matches any version. It returns the configured name as well as
+// the workflow.
+func (p *WorkflowConfig) GetWorkflowByReference(namespace, name, version string) (string, *models.Workflow, error) {
+
+	matchesVersion := func(v string) bool {
+		return len(version) == 0 || strings.EqualFold(version, "latest") || v == version
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(p.Definitions)) {
+
+		workflow := p.Definitions[key]
+		definition := workflow.GetWorkflow()
+
+		if definition == nil {
+			continue
+		}
+
+		document := definition.Document
+
+		if document.Name == name && document.Namespace == namespace && matchesVersion(document.Version) {
+			return key, &workflow, nil
+		}
+	}
+
+	if workflow, exists := p.Definitions[name]; exists && workflow.GetWorkflow() != nil {
+		document := workflow.GetWorkflow().Document
+		if document.Namespace == namespace && matchesVersion(document.Version) {
+			return name, &workflow, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("workflow not found: %s/%s:%s", namespace, name, version)
+}
+
 func (p *WorkflowConfig) GetDefinitions() map[string]models.Workflow {
 	return p.Definitions
 }
 
+// WorkflowScriptConfig limits the sandbox run.script tasks execute in.
+// Scripts never have access to the filesystem, network or processes.
+type WorkflowScriptConfig struct {
+	Timeout       time.Duration `mapstructure:"timeout"`         // Longest a script can run for
+	MemoryLimitMB uint64        `mapstructure:"memory_limit_mb"` // Heap a script can grow by before it is stopped
+}
+
+// WorkflowContainerConfig is the default sandbox for run.container tasks.
+// Tasks can tighten or loosen these through their container metadata.
+type WorkflowContainerConfig struct {
+	Timeout       time.Duration `mapstructure:"timeout"`         // Longest a container can run for
+	PullPolicy    string        `mapstructure:"pull_policy"`     // always, ifNotPresent or never
+	MemoryLimitMB int64         `mapstructure:"memory_limit_mb"` // Memory a container can use, 0 for no limit
+	CPUs          float64       `mapstructure:"cpus"`            // CPUs a container can use, 0 for no limit
+	PidsLimit     int64         `mapstructure:"pids_limit"`      // Processes a container can run, 0 for no limit
+	Network       string        `mapstructure:"network"`         // Network mode such as bridge or none
+}
+
+// WorkflowDurableConfig persists workflows that wait or listen when
+// Temporal isn't configured, so their timers and revocations survive a
+// restart of the server
+type WorkflowDurableConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Path         string        `mapstructure:"path"`          // Directory suspended workflows are stored in
+	PollInterval time.Duration `mapstructure:"poll_interval"` // How often expired timers are checked for
+	MinWait      time.Duration `mapstructure:"min_wait"`      // Shorter waits sleep in process instead
+	Concurrency  int           `mapstructure:"concurrency"`   // How many expired workflows are resumed at once
+}
+
+// WorkflowEventsConfig routes the events emit tasks publish to external
+// systems such as a SIEM or ticketing system
+type WorkflowEventsConfig struct {
+	Sinks []WorkflowEventSinkConfig `mapstructure:"sinks"`
+}
+
+// WorkflowEventSinkConfig is a CloudEvents destination. HTTP and NATS
+// sinks are built in, other types such as Kafka must be registered in
+// code before they can be configured.
+type WorkflowEventSinkConfig struct {
+	Name     string            `mapstructure:"name"`
+	Type     string            `mapstructure:"type"`     // http, nats, or a type registered with runner.RegisterEventSink
+	Endpoint string            `mapstructure:"endpoint"` // URL for http, server URL such as nats://host:4222 for nats
+	Mode     string            `mapstructure:"mode"`     // binary or structured content mode
+	Topic    string            `mapstructure:"topic"`    // Subject for nats, topic for other brokers
+	Headers  map[string]string `mapstructure:"headers"`  // Extra headers, such as authorization
+	Types    []string          `mapstructure:"types"`    // Event types to route, such as com.thand.*. Empty routes all
+	Timeout  time.Duration     `mapstructure:"timeout"`
+}
+
+// GetSinksForEvent returns the sinks an event type is routed to
+func (e *WorkflowEventsConfig) GetSinksForEvent(eventType string) []WorkflowEventSinkConfig {
+	var sinks []WorkflowEventSinkConfig
+	for _, sink := range e.Sinks {
+		if sink.Routes(eventType) {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
+}
+
+// Routes returns whether the sink receives events of the type. Types
+// match exactly, or by prefix when they end with a *.
+func (s *WorkflowEventSinkConfig) Routes(eventType string) bool {
+	return matchesEventType(s.Types, eventType)
+}
+
+// matchesEventType matches an event type against a list of types, where
+// an empty list matches everything
+func matchesEventType(types []string, eventType string) bool {
+
+	if len(types) == 0 {
+		return true
+	}
+
+	for _, pattern := range types {
+		if prefix, found := strings.CutSuffix(pattern, "*"); found {
+			if strings.HasPrefix(eventType, prefix) {
+				return true
+			}
+		} else if pattern == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
 type WorkflowPluginConfig struct {
 	Path string `mapstructure:"path"`
 	URL  string `mapstructure:"url"`
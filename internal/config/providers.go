@@ -22,6 +22,18 @@ import (
 
 // LoadProviders loads providers from a file or URL and maps them to their implementations
 func (c *Config) LoadProviders() (map[string]models.Provider, error) {
+	foundProviders, err := c.loadProviderDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	defs := c.processProviderDefinitions(foundProviders)
+	return c.initializeProviders(defs)
+}
+
+// loadProviderDefinitions loads the provider definitions without
+// initializing them, including disabled providers
+func (c *Config) loadProviderDefinitions() ([]*ProviderDefinitions, error) {
 	vaultData, err := c.loadVaultData()
 	if err != nil {
 		return nil, err
@@ -38,8 +50,7 @@ func (c *Config) LoadProviders() (map[string]models.Provider, error) {
 		return nil, fmt.Errorf("failed to load providers data: %w", err)
 	}
 
-	defs := c.processProviderDefinitions(foundProviders)
-	return c.initializeProviders(defs)
+	return foundProviders, nil
 }
 
 // loadVaultData loads provider data from vault if configured
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/models"
+	"go.temporal.io/api/workflowservice/v1"
+)
+
+// How far back to look for previous elevations of a role
+const riskHistoryWindow = 90 * 24 * time.Hour
+
+// AssessRisk scores the elevation request. Wildcard permissions are
+// expanded against each provider's catalog so they are scored by what they
+// grant, and the requester's history is taken from temporal when available.
+func (c *Config) AssessRisk(
+	ctx context.Context,
+	request *models.ElevateRequest,
+	user *models.User,
+) *models.RiskAssessment {
+
+	if request.Role == nil {
+		return nil
+	}
+
+	input := models.RiskInput{
+		Permissions: c.getRiskPermissions(ctx, request),
+		Resources:   request.Role.Resources.Allow,
+		BreakGlass:  request.BreakGlass,
+	}
+
+	if duration, err := request.AsDuration(); err == nil {
+		input.Duration = duration
+	}
+
+	if user != nil {
+		previous, err := c.countPreviousElevations(ctx, request.Role.Name, user.Email)
+		if err != nil {
+			logrus.WithError(err).Debugln("Unable to get elevation history for risk assessment")
+		} else {
+			input.PreviousElevations = &previous
+		}
+	}
+
+	assessment := models.AssessRisk(input)
+
+	logrus.WithFields(logrus.Fields{
+		"role":  request.Role.Name,
+		"score": assessment.Score,
+		"level": assessment.Level,
+	}).Infoln("Assessed elevation risk")
+
+	return assessment
+}
+
+// getRiskPermissions returns the requested permissions, including those
+// from each provider's translation of the role's permission sets, with
+// wildcards expanded by the first provider that recognises them
+func (c *Config) getRiskPermissions(ctx context.Context, request *models.ElevateRequest) []string {
+
+	requested := slices.Clone(request.Role.Permissions.Allow)
+
+	for _, providerName := range request.Providers {
+
+		provider, err := c.GetProviderByName(providerName)
+		if err != nil {
+			continue
+		}
+
+		translated, err := request.Role.TranslatePermissionSets(provider.Provider)
+		if err != nil {
+			continue
+		}
+
+		for _, permission := range translated.Permissions.Allow {
+			if !slices.Contains(requested, permission) {
+				requested = append(requested, permission)
+			}
+		}
+	}
+
+	permissions := []string{}
+
+	for _, permission := range requested {
+
+		if !models.IsPermissionWildcard(permission) {
+			permissions = append(permissions, permission)
+			continue
+		}
+
+		expanded := []string{}
+
+		for _, providerName := range request.Providers {
+
+			provider, err := c.GetProviderByName(providerName)
+			if err != nil || provider.GetClient() == nil {
+				continue
+			}
+
+			client := provider.GetClient()
+
+			providerPermissions, err := client.ListPermissions(ctx)
+			if err != nil {
+				continue
+			}
+
+			expanded = models.ExpandPermissionsWildcard(
+				providerPermissions, client.GetPermissionWildcards(), permission)
+
+			if len(expanded) > 0 {
+				break
+			}
+		}
+
+		// Without a catalog the wildcard is scored on its own
+		if len(expanded) == 0 {
+			expanded = []string{permission}
+		}
+
+		permissions = append(permissions, expanded...)
+	}
+
+	return permissions
+}
+
+// countPreviousElevations returns how many times the user has requested
+// the role recently
+func (c *Config) countPreviousElevations(ctx context.Context, role string, user string) (int, error) {
+
+	temporalService := c.GetServices().GetTemporal()
+
+	if temporalService == nil || !temporalService.HasClient() {
+		return 0, fmt.Errorf("temporal is not configured")
+	}
+
+	query := fmt.Sprintf(
+		"TaskQueue=%s AND role=%s AND user=%s AND StartTime > %s",
+		quoteQueryValue(temporalService.GetTaskQueue()),
+		quoteQueryValue(role),
+		quoteQueryValue(user),
+		quoteQueryValue(time.Now().Add(-riskHistoryWindow).UTC().Format(time.RFC3339)),
+	)
+
+	resp, err := temporalService.GetClient().CountWorkflow(ctx, &workflowservice.CountWorkflowExecutionsRequest{
+		Namespace: temporalService.GetNamespace(),
+		Query:     query,
+	})
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to count previous elevations: %w", err)
+	}
+
+	return int(resp.GetCount()), nil
+}
@@ -8,7 +8,41 @@ import (
 )
 
 // LoadRoles loads roles from a file or URL
-func (c *Config) LoadRoles() (map[string]models.Role, error) {
+// LoadRoles returns the effective roles, with local inheritance flattened,
+// along with the roles as they were declared.
+func (c *Config) LoadRoles() (map[string]models.Role, map[string]models.Role, error) {
+
+	declared, err := c.loadDeclaredRoles()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	effective, err := models.FlattenRoles(declared)
+
+	if err != nil {
+		logrus.WithError(err).Errorln("Failed to resolve role inheritance")
+		return nil, nil, fmt.Errorf("failed to resolve role inheritance: %w", err)
+	}
+
+	defs := make(map[string]models.Role)
+	declaredDefs := make(map[string]models.Role)
+
+	for roleKey, r := range effective {
+
+		if !r.Enabled {
+			logrus.Infoln("Role disabled:", roleKey)
+			continue
+		}
+
+		defs[roleKey] = r
+		declaredDefs[roleKey] = declared[roleKey]
+	}
+
+	return defs, declaredDefs, nil
+}
+
+// loadDeclaredRoles loads every role as declared, including disabled roles
+func (c *Config) loadDeclaredRoles() (map[string]models.Role, error) {
 
 	vaultData := ""
 
@@ -45,19 +79,22 @@ func (c *Config) LoadRoles() (map[string]models.Role, error) {
 		return nil, fmt.Errorf("failed to load roles data: %w", err)
 	}
 
-	defs := make(map[string]models.Role)
+	return collectDeclaredRoles(foundRoles), nil
+}
+
+// collectDeclaredRoles merges the loaded role definitions. Disabled roles
+// are kept until inheritance is resolved so they can still be used as a
+// base for other roles.
+func collectDeclaredRoles(foundRoles []*RoleDefinitions) map[string]models.Role {
+
+	declared := make(map[string]models.Role)
 
 	logrus.Debugln("Processing loaded roles: ", len(foundRoles))
 
 	for _, role := range foundRoles {
 		for roleKey, r := range role.Roles {
 
-			if !r.Enabled {
-				logrus.Infoln("Role disabled:", roleKey)
-				continue
-			}
-
-			if _, exists := defs[roleKey]; exists {
+			if _, exists := declared[roleKey]; exists {
 				logrus.Warningln("Duplicate role key found, skipping:", roleKey)
 				continue
 			}
@@ -66,9 +103,9 @@ func (c *Config) LoadRoles() (map[string]models.Role, error) {
 				r.Name = roleKey
 			}
 
-			defs[roleKey] = r
+			declared[roleKey] = r
 		}
 	}
 
-	return defs, nil
+	return declared
 }
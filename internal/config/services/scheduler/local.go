@@ -32,8 +32,18 @@ func (c *CronScheduler) Shutdown() error {
 
 func (c *CronScheduler) AddJob(job models.JobImpl) error {
 
-	// For one-time execution at a specific time, use StartAt with LimitRunsTo(1)
-	cjb, err := c.scheduler.Every(1).Day().StartAt(job.GetAt()).LimitRunsTo(1).Do(job.GetTask())
+	var cjb *gocron.Job
+	var err error
+
+	if schedule := job.GetSchedule(); len(schedule) > 0 {
+		// Recurring jobs use a cron expression or @every descriptor. A run
+		// is skipped if the previous one is still going.
+		cjb, err = c.scheduler.Cron(schedule).SingletonMode().Do(job.GetTask())
+	} else {
+		// For one-time execution at a specific time, use StartAt with LimitRunsTo(1)
+		cjb, err = c.scheduler.Every(1).Day().StartAt(job.GetAt()).LimitRunsTo(1).Do(job.GetTask())
+	}
+
 	if err != nil {
 		return err
 	}
@@ -174,6 +174,8 @@ func (c *TemporalClient) validateTemporalNamespace() error {
 		models.TypedSearchAttributeDuration,
 		models.TypedSearchAttributeIdentities,
 		models.TypedSearchAttributeApproved,
+		models.TypedSearchAttributeBreakGlass,
+		models.TypedSearchAttributeReviewDueAt,
 	}
 
 	// Check if all required search attributes are defined
@@ -10,6 +10,39 @@ import (
 // LoadWorkflows loads workflows from a file or URL
 func (c *Config) LoadWorkflows() (map[string]models.Workflow, error) {
 
+	foundWorkflows, err := c.loadWorkflowDefinitions()
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make(map[string]models.Workflow)
+
+	logrus.Debugln("Processing loaded workflows: ", len(foundWorkflows))
+
+	for _, workflow := range foundWorkflows {
+		for workflowKey, p := range workflow.Workflows {
+
+			if !p.Enabled {
+				logrus.Infoln("Workflow disabled:", workflowKey)
+				continue
+			}
+
+			if _, exists := defs[workflowKey]; exists {
+				logrus.Warningln("Duplicate workflow key found, skipping:", workflowKey)
+				continue
+			}
+
+			defs[workflowKey] = p
+		}
+	}
+
+	return defs, nil
+}
+
+// loadWorkflowDefinitions loads the workflow definitions, including
+// disabled workflows
+func (c *Config) loadWorkflowDefinitions() ([]*WorkflowDefinitions, error) {
+
 	vaultData := ""
 
 	if len(c.Workflows.Vault) > 0 {
@@ -43,26 +76,5 @@ func (c *Config) LoadWorkflows() (map[string]models.Workflow, error) {
 		return nil, fmt.Errorf("failed to load workflows data: %w", err)
 	}
 
-	defs := make(map[string]models.Workflow)
-
-	logrus.Debugln("Processing loaded workflows: ", len(foundWorkflows))
-
-	for _, workflow := range foundWorkflows {
-		for workflowKey, p := range workflow.Workflows {
-
-			if !p.Enabled {
-				logrus.Infoln("Workflow disabled:", workflowKey)
-				continue
-			}
-
-			if _, exists := defs[workflowKey]; exists {
-				logrus.Warningln("Duplicate workflow key found, skipping:", workflowKey)
-				continue
-			}
-
-			defs[workflowKey] = p
-		}
-	}
-
-	return defs, nil
+	return foundWorkflows, nil
 }
@@ -44,7 +44,11 @@ func (s *Server) getElevate(c *gin.Context) {
 		primaryWorkflow = role.Workflows[0]
 	}
 
-	s.elevate(c, models.ElevateRequest{
+	if len(request.Params) == 0 {
+		request.Params = c.QueryMap("params")
+	}
+
+	elevateRequest := models.ElevateRequest{
 		Role:       role,
 		Providers:  []string{request.Provider},
 		Identities: request.Identities,
@@ -52,7 +56,15 @@ func (s *Server) getElevate(c *gin.Context) {
 		Reason:     request.Reason,
 		Duration:   request.Duration,
 		Session:    request.Session,
-	})
+		Params:     request.Params,
+	}
+
+	if request.DryRun {
+		s.planElevation(c, elevateRequest)
+		return
+	}
+
+	s.elevate(c, elevateRequest)
 }
 
 func (s *Server) postElevate(c *gin.Context) {
@@ -164,6 +176,109 @@ func (s *Server) handleDynamicRequest(c *gin.Context, dynamicRequest models.Elev
 	s.elevate(c, elevateRequest)
 }
 
+// planElevation handles /api/v1/elevate?dry_run=true by returning the
+// changes each provider would make without applying any of them
+func (s *Server) planElevation(c *gin.Context, request models.ElevateRequest) {
+
+	if !s.Config.IsServer() {
+		s.getErrorPage(c, http.StatusBadRequest, "Dry run elevation is only available in server mode")
+		return
+	}
+
+	if request.Role == nil {
+		s.getErrorPage(c, http.StatusBadRequest, "Role is required for dry run elevation")
+		return
+	}
+
+	// Conditions are evaluated against the same metadata as elevate
+	requestedAt := time.Now().UTC()
+	request.Metadata = &models.ElevateRequestMetadata{
+		ClientIP:    c.ClientIP(),
+		RequestedAt: &requestedAt,
+	}
+
+	requestedRole := request.Role
+
+	configuredRole, err := s.getConfiguredRole(request.Role, request.Params)
+
+	if err != nil {
+		s.getErrorPage(c, http.StatusBadRequest, "Invalid role for dry run elevation", err)
+		return
+	}
+
+	request.Role = configuredRole
+
+	authProvider, foundUser, err := s.getUserFromElevationRequest(c, request)
+
+	if err != nil {
+		s.getErrorPage(c, http.StatusUnauthorized, "Unauthorized: unable to get user for dry run elevation", err)
+		return
+	}
+
+	if foundUser == nil {
+		s.getErrorPage(c, http.StatusUnauthorized, "Unauthorized: user not found for dry run elevation")
+		return
+	}
+
+	s.Config.ResolveUserGroups(c.Request.Context(), foundUser.User)
+
+	if err := request.Role.ValidateApplies(foundUser.User); err != nil {
+		s.getErrorPage(c, http.StatusForbidden, "Forbidden: role cannot be requested by this user", err)
+		return
+	}
+
+	if err := s.Config.Roles.ValidateInherits(requestedRole, foundUser.User, authProvider); err != nil {
+		s.getErrorPage(c, http.StatusForbidden, "Forbidden: role inherits from roles this user cannot request", err)
+		return
+	}
+
+	// Plans show the identities' current access, so they are only
+	// returned for requests the user could actually make
+	if err := models.ValidateDelegation(request.Role, foundUser.User, request.Identities); err != nil {
+		s.getErrorPage(c, http.StatusForbidden, "Forbidden: role cannot be requested for these identities", err)
+		return
+	}
+
+	elevateRequest := models.ElevateRequestInternal{
+		ElevateRequest: request,
+		User:           foundUser.User,
+	}
+	elevateRequest.Authenticator = authProvider
+
+	if err := request.Role.EvaluateConditions(&elevateRequest); err != nil {
+		s.getErrorPage(c, http.StatusForbidden, "Forbidden: request does not meet the role conditions", err)
+		return
+	}
+
+	response := models.ElevatePlanResponse{
+		Role:  request.Role.Name,
+		Plans: []models.RolePlan{},
+	}
+
+	for _, providerName := range request.Providers {
+
+		provider, err := s.Config.GetProviderByName(providerName)
+
+		if err != nil {
+			s.getErrorPage(c, http.StatusBadRequest, "Invalid provider", err)
+			return
+		}
+
+		plans, err := models.PlanRole(c.Request.Context(), provider.GetClient(), elevateRequest)
+
+		if err != nil {
+			s.getErrorPage(c, http.StatusBadRequest, "Failed to plan elevation", err)
+			return
+		}
+
+		for _, plan := range plans {
+			response.Plans = append(response.Plans, *plan)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 func (s *Server) elevate(c *gin.Context, request models.ElevateRequest) {
 
 	// Increment elevate requests counter
@@ -171,6 +286,14 @@ func (s *Server) elevate(c *gin.Context, request models.ElevateRequest) {
 
 	ctx := context.Background()
 
+	// Never trust metadata sent with the request
+	requestedAt := time.Now().UTC()
+	request.Metadata = &models.ElevateRequestMetadata{
+		ClientIP:    c.ClientIP(),
+		RequestedAt: &requestedAt,
+	}
+	request.Risk = nil
+
 	// If we have a web session and one hasn't been set then
 	// lets attach a user session to the request.
 	if s.Config.IsServer() {
@@ -180,6 +303,22 @@ func (s *Server) elevate(c *gin.Context, request models.ElevateRequest) {
 			return
 		}
 
+		if request.Role == nil {
+			s.getErrorPage(c, http.StatusBadRequest, "Role is required for elevation request")
+			return
+		}
+
+		requestedRole := request.Role
+
+		configuredRole, err := s.getConfiguredRole(request.Role, request.Params)
+
+		if err != nil {
+			s.getErrorPage(c, http.StatusBadRequest, "Invalid role for elevation request", err)
+			return
+		}
+
+		request.Role = configuredRole
+
 		authProvider, foundUser, err := s.getUserFromElevationRequest(c, request)
 
 		if err != nil {
@@ -187,8 +326,48 @@ func (s *Server) elevate(c *gin.Context, request models.ElevateRequest) {
 			return
 		}
 
+		var requester *models.User
+
+		if foundUser != nil {
+
+			requester = foundUser.User
+
+			// Groups are carried in the session so the workflow
+			// can check applicability against the same groups
+			s.Config.ResolveUserGroups(c.Request.Context(), foundUser.User)
+
+			if err := request.Role.ValidateApplies(foundUser.User); err != nil {
+				s.getErrorPage(c, http.StatusForbidden, "Forbidden: role cannot be requested by this user", err)
+				return
+			}
+		}
+
+		// A role that isn't configured can only inherit roles the
+		// requester could request directly
+		if err := s.Config.Roles.ValidateInherits(requestedRole, requester, authProvider); err != nil {
+			s.getErrorPage(c, http.StatusForbidden, "Forbidden: role inherits from roles this user cannot request", err)
+			return
+		}
+
 		if foundUser != nil {
 
+			// Only roles that allow delegation can target other identities
+			if err := models.ValidateDelegation(request.Role, foundUser.User, request.Identities); err != nil {
+				s.getErrorPage(c, http.StatusForbidden, "Forbidden: role cannot be requested for these identities", err)
+				return
+			}
+
+			conditionsRequest := models.ElevateRequestInternal{
+				ElevateRequest: request,
+				User:           foundUser.User,
+			}
+			conditionsRequest.Authenticator = authProvider
+
+			if err := request.Role.EvaluateConditions(&conditionsRequest); err != nil {
+				s.getErrorPage(c, http.StatusForbidden, "Forbidden: request does not meet the role conditions", err)
+				return
+			}
+
 			exportableSession := &models.ExportableSession{
 				Session:  foundUser,
 				Provider: authProvider,
@@ -198,6 +377,35 @@ func (s *Server) elevate(c *gin.Context, request models.ElevateRequest) {
 				s.Config.GetServices().GetEncryption())
 		}
 
+		// Break-glass must be asked for explicitly and only for break-glass
+		// roles, and its policy applies whether or not there is a user
+		if request.BreakGlass != request.Role.BreakGlass {
+			s.getErrorPage(c, http.StatusBadRequest, fmt.Sprintf(
+				"Role %s can only be requested with break-glass set to %t", request.Role.Name, request.Role.BreakGlass))
+			return
+		}
+
+		if request.BreakGlass {
+
+			requesterEmail := ""
+			if requester != nil {
+				requesterEmail = requester.Email
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"break_glass": true,
+				"role":        request.Role.Name,
+				"user":        requesterEmail,
+			}).Warnln("Break-glass elevation requested")
+
+			if err := s.Config.PrepareBreakGlass(c.Request.Context(), &request, requester); err != nil {
+				s.getErrorPage(c, http.StatusInternalServerError, "Failed to start break-glass elevation", err)
+				return
+			}
+		}
+
+		// Workflows can route on the risk through $context.risk
+		request.Risk = s.Config.AssessRisk(c.Request.Context(), &request, requester)
 	}
 
 	workflowTask, err := s.Workflows.CreateWorkflow(ctx, request)
@@ -345,6 +553,13 @@ func (s *Server) getElevateAuthOAuth2(c *gin.Context) {
 
 func (s *Server) resumeWorkflow(c *gin.Context, workflow *models.WorkflowTask) {
 
+	// Approvals must come from someone other than the requester
+	if approvalEvent, isApproval := workflow.GetApprovalEvent(); isApproval && s.Config.IsServer() {
+		if err := s.validateApprover(c, workflow, approvalEvent); err != nil {
+			return
+		}
+	}
+
 	// Provide no input to resume the workflow as it'll use the saved state
 	// inputs are only for signals
 	workflowTask, err := s.Workflows.ResumeWorkflow(
@@ -398,6 +613,59 @@ func (s *Server) resumeWorkflow(c *gin.Context, workflow *models.WorkflowTask) {
 
 }
 
+// validateApprover checks the signed in user isn't approving a request
+// they made, or one that grants them access, and records who approved it
+func (s *Server) validateApprover(c *gin.Context, workflow *models.WorkflowTask, approvalEvent map[string]any) error {
+
+	_, approver, err := s.getUser(c)
+
+	if err != nil || approver == nil || approver.User == nil {
+		s.getErrorPage(c, http.StatusUnauthorized, "Unauthorized: you must be signed in to respond to an approval", err)
+		return fmt.Errorf("no approver session found")
+	}
+
+	elevateRequest, err := workflow.GetContextAsElevationRequest()
+
+	if err != nil {
+		s.getErrorPage(c, http.StatusBadRequest, "Failed to get elevation request for approval", err)
+		return err
+	}
+
+	approverEmail := approver.User.Email
+
+	if elevateRequest.IsBeneficiary(approverEmail) {
+
+		err := fmt.Errorf("%s cannot approve a request they made or benefit from", approverEmail)
+
+		roleName := ""
+		if elevateRequest.Role != nil {
+			roleName = elevateRequest.Role.Name
+		}
+
+		s.Config.RecordAuditEvent(models.AuditEvent{
+			Type:       models.AuditEventSelfApproval,
+			User:       approverEmail,
+			Role:       roleName,
+			WorkflowID: workflow.WorkflowID,
+			Message:    err.Error(),
+			Details: map[string]any{
+				"event": approvalEvent["data"],
+			},
+		})
+
+		s.getErrorPage(c, http.StatusForbidden, "Forbidden: requesters cannot approve their own requests", err)
+		return err
+	}
+
+	// Record who responded to the approval
+	if data, ok := approvalEvent["data"].(map[string]any); ok {
+		data["user"] = approverEmail
+		workflow.SetInput(approvalEvent)
+	}
+
+	return nil
+}
+
 // getElevateLLM handles POST /elevate/llm?reason=I need access to aws
 // This function is a handler to take a users reason for an
 // elevation and response with a role based on the users request
@@ -469,7 +737,27 @@ func (s *Server) handleLargeLanguageModelRequest(c *gin.Context, elevateRequest
 		return
 	}
 
-	workflows := s.Config.GetWorkflows().Definitions
+	s.Config.ResolveUserGroups(c.Request.Context(), foundUser.User)
+
+	// Only offer the providers and workflows the user can use
+	for name, provider := range providers {
+		if !provider.HasPermission(foundUser.User) {
+			delete(providers, name)
+		}
+	}
+
+	if len(providers) == 0 {
+		s.getErrorPage(c, http.StatusForbidden, "Forbidden: no providers are available to this user")
+		return
+	}
+
+	workflows := map[string]models.Workflow{}
+
+	for name, workflow := range s.Config.GetWorkflows().Definitions {
+		if workflow.HasPermission(foundUser.User) {
+			workflows[name] = workflow
+		}
+	}
 
 	if len(workflows) == 0 {
 		s.getErrorPage(c, http.StatusBadRequest, "No workflows are configured")
@@ -490,9 +778,52 @@ func (s *Server) handleLargeLanguageModelRequest(c *gin.Context, elevateRequest
 		return
 	}
 
+	// The generated role is only for the user who asked for it
+	if elevateResponse != nil && elevateResponse.Role != nil {
+		elevateResponse.Role.Applies = &models.RoleApplies{
+			Users: []string{foundUser.User.GetIdentity()},
+		}
+	}
+
 	c.JSON(http.StatusOK, elevateResponse)
 }
 
+// getConfiguredRole returns the configured definition for a requested role.
+// Requests can carry a full role, so a role with a configured name must use
+// the configured applicability rather than whatever was submitted. Other
+// roles have their local inheritance flattened against the configured roles
+// and can never delegate to other identities or be break-glass roles, which
+// would skip approvals. Templated roles are then filled in with the
+// request's parameters.
+func (s *Server) getConfiguredRole(role *models.Role, params map[string]string) (*models.Role, error) {
+
+	if role == nil {
+		return nil, nil
+	}
+
+	resolved, err := s.Config.GetRoleByName(role.Name)
+
+	if err != nil {
+		resolved, err = s.Config.Roles.ResolveRole(role)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved.Delegation = nil
+		resolved.BreakGlass = false
+	}
+
+	if resolved.IsTemplate() {
+		return resolved.ApplyParameters(params)
+	}
+
+	if len(params) > 0 {
+		return nil, fmt.Errorf("role %s does not take parameters", resolved.Name)
+	}
+
+	return resolved, nil
+}
+
 // getElevatePage handles the request for the elevation page
 func (s *Server) getElevatePage(c *gin.Context) {
 	data := s.GetTemplateData(c)
@@ -32,7 +32,7 @@ request.
    - Reference the time-limited nature
 
 2. **Role Inheritance & Permissions**:
-   **You can use EITHER inheritance OR explicit permissions OR BOTH:**
+   **You can use inheritance, permission sets, explicit permissions or any combination:**
    
    - **Inheritance Approach**: Inherit from existing roles that provide baseline permissions
      - Use the "inherits" field to specify role names to inherit from
@@ -45,6 +45,11 @@ request.
      - Grant ONLY the minimum permissions needed
      - Use specific service actions rather than wildcards when possible
    
+   - **Permission Sets Approach**: Use provider neutral permission sets
+     - Use the "permission_sets" field with names from the permission_sets list
+     - Each set is translated into the provider's own permissions when granted
+     - Ideal when a set such as storage-read or compute-debug matches the task
+
    - **Hybrid Approach**: Combine inheritance with additional explicit permissions
      - Inherit from a base role that provides common permissions
      - Add specific additional permissions in "permissions.allow" for unique requirements
@@ -155,6 +160,9 @@ func buildQueryResponseMap(ctx context.Context, providerClient models.ProviderIm
 		"roles":       []string{},
 		"permissions": []string{},
 		"resources":   []string{},
+
+		// Sets the role can use instead of listing every permission
+		"permission_sets": models.GetPermissionSets(providerClient.GetProvider()),
 	}
 
 	if len(queryResponse.Roles) > 0 {
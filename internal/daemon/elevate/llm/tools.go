@@ -257,7 +257,7 @@ func getRoleTools() *genai.FunctionDeclaration {
 
 	return &genai.FunctionDeclaration{
 		Name:        GenerateRoleToolName,
-		Description: "Creates a new role. It is critical that you provide either a role to inherit from, explicit permissions or both.",
+		Description: "Creates a new role. It is critical that you provide at least one of a role to inherit from, a permission set or explicit permissions.",
 		Parameters: &genai.Schema{
 			Type: genai.TypeObject,
 			Required: []string{
@@ -316,6 +316,17 @@ func getRoleTools() *genai.FunctionDeclaration {
 						},
 					},
 				},
+				"permission_sets": {
+					Type:        genai.TypeArray,
+					Description: "Names of provider neutral permission sets from the permission_sets list to include. Each set is translated into the provider's own permissions when the role is granted. Prefer a set when one matches the task, then add any extra explicit permissions that are still needed.",
+					Items: &genai.Schema{
+						Type: genai.TypeString,
+					},
+					Example: []string{
+						"compute-debug",
+						"logs-read",
+					},
+				},
 				"resources": {
 					Type:        genai.TypeObject,
 					Description: "Resource restrictions for the role",
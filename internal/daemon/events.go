@@ -0,0 +1,253 @@
+package daemon
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/config"
+	"github.com/thand-io/agent/internal/models"
+	"github.com/thand-io/agent/internal/workflows/manager"
+)
+
+const (
+	maxEventBodySize             = 1 << 20 // 1MB
+	defaultEventSignatureHeader  = "X-Thand-Signature"
+	eventTimestampHeader         = "X-Thand-Timestamp"
+	eventSignaturePrefix         = "sha256="
+	eventAuthorizationBearerType = "Bearer "
+
+	// How old an event can be, and how long its ID is remembered for
+	eventReplayWindow = 5 * time.Minute
+)
+
+type EventResponse struct {
+	ID        string   `json:"id"`
+	Workflows []string `json:"workflows"` // The workflows the event started or was signalled to
+}
+
+// postEvent handles POST /api/v1/events
+//
+// Accepts a CloudEvent in binary or structured mode from a configured
+// source. It starts the workflows scheduled on the event and signals it
+// to the workflows whose listen tasks it matches.
+func (s *Server) postEvent(c *gin.Context) {
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEventBodySize))
+	if err != nil {
+		s.getErrorPage(c, http.StatusBadRequest, "Failed to read request body", err)
+		return
+	}
+
+	// The body is read again to decode the event
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	event, err := cehttp.NewEventFromHTTPRequest(c.Request)
+	if err != nil {
+		s.getErrorPage(c, http.StatusBadRequest, "Invalid CloudEvent", err)
+		return
+	}
+
+	if err := event.Validate(); err != nil {
+		s.getErrorPage(c, http.StatusBadRequest, "Invalid CloudEvent", err)
+		return
+	}
+
+	if err := s.authenticateEvent(c.Request, event, body); err != nil {
+		s.getErrorPage(c, http.StatusUnauthorized, "Unauthorized event", err)
+		return
+	}
+
+	if !s.receivedEvents.accept(event.Source(), event.ID(), time.Now()) {
+		s.getErrorPage(c, http.StatusConflict, "Duplicate event",
+			fmt.Errorf("event %s from %s has already been received", event.ID(), event.Source()))
+		return
+	}
+
+	workflowIDs, err := s.Workflows.CorrelateEvent(c.Request.Context(), *event)
+
+	// The sender can retry an event that wasn't correlated
+	if err != nil {
+		s.receivedEvents.forget(event.Source(), event.ID())
+	}
+
+	if errors.Is(err, manager.ErrorCorrelationUnsupported) {
+		s.getErrorPage(c, http.StatusServiceUnavailable, "Events can't be correlated", err)
+		return
+	} else if err != nil {
+		s.getErrorPage(c, http.StatusInternalServerError, "Failed to correlate event", err)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"eventId":   event.ID(),
+		"eventType": event.Type(),
+		"source":    event.Source(),
+		"workflows": len(workflowIDs),
+	}).Info("Received event")
+
+	c.JSON(http.StatusAccepted, EventResponse{
+		ID:        event.ID(),
+		Workflows: workflowIDs,
+	})
+}
+
+// authenticateEvent checks the event came from its configured source
+// using the source's bearer token and HMAC secret, and was sent recently.
+// Events of the agent's own types are refused whatever their source.
+func (s *Server) authenticateEvent(req *http.Request, event *cloudevents.Event, body []byte) error {
+
+	if strings.HasPrefix(event.Type(), models.ReservedEventTypePrefix) {
+		return fmt.Errorf("events of type %s can't be sent by external sources", event.Type())
+	}
+
+	eventSource, err := s.Config.API.Events.GetEventSource(event.Source())
+	if err != nil {
+		return err
+	}
+
+	if len(eventSource.Token) == 0 && len(eventSource.Secret) == 0 {
+		return fmt.Errorf("event source %s has no token or secret configured", eventSource.Source)
+	}
+
+	if !eventSource.AllowsType(event.Type()) {
+		return fmt.Errorf("event source %s can't send events of type %s", eventSource.Source, event.Type())
+	}
+
+	if len(eventSource.Token) > 0 {
+
+		token, found := strings.CutPrefix(req.Header.Get("Authorization"), eventAuthorizationBearerType)
+
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(eventSource.Token)) != 1 {
+			return fmt.Errorf("invalid bearer token")
+		}
+	}
+
+	// Signed events are dated by their signed timestamp, others by the
+	// event's own time
+	if len(eventSource.Secret) > 0 {
+		return verifyEventSignature(eventSource, req.Header, body, time.Now())
+	}
+
+	if event.Time().IsZero() {
+		return fmt.Errorf("event has no time")
+	}
+
+	return checkEventAge(event.Time(), time.Now())
+}
+
+// checkEventAge rejects events sent outside the replay window
+func checkEventAge(sent time.Time, now time.Time) error {
+
+	if age := now.Sub(sent); age > eventReplayWindow || age < -eventReplayWindow {
+		return fmt.Errorf("event was sent at %s, outside the %s allowed", sent.UTC().Format(time.RFC3339), eventReplayWindow)
+	}
+
+	return nil
+}
+
+// verifyEventSignature checks the sha256=<hex> HMAC of the timestamp
+// header and request body, joined by a full stop
+func verifyEventSignature(eventSource *config.EventSourceConfig, header http.Header, body []byte, now time.Time) error {
+
+	headerName := eventSource.SignatureHeader
+	if len(headerName) == 0 {
+		headerName = defaultEventSignatureHeader
+	}
+
+	timestamp := header.Get(eventTimestampHeader)
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing or invalid %s header", eventTimestampHeader)
+	}
+
+	if err := checkEventAge(time.Unix(seconds, 0), now); err != nil {
+		return err
+	}
+
+	signature, found := strings.CutPrefix(header.Get(headerName), eventSignaturePrefix)
+	if !found {
+		return fmt.Errorf("missing %s signature", headerName)
+	}
+
+	provided, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid %s signature: %w", headerName, err)
+	}
+
+	mac := hmac.New(sha256.New, []byte(eventSource.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	if !hmac.Equal(provided, mac.Sum(nil)) {
+		return fmt.Errorf("invalid %s signature", headerName)
+	}
+
+	return nil
+}
+
+// eventReplayCache remembers the events received within the replay
+// window so each is only accepted once. Older events are already refused
+// by their timestamp.
+type eventReplayCache struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // Keyed by source and ID, when they expire
+}
+
+func newEventReplayCache() *eventReplayCache {
+	return &eventReplayCache{
+		seen: map[string]time.Time{},
+	}
+}
+
+// accept records the event, returning false if it was already received
+func (c *eventReplayCache) accept(source string, id string, now time.Time) bool {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	maps.DeleteFunc(c.seen, func(_ string, expires time.Time) bool {
+		return now.After(expires)
+	})
+
+	key := eventReplayKey(source, id)
+
+	if _, found := c.seen[key]; found {
+		return false
+	}
+
+	// Events can be dated up to the window ahead, so remember them for
+	// twice as long
+	c.seen[key] = now.Add(2 * eventReplayWindow)
+
+	return true
+}
+
+// forget removes an event so it can be received again
+func (c *eventReplayCache) forget(source string, id string) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.seen, eventReplayKey(source, id))
+}
+
+func eventReplayKey(source string, id string) string {
+	return source + "\x00" + id
+}
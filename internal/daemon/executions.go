@@ -323,6 +323,20 @@ func workflowExecutionInfo(
 		}
 	}
 
+	if breakGlassAttr, exists := searchAttributes["break_glass"]; exists && breakGlassAttr != nil {
+		var breakGlassValue bool
+		if err := dataConverter.FromPayload(breakGlassAttr, &breakGlassValue); err == nil {
+			response.BreakGlass = breakGlassValue
+		}
+	}
+
+	if reviewDueAttr, exists := searchAttributes["review_due_at"]; exists && reviewDueAttr != nil {
+		var reviewDueValue time.Time
+		if err := dataConverter.FromPayload(reviewDueAttr, &reviewDueValue); err == nil {
+			response.ReviewDueAt = &reviewDueValue
+		}
+	}
+
 	return &response
 
 }
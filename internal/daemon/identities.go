@@ -2,11 +2,14 @@ package daemon
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thand-io/agent/internal/models"
 )
 
+// getIdentities returns the users and groups that roles can be requested for.
+// Use the q query parameter to search the identities for autocomplete.
 func (s *Server) getIdentities(c *gin.Context) {
 
 	// Get user information
@@ -23,9 +26,31 @@ func (s *Server) getIdentities(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"identities": []models.User{
-			*foundUser.User,
-		},
-	})
+	query := c.Query("q")
+
+	identities, err := s.Config.SearchIdentities(c.Request.Context(), query)
+
+	if err != nil {
+		s.getErrorPage(c, http.StatusInternalServerError, "Failed to search identities", err)
+		return
+	}
+
+	// Always offer the requesting user, first, when they match the query
+	self := models.NewUserIdentity(foundUser.User).AsResponse()
+	response := models.IdentitiesResponse{
+		Identities: []models.IdentityResponse{},
+	}
+
+	if self.MatchesFilters(strings.Fields(query)...) {
+		response.Identities = append(response.Identities, self)
+	}
+
+	for _, identity := range identities {
+		if identity.Type == models.IdentityTypeUser && strings.EqualFold(identity.ID, self.ID) {
+			continue
+		}
+		response.Identities = append(response.Identities, identity)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
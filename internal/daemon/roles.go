@@ -1,8 +1,10 @@
 package daemon
 
 import (
+	"fmt"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,19 +15,16 @@ import (
 // getRoles handles GET /api/v1/roles
 func (s *Server) getRoles(c *gin.Context) {
 
-	var authenticatedUser *models.Session
-
 	// If we're in server mode then we need to ensure the user is authenticated
 	// before we return any roles
 	// This is because roles can contain sensitive information
 	// and we want to ensure that only authenticated users can access them
 	if s.Config.IsServer() {
-		_, foundUser, err := s.getUser(c)
+		_, _, err := s.getUser(c)
 		if err != nil {
 			s.getErrorPage(c, http.StatusUnauthorized, "Unauthorized: unable to get user for list of available roles", err)
 			return
 		}
-		authenticatedUser = foundUser
 	}
 
 	// Allow to filter by providers can be comma separated
@@ -45,8 +44,11 @@ func (s *Server) getRoles(c *gin.Context) {
 		if len(providers) > 0 && !hasAnyProvider(role.Providers, providers) {
 			continue
 		}
-		if authenticatedUser != nil && !role.HasPermission(authenticatedUser.User) {
-			continue
+		// Only list the roles the user can request
+		if s.Config.IsServer() {
+			if _, _, err := s.getRoleUser(c, &role); err != nil {
+				continue
+			}
 		}
 		filteredRoles[roleName] = models.RoleResponse{
 			Role: role,
@@ -86,6 +88,7 @@ func hasAnyProvider(roleProviders []string, requestedProviders []string) bool {
 }
 
 // getRoleByName handles GET /api/v1/role/:role
+// Pass ?effective=true to get the role with its local inheritance flattened
 func (s *Server) getRoleByName(c *gin.Context) {
 	roleName := c.Param("role")
 
@@ -100,7 +103,54 @@ func (s *Server) getRoleByName(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, role)
+	// Roles the user can't request are hidden the same as missing ones
+	if s.Config.IsServer() {
+		if _, _, err := s.getRoleUser(c, &role); err != nil {
+			s.getErrorPage(c, http.StatusNotFound, "Role not found")
+			return
+		}
+	}
+
+	if effective, _ := strconv.ParseBool(c.Query("effective")); effective {
+		c.JSON(http.StatusOK, role)
+		return
+	}
+
+	declared, err := s.Config.Roles.GetDeclaredRoleByName(roleName)
+	if err != nil {
+		s.getErrorPage(c, http.StatusNotFound, "Role not found", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, declared)
+}
+
+// getRoleUser returns the session that can request the role. Roles limited
+// to specific authenticators need a session from one of them, and the role
+// must apply to that session's user.
+func (s *Server) getRoleUser(c *gin.Context, role *models.Role) (string, *models.Session, error) {
+
+	authProvider, foundUser, err := s.getUser(c, role.Authenticators...)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	if foundUser == nil || foundUser.User == nil {
+		return "", nil, fmt.Errorf("no user found for role %s", role.Name)
+	}
+
+	if !role.AllowsAuthenticator(authProvider) {
+		return "", nil, fmt.Errorf("authenticator %s is not allowed for role %s", authProvider, role.Name)
+	}
+
+	s.Config.ResolveUserGroups(c.Request.Context(), foundUser.User)
+
+	if err := role.ValidateApplies(foundUser.User); err != nil {
+		return "", nil, err
+	}
+
+	return authProvider, foundUser, nil
 }
 
 func (s *Server) getRolesPage(c *gin.Context) {
@@ -0,0 +1,107 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/models"
+	"github.com/thand-io/agent/internal/workflows/manager"
+)
+
+type SchedulesResponse struct {
+	Schedules []models.WorkflowSchedule `json:"schedules"`
+}
+
+// listSchedules handles GET /api/v1/schedules
+func (s *Server) listSchedules(c *gin.Context) {
+
+	if !s.authorizeScheduleRequest(c) {
+		return
+	}
+
+	c.JSON(http.StatusOK, SchedulesResponse{
+		Schedules: s.Workflows.ListSchedules(c.Request.Context()),
+	})
+}
+
+// pauseSchedule handles POST /api/v1/schedule/:name/pause
+func (s *Server) pauseSchedule(c *gin.Context) {
+	s.updateSchedule(c, "paused", s.Workflows.PauseSchedule)
+}
+
+// resumeSchedule handles POST /api/v1/schedule/:name/resume
+func (s *Server) resumeSchedule(c *gin.Context) {
+	s.updateSchedule(c, "resumed", s.Workflows.UnpauseSchedule)
+}
+
+// triggerSchedule handles POST /api/v1/schedule/:name/trigger
+//
+// Starts the schedule's workflow straight away, even if it is paused.
+func (s *Server) triggerSchedule(c *gin.Context) {
+	s.updateSchedule(c, "triggered", s.Workflows.TriggerSchedule)
+}
+
+func (s *Server) updateSchedule(
+	c *gin.Context,
+	action string,
+	update func(ctx context.Context, name string) error,
+) {
+
+	if !s.authorizeScheduleRequest(c) {
+		return
+	}
+
+	name := c.Param("name")
+
+	if len(name) == 0 {
+		s.getErrorPage(c, http.StatusBadRequest, "Schedule name is required")
+		return
+	}
+
+	err := update(c.Request.Context(), name)
+
+	if errors.Is(err, manager.ErrorScheduleNotFound) {
+		s.getErrorPage(c, http.StatusNotFound, "Schedule not found", err)
+		return
+	} else if err != nil {
+		s.getErrorPage(c, http.StatusBadRequest, "Failed to update schedule", err)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"schedule": name,
+		"action":   action,
+	}).Info("Updated workflow schedule")
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":   name,
+		"status": action,
+	})
+}
+
+// authorizeScheduleRequest checks schedules are being managed on a server
+// by a signed in user
+func (s *Server) authorizeScheduleRequest(c *gin.Context) bool {
+
+	if !s.Config.IsServer() {
+		s.getErrorPage(c, http.StatusBadRequest, "Schedules are only available in server mode")
+		return false
+	}
+
+	_, foundUser, err := s.getUser(c)
+
+	if err != nil {
+		s.getErrorPage(c, http.StatusUnauthorized, "Unauthorized: unable to get user to manage schedules", err)
+		return false
+	}
+
+	if foundUser == nil || foundUser.User == nil || len(foundUser.User.Email) == 0 {
+		s.getErrorPage(c, http.StatusUnauthorized, "Unauthorized: user information is incomplete", nil)
+		return false
+	}
+
+	return true
+}
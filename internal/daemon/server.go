@@ -53,6 +53,7 @@ func NewServer(cfg *config.Config) *Server {
 		Config:         cfg,
 		TemplateEngine: tmpl,
 		Workflows:      workflows,
+		receivedEvents: newEventReplayCache(),
 		StartTime:      time.Now().UTC(),
 	}
 
@@ -68,6 +69,8 @@ type Server struct {
 	TotalRequests   int64
 	ElevateRequests int64
 	server          *http.Server
+	stopBackground  context.CancelFunc
+	receivedEvents  *eventReplayCache
 }
 
 func (s *Server) GetConfig() *config.Config {
@@ -210,6 +213,25 @@ func (s *Server) Start() error {
 	// Store server reference for shutdown
 	s.server = server
 
+	if s.Config.IsServer() {
+
+		backgroundCtx, stopBackground := context.WithCancel(context.Background())
+		s.stopBackground = stopBackground
+
+		// Without temporal, suspended workflows are resumed by us
+		if s.Workflows.HasDurableStore() {
+			if err := s.Workflows.StartDurableTimers(backgroundCtx); err != nil {
+				stopBackground()
+				return fmt.Errorf("failed to start durable workflow timers: %w", err)
+			}
+		}
+
+		if err := s.Workflows.StartSchedules(backgroundCtx); err != nil {
+			stopBackground()
+			return fmt.Errorf("failed to start workflow schedules: %w", err)
+		}
+	}
+
 	// Channel to capture startup errors
 	errChan := make(chan error, 1)
 
@@ -236,6 +258,10 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Stop() {
+	if s.stopBackground != nil {
+		s.stopBackground()
+	}
+
 	if s.server == nil {
 		return
 	}
@@ -392,6 +418,15 @@ func (s *Server) setupRoutes(router *gin.Engine) {
 			api.GET("/execution/:id/cancel", s.cancelRunningWorkflow)
 			api.GET("/execution/:id/terminate", s.terminateRunningWorkflow)
 
+			// CloudEvents from external systems for waiting workflows
+			api.POST("/events", s.postEvent)
+
+			// Workflows that start on a timer or event
+			api.GET("/schedules", s.listSchedules)
+			api.POST("/schedule/:name/pause", s.pauseSchedule)
+			api.POST("/schedule/:name/resume", s.resumeSchedule)
+			api.POST("/schedule/:name/trigger", s.triggerSchedule)
+
 		}
 
 	}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type AuditEventType string
+
+const (
+	AuditEventRoleConflict AuditEventType = "role_conflict"
+	AuditEventSelfApproval AuditEventType = "self_approval"
+	AuditEventBreakGlass   AuditEventType = "break_glass"
+)
+
+// AuditEvent records a decision that compliance needs to be able to review,
+// such as a request that was blocked by separation of duties
+type AuditEvent struct {
+	Type       AuditEventType `json:"type"`
+	Time       time.Time      `json:"time"`
+	User       string         `json:"user,omitempty"`
+	Role       string         `json:"role,omitempty"`
+	WorkflowID string         `json:"workflow_id,omitempty"`
+	Message    string         `json:"message"`
+	Details    map[string]any `json:"details,omitempty"`
+}
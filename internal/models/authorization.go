@@ -0,0 +1,498 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ProviderAuthorizationStatus string
+
+const (
+	ProviderAuthorizationStatusAuthorized ProviderAuthorizationStatus = "authorized"
+	ProviderAuthorizationStatusFailed     ProviderAuthorizationStatus = "failed"
+	ProviderAuthorizationStatusRolledBack ProviderAuthorizationStatus = "rolled_back"
+	ProviderAuthorizationStatusRevoked    ProviderAuthorizationStatus = "revoked"
+)
+
+// ProviderAuthorization records the outcome of authorizing a role
+// against a single provider. These are stored in the workflow context
+// under the authorizations key so revocation can walk the same set.
+type ProviderAuthorization struct {
+	Provider     string                      `json:"provider"`
+	Status       ProviderAuthorizationStatus `json:"status"`
+	AuthorizedAt *time.Time                  `json:"authorized_at,omitempty"`
+	RevokedAt    *time.Time                  `json:"revoked_at,omitempty"`
+	Grants       []*ProviderGrant            `json:"grants,omitempty"`
+	Metadata     map[string]any              `json:"metadata,omitempty"` // Provider output keyed by identity
+	Error        string                      `json:"error,omitempty"`
+}
+
+// ProviderGrant is a single identity granted the role by a provider
+type ProviderGrant struct {
+	Identity    *Identity      `json:"identity"`
+	Metadata    map[string]any `json:"metadata,omitempty"`    // Metadata returned from AuthorizeRole
+	Permissions []string       `json:"permissions,omitempty"` // Permissions granted once the role's permission sets were translated
+}
+
+// GetRole returns the role as it was granted. Permission sets are revoked
+// as they were translated when granted, not as the catalog translates
+// them now.
+func (g *ProviderGrant) GetRole(providerCall ProviderImpl, role *Role) (*Role, error) {
+
+	if role == nil || len(role.PermissionSets) == 0 {
+		return role, nil
+	}
+
+	if len(g.Permissions) > 0 {
+		granted := *role
+		granted.PermissionSets = nil
+		granted.Permissions.Allow = slices.Clone(g.Permissions)
+		return &granted, nil
+	}
+
+	// Grants made before the translation was recorded
+	translated, err := role.TranslatePermissionSets(providerCall.GetProvider())
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate permission sets granted to %s: %w", g.Identity.ID, err)
+	}
+
+	return translated, nil
+}
+
+func (a *ProviderAuthorization) IsAuthorized() bool {
+	return a.Status == ProviderAuthorizationStatusAuthorized
+}
+
+func (a *ProviderAuthorization) IsRevoked() bool {
+	return a.Status == ProviderAuthorizationStatusRevoked
+}
+
+// ProviderLookup resolves a provider by its configured name
+type ProviderLookup func(name string) (*Provider, error)
+
+// IsGranted returns true while at least one provider has authorized the
+// role and has not yet revoked it
+func (e *ElevateRequestInternal) IsGranted() bool {
+	for providerName, auth := range e.Authorizations {
+		if !auth.IsAuthorized() {
+			continue
+		}
+		if revocation, ok := e.Revocations[providerName]; ok && revocation.IsRevoked() {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// GetHolders returns who receives the role: the requested identities,
+// or the requesting user if there are none
+func (e *ElevateRequestInternal) GetHolders() []string {
+	if len(e.Identities) > 0 {
+		return e.Identities
+	}
+	if e.User != nil && len(e.User.Email) > 0 {
+		return []string{e.User.Email}
+	}
+	return nil
+}
+
+// IsBeneficiary returns whether the identity made the request or
+// receives the role, so can't approve it
+func (e *ElevateRequestInternal) IsBeneficiary(identity string) bool {
+
+	beneficiaries := slices.Clone(e.Identities)
+	if e.User != nil {
+		beneficiaries = append(beneficiaries, e.User.Email)
+	}
+
+	return slices.ContainsFunc(beneficiaries, func(beneficiary string) bool {
+		return strings.EqualFold(beneficiary, identity)
+	})
+}
+
+// GetRevocationProviders returns the providers that still need to be
+// revoked. Requests authorized before per-provider tracking fall back
+// to every requested provider.
+func (e *ElevateRequestInternal) GetRevocationProviders() []string {
+
+	providers := []string{}
+
+	// Keep the requested order so revocation mirrors authorization
+	for _, providerName := range e.Providers {
+
+		if len(e.Authorizations) > 0 {
+			if auth, ok := e.Authorizations[providerName]; !ok || !auth.IsAuthorized() {
+				continue
+			}
+		}
+
+		if revocation, ok := e.Revocations[providerName]; ok && revocation.IsRevoked() {
+			continue
+		}
+
+		providers = append(providers, providerName)
+	}
+
+	return providers
+}
+
+// GetProviderGrants returns the grants recorded for the provider. Requests
+// authorized before grants were tracked fall back to the requesting user
+// with the given metadata.
+func (e *ElevateRequestInternal) GetProviderGrants(
+	providerName string,
+	fallback map[string]any,
+) []*ProviderGrant {
+	if auth, ok := e.Authorizations[providerName]; ok && len(auth.Grants) > 0 {
+		return auth.Grants
+	}
+	return []*ProviderGrant{{
+		Identity: NewUserIdentity(e.User),
+		Metadata: fallback,
+	}}
+}
+
+// ResolveIdentities resolves the identities the role should be granted to.
+// If no identities were requested then the requesting user is the target.
+func ResolveIdentities(
+	ctx context.Context,
+	providerCall ProviderImpl,
+	elevateRequest *ElevateRequestInternal,
+) ([]*Identity, error) {
+
+	if len(elevateRequest.Identities) == 0 {
+		return []*Identity{NewUserIdentity(elevateRequest.User)}, nil
+	}
+
+	identities := []*Identity{}
+
+	for _, requested := range elevateRequest.Identities {
+
+		identity, err := providerCall.ResolveIdentity(ctx, requested)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve identity %s: %w", requested, err)
+		}
+
+		identities = append(identities, identity)
+	}
+
+	return identities, nil
+}
+
+// AuthorizeProviders authorizes the role for every identity against every
+// provider in the request. If any grant fails everything already granted
+// is rolled back in reverse order.
+func AuthorizeProviders(
+	ctx context.Context,
+	lookup ProviderLookup,
+	elevateRequest *ElevateRequestInternal,
+	duration time.Duration,
+) (map[string]*ProviderAuthorization, error) {
+
+	if err := ValidateDelegation(
+		elevateRequest.Role, elevateRequest.User, elevateRequest.Identities); err != nil {
+		return nil, err
+	}
+
+	authorizations := map[string]*ProviderAuthorization{}
+	granted := []string{}
+
+	for _, providerName := range elevateRequest.Providers {
+
+		authorization := &ProviderAuthorization{
+			Provider: providerName,
+		}
+		authorizations[providerName] = authorization
+
+		providerCall, err := lookup(providerName)
+
+		if err == nil {
+			err = authorizeProvider(ctx, providerCall.GetClient(), elevateRequest, duration, authorization)
+		}
+
+		if err != nil {
+
+			authorization.Status = ProviderAuthorizationStatusFailed
+			authorization.Error = err.Error()
+
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"provider": providerName,
+				"role":     elevateRequest.Role.Name,
+			}).Error("Failed to authorize provider, rolling back")
+
+			// Undo any identities this provider granted before failing
+			var rollbackErrs []error
+
+			if providerCall != nil && len(authorization.Grants) > 0 {
+				if revokeErr := revokeGrants(
+					ctx, providerCall.GetClient(), elevateRequest, authorization.Grants); revokeErr != nil {
+					rollbackErrs = append(rollbackErrs, fmt.Errorf("failed to roll back provider %s: %w", providerName, revokeErr))
+				}
+			}
+
+			rollbackErrs = append(rollbackErrs,
+				rollbackProviders(ctx, lookup, elevateRequest, authorizations, granted))
+
+			return authorizations, errors.Join(
+				fmt.Errorf("failed to authorize provider %s: %w", providerName, err),
+				errors.Join(rollbackErrs...),
+			)
+		}
+
+		authorizedAt := time.Now().UTC()
+		authorization.Status = ProviderAuthorizationStatusAuthorized
+		authorization.AuthorizedAt = &authorizedAt
+
+		granted = append(granted, providerName)
+
+		logrus.WithFields(logrus.Fields{
+			"provider": providerName,
+			"role":     elevateRequest.Role.Name,
+			"grants":   len(authorization.Grants),
+		}).Info("Authorized provider")
+	}
+
+	return authorizations, nil
+}
+
+// authorizeProvider grants the role to each resolved identity, recording
+// every successful grant on the authorization as it goes
+func authorizeProvider(
+	ctx context.Context,
+	providerCall ProviderImpl,
+	elevateRequest *ElevateRequestInternal,
+	duration time.Duration,
+	authorization *ProviderAuthorization,
+) error {
+
+	identities, err := ResolveIdentities(ctx, providerCall, elevateRequest)
+
+	if err != nil {
+		return err
+	}
+
+	role, err := RoleForProvider(providerCall, elevateRequest.Role)
+
+	if err != nil {
+		return err
+	}
+
+	for _, identity := range identities {
+
+		// Groups have no user, only providers that resolve
+		// groups will receive them and must use the identity
+		authOut, err := providerCall.AuthorizeRole(ctx, &AuthorizeRoleRequest{
+			User:     identity.GetUser(),
+			Role:     role,
+			Duration: &duration,
+			Identity: identity,
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed to authorize identity %s: %w", identity.ID, err)
+		}
+
+		grant := &ProviderGrant{
+			Identity: identity,
+			Metadata: authOut,
+		}
+
+		// Keep what the permission sets translated to so the same
+		// permissions are revoked if the catalog changes
+		if len(elevateRequest.Role.PermissionSets) > 0 {
+			grant.Permissions = slices.Clone(role.Permissions.Allow)
+		}
+
+		authorization.Grants = append(authorization.Grants, grant)
+
+		if len(authOut) > 0 {
+			if authorization.Metadata == nil {
+				authorization.Metadata = map[string]any{}
+			}
+			authorization.Metadata[identity.ID] = authOut
+		}
+	}
+
+	return nil
+}
+
+// RollbackProviders revokes every authorized provider in reverse order and
+// marks them as rolled back. Used when a later step fails after the grants
+// have been made.
+func RollbackProviders(
+	ctx context.Context,
+	lookup ProviderLookup,
+	elevateRequest *ElevateRequestInternal,
+	authorizations map[string]*ProviderAuthorization,
+) error {
+
+	granted := []string{}
+
+	for _, providerName := range elevateRequest.Providers {
+		if auth, ok := authorizations[providerName]; ok && auth.IsAuthorized() {
+			granted = append(granted, providerName)
+		}
+	}
+
+	return rollbackProviders(ctx, lookup, elevateRequest, authorizations, granted)
+}
+
+func rollbackProviders(
+	ctx context.Context,
+	lookup ProviderLookup,
+	elevateRequest *ElevateRequestInternal,
+	authorizations map[string]*ProviderAuthorization,
+	granted []string,
+) error {
+
+	var errs []error
+
+	for _, providerName := range slices.Backward(granted) {
+
+		authorization := authorizations[providerName]
+
+		err := revokeProvider(ctx, lookup, elevateRequest, providerName, authorization.Grants)
+
+		if err != nil {
+			logrus.WithError(err).WithField("provider", providerName).Error("Failed to roll back provider")
+			authorization.Error = err.Error()
+			errs = append(errs, fmt.Errorf("failed to roll back provider %s: %w", providerName, err))
+			continue
+		}
+
+		authorization.Status = ProviderAuthorizationStatusRolledBack
+
+		logrus.WithField("provider", providerName).Info("Rolled back provider")
+	}
+
+	return errors.Join(errs...)
+}
+
+// RevokeProviders revokes the role from every provider that was authorized.
+// Revocation is best effort: every provider is attempted and the errors are
+// returned together with the revocations, including those that succeeded.
+func RevokeProviders(
+	ctx context.Context,
+	lookup ProviderLookup,
+	elevateRequest *ElevateRequestInternal,
+	fallbackMetadata map[string]any,
+) (map[string]*ProviderAuthorization, error) {
+
+	// Carry forward earlier revocations so the context keeps the full set
+	revocations := maps.Clone(elevateRequest.Revocations)
+	if revocations == nil {
+		revocations = map[string]*ProviderAuthorization{}
+	}
+
+	var errs []error
+
+	for _, providerName := range elevateRequest.GetRevocationProviders() {
+
+		grants := elevateRequest.GetProviderGrants(providerName, fallbackMetadata)
+
+		revocation := &ProviderAuthorization{
+			Provider: providerName,
+			Grants:   grants,
+		}
+
+		if auth, ok := elevateRequest.Authorizations[providerName]; ok {
+			revocation.AuthorizedAt = auth.AuthorizedAt
+		}
+
+		revocations[providerName] = revocation
+
+		providerCall, err := lookup(providerName)
+
+		if err == nil {
+			revocation.Metadata, err = revokeGrantsWithOutput(ctx, providerCall.GetClient(), elevateRequest, grants)
+		}
+
+		if err != nil {
+			logrus.WithError(err).WithField("provider", providerName).Error("Failed to revoke provider")
+			revocation.Status = ProviderAuthorizationStatusFailed
+			revocation.Error = err.Error()
+			errs = append(errs, fmt.Errorf("failed to revoke provider %s: %w", providerName, err))
+			continue
+		}
+
+		revokedAt := time.Now().UTC()
+		revocation.Status = ProviderAuthorizationStatusRevoked
+		revocation.RevokedAt = &revokedAt
+	}
+
+	return revocations, errors.Join(errs...)
+}
+
+func revokeProvider(
+	ctx context.Context,
+	lookup ProviderLookup,
+	elevateRequest *ElevateRequestInternal,
+	providerName string,
+	grants []*ProviderGrant,
+) error {
+
+	providerCall, err := lookup(providerName)
+	if err != nil {
+		return fmt.Errorf("failed to get provider: %w", err)
+	}
+
+	return revokeGrants(ctx, providerCall.GetClient(), elevateRequest, grants)
+}
+
+func revokeGrants(
+	ctx context.Context,
+	providerCall ProviderImpl,
+	elevateRequest *ElevateRequestInternal,
+	grants []*ProviderGrant,
+) error {
+	_, err := revokeGrantsWithOutput(ctx, providerCall, elevateRequest, grants)
+	return err
+}
+
+// revokeGrantsWithOutput revokes each grant in reverse order, returning
+// the provider output keyed by identity. Every grant is attempted even if
+// an earlier one fails.
+func revokeGrantsWithOutput(
+	ctx context.Context,
+	providerCall ProviderImpl,
+	elevateRequest *ElevateRequestInternal,
+	grants []*ProviderGrant,
+) (map[string]any, error) {
+
+	revokeOut := map[string]any{}
+
+	var errs []error
+
+	for _, grant := range slices.Backward(grants) {
+
+		// Revoke the role as it was granted
+		role, err := grant.GetRole(providerCall, elevateRequest.Role)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		out, err := providerCall.RevokeRole(
+			ctx, grant.Identity.GetUser(), role, grant.Metadata)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to revoke identity %s: %w", grant.Identity.ID, err))
+			continue
+		}
+
+		if len(out) > 0 {
+			revokeOut[grant.Identity.ID] = out
+		}
+	}
+
+	return revokeOut, errors.Join(errs...)
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type mockRBACProvider struct {
+	*BaseProvider
+	failAuthorize bool
+	authorized    *[]string
+	revoked       *[]string
+}
+
+func (m *mockRBACProvider) AuthorizeRole(
+	ctx context.Context,
+	req *AuthorizeRoleRequest,
+) (map[string]any, error) {
+	if m.failAuthorize {
+		return nil, errors.New("authorize failed")
+	}
+	*m.authorized = append(*m.authorized, m.GetName())
+	return map[string]any{"grant": m.GetName()}, nil
+}
+
+func (m *mockRBACProvider) RevokeRole(
+	ctx context.Context,
+	user *User,
+	role *Role,
+	metadata map[string]any,
+) (map[string]any, error) {
+	if metadata["grant"] != m.GetName() {
+		return nil, fmt.Errorf("unexpected metadata %v", metadata)
+	}
+	*m.revoked = append(*m.revoked, m.GetName())
+	return nil, nil
+}
+
+func newMockLookup(failing string, authorized, revoked *[]string) ProviderLookup {
+	return func(name string) (*Provider, error) {
+		provider := &Provider{Name: name, Provider: "mock"}
+		provider.SetClient(&mockRBACProvider{
+			BaseProvider:  NewBaseProvider(*provider, ProviderCapabilityRBAC),
+			failAuthorize: name == failing,
+			authorized:    authorized,
+			revoked:       revoked,
+		})
+		return provider, nil
+	}
+}
+
+func newMockElevateRequest(providers ...string) *ElevateRequestInternal {
+	return &ElevateRequestInternal{
+		ElevateRequest: ElevateRequest{
+			Role:      &Role{Name: "incident-response"},
+			Providers: providers,
+			Reason:    "incident",
+		},
+		User: &User{Name: "alice"},
+	}
+}
+
+func TestAuthorizeProviders_AllSucceed(t *testing.T) {
+
+	var authorized, revoked []string
+	lookup := newMockLookup("", &authorized, &revoked)
+	request := newMockElevateRequest("aws", "gcp", "github")
+
+	authorizations, err := AuthorizeProviders(context.Background(), lookup, request, time.Hour)
+
+	if err != nil {
+		t.Fatalf("AuthorizeProviders() error = %v", err)
+	}
+
+	if len(authorized) != 3 || len(revoked) != 0 {
+		t.Fatalf("expected 3 grants and no revocations, got %v and %v", authorized, revoked)
+	}
+
+	for _, name := range request.Providers {
+		if !authorizations[name].IsAuthorized() {
+			t.Errorf("expected %s to be authorized, got %s", name, authorizations[name].Status)
+		}
+
+		// Each provider keeps its own output, keyed by identity
+		for identity, out := range authorizations[name].Metadata {
+			if out.(map[string]any)["grant"] != name {
+				t.Errorf("expected %s output for %s, got %v", name, identity, out)
+			}
+		}
+	}
+
+	// Revocation walks the same set using each provider's metadata
+	request.Authorizations = authorizations
+
+	revocations, err := RevokeProviders(context.Background(), lookup, request, nil)
+
+	if err != nil {
+		t.Fatalf("RevokeProviders() error = %v", err)
+	}
+
+	if len(revoked) != 3 {
+		t.Fatalf("expected 3 revocations, got %v", revoked)
+	}
+
+	// Already revoked providers are skipped on a second pass
+	request.Revocations = revocations
+
+	if remaining := request.GetRevocationProviders(); len(remaining) != 0 {
+		t.Errorf("expected no remaining providers, got %v", remaining)
+	}
+}
+
+func TestAuthorizeProviders_RollsBackOnFailure(t *testing.T) {
+
+	var authorized, revoked []string
+	lookup := newMockLookup("github", &authorized, &revoked)
+	request := newMockElevateRequest("aws", "gcp", "github")
+
+	authorizations, err := AuthorizeProviders(context.Background(), lookup, request, time.Hour)
+
+	if err == nil {
+		t.Fatal("expected AuthorizeProviders() to fail")
+	}
+
+	// Rolled back in reverse order
+	if len(revoked) != 2 || revoked[0] != "gcp" || revoked[1] != "aws" {
+		t.Fatalf("expected gcp and aws to be rolled back in order, got %v", revoked)
+	}
+
+	expected := map[string]ProviderAuthorizationStatus{
+		"aws":    ProviderAuthorizationStatusRolledBack,
+		"gcp":    ProviderAuthorizationStatusRolledBack,
+		"github": ProviderAuthorizationStatusFailed,
+	}
+
+	for name, status := range expected {
+		if authorizations[name].Status != status {
+			t.Errorf("expected %s to be %s, got %s", name, status, authorizations[name].Status)
+		}
+	}
+}
+
+func (m *mockRBACProvider) PlanRole(
+	ctx context.Context,
+	req *AuthorizeRoleRequest,
+) (*RolePlan, error) {
+	return NewRolePlan(m, req), nil
+}
+
+func TestPlanRole_EachIdentity(t *testing.T) {
+
+	var authorized, revoked []string
+	provider, _ := newMockLookup("", &authorized, &revoked)("aws")
+
+	request := newMockElevateRequest("aws")
+	request.Duration = "1h"
+	request.Identities = []string{"bob@example.com", "carol@example.com"}
+
+	plans, err := PlanRole(context.Background(), provider.GetClient(), *request)
+
+	if err != nil {
+		t.Fatalf("PlanRole() error = %v", err)
+	}
+
+	if len(plans) != 2 {
+		t.Fatalf("expected a plan for each identity, got %d", len(plans))
+	}
+
+	for i, identity := range request.Identities {
+		if plans[i].User != identity {
+			t.Errorf("expected plan for %s, got %s", identity, plans[i].User)
+		}
+	}
+}
+
+func TestElevateRequest_IsGranted(t *testing.T) {
+
+	request := newMockElevateRequest("aws", "gcp")
+
+	if request.IsGranted() {
+		t.Fatal("expected a request without authorizations not to be granted")
+	}
+
+	request.Authorizations = map[string]*ProviderAuthorization{
+		"aws": {Provider: "aws", Status: ProviderAuthorizationStatusAuthorized},
+		"gcp": {Provider: "gcp", Status: ProviderAuthorizationStatusAuthorized},
+	}
+
+	if !request.IsGranted() {
+		t.Fatal("expected an authorized request to be granted")
+	}
+
+	// Still granted until every provider has been revoked
+	request.Revocations = map[string]*ProviderAuthorization{
+		"aws": {Provider: "aws", Status: ProviderAuthorizationStatusRevoked},
+	}
+
+	if !request.IsGranted() {
+		t.Fatal("expected a partially revoked request to be granted")
+	}
+
+	request.Revocations["gcp"] = &ProviderAuthorization{Provider: "gcp", Status: ProviderAuthorizationStatusRevoked}
+
+	if request.IsGranted() {
+		t.Fatal("expected a revoked request not to be granted")
+	}
+}
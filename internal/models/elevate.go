@@ -19,6 +19,10 @@ type ElevateStaticRequest struct {
 	Reason     string   `json:"reason" form:"reason" binding:"required"`
 	Duration   string   `json:"duration,omitempty" form:"duration,omitempty"`     // Duration in ISO 8601 format
 	Identities []string `json:"identities,omitempty" form:"identities,omitempty"` // Optional identities to elevate, if empty the requesting user is used
+	DryRun     bool     `json:"dry_run,omitempty" form:"dry_run,omitempty"`       // Plan the elevation without applying it
+
+	// Values for a templated role, sent as params[name]=value in queries
+	Params map[string]string `json:"params,omitempty" form:"-"`
 
 	// Protected session
 	Session *LocalSession `json:"session,omitempty" form:"session,omitempty"`
@@ -34,6 +38,12 @@ func (r *ElevateStaticRequest) GetUrlParams() url.Values {
 		"identities": {strings.Join(r.Identities, ",")},
 		"session":    {r.GetEncodedSession()}, // TODO provide the current auth session
 	}
+	if r.DryRun {
+		params.Set("dry_run", "true")
+	}
+	for name, value := range r.Params {
+		params.Set("params["+name+"]", value)
+	}
 	return params
 }
 
@@ -51,6 +61,12 @@ type ElevateResponse struct {
 	Output map[string]any  `json:"output,omitempty"`
 }
 
+// ElevatePlanResponse represents the response for a dry run of the /elevate endpoint
+type ElevatePlanResponse struct {
+	Role  string     `json:"role"`
+	Plans []RolePlan `json:"plans"`
+}
+
 type ElevateRequest struct {
 	Role          *Role         `json:"role"`
 	Providers     []string      `json:"providers"`     // A role can be applied to multiple providers
@@ -60,6 +76,14 @@ type ElevateRequest struct {
 	Duration      string        `json:"duration,omitempty"`   // Duration in ISO 8601 format
 	Identities    []string      `json:"identities,omitempty"` // Optional identities to elevate, if empty the requesting user is used
 	Session       *LocalSession `json:"session,omitempty"`
+	BreakGlass    bool          `json:"break_glass,omitempty"` // Confirms the requester is using a break-glass role
+
+	// Values for a templated role
+	Params map[string]string `json:"params,omitempty"`
+
+	// Set by the server when the request is received
+	Metadata *ElevateRequestMetadata `json:"metadata,omitempty"`
+	Risk     *RiskAssessment         `json:"risk,omitempty"`
 }
 
 func (e *ElevateRequest) IsValid() bool {
@@ -79,6 +103,10 @@ func (e *ElevateRequest) AsMap() map[string]any {
 		"reason":        e.Reason,
 		"duration":      e.Duration,
 		"identities":    e.Identities,
+		"break_glass":   e.BreakGlass,
+		"params":        e.Params,
+		"metadata":      e.Metadata,
+		"risk":          e.Risk,
 	}
 }
 
@@ -98,6 +126,10 @@ type ElevateRequestInternal struct {
 	// Protected user
 	User         *User      `json:"user,omitempty"`
 	AuthorizedAt *time.Time `json:"authorized_at,omitempty"`
+
+	// Per-provider authorization and revocation results
+	Authorizations map[string]*ProviderAuthorization `json:"authorizations,omitempty"`
+	Revocations    map[string]*ProviderAuthorization `json:"revocations,omitempty"`
 }
 
 type ElevateDynamicRequest struct {
@@ -0,0 +1,261 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+type IdentityType string
+
+const (
+	IdentityTypeUser           IdentityType = "user"
+	IdentityTypeGroup          IdentityType = "group"
+	IdentityTypeServiceAccount IdentityType = "service_account"
+)
+
+type Group struct {
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+}
+
+// Identity is a principal a role can be granted to. Identities
+// are requested as strings such as alice@example.com, group:eng@example.com
+// or serviceAccount:ci@example.com and resolved by each provider.
+type Identity struct {
+	ID       string       `json:"id"` // The identity as it was requested
+	Type     IdentityType `json:"type"`
+	Provider string       `json:"provider,omitempty"` // The provider the identity was resolved from
+	User     *User        `json:"user,omitempty"`     // Set for users and service accounts
+	Group    *Group       `json:"group,omitempty"`    // Set for groups
+}
+
+func NewUserIdentity(user *User) *Identity {
+	identity := &Identity{
+		Type: IdentityTypeUser,
+		User: user,
+	}
+	if user != nil {
+		identity.ID = user.GetName()
+		if len(user.Email) > 0 {
+			identity.ID = user.Email
+		}
+	}
+	return identity
+}
+
+func NewGroupIdentity(group *Group) *Identity {
+	identity := &Identity{
+		Type:  IdentityTypeGroup,
+		Group: group,
+	}
+	if group != nil {
+		identity.ID = "group:" + group.Name
+		if len(group.Email) > 0 {
+			identity.ID = "group:" + group.Email
+		}
+	}
+	return identity
+}
+
+// ParseIdentity parses an identity string into its type and principal.
+// Identities without a type prefix are treated as users.
+func ParseIdentity(identity string) (*Identity, error) {
+
+	identity = strings.TrimSpace(identity)
+
+	if len(identity) == 0 {
+		return nil, fmt.Errorf("identity cannot be empty")
+	}
+
+	identityType := IdentityTypeUser
+	principal := identity
+
+	if prefix, value, found := strings.Cut(identity, ":"); found {
+		switch strings.ToLower(prefix) {
+		case "user":
+			identityType = IdentityTypeUser
+		case "group":
+			identityType = IdentityTypeGroup
+		case "serviceaccount", "service_account":
+			identityType = IdentityTypeServiceAccount
+		default:
+			return nil, fmt.Errorf("unknown identity type: %s", prefix)
+		}
+		principal = value
+	}
+
+	if len(principal) == 0 {
+		return nil, fmt.Errorf("identity %s has no principal", identity)
+	}
+
+	result := &Identity{
+		ID:   identity,
+		Type: identityType,
+	}
+
+	switch identityType {
+	case IdentityTypeGroup:
+		result.Group = &Group{
+			Name: principal,
+		}
+		if strings.Contains(principal, "@") {
+			result.Group.Email = principal
+		}
+	default:
+		result.User = &User{
+			Username: principal,
+			Name:     principal,
+		}
+		if strings.Contains(principal, "@") {
+			result.User.Email = principal
+			result.User.Username = strings.Split(principal, "@")[0]
+		}
+	}
+
+	return result, nil
+}
+
+func (g *Group) MatchesFilters(filters ...string) bool {
+	return matchesFilters([]string{g.ID, g.Name, g.Email}, filters...)
+}
+
+// matchesFilters returns true if every filter is contained
+// in at least one of the values, ignoring case
+func matchesFilters(values []string, filters ...string) bool {
+	for _, filter := range filters {
+		filter = strings.ToLower(strings.TrimSpace(filter))
+		if len(filter) == 0 {
+			continue
+		}
+		found := false
+		for _, value := range values {
+			if strings.Contains(strings.ToLower(value), filter) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *Identity) GetUser() *User {
+	return i.User
+}
+
+func (i *Identity) GetGroup() *Group {
+	return i.Group
+}
+
+func (i *Identity) IsUser() bool {
+	return i.Type == IdentityTypeUser
+}
+
+func (i *Identity) IsGroup() bool {
+	return i.Type == IdentityTypeGroup
+}
+
+func (i *Identity) IsServiceAccount() bool {
+	return i.Type == IdentityTypeServiceAccount
+}
+
+func (i *Identity) GetName() string {
+	if i.User != nil {
+		return i.User.GetName()
+	} else if i.Group != nil {
+		return i.Group.Name
+	}
+	return i.ID
+}
+
+func (i *Identity) GetEmail() string {
+	if i.User != nil {
+		return i.User.Email
+	} else if i.Group != nil {
+		return i.Group.Email
+	}
+	return ""
+}
+
+// Matches returns true if the identity refers to the given user. Only the
+// email, username and ID are compared, never the display name, which the
+// user can choose.
+func (i *Identity) Matches(user *User) bool {
+
+	if user == nil || !i.IsUser() || i.User == nil {
+		return false
+	}
+
+	if len(i.User.Email) > 0 {
+		return len(user.Email) > 0 && strings.EqualFold(i.User.Email, user.Email)
+	}
+
+	principal := i.User.Username
+
+	if len(principal) == 0 {
+		return false
+	}
+
+	return (len(user.Username) > 0 && strings.EqualFold(principal, user.Username)) ||
+		(len(user.ID) > 0 && principal == user.ID)
+}
+
+// ValidateDelegation checks the requester is allowed to target the
+// requested identities with the role. Requesting access for yourself
+// is always allowed, anything else must be permitted by the role.
+func ValidateDelegation(role *Role, requester *User, identities []string) error {
+
+	if role == nil {
+		return fmt.Errorf("role must be provided to validate delegation")
+	}
+
+	for _, requested := range identities {
+
+		identity, err := ParseIdentity(requested)
+
+		if err != nil {
+			return fmt.Errorf("invalid identity %s: %w", requested, err)
+		}
+
+		if identity.Matches(requester) {
+			continue
+		}
+
+		if !role.CanDelegate(identity.Type) {
+			return fmt.Errorf("role %s does not allow delegation to %s identities", role.Name, identity.Type)
+		}
+	}
+
+	return nil
+}
+
+// IdentitiesResponse represents the response for /identities endpoint
+type IdentitiesResponse struct {
+	Identities []IdentityResponse `json:"identities"`
+}
+
+// IdentityResponse is a flattened identity used for autocomplete
+type IdentityResponse struct {
+	ID       string       `json:"id"` // The value to request the identity with
+	Type     IdentityType `json:"type"`
+	Name     string       `json:"name"`
+	Email    string       `json:"email,omitempty"`
+	Provider string       `json:"provider,omitempty"`
+}
+
+func (i IdentityResponse) MatchesFilters(filters ...string) bool {
+	return matchesFilters([]string{i.ID, i.Name, i.Email}, filters...)
+}
+
+func (i *Identity) AsResponse() IdentityResponse {
+	return IdentityResponse{
+		ID:       i.ID,
+		Type:     i.Type,
+		Name:     i.GetName(),
+		Email:    i.GetEmail(),
+		Provider: i.Provider,
+	}
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestParseIdentity(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		identity     string
+		expectedType IdentityType
+		expectedName string
+		wantErr      bool
+	}{
+		{
+			name:         "plain email is a user",
+			identity:     "alice@example.com",
+			expectedType: IdentityTypeUser,
+			expectedName: "alice@example.com",
+		},
+		{
+			name:         "group prefix",
+			identity:     "group:eng@example.com",
+			expectedType: IdentityTypeGroup,
+			expectedName: "eng@example.com",
+		},
+		{
+			name:         "service account prefix",
+			identity:     "serviceAccount:ci@project.iam.gserviceaccount.com",
+			expectedType: IdentityTypeServiceAccount,
+			expectedName: "ci@project.iam.gserviceaccount.com",
+		},
+		{
+			name:     "unknown prefix",
+			identity: "robot:ci",
+			wantErr:  true,
+		},
+		{
+			name:     "empty identity",
+			identity: " ",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := ParseIdentity(tt.identity)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseIdentity(%q) expected error", tt.identity)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseIdentity(%q) error = %v", tt.identity, err)
+			}
+
+			if identity.Type != tt.expectedType {
+				t.Errorf("Type = %v, expected %v", identity.Type, tt.expectedType)
+			}
+
+			if identity.GetName() != tt.expectedName {
+				t.Errorf("GetName() = %v, expected %v", identity.GetName(), tt.expectedName)
+			}
+		})
+	}
+}
+
+func TestValidateDelegation(t *testing.T) {
+
+	requester := &User{Email: "lead@example.com", Username: "lead", Name: "admin"}
+
+	role := &Role{Name: "contractor"}
+	delegatedRole := &Role{
+		Name:       "ci",
+		Delegation: []IdentityType{IdentityTypeServiceAccount},
+	}
+
+	tests := []struct {
+		name       string
+		role       *Role
+		identities []string
+		wantErr    bool
+	}{
+		{
+			name: "no identities",
+			role: role,
+		},
+		{
+			name:       "requesting for yourself",
+			role:       role,
+			identities: []string{"LEAD@example.com"},
+		},
+		{
+			name:       "requesting for yourself by username",
+			role:       role,
+			identities: []string{"lead"},
+		},
+		{
+			name:       "display name is not the requester",
+			role:       role,
+			identities: []string{"admin"},
+			wantErr:    true,
+		},
+		{
+			name:       "delegating without permission",
+			role:       role,
+			identities: []string{"contractor@example.com"},
+			wantErr:    true,
+		},
+		{
+			name:       "delegating to an allowed type",
+			role:       delegatedRole,
+			identities: []string{"serviceAccount:ci@example.com"},
+		},
+		{
+			name:       "delegating to a disallowed type",
+			role:       delegatedRole,
+			identities: []string{"group:eng@example.com"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDelegation(tt.role, requester, tt.identities)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateDelegation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroup_MatchesFilters(t *testing.T) {
+
+	group := Group{ID: "g-123", Name: "Platform Engineering", Email: "platform@example.com"}
+
+	tests := []struct {
+		name     string
+		filters  []string
+		expected bool
+	}{
+		{name: "no filters", filters: nil, expected: true},
+		{name: "case insensitive name", filters: []string{"platform"}, expected: true},
+		{name: "every filter must match", filters: []string{"platform", "example"}, expected: true},
+		{name: "one filter missing", filters: []string{"platform", "security"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := group.MatchesFilters(tt.filters...); got != tt.expected {
+				t.Errorf("MatchesFilters(%v) = %v, expected %v", tt.filters, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PermissionSet is an intent-level group of permissions, such as
+// storage-read, that roles can reference once and have translated into
+// each provider's own permissions when the role is granted.
+//
+//	roles:
+//	  storage-reader:
+//	    permission_sets: [storage-read]
+//	    providers: [aws-prod, gcp-prod]
+type PermissionSet struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Providers   map[string][]string `json:"providers"` // Permissions keyed by provider type such as aws, gcp or azure
+}
+
+// GetPermissions returns the permissions the set grants in the provider type
+func (s *PermissionSet) GetPermissions(providerType string) ([]string, bool) {
+	for key, permissions := range s.Providers {
+		if strings.EqualFold(key, providerType) {
+			return permissions, true
+		}
+	}
+	return nil, false
+}
+
+// SupportsProvider returns true if the set can be translated for the provider type
+func (s *PermissionSet) SupportsProvider(providerType string) bool {
+	permissions, found := s.GetPermissions(providerType)
+	return found && len(permissions) > 0
+}
+
+var (
+	permissionSetsMu sync.RWMutex
+	permissionSets   = maps.Clone(defaultPermissionSets)
+)
+
+// RegisterPermissionSets adds permission sets to the catalog. Sets with the
+// same name as a built in set replace it.
+func RegisterPermissionSets(sets map[string]PermissionSet) {
+
+	permissionSetsMu.Lock()
+	defer permissionSetsMu.Unlock()
+
+	for name, set := range sets {
+		if len(set.Name) == 0 {
+			set.Name = name
+		}
+		permissionSets[strings.ToLower(name)] = set
+
+		logrus.WithField("set", name).Debugln("Registered permission set")
+	}
+}
+
+// GetPermissionSet returns the permission set with the given name
+func GetPermissionSet(name string) (*PermissionSet, error) {
+
+	permissionSetsMu.RLock()
+	defer permissionSetsMu.RUnlock()
+
+	set, exists := permissionSets[strings.ToLower(name)]
+	if !exists {
+		return nil, fmt.Errorf("permission set not found: %s", name)
+	}
+
+	return &set, nil
+}
+
+// GetPermissionSets returns every permission set that can be translated
+// for the provider type, or all sets if no type is given, sorted by name
+func GetPermissionSets(providerType string) []PermissionSet {
+
+	permissionSetsMu.RLock()
+	defer permissionSetsMu.RUnlock()
+
+	sets := []PermissionSet{}
+
+	for _, name := range slices.Sorted(maps.Keys(permissionSets)) {
+		set := permissionSets[name]
+		if len(providerType) == 0 || set.SupportsProvider(providerType) {
+			sets = append(sets, set)
+		}
+	}
+
+	return sets
+}
+
+// TranslatePermissionSets returns a copy of the role with its permission
+// sets replaced by the permissions they grant in the provider type. Roles
+// without permission sets are returned as they are.
+func (r *Role) TranslatePermissionSets(providerType string) (*Role, error) {
+
+	if r == nil || len(r.PermissionSets) == 0 {
+		return r, nil
+	}
+
+	translated := *r
+	translated.PermissionSets = nil
+	translated.Permissions.Allow = slices.Clone(r.Permissions.Allow)
+
+	for _, name := range r.PermissionSets {
+
+		set, err := GetPermissionSet(name)
+		if err != nil {
+			return nil, fmt.Errorf("role %s uses an unknown permission set: %w", r.Name, err)
+		}
+
+		permissions, found := set.GetPermissions(providerType)
+		if !found || len(permissions) == 0 {
+			return nil, fmt.Errorf("permission set %s has no permissions for %s", name, providerType)
+		}
+
+		translated.Permissions.Allow = appendUnique(translated.Permissions.Allow, permissions...)
+	}
+
+	// Denies on the role still take precedence over its sets
+	translated.applyDenies()
+
+	logrus.WithFields(logrus.Fields{
+		"role":        r.Name,
+		"provider":    providerType,
+		"sets":        r.PermissionSets,
+		"permissions": len(translated.Permissions.Allow),
+	}).Debugln("Translated permission sets")
+
+	return &translated, nil
+}
+
+// RoleForProvider returns the role as the provider should grant it. Any
+// permission sets are translated into the provider's permissions and
+// checked against its catalog, so wildcards in sets are resolved the same
+// way as wildcards in the role itself.
+func RoleForProvider(provider ProviderImpl, role *Role) (*Role, error) {
+
+	if role == nil || len(role.PermissionSets) == 0 {
+		return role, nil
+	}
+
+	translated, err := role.TranslatePermissionSets(provider.GetProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateRolePermissions(provider, translated); err != nil {
+		return nil, fmt.Errorf("failed to validate permission sets for %s: %w", provider.GetName(), err)
+	}
+
+	return translated, nil
+}
@@ -0,0 +1,167 @@
+package models
+
+// defaultPermissionSets are the permission sets available without any
+// configuration. Each maps an intent to the equivalent AWS actions, GCP
+// permissions and Azure actions.
+var defaultPermissionSets = map[string]PermissionSet{
+	"storage-read": {
+		Name:        "storage-read",
+		Description: "List buckets and read objects",
+		Providers: map[string][]string{
+			"aws": {
+				"s3:ListAllMyBuckets",
+				"s3:ListBucket",
+				"s3:GetBucketLocation",
+				"s3:GetObject",
+			},
+			"gcp": {
+				"storage.buckets.get",
+				"storage.buckets.list",
+				"storage.objects.get",
+				"storage.objects.list",
+			},
+			"azure": {
+				"Microsoft.Storage/storageAccounts/read",
+				"Microsoft.Storage/storageAccounts/blobServices/containers/read",
+				"Microsoft.Storage/storageAccounts/blobServices/generateUserDelegationKey/action",
+			},
+		},
+	},
+	"storage-write": {
+		Name:        "storage-write",
+		Description: "Read, write and delete objects in existing buckets",
+		Providers: map[string][]string{
+			"aws": {
+				"s3:ListAllMyBuckets",
+				"s3:ListBucket",
+				"s3:GetBucketLocation",
+				"s3:GetObject",
+				"s3:PutObject",
+				"s3:DeleteObject",
+			},
+			"gcp": {
+				"storage.buckets.get",
+				"storage.buckets.list",
+				"storage.objects.get",
+				"storage.objects.list",
+				"storage.objects.create",
+				"storage.objects.update",
+				"storage.objects.delete",
+			},
+			"azure": {
+				"Microsoft.Storage/storageAccounts/read",
+				"Microsoft.Storage/storageAccounts/blobServices/containers/read",
+				"Microsoft.Storage/storageAccounts/blobServices/containers/write",
+				"Microsoft.Storage/storageAccounts/blobServices/generateUserDelegationKey/action",
+			},
+		},
+	},
+	"compute-read": {
+		Name:        "compute-read",
+		Description: "View virtual machines, disks and networks",
+		Providers: map[string][]string{
+			"aws": {
+				"ec2:DescribeInstances",
+				"ec2:DescribeInstanceStatus",
+				"ec2:DescribeVolumes",
+				"ec2:DescribeSecurityGroups",
+				"ec2:DescribeSubnets",
+				"ec2:DescribeVpcs",
+			},
+			"gcp": {
+				"compute.instances.get",
+				"compute.instances.list",
+				"compute.disks.get",
+				"compute.disks.list",
+				"compute.firewalls.list",
+				"compute.networks.list",
+				"compute.subnetworks.list",
+			},
+			"azure": {
+				"Microsoft.Compute/virtualMachines/read",
+				"Microsoft.Compute/disks/read",
+				"Microsoft.Network/networkInterfaces/read",
+				"Microsoft.Network/networkSecurityGroups/read",
+				"Microsoft.Network/virtualNetworks/read",
+			},
+		},
+	},
+	"compute-debug": {
+		Name:        "compute-debug",
+		Description: "View virtual machines, read their console output and restart them",
+		Providers: map[string][]string{
+			"aws": {
+				"ec2:DescribeInstances",
+				"ec2:DescribeInstanceStatus",
+				"ec2:DescribeSecurityGroups",
+				"ec2:GetConsoleOutput",
+				"ec2:GetConsoleScreenshot",
+				"ec2:RebootInstances",
+				"ssm:StartSession",
+				"ssm:TerminateSession",
+				"ssm:DescribeInstanceInformation",
+			},
+			"gcp": {
+				"compute.instances.get",
+				"compute.instances.list",
+				"compute.instances.getSerialPortOutput",
+				"compute.instances.getScreenshot",
+				"compute.instances.reset",
+				"compute.firewalls.list",
+			},
+			"azure": {
+				"Microsoft.Compute/virtualMachines/read",
+				"Microsoft.Compute/virtualMachines/instanceView/read",
+				"Microsoft.Compute/virtualMachines/restart/action",
+				"Microsoft.Compute/virtualMachines/retrieveBootDiagnosticsData/action",
+				"Microsoft.Network/networkSecurityGroups/read",
+			},
+		},
+	},
+	"logs-read": {
+		Name:        "logs-read",
+		Description: "Search and read application and platform logs",
+		Providers: map[string][]string{
+			"aws": {
+				"logs:DescribeLogGroups",
+				"logs:DescribeLogStreams",
+				"logs:GetLogEvents",
+				"logs:FilterLogEvents",
+				"logs:StartQuery",
+				"logs:GetQueryResults",
+			},
+			"gcp": {
+				"logging.logEntries.list",
+				"logging.logs.list",
+				"logging.views.access",
+			},
+			"azure": {
+				"Microsoft.Insights/diagnosticSettings/read",
+				"Microsoft.OperationalInsights/workspaces/read",
+				"Microsoft.OperationalInsights/workspaces/query/read",
+			},
+		},
+	},
+	"database-read": {
+		Name:        "database-read",
+		Description: "View managed database instances and their configuration",
+		Providers: map[string][]string{
+			"aws": {
+				"rds:DescribeDBInstances",
+				"rds:DescribeDBClusters",
+				"rds:DescribeDBSnapshots",
+				"rds:DescribeDBLogFiles",
+			},
+			"gcp": {
+				"cloudsql.instances.get",
+				"cloudsql.instances.list",
+				"cloudsql.backupRuns.list",
+				"cloudsql.databases.list",
+			},
+			"azure": {
+				"Microsoft.Sql/servers/read",
+				"Microsoft.Sql/servers/databases/read",
+			},
+		},
+	},
+}
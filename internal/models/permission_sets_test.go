@@ -0,0 +1,198 @@
+package models
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+type mockCatalogProvider struct {
+	*BaseProvider
+	permissions []ProviderPermission
+}
+
+func (m *mockCatalogProvider) ListPermissions(ctx context.Context, filters ...string) ([]ProviderPermission, error) {
+	return m.permissions, nil
+}
+
+func TestRole_TranslatePermissionSets(t *testing.T) {
+
+	role := &Role{
+		Name:           "debugger",
+		PermissionSets: []string{"storage-read", "Logs-Read"},
+		Permissions: Permissions{
+			Allow: []string{"ec2:DescribeInstances"},
+			Deny:  []string{"s3:GetObject"},
+		},
+	}
+
+	translated, err := role.TranslatePermissionSets("AWS")
+	if err != nil {
+		t.Fatalf("TranslatePermissionSets() error = %v", err)
+	}
+
+	if len(translated.PermissionSets) != 0 {
+		t.Errorf("expected the sets to be replaced, got %v", translated.PermissionSets)
+	}
+
+	for _, permission := range []string{"ec2:DescribeInstances", "s3:ListBucket", "logs:GetLogEvents"} {
+		if !slices.Contains(translated.Permissions.Allow, permission) {
+			t.Errorf("expected %s to be allowed, got %v", permission, translated.Permissions.Allow)
+		}
+	}
+
+	if slices.Contains(translated.Permissions.Allow, "s3:GetObject") {
+		t.Error("expected the role's deny to take precedence over its sets")
+	}
+
+	// The role itself is left untouched for other providers
+	if len(role.Permissions.Allow) != 1 || len(role.PermissionSets) != 2 {
+		t.Errorf("expected the role to be unchanged, got %+v", role)
+	}
+
+	gcp, err := role.TranslatePermissionSets("gcp")
+	if err != nil {
+		t.Fatalf("TranslatePermissionSets() error = %v", err)
+	}
+
+	if !slices.Contains(gcp.Permissions.Allow, "storage.objects.get") {
+		t.Errorf("expected gcp permissions, got %v", gcp.Permissions.Allow)
+	}
+}
+
+func TestRole_TranslatePermissionSetsErrors(t *testing.T) {
+
+	unknown := &Role{Name: "unknown", PermissionSets: []string{"does-not-exist"}}
+	if _, err := unknown.TranslatePermissionSets("aws"); err == nil {
+		t.Error("expected an unknown permission set to fail")
+	}
+
+	unsupported := &Role{Name: "unsupported", PermissionSets: []string{"storage-read"}}
+	if _, err := unsupported.TranslatePermissionSets("github"); err == nil {
+		t.Error("expected a provider without a translation to fail")
+	}
+
+	var nilRole *Role
+	if translated, err := nilRole.TranslatePermissionSets("aws"); err != nil || translated != nil {
+		t.Errorf("expected a nil role to be returned as is, got %v, %v", translated, err)
+	}
+}
+
+func TestRegisterPermissionSets(t *testing.T) {
+
+	t.Cleanup(func() {
+		permissionSetsMu.Lock()
+		delete(permissionSets, "queue-read")
+		permissionSetsMu.Unlock()
+	})
+
+	RegisterPermissionSets(map[string]PermissionSet{
+		"queue-read": {
+			Providers: map[string][]string{
+				"aws": {"sqs:ReceiveMessage"},
+			},
+		},
+	})
+
+	set, err := GetPermissionSet("queue-read")
+	if err != nil {
+		t.Fatalf("GetPermissionSet() error = %v", err)
+	}
+
+	if set.Name != "queue-read" {
+		t.Errorf("expected the name to default to the key, got %s", set.Name)
+	}
+
+	if !slices.ContainsFunc(GetPermissionSets("aws"), func(s PermissionSet) bool { return s.Name == "queue-read" }) {
+		t.Error("expected the set to be listed for aws")
+	}
+
+	if slices.ContainsFunc(GetPermissionSets("gcp"), func(s PermissionSet) bool { return s.Name == "queue-read" }) {
+		t.Error("expected the set not to be listed for gcp")
+	}
+}
+
+func TestRoleForProvider(t *testing.T) {
+
+	provider := &mockCatalogProvider{
+		BaseProvider: NewBaseProvider(Provider{Name: "gcp-prod", Provider: "gcp"}),
+		permissions: []ProviderPermission{
+			{Name: "storage.buckets.get"},
+			{Name: "storage.buckets.list"},
+			{Name: "storage.objects.get"},
+			{Name: "storage.objects.list"},
+		},
+	}
+
+	role := &Role{Name: "reader", PermissionSets: []string{"storage-read"}}
+
+	granted, err := RoleForProvider(provider, role)
+	if err != nil {
+		t.Fatalf("RoleForProvider() error = %v", err)
+	}
+
+	if len(granted.Permissions.Allow) != 4 {
+		t.Errorf("expected 4 permissions, got %v", granted.Permissions.Allow)
+	}
+
+	// Sets are checked against the provider's catalog
+	provider.permissions = provider.permissions[:1]
+
+	if _, err := RoleForProvider(provider, role); err == nil {
+		t.Error("expected a set permission missing from the catalog to fail")
+	}
+}
+
+func TestResolveRole_InheritsPermissionSets(t *testing.T) {
+
+	roles := map[string]Role{
+		"base":  {Name: "base", PermissionSets: []string{"logs-read"}},
+		"child": {Name: "child", Inherits: []string{"base"}, PermissionSets: []string{"compute-debug"}},
+	}
+
+	child := roles["child"]
+
+	resolved, err := ResolveRole(&child, roles)
+	if err != nil {
+		t.Fatalf("ResolveRole() error = %v", err)
+	}
+
+	if !slices.Equal(resolved.PermissionSets, []string{"compute-debug", "logs-read"}) {
+		t.Errorf("expected inherited permission sets, got %v", resolved.PermissionSets)
+	}
+}
+
+func TestProviderGrant_GetRole(t *testing.T) {
+
+	provider := &mockCatalogProvider{
+		BaseProvider: NewBaseProvider(Provider{Name: "aws-prod", Provider: "aws"}, ProviderCapabilityRBAC),
+	}
+
+	role := &Role{
+		Name:           "debugger",
+		PermissionSets: []string{"no-longer-in-the-catalog"},
+	}
+
+	// The permissions recorded when granted are revoked, even though
+	// the set can no longer be translated
+	grant := &ProviderGrant{
+		Identity:    &Identity{ID: "dev@example.com"},
+		Permissions: []string{"s3:ListBucket"},
+	}
+
+	granted, err := grant.GetRole(provider, role)
+	if err != nil {
+		t.Fatalf("GetRole() error = %v", err)
+	}
+
+	if !slices.Equal(granted.Permissions.Allow, []string{"s3:ListBucket"}) || len(granted.PermissionSets) != 0 {
+		t.Errorf("expected the granted permissions, got %+v", granted)
+	}
+
+	// Without a record the set must translate rather than fall back
+	legacy := &ProviderGrant{Identity: &Identity{ID: "dev@example.com"}}
+
+	if _, err := legacy.GetRole(provider, role); err == nil {
+		t.Error("expected an error for a set that can't be translated")
+	}
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"strings"
+
+	"github.com/thand-io/agent/internal/common"
+)
+
+// PermissionWildcards describes how a provider's policy language treats
+// wildcards in permissions. A '*' matches any run of characters and a '?'
+// matches a single character, so s3:Get*, ec2:Describe*Instances, *:List*
+// and storage.*.get can all be used in roles.
+type PermissionWildcards struct {
+	CaseInsensitive bool // Permissions match regardless of case, as AWS actions do
+	Native          bool // Policies accept wildcards directly so they are kept unexpanded
+}
+
+// Match reports whether the permission pattern matches the permission
+func (w PermissionWildcards) Match(pattern, permission string) bool {
+
+	if w.CaseInsensitive {
+		pattern = strings.ToLower(pattern)
+		permission = strings.ToLower(permission)
+	}
+
+	if common.MatchGlob(pattern, permission) {
+		return true
+	}
+
+	// Roles have always been able to use service:* for providers
+	// that separate permissions with dots, such as compute:* in GCP
+	if service, found := strings.CutSuffix(pattern, ":*"); found {
+		return common.MatchGlob(service+".*", permission)
+	}
+
+	return false
+}
+
+// IsPermissionWildcard returns true if the permission is matched against
+// the provider's permissions rather than used as is
+func IsPermissionWildcard(permission string) bool {
+	return strings.ContainsAny(permission, "*?")
+}
+
+// ExpandPermissionsWildcard returns the provider permissions matched by
+// the wildcard permission
+func ExpandPermissionsWildcard(
+	providerPermissions []ProviderPermission,
+	wildcards PermissionWildcards,
+	permission string,
+) []string {
+
+	expandedPermissions := []string{}
+
+	for _, providerPerm := range providerPermissions {
+		if wildcards.Match(permission, providerPerm.Name) {
+			expandedPermissions = append(expandedPermissions, providerPerm.Name)
+		}
+	}
+
+	return expandedPermissions
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpandPermissionsWildcard(t *testing.T) {
+
+	aws := []ProviderPermission{
+		{Name: "s3:GetObject"},
+		{Name: "s3:GetObjectAcl"},
+		{Name: "s3:PutObject"},
+		{Name: "s3:ListBucket"},
+		{Name: "ec2:DescribeInstances"},
+		{Name: "ec2:DescribeSpotInstances"},
+		{Name: "ec2:DescribeVolumes"},
+		{Name: "iam:ListRoles"},
+	}
+
+	gcp := []ProviderPermission{
+		{Name: "storage.objects.get"},
+		{Name: "storage.buckets.get"},
+		{Name: "storage.objects.list"},
+		{Name: "compute.instances.list"},
+	}
+
+	awsWildcards := PermissionWildcards{CaseInsensitive: true, Native: true}
+
+	tests := []struct {
+		name        string
+		permissions []ProviderPermission
+		wildcards   PermissionWildcards
+		pattern     string
+		expected    []string
+	}{
+		{"prefix", aws, awsWildcards, "s3:Get*", []string{"s3:GetObject", "s3:GetObjectAcl"}},
+		{"infix", aws, awsWildcards, "ec2:Describe*Instances", []string{"ec2:DescribeInstances", "ec2:DescribeSpotInstances"}},
+		{"any service", aws, awsWildcards, "*:List*", []string{"s3:ListBucket", "iam:ListRoles"}},
+		{"ignores case", aws, awsWildcards, "S3:get*acl", []string{"s3:GetObjectAcl"}},
+		{"single character", aws, awsWildcards, "s3:?utObject", []string{"s3:PutObject"}},
+		{"segment", gcp, PermissionWildcards{}, "storage.*.get", []string{"storage.objects.get", "storage.buckets.get"}},
+		{"service", gcp, PermissionWildcards{}, "compute:*", []string{"compute.instances.list"}},
+		{"case sensitive", gcp, PermissionWildcards{}, "Storage.*", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ExpandPermissionsWildcard(tt.permissions, tt.wildcards, tt.pattern)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("ExpandPermissionsWildcard(%q) = %v, expected %v", tt.pattern, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidatePermissions_Wildcards(t *testing.T) {
+
+	permissions := []ProviderPermission{
+		{Name: "s3:GetObject"},
+		{Name: "s3:GetObjectAcl"},
+	}
+
+	native, err := validatePermissions(permissions, PermissionWildcards{Native: true}, []string{"s3:Get*"})
+	if err != nil || !slices.Equal(native, []string{"s3:Get*"}) {
+		t.Errorf("expected native wildcard to be kept, got %v, %v", native, err)
+	}
+
+	expanded, err := validatePermissions(permissions, PermissionWildcards{}, []string{"s3:Get*"})
+	if err != nil || !slices.Equal(expanded, []string{"s3:GetObject", "s3:GetObjectAcl"}) {
+		t.Errorf("expected wildcard to be expanded, got %v, %v", expanded, err)
+	}
+
+	if _, err := validatePermissions(permissions, PermissionWildcards{}, []string{"s3:Put*"}); err == nil {
+		t.Error("expected a wildcard matching nothing to fail")
+	}
+}
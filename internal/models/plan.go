@@ -0,0 +1,280 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type RolePlanAction string
+
+const (
+	RolePlanActionCreate RolePlanAction = "create"
+	RolePlanActionUpdate RolePlanAction = "update"
+	RolePlanActionDelete RolePlanAction = "delete"
+	RolePlanActionNone   RolePlanAction = "none" // Already in the desired state
+)
+
+/*
+A role plan describes the changes a provider would make to
+authorize a role, without applying any of them. Plans are
+returned for dry-run elevations and summarised in approvals.
+*/
+type RolePlan struct {
+	Provider         string                    `json:"provider"`
+	Role             string                    `json:"role"`
+	User             string                    `json:"user,omitempty"`
+	Permissions      RolePlanPermissions       `json:"permissions"`
+	PolicyDocuments  []RolePlanPolicyDocument  `json:"policy_documents,omitempty"`
+	Bindings         []RolePlanBinding         `json:"bindings,omitempty"`
+	PermissionSets   []RolePlanPermissionSet   `json:"permission_sets,omitempty"`
+	GroupMemberships []RolePlanGroupMembership `json:"group_memberships,omitempty"`
+}
+
+// RolePlanPermissions holds the resolved permissions once
+// any wildcards have been expanded against the provider
+type RolePlanPermissions struct {
+	Allow    []string            `json:"allow,omitempty"`
+	Deny     []string            `json:"deny,omitempty"`
+	Expanded map[string][]string `json:"expanded,omitempty"` // Wildcard -> resolved permissions
+	Native   bool                `json:"native,omitempty"`   // Wildcards are passed to the provider unexpanded
+}
+
+type RolePlanPolicyDocument struct {
+	Action   RolePlanAction `json:"action"`
+	Name     string         `json:"name"`
+	Target   string         `json:"target,omitempty"` // What the policy is attached to
+	Document any            `json:"document,omitempty"`
+}
+
+type RolePlanBinding struct {
+	Action    RolePlanAction `json:"action"`
+	Principal string         `json:"principal"`
+	Role      string         `json:"role"`
+	Scope     string         `json:"scope,omitempty"`
+}
+
+type RolePlanPermissionSet struct {
+	Action          RolePlanAction `json:"action"`
+	Name            string         `json:"name"`
+	Arn             string         `json:"arn,omitempty"`
+	ManagedPolicies []string       `json:"managed_policies,omitempty"`
+}
+
+type RolePlanGroupMembership struct {
+	Action RolePlanAction `json:"action"`
+	Group  string         `json:"group"`
+	Member string         `json:"member"`
+	Role   string         `json:"role,omitempty"`
+}
+
+func NewRolePlan(provider ProviderImpl, req *AuthorizeRoleRequest) *RolePlan {
+	plan := &RolePlan{
+		Provider: provider.GetName(),
+	}
+
+	if role := req.GetRole(); role != nil {
+		plan.Role = role.Name
+		plan.Permissions = RolePlanPermissions{
+			Allow: role.Permissions.Allow,
+			Deny:  role.Permissions.Deny,
+		}
+	}
+
+	if user := req.GetUser(); user != nil {
+		plan.User = user.GetName()
+	} else if identity := req.GetIdentity(); identity != nil {
+		plan.User = identity.ID
+	}
+
+	return plan
+}
+
+// HasChanges returns true if applying the plan would modify the provider
+func (p *RolePlan) HasChanges() bool {
+	for _, doc := range p.PolicyDocuments {
+		if doc.Action != RolePlanActionNone {
+			return true
+		}
+	}
+	for _, binding := range p.Bindings {
+		if binding.Action != RolePlanActionNone {
+			return true
+		}
+	}
+	for _, permissionSet := range p.PermissionSets {
+		if permissionSet.Action != RolePlanActionNone {
+			return true
+		}
+	}
+	for _, membership := range p.GroupMemberships {
+		if membership.Action != RolePlanActionNone {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary returns a short human readable description of the plan
+func (p *RolePlan) Summary() string {
+	var summary strings.Builder
+
+	summary.WriteString(fmt.Sprintf("%s: role %s", p.Provider, p.Role))
+
+	if len(p.User) > 0 {
+		summary.WriteString(fmt.Sprintf(" for %s", p.User))
+	}
+
+	summary.WriteString("\n")
+
+	if len(p.Permissions.Allow) > 0 {
+		summary.WriteString(fmt.Sprintf("• %d allowed permission(s)", len(p.Permissions.Allow)))
+		if len(p.Permissions.Expanded) > 0 {
+			summary.WriteString(fmt.Sprintf(", %d wildcard(s) expanded", len(p.Permissions.Expanded)))
+		}
+		summary.WriteString("\n")
+	}
+
+	for _, wildcard := range slices.Sorted(maps.Keys(p.Permissions.Expanded)) {
+		summary.WriteString(fmt.Sprintf("• %s matches %d permission(s)", wildcard, len(p.Permissions.Expanded[wildcard])))
+		if p.Permissions.Native {
+			summary.WriteString(", kept as a wildcard")
+		}
+		summary.WriteString("\n")
+	}
+
+	if len(p.Permissions.Deny) > 0 {
+		summary.WriteString(fmt.Sprintf("• %d denied permission(s)\n", len(p.Permissions.Deny)))
+	}
+
+	for _, doc := range p.PolicyDocuments {
+		summary.WriteString(fmt.Sprintf("• %s policy %s", doc.Action, doc.Name))
+		if len(doc.Target) > 0 {
+			summary.WriteString(fmt.Sprintf(" on %s", doc.Target))
+		}
+		summary.WriteString("\n")
+	}
+
+	for _, permissionSet := range p.PermissionSets {
+		summary.WriteString(fmt.Sprintf("• %s permission set %s", permissionSet.Action, permissionSet.Name))
+		if len(permissionSet.ManagedPolicies) > 0 {
+			summary.WriteString(fmt.Sprintf(" (%s)", strings.Join(permissionSet.ManagedPolicies, ", ")))
+		}
+		summary.WriteString("\n")
+	}
+
+	for _, binding := range p.Bindings {
+		summary.WriteString(fmt.Sprintf("• %s binding %s -> %s", binding.Action, binding.Principal, binding.Role))
+		if len(binding.Scope) > 0 {
+			summary.WriteString(fmt.Sprintf(" on %s", binding.Scope))
+		}
+		summary.WriteString("\n")
+	}
+
+	for _, membership := range p.GroupMemberships {
+		summary.WriteString(fmt.Sprintf("• %s membership %s in %s", membership.Action, membership.Member, membership.Group))
+		if len(membership.Role) > 0 {
+			summary.WriteString(fmt.Sprintf(" as %s", membership.Role))
+		}
+		summary.WriteString("\n")
+	}
+
+	return summary.String()
+}
+
+// ResolveRolePlanPermissions expands any wildcard permissions in the
+// role against the providers permission catalog without modifying the role
+func ResolveRolePlanPermissions(provider ProviderImpl, role *Role) (*RolePlanPermissions, error) {
+
+	resolved := &RolePlanPermissions{
+		Allow: role.Permissions.Allow,
+		Deny:  role.Permissions.Deny,
+	}
+
+	if len(role.Permissions.Allow) == 0 && len(role.Permissions.Deny) == 0 {
+		return resolved, nil
+	}
+
+	providerPermissions, err := provider.ListPermissions(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(providerPermissions) == 0 {
+		return resolved, nil
+	}
+
+	wildcards := provider.GetPermissionWildcards()
+
+	expanded := map[string][]string{}
+
+	for _, perm := range slices.Concat(role.Permissions.Allow, role.Permissions.Deny) {
+		if IsPermissionWildcard(perm) {
+			expanded[perm] = ExpandPermissionsWildcard(providerPermissions, wildcards, perm)
+		}
+	}
+
+	resolved.Allow, err = validatePermissions(providerPermissions, wildcards, role.Permissions.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved.Deny, err = validatePermissions(providerPermissions, wildcards, role.Permissions.Deny)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(expanded) > 0 {
+		resolved.Expanded = expanded
+		resolved.Native = wildcards.Native
+	}
+
+	return resolved, nil
+}
+
+// PlanRole builds a plan for each identity in the elevation request
+// against a provider
+func PlanRole(
+	ctx context.Context,
+	providerCall ProviderImpl,
+	elevateRequest ElevateRequestInternal,
+) ([]*RolePlan, error) {
+
+	duration, err := elevateRequest.AsDuration()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get duration: %w", err)
+	}
+
+	identities, err := ResolveIdentities(ctx, providerCall, &elevateRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan role: %w", err)
+	}
+
+	// Wildcards in permission sets are expanded by the plan itself
+	role, err := elevateRequest.Role.TranslatePermissionSets(providerCall.GetProvider())
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan role: %w", err)
+	}
+
+	plans := []*RolePlan{}
+
+	for _, identity := range identities {
+
+		plan, err := providerCall.PlanRole(ctx, &AuthorizeRoleRequest{
+			User:     identity.GetUser(),
+			Role:     role,
+			Duration: &duration,
+			Identity: identity,
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan role for identity %s: %w", identity.ID, err)
+		}
+
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
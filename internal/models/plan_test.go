@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRolePlan_HasChanges(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		plan     RolePlan
+		expected bool
+	}{
+		{
+			name:     "empty plan",
+			plan:     RolePlan{},
+			expected: false,
+		},
+		{
+			name: "no-op binding",
+			plan: RolePlan{
+				Bindings: []RolePlanBinding{
+					{Action: RolePlanActionNone, Principal: "user:alice@example.com", Role: "viewer"},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "new binding",
+			plan: RolePlan{
+				Bindings: []RolePlanBinding{
+					{Action: RolePlanActionNone, Principal: "user:alice@example.com", Role: "viewer"},
+					{Action: RolePlanActionCreate, Principal: "user:alice@example.com", Role: "editor"},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "updated policy document",
+			plan: RolePlan{
+				PolicyDocuments: []RolePlanPolicyDocument{
+					{Action: RolePlanActionUpdate, Name: "thand-admin-policy"},
+				},
+			},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.plan.HasChanges(); got != tt.expected {
+				t.Errorf("HasChanges() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRolePlan_Summary(t *testing.T) {
+
+	plan := RolePlan{
+		Provider: "gcp-prod",
+		Role:     "editor",
+		User:     "alice",
+		Permissions: RolePlanPermissions{
+			Allow: []string{"storage.objects.get", "storage.objects.list"},
+		},
+		Bindings: []RolePlanBinding{
+			{
+				Action:    RolePlanActionCreate,
+				Principal: "user:alice@example.com",
+				Role:      "projects/test/roles/editor",
+				Scope:     "projects/test",
+			},
+		},
+	}
+
+	summary := plan.Summary()
+
+	for _, expected := range []string{
+		"gcp-prod: role editor for alice",
+		"2 allowed permission(s)",
+		"create binding user:alice@example.com -> projects/test/roles/editor",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("Summary() = %q, expected it to contain %q", summary, expected)
+		}
+	}
+}
@@ -89,6 +89,7 @@ const (
 	ProviderCapabilityRBAC       ProviderCapability = "rbac"
 	ProviderCapabilityAuthorizor ProviderCapability = "authorizor"
 	ProviderCapabilityNotifier   ProviderCapability = "notifier"
+	ProviderCapabilityIdentity   ProviderCapability = "identity"
 )
 
 func GetCapabilityFromString(cap string) (ProviderCapability, error) {
@@ -99,6 +100,8 @@ func GetCapabilityFromString(cap string) (ProviderCapability, error) {
 		return ProviderCapabilityAuthorizor, nil
 	case string(ProviderCapabilityNotifier):
 		return ProviderCapabilityNotifier, nil
+	case string(ProviderCapabilityIdentity):
+		return ProviderCapabilityIdentity, nil
 	default:
 		return "", fmt.Errorf("unknown capability: %s", cap)
 	}
@@ -127,6 +130,7 @@ type ProviderImpl interface {
 	ProviderNotifier
 	ProviderAuthorizor
 	ProviderRoleBasedAccessControl
+	ProviderIdentities
 }
 
 type NotificationRequest map[string]any
@@ -145,6 +149,7 @@ type AuthorizeRoleRequest struct {
 	User     *User          `json:"user"`
 	Role     *Role          `json:"role"`
 	Duration *time.Duration `json:"duration,omitempty"` // Optional duration for temporary access
+	Identity *Identity      `json:"identity,omitempty"` // Optional identity to grant, defaults to the user
 }
 
 // IsValid checks if any of the fields are nil
@@ -165,6 +170,15 @@ func (r *AuthorizeRoleRequest) GetDuration() *time.Duration {
 	return r.Duration
 }
 
+// GetIdentity returns the identity being granted the role. If no
+// identity was resolved then the user is the target.
+func (r *AuthorizeRoleRequest) GetIdentity() *Identity {
+	if r.Identity != nil {
+		return r.Identity
+	}
+	return NewUserIdentity(r.User)
+}
+
 type AuthorizeRoleResponse struct {
 }
 
@@ -177,6 +191,14 @@ type ProviderAuthorizor interface {
 	RenewSession(ctx context.Context, session *Session) (*Session, error)
 }
 
+type ProviderIdentities interface {
+
+	// Users and groups from the provider's directory
+	GetUser(ctx context.Context, user string) (*User, error)
+	ListUsers(ctx context.Context, filters ...string) ([]User, error)
+	ListGroups(ctx context.Context, filters ...string) ([]Group, error)
+}
+
 type ProviderRoleBasedAccessControl interface {
 
 	// Role
@@ -191,6 +213,12 @@ type ProviderRoleBasedAccessControl interface {
 	GetResource(ctx context.Context, resource string) (*ProviderResource, error)
 	ListResources(ctx context.Context, filters ...string) ([]ProviderResource, error)
 
+	// Resolve an identity (user, group or service account) to grant a role to
+	ResolveIdentity(ctx context.Context, identity string) (*Identity, error)
+
+	// How wildcards in permissions are matched by the provider's policy language
+	GetPermissionWildcards() PermissionWildcards
+
 	// Bind a user to a role
 	ValidateRole(ctx context.Context, user *User, role *Role) (map[string]any, error)
 	AuthorizeRole(
@@ -200,6 +228,11 @@ type ProviderRoleBasedAccessControl interface {
 		map[string]any, // Return any custom metadata the provider wants to store
 		error,
 	)
+	// Plan the changes AuthorizeRole would make without applying them
+	PlanRole(
+		ctx context.Context,
+		req *AuthorizeRoleRequest,
+	) (*RolePlan, error)
 	RevokeRole(
 		ctx context.Context,
 		user *User,
@@ -252,6 +285,19 @@ func (p *BaseProvider) HasAnyCapability(capabilities ...ProviderCapability) bool
 	return slices.ContainsFunc(capabilities, p.HasCapability)
 }
 
+// GetPermissionWildcards defaults to case sensitive matching with every
+// wildcard expanded, as not all IAMs support wildcards in their policies
+func (p *BaseProvider) GetPermissionWildcards() PermissionWildcards {
+	return PermissionWildcards{}
+}
+
+// EnableCapability adds a capability that depends on optional configuration
+func (p *BaseProvider) EnableCapability(capability ProviderCapability) {
+	if !p.HasCapability(capability) {
+		p.capabilities = append(p.capabilities, capability)
+	}
+}
+
 type ProviderPermissionsResponse struct {
 	Version     string               `json:"version"`
 	Provider    string               `json:"provider"`
@@ -305,6 +351,23 @@ func (p *BaseProvider) RenewSession(ctx context.Context, session *Session) (*Ses
 	return nil, fmt.Errorf("the provider '%s' does not implement RenewSession", p.GetProvider())
 }
 
+/* Default implementations for identities */
+
+func (p *BaseProvider) GetUser(ctx context.Context, user string) (*User, error) {
+	// Default implementation does nothing
+	return nil, fmt.Errorf("the provider '%s' does not implement GetUser", p.GetProvider())
+}
+
+func (p *BaseProvider) ListUsers(ctx context.Context, filters ...string) ([]User, error) {
+	// Default implementation does nothing
+	return nil, fmt.Errorf("the provider '%s' does not implement ListUsers", p.GetProvider())
+}
+
+func (p *BaseProvider) ListGroups(ctx context.Context, filters ...string) ([]Group, error) {
+	// Default implementation does nothing
+	return nil, fmt.Errorf("the provider '%s' does not implement ListGroups", p.GetProvider())
+}
+
 /* Default implementations for role-based access control */
 
 func (p *BaseProvider) GetRole(ctx context.Context, role string) (*ProviderRole, error) {
@@ -337,6 +400,19 @@ func (p *BaseProvider) ListResources(ctx context.Context, filters ...string) ([]
 	return nil, fmt.Errorf("the provider '%s' does not implement ListResources", p.GetProvider())
 }
 
+// ResolveIdentity by default only supports users. Providers that can grant
+// roles to groups or service accounts should override this.
+func (p *BaseProvider) ResolveIdentity(ctx context.Context, identity string) (*Identity, error) {
+	resolved, err := ParseIdentity(identity)
+	if err != nil {
+		return nil, err
+	}
+	if !resolved.IsUser() {
+		return nil, fmt.Errorf("the provider '%s' does not support %s identities", p.GetProvider(), resolved.Type)
+	}
+	return resolved, nil
+}
+
 func (p *BaseProvider) AuthorizeRole(
 	ctx context.Context,
 	req *AuthorizeRoleRequest,
@@ -345,6 +421,14 @@ func (p *BaseProvider) AuthorizeRole(
 	return nil, fmt.Errorf("the provider '%s' does not implement AuthorizeRole", p.GetProvider())
 }
 
+func (p *BaseProvider) PlanRole(
+	ctx context.Context,
+	req *AuthorizeRoleRequest,
+) (*RolePlan, error) {
+	// Default implementation does nothing
+	return nil, fmt.Errorf("the provider '%s' does not implement PlanRole", p.GetProvider())
+}
+
 func (p *BaseProvider) RevokeRole(ctx context.Context, user *User, role *Role, metadata map[string]any) (map[string]any, error) {
 	// Default implementation does nothing
 	return nil, fmt.Errorf("the provider '%s' does not implement RevokeRole", p.GetProvider())
@@ -363,10 +447,16 @@ func ValidateRole(
 ) (map[string]any, error) {
 	// Check the user has access to the required scopes etc
 
+	// Permission sets are validated as the provider's own permissions
+	role, err := RoleForProvider(providerCall, elevateRequest.Role)
+	if err != nil {
+		return nil, err
+	}
+
 	res, err := providerCall.ValidateRole(
 		context.Background(),
 		elevateRequest.User,
-		elevateRequest.Role,
+		role,
 	)
 
 	if err != nil {
@@ -376,7 +466,7 @@ func ValidateRole(
 		}
 
 		logrus.Warn("Provider does not implement role validation, using default")
-		err = validateRole(providerCall, elevateRequest.User, elevateRequest.Role)
+		err = validateRole(providerCall, elevateRequest.User, role)
 
 		if err != nil {
 			return nil, err
@@ -462,19 +552,19 @@ func validateRolePermissions(provider ProviderImpl, role *Role) error {
 		return nil
 	}
 
-	return validateRolePermissionLists(role, providerPermissions)
+	return validateRolePermissionLists(role, providerPermissions, provider.GetPermissionWildcards())
 }
 
 // validateRolePermissionLists validates both allow and deny permission lists
-func validateRolePermissionLists(role *Role, providerPermissions []ProviderPermission) error {
+func validateRolePermissionLists(role *Role, providerPermissions []ProviderPermission, wildcards PermissionWildcards) error {
 	var err error
 
-	role.Permissions.Allow, err = validatePermissions(providerPermissions, role.Permissions.Allow)
+	role.Permissions.Allow, err = validatePermissions(providerPermissions, wildcards, role.Permissions.Allow)
 	if err != nil {
 		return err
 	}
 
-	role.Permissions.Deny, err = validatePermissions(providerPermissions, role.Permissions.Deny)
+	role.Permissions.Deny, err = validatePermissions(providerPermissions, wildcards, role.Permissions.Deny)
 	if err != nil {
 		return err
 	}
@@ -482,21 +572,43 @@ func validateRolePermissionLists(role *Role, providerPermissions []ProviderPermi
 	return nil
 }
 
-func validatePermissions(providerPermissions []ProviderPermission, permissions []string) ([]string, error) {
+func validatePermissions(
+	providerPermissions []ProviderPermission,
+	wildcards PermissionWildcards,
+	permissions []string,
+) ([]string, error) {
 
 	validatedPermissions := []string{}
 
 	// Now lets check are remove permissions that don't exist
 	for _, perm := range permissions {
 
-		if strings.HasSuffix(perm, ":*") || strings.HasSuffix(perm, ".*") {
-			// Permission ends with a wildcard. Lets expand this
-			// out to include all permissions. As some IAMs do not
-			// support wildcarding.
-			validatedPermissions = append(validatedPermissions,
-				expandPermissionsWildcard(providerPermissions, perm)...)
+		if IsPermissionWildcard(perm) {
+
+			expanded := ExpandPermissionsWildcard(providerPermissions, wildcards, perm)
+
+			if len(expanded) == 0 {
+				return nil, fmt.Errorf("the requested permission: %s did not match any permissions", perm)
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"permission": perm,
+				"matches":    len(expanded),
+				"native":     wildcards.Native,
+			}).Debugln("Resolved permission wildcard")
+
+			if wildcards.Native {
+				// The provider understands the wildcard so keep
+				// it as is rather than growing the policy
+				validatedPermissions = append(validatedPermissions, perm)
+			} else {
+				// Some IAMs do not support wildcarding so lets
+				// expand this out to include all matching permissions
+				validatedPermissions = append(validatedPermissions, expanded...)
+			}
+
 		} else if !slices.ContainsFunc(providerPermissions, func(p ProviderPermission) bool {
-			found := strings.Compare(p.Name, perm) == 0
+			found := wildcards.Match(perm, p.Name)
 			if found {
 				validatedPermissions = append(validatedPermissions, p.Name)
 			}
@@ -508,22 +620,3 @@ func validatePermissions(providerPermissions []ProviderPermission, permissions [
 
 	return validatedPermissions, nil
 }
-
-func expandPermissionsWildcard(providerPermissions []ProviderPermission, permission string) []string {
-
-	if strings.HasSuffix(permission, ":*") {
-		permission = strings.TrimSuffix(permission, ":*")
-	} else if strings.HasSuffix(permission, ".*") {
-		permission = strings.TrimSuffix(permission, ".*")
-	}
-
-	expandedPermissions := []string{}
-
-	for _, providerPerm := range providerPermissions {
-		if strings.HasPrefix(providerPerm.Name, permission) {
-			expandedPermissions = append(expandedPermissions, providerPerm.Name)
-		}
-	}
-
-	return expandedPermissions
-}
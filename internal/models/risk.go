@@ -0,0 +1,264 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PermissionSensitivity is how much damage a permission could do. Levels
+// are ordered so the most sensitive permission in a role decides its risk.
+type PermissionSensitivity string
+
+const (
+	PermissionSensitivityRead                PermissionSensitivity = "read"
+	PermissionSensitivityWrite               PermissionSensitivity = "write"
+	PermissionSensitivityDataAccess          PermissionSensitivity = "data_access"
+	PermissionSensitivityAdmin               PermissionSensitivity = "admin"
+	PermissionSensitivityPrivilegeEscalation PermissionSensitivity = "privilege_escalation"
+)
+
+var permissionSensitivityOrder = []PermissionSensitivity{
+	PermissionSensitivityRead,
+	PermissionSensitivityWrite,
+	PermissionSensitivityDataAccess,
+	PermissionSensitivityAdmin,
+	PermissionSensitivityPrivilegeEscalation,
+}
+
+var permissionSensitivityScores = map[PermissionSensitivity]int{
+	PermissionSensitivityRead:                0,
+	PermissionSensitivityWrite:               20,
+	PermissionSensitivityDataAccess:          25,
+	PermissionSensitivityAdmin:               40,
+	PermissionSensitivityPrivilegeEscalation: 50,
+}
+
+type RiskLevel string
+
+const (
+	RiskLevelLow      RiskLevel = "low"
+	RiskLevelMedium   RiskLevel = "medium"
+	RiskLevelHigh     RiskLevel = "high"
+	RiskLevelCritical RiskLevel = "critical"
+)
+
+// RiskFactor is one reason a request scored the way it did
+type RiskFactor struct {
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// RiskAssessment scores an elevation request from 0 to 100. It is
+// available to workflows as $context.risk so switch tasks can route
+// riskier requests to stricter approvals.
+//
+//	switch:
+//	  - high_risk:
+//	      when: $context.risk.level == "high" or $context.risk.level == "critical"
+//	      then: security_approval
+type RiskAssessment struct {
+	Score       int                           `json:"score"`
+	Level       RiskLevel                     `json:"level"`
+	Sensitivity PermissionSensitivity         `json:"sensitivity"`           // The most sensitive permission requested
+	Permissions map[PermissionSensitivity]int `json:"permissions,omitempty"` // Number of permissions at each sensitivity
+	Factors     []RiskFactor                  `json:"factors,omitempty"`
+}
+
+// RiskInput is what a request is scored on. Permissions should already
+// have any wildcards expanded against the provider catalogs.
+type RiskInput struct {
+	Permissions []string
+	Resources   []string
+	Duration    time.Duration
+	BreakGlass  bool
+
+	// Previous elevations of the same role by the requester, nil if unknown
+	PreviousElevations *int
+}
+
+// Verbs are matched against the action name, such as GetObject in
+// s3:GetObject, setIamPolicy in storage.buckets.setIamPolicy or write
+// in Microsoft.Compute/virtualMachines/write
+var (
+	permissionReadVerbs = regexp.MustCompile(
+		`^(get|list|describe|read|view|search|lookup|query|scan|select|check|test|batchget|head)`)
+
+	permissionPrivilegeEscalation = regexp.MustCompile(
+		`(?i)(setiampolicy|passrole|assumerole|actas|getaccesstoken|signjwt|signblob|` +
+			`createaccesskey|createloginprofile|updateloginprofile|` +
+			`(attach|put|detach|delete)(user|role|group)policy|createpolicyversion|setdefaultpolicyversion|` +
+			`updateassumerolepolicy|addusertogroup|serviceaccountkeys\.create|` +
+			`roleassignments/write|roledefinitions/write|elevateaccess)`)
+
+	permissionDataAccess = regexp.MustCompile(
+		`(?i)^(s3:getobject|secretsmanager:getsecretvalue|kms:decrypt|ssm:getparameter|` +
+			`dynamodb:(getitem|batchgetitem|query|scan)|rds-data:|athena:getqueryresults|` +
+			`storage\.objects\.get|bigquery\.tables\.(getdata|export)|secretmanager\.versions\.access|` +
+			`cloudkms\.cryptokeyversions\.usetodecrypt|spanner\.databases\.read|datastore\.entities\.get|` +
+			`microsoft\.keyvault/vaults/secrets/)`)
+
+	permissionAdminServices = []string{"*", "iam", "sts", "organizations", "resourcemanager", "microsoft.authorization"}
+)
+
+// ClassifyPermission returns the sensitivity of a permission from its
+// service and action. Wildcards are classed by what they could match.
+func ClassifyPermission(permission string) PermissionSensitivity {
+
+	service, action := splitPermission(permission)
+
+	service = strings.ToLower(service)
+	lowerAction := strings.ToLower(action)
+
+	if permissionPrivilegeEscalation.MatchString(permission) {
+		return PermissionSensitivityPrivilegeEscalation
+	}
+
+	// A whole service or everything, such as *, ec2:* or iam.roles.*
+	if lowerAction == "*" || len(lowerAction) == 0 {
+		if slices.Contains(permissionAdminServices, service) {
+			return PermissionSensitivityPrivilegeEscalation
+		}
+		return PermissionSensitivityAdmin
+	}
+
+	if permissionDataAccess.MatchString(permission) {
+		return PermissionSensitivityDataAccess
+	}
+
+	if permissionReadVerbs.MatchString(lowerAction) {
+		return PermissionSensitivityRead
+	}
+
+	// Changing identity and access services can always lead to escalation
+	if slices.Contains(permissionAdminServices, service) {
+		return PermissionSensitivityPrivilegeEscalation
+	}
+
+	return PermissionSensitivityWrite
+}
+
+// splitPermission returns the service and the final action of a permission
+// in the AWS (service:Action), GCP (service.resource.verb) or Azure
+// (Provider/resource/action) formats
+func splitPermission(permission string) (string, string) {
+
+	if service, action, found := strings.Cut(permission, ":"); found {
+		return service, action
+	}
+
+	if strings.Contains(permission, "/") {
+		parts := strings.Split(permission, "/")
+		return parts[0], parts[len(parts)-1]
+	}
+
+	if strings.Contains(permission, ".") {
+		parts := strings.Split(permission, ".")
+		return parts[0], parts[len(parts)-1]
+	}
+
+	return permission, ""
+}
+
+// AssessRisk scores a request from the sensitivity of its permissions,
+// how widely they apply, how long they are held and whether the requester
+// has held the role before
+func AssessRisk(input RiskInput) *RiskAssessment {
+
+	assessment := &RiskAssessment{
+		Sensitivity: PermissionSensitivityRead,
+		Permissions: map[PermissionSensitivity]int{},
+	}
+
+	for _, permission := range input.Permissions {
+
+		sensitivity := ClassifyPermission(permission)
+		assessment.Permissions[sensitivity]++
+
+		if slices.Index(permissionSensitivityOrder, sensitivity) >
+			slices.Index(permissionSensitivityOrder, assessment.Sensitivity) {
+			assessment.Sensitivity = sensitivity
+		}
+	}
+
+	if len(input.Permissions) > 0 {
+		assessment.addFactor("sensitivity", permissionSensitivityScores[assessment.Sensitivity],
+			fmt.Sprintf("most sensitive permission is %s", assessment.Sensitivity))
+	}
+
+	// Many sensitive permissions are riskier than one
+	sensitive := len(input.Permissions) - assessment.Permissions[PermissionSensitivityRead]
+	if sensitive > 10 {
+		assessment.addFactor("breadth", min(10, sensitive/10),
+			fmt.Sprintf("%d permissions can change or read data", sensitive))
+	}
+
+	if len(input.Resources) == 0 || slices.Contains(input.Resources, "*") {
+		assessment.addFactor("resources", 10, "permissions apply to all resources")
+	}
+
+	switch {
+	case input.Duration > 24*time.Hour:
+		assessment.addFactor("duration", 20, fmt.Sprintf("access is held for %s", input.Duration))
+	case input.Duration > 4*time.Hour:
+		assessment.addFactor("duration", 10, fmt.Sprintf("access is held for %s", input.Duration))
+	case input.Duration > time.Hour:
+		assessment.addFactor("duration", 5, fmt.Sprintf("access is held for %s", input.Duration))
+	}
+
+	if input.PreviousElevations != nil && *input.PreviousElevations == 0 {
+		assessment.addFactor("history", 10, "first request for this role")
+	}
+
+	if input.BreakGlass {
+		assessment.addFactor("break_glass", 20, "break-glass access skips approval")
+	}
+
+	assessment.Score = min(100, assessment.Score)
+	assessment.Level = GetRiskLevel(assessment.Score)
+
+	return assessment
+}
+
+func (r *RiskAssessment) addFactor(name string, score int, detail string) {
+	if score == 0 {
+		return
+	}
+	r.Score += score
+	r.Factors = append(r.Factors, RiskFactor{
+		Name:   name,
+		Score:  score,
+		Detail: detail,
+	})
+}
+
+// GetRiskLevel buckets a risk score
+func GetRiskLevel(score int) RiskLevel {
+	switch {
+	case score >= 75:
+		return RiskLevelCritical
+	case score >= 50:
+		return RiskLevelHigh
+	case score >= 25:
+		return RiskLevelMedium
+	default:
+		return RiskLevelLow
+	}
+}
+
+// Summary returns a short human readable description of the risk
+func (r *RiskAssessment) Summary() string {
+
+	var summary strings.Builder
+
+	summary.WriteString(fmt.Sprintf("Risk: %s (%d/100)\n", r.Level, r.Score))
+
+	for _, factor := range r.Factors {
+		summary.WriteString(fmt.Sprintf("• +%d %s\n", factor.Score, factor.Detail))
+	}
+
+	return summary.String()
+}
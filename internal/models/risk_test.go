@@ -0,0 +1,74 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClassifyPermission(t *testing.T) {
+
+	tests := []struct {
+		permission string
+		expected   PermissionSensitivity
+	}{
+		{"ec2:DescribeInstances", PermissionSensitivityRead},
+		{"compute.instances.list", PermissionSensitivityRead},
+		{"Microsoft.Compute/virtualMachines/read", PermissionSensitivityRead},
+		{"ec2:TerminateInstances", PermissionSensitivityWrite},
+		{"compute.instances.delete", PermissionSensitivityWrite},
+		{"s3:GetObject", PermissionSensitivityDataAccess},
+		{"secretmanager.versions.access", PermissionSensitivityDataAccess},
+		{"ec2:*", PermissionSensitivityAdmin},
+		{"*", PermissionSensitivityPrivilegeEscalation},
+		{"iam:PassRole", PermissionSensitivityPrivilegeEscalation},
+		{"iam:CreateUser", PermissionSensitivityPrivilegeEscalation},
+		{"iam:ListRoles", PermissionSensitivityRead},
+		{"storage.buckets.setIamPolicy", PermissionSensitivityPrivilegeEscalation},
+		{"iam.serviceAccounts.actAs", PermissionSensitivityPrivilegeEscalation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.permission, func(t *testing.T) {
+			if got := ClassifyPermission(tt.permission); got != tt.expected {
+				t.Errorf("ClassifyPermission(%q) = %s, expected %s", tt.permission, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAssessRisk(t *testing.T) {
+
+	previous := 3
+
+	readOnly := AssessRisk(RiskInput{
+		Permissions:        []string{"ec2:DescribeInstances", "s3:ListBucket"},
+		Resources:          []string{"arn:aws:s3:::reports"},
+		Duration:           time.Hour,
+		PreviousElevations: &previous,
+	})
+
+	if readOnly.Level != RiskLevelLow || readOnly.Score != 0 {
+		t.Errorf("expected a read-only request to be low risk, got %s (%d)", readOnly.Level, readOnly.Score)
+	}
+
+	first := 0
+
+	admin := AssessRisk(RiskInput{
+		Permissions:        []string{"iam:AttachRolePolicy", "ec2:DescribeInstances"},
+		Duration:           8 * time.Hour,
+		PreviousElevations: &first,
+	})
+
+	// 50 escalation + 10 all resources + 10 duration + 10 history
+	if admin.Level != RiskLevelCritical || admin.Score != 80 {
+		t.Errorf("expected an IAM admin request to be critical, got %s (%d): %+v", admin.Level, admin.Score, admin.Factors)
+	}
+
+	if admin.Sensitivity != PermissionSensitivityPrivilegeEscalation {
+		t.Errorf("unexpected sensitivity: %s", admin.Sensitivity)
+	}
+
+	if admin.Permissions[PermissionSensitivityRead] != 1 {
+		t.Errorf("unexpected permission counts: %v", admin.Permissions)
+	}
+}
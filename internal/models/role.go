@@ -1,21 +1,30 @@
 package models
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/common"
 )
 
 type Role struct {
-	Name           string       `json:"name"`
-	Description    string       `json:"description"`
-	Authenticators []string     `json:"authenticators"`      // All the auth providers that the role can use. If empty then any provider can be used
-	Workflows      []string     `json:"workflows,omitempty"` // The workflows to execute
-	Inherits       []string     `json:"inherits,omitempty"`
-	Permissions    Permissions  `json:"permissions,omitempty"`
-	Resources      Resources    `json:"resources,omitempty"`
-	Applies        *RoleApplies `json:"applies,omitempty"`
-	Providers      []string     `json:"providers"`
-	Enabled        bool         `json:"enabled" default:"true"` // By default enable the role
+	Name           string                   `json:"name"`
+	Description    string                   `json:"description"`
+	Authenticators []string                 `json:"authenticators"`      // All the auth providers that the role can use. If empty then any provider can be used
+	Workflows      []string                 `json:"workflows,omitempty"` // The workflows to execute
+	Inherits       []string                 `json:"inherits,omitempty"`
+	Permissions    Permissions              `json:"permissions,omitempty"`
+	PermissionSets []string                 `json:"permission_sets,omitempty"` // Provider neutral sets translated for each provider, such as storage-read
+	Resources      Resources                `json:"resources,omitempty"`
+	Applies        *RoleApplies             `json:"applies,omitempty"`
+	Parameters     map[string]RoleParameter `json:"parameters,omitempty"` // Values filled in at request time as ${params.name}
+	Conditions     map[string]RoleCondition `json:"conditions,omitempty"` // Named jq expressions every request must pass
+	Providers      []string                 `json:"providers"`
+	BreakGlass     bool                     `json:"break_glass,omitempty"`    // Emergency role that skips approvals and is reviewed afterwards
+	ConflictsWith  []string                 `json:"conflicts_with,omitempty"` // Roles that can't be held at the same time as this one
+	Delegation     []IdentityType           `json:"delegation,omitempty"`     // The identity types this role can be requested for on behalf of others
+	Enabled        bool                     `json:"enabled" default:"true"`   // By default enable the role
 }
 
 func (r *Role) HasPermission(user *User) bool {
@@ -25,9 +34,63 @@ func (r *Role) HasPermission(user *User) bool {
 		return false
 	}
 
+	if err := r.ValidateApplies(user); err != nil {
+		logrus.WithError(err).Debugln("Role.HasPermission: role does not apply to user")
+		return false
+	}
+
 	return true
 }
 
+// ValidateApplies checks the role can be requested by the user. Denied
+// users and groups always take precedence. If no users or groups are
+// allowed then the role applies to everyone.
+func (r *Role) ValidateApplies(user *User) error {
+
+	if user == nil {
+		return fmt.Errorf("user must be provided to check role %s applies", r.Name)
+	}
+
+	if r.Applies == nil {
+		return nil
+	}
+
+	if r.Applies.Deny != nil {
+		if r.Applies.Deny.MatchesUser(user) {
+			return fmt.Errorf("user %s is denied from requesting role %s", user.GetName(), r.Name)
+		}
+		if group, found := r.Applies.Deny.MatchesGroup(user); found {
+			return fmt.Errorf("group %s is denied from requesting role %s", group, r.Name)
+		}
+	}
+
+	if len(r.Applies.Users) == 0 && len(r.Applies.Groups) == 0 {
+		return nil
+	}
+
+	if r.Applies.MatchesUser(user) {
+		return nil
+	}
+
+	if _, found := r.Applies.MatchesGroup(user); found {
+		return nil
+	}
+
+	return fmt.Errorf("role %s does not apply to user %s", r.Name, user.GetName())
+}
+
+// AllowsAuthenticator returns true if the role can be requested with
+// a session from the given authenticator
+func (r *Role) AllowsAuthenticator(authenticator string) bool {
+	return len(r.Authenticators) == 0 || slices.Contains(r.Authenticators, authenticator)
+}
+
+// CanDelegate returns true if the role can be granted to
+// other identities of the given type
+func (r *Role) CanDelegate(identityType IdentityType) bool {
+	return slices.Contains(r.Delegation, identityType)
+}
+
 func (r *Role) IsValid() bool {
 	return len(r.Name) > 0 && len(r.Description) > 0
 }
@@ -49,11 +112,57 @@ type Permissions struct {
 	Deny  []string `json:"deny,omitempty"`
 }
 
+// RoleApplies controls who can request a role. Users are matched on
+// their email, username or name and groups on the groups from the
+// user's session. Both support glob patterns such as *@example.com.
 type RoleApplies struct {
+	Groups []string            `json:"groups,omitempty"`
+	Users  []string            `json:"users,omitempty"`
+	Deny   *RoleAppliesMatcher `json:"deny,omitempty"` // Users and groups that can never request the role
+}
+
+type RoleAppliesMatcher struct {
 	Groups []string `json:"groups,omitempty"`
 	Users  []string `json:"users,omitempty"`
 }
 
+func (a *RoleApplies) MatchesUser(user *User) bool {
+	return matchesUser(a.Users, user)
+}
+
+func (a *RoleApplies) MatchesGroup(user *User) (string, bool) {
+	return matchesGroup(a.Groups, user)
+}
+
+func (a *RoleAppliesMatcher) MatchesUser(user *User) bool {
+	return matchesUser(a.Users, user)
+}
+
+func (a *RoleAppliesMatcher) MatchesGroup(user *User) (string, bool) {
+	return matchesGroup(a.Groups, user)
+}
+
+// matchesUser matches the user's email and username. The display name is
+// never matched as users can set it to anything.
+func matchesUser(patterns []string, user *User) bool {
+	for _, value := range []string{user.Email, user.Username} {
+		if len(value) > 0 && common.MatchAnyGlob(patterns, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesGroup returns the first of the user's groups matching the patterns
+func matchesGroup(patterns []string, user *User) (string, bool) {
+	for _, group := range user.Groups {
+		if common.MatchAnyGlob(patterns, group) {
+			return group, true
+		}
+	}
+	return "", false
+}
+
 // RolesResponse represents the response for /roles endpoint
 type RolesResponse struct {
 	Version string                  `json:"version"`
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/itchyny/gojq"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/thand-io/agent/internal/common"
+)
+
+// RoleCondition is a jq expression that must evaluate to true for a
+// request for the role to be allowed. The expression is run against the
+// elevate request, with details about the request itself in $request.
+//
+//	conditions:
+//	  max_duration:
+//	    expression: $request.duration_seconds <= 14400
+//	    message: Requests are limited to 4 hours
+//	  business_hours:
+//	    expression: $request.weekday_number >= 1 and $request.weekday_number <= 5
+//	    message: Only available Monday to Friday
+//	    timezone: Europe/London
+//	  ticket:
+//	    expression: .reason | test("[A-Z]+-[0-9]+")
+//	    message: The reason must reference a ticket
+type RoleCondition struct {
+	Expression string `json:"expression"`
+	Message    string `json:"message,omitempty"`
+	Timezone   string `json:"timezone,omitempty"` // Timezone for the $request time fields, defaults to UTC
+}
+
+// ElevateRequestMetadata is captured when the request is received so
+// conditions see the same values wherever they are evaluated
+type ElevateRequestMetadata struct {
+	ClientIP    string     `json:"client_ip,omitempty"`
+	RequestedAt *time.Time `json:"requested_at,omitempty"`
+	ReviewDueAt *time.Time `json:"review_due_at,omitempty"` // When a break-glass elevation must be reviewed by
+}
+
+// RoleConditionFailure is a condition that did not pass
+type RoleConditionFailure struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// RoleConditionsError is returned when one or more conditions fail
+type RoleConditionsError struct {
+	Role     string                 `json:"role"`
+	Failures []RoleConditionFailure `json:"failures"`
+}
+
+func (e *RoleConditionsError) Error() string {
+	messages := make([]string, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		messages = append(messages, fmt.Sprintf("%s: %s", failure.Name, failure.Message))
+	}
+	return fmt.Sprintf("request does not meet the conditions for role %s: %s",
+		e.Role, strings.Join(messages, "; "))
+}
+
+// ValidateConditions checks every condition expression compiles
+func (r *Role) ValidateConditions() error {
+	for name, condition := range r.Conditions {
+		if _, err := compileCondition(condition.Expression); err != nil {
+			return fmt.Errorf("role %s condition %s is invalid: %w", r.Name, name, err)
+		}
+		if _, err := loadConditionLocation(condition.Timezone); err != nil {
+			return fmt.Errorf("role %s condition %s has an invalid timezone: %w", r.Name, name, err)
+		}
+	}
+	return nil
+}
+
+// EvaluateConditions runs every condition against the request and returns
+// a RoleConditionsError listing each one that failed
+func (r *Role) EvaluateConditions(request *ElevateRequestInternal) error {
+
+	if len(r.Conditions) == 0 {
+		return nil
+	}
+
+	var input map[string]any
+	if err := common.ConvertInterfaceToInterface(request, &input); err != nil {
+		return fmt.Errorf("failed to convert request for conditions: %w", err)
+	}
+
+	names := make([]string, 0, len(r.Conditions))
+	for name := range r.Conditions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	conditionsErr := &RoleConditionsError{Role: r.Name}
+
+	for _, name := range names {
+
+		condition := r.Conditions[name]
+
+		if err := evaluateCondition(condition, input, request); err != nil {
+
+			message := condition.Message
+			if len(message) == 0 {
+				message = err.Error()
+			}
+
+			conditionsErr.Failures = append(conditionsErr.Failures, RoleConditionFailure{
+				Name:    name,
+				Message: message,
+			})
+		}
+	}
+
+	if len(conditionsErr.Failures) > 0 {
+		return conditionsErr
+	}
+
+	return nil
+}
+
+func evaluateCondition(condition RoleCondition, input map[string]any, request *ElevateRequestInternal) error {
+
+	code, err := compileCondition(condition.Expression)
+	if err != nil {
+		return err
+	}
+
+	location, err := loadConditionLocation(condition.Timezone)
+	if err != nil {
+		return err
+	}
+
+	iter := code.Run(input, getConditionVariables(request, location))
+
+	result, ok := iter.Next()
+	if !ok {
+		return fmt.Errorf("condition returned no result")
+	}
+
+	if errVal, isErr := result.(error); isErr {
+		return fmt.Errorf("condition failed to evaluate: %w", errVal)
+	}
+
+	passed, isBool := result.(bool)
+	if !isBool {
+		return fmt.Errorf("condition returned %v rather than a boolean", result)
+	}
+
+	if !passed {
+		return fmt.Errorf("condition %s is not met", condition.Expression)
+	}
+
+	return nil
+}
+
+func compileCondition(expression string) (*gojq.Code, error) {
+
+	expression = strings.TrimSpace(expression)
+
+	if model.IsStrictExpr(expression) {
+		expression = model.SanitizeExpr(expression)
+	}
+
+	query, err := gojq.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jq expression: %s, error: %w", expression, err)
+	}
+
+	code, err := gojq.Compile(query, gojq.WithVariables([]string{"$request"}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile jq expression: %s, error: %w", expression, err)
+	}
+
+	return code, nil
+}
+
+func loadConditionLocation(timezone string) (*time.Location, error) {
+	if len(timezone) == 0 {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(timezone)
+}
+
+// getConditionVariables returns the $request variable for a condition
+func getConditionVariables(request *ElevateRequestInternal, location *time.Location) map[string]any {
+
+	requestedAt := time.Now()
+	clientIP := ""
+
+	if request.Metadata != nil {
+		clientIP = request.Metadata.ClientIP
+		if request.Metadata.RequestedAt != nil {
+			requestedAt = *request.Metadata.RequestedAt
+		}
+	}
+
+	requestedAt = requestedAt.In(location)
+
+	variables := map[string]any{
+		"time":           requestedAt.Format(time.RFC3339),
+		"timestamp":      int(requestedAt.Unix()),
+		"hour":           requestedAt.Hour(),
+		"minute":         requestedAt.Minute(),
+		"weekday":        requestedAt.Weekday().String(),
+		"weekday_number": int(requestedAt.Weekday()),
+		"ip":             clientIP,
+		"authenticator":  request.Authenticator,
+	}
+
+	if duration, err := request.AsDuration(); err == nil {
+		variables["duration_seconds"] = int(duration.Seconds())
+	}
+
+	return variables
+}
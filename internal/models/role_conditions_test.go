@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRole_EvaluateConditions(t *testing.T) {
+
+	role := &Role{
+		Name: "prod-admin",
+		Conditions: map[string]RoleCondition{
+			"max_duration": {
+				Expression: "$request.duration_seconds <= 14400",
+				Message:    "Requests are limited to 4 hours",
+			},
+			"business_hours": {
+				Expression: "${ $request.hour >= 9 and $request.hour < 17 }",
+				Message:    "Only available during business hours",
+				Timezone:   "America/New_York",
+			},
+			"ticket": {
+				Expression: `.reason | test("[A-Z]+-[0-9]+")`,
+				Message:    "The reason must reference a ticket",
+			},
+		},
+	}
+
+	if err := role.ValidateConditions(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 14:00 UTC is 10:00 in New York
+	morning := time.Date(2025, time.June, 2, 14, 0, 0, 0, time.UTC)
+
+	request := &ElevateRequestInternal{
+		ElevateRequest: ElevateRequest{
+			Role:     role,
+			Reason:   "Fixing OPS-123",
+			Duration: "PT1H",
+			Metadata: &ElevateRequestMetadata{RequestedAt: &morning},
+		},
+	}
+
+	if err := role.EvaluateConditions(request); err != nil {
+		t.Fatalf("expected conditions to pass, got: %v", err)
+	}
+
+	// 23:00 UTC is 19:00 in New York
+	evening := time.Date(2025, time.June, 2, 23, 0, 0, 0, time.UTC)
+
+	request.Reason = "just because"
+	request.Duration = "PT8H"
+	request.Metadata.RequestedAt = &evening
+
+	err := role.EvaluateConditions(request)
+
+	var conditionsErr *RoleConditionsError
+	if !errors.As(err, &conditionsErr) {
+		t.Fatalf("expected RoleConditionsError, got: %v", err)
+	}
+
+	if len(conditionsErr.Failures) != 3 {
+		t.Fatalf("expected 3 failures, got: %v", conditionsErr.Failures)
+	}
+
+	// Failures are reported in name order
+	if conditionsErr.Failures[0].Name != "business_hours" ||
+		conditionsErr.Failures[0].Message != "Only available during business hours" {
+		t.Errorf("unexpected failure: %+v", conditionsErr.Failures[0])
+	}
+}
+
+func TestRole_ValidateConditions_Invalid(t *testing.T) {
+
+	role := &Role{
+		Name: "broken",
+		Conditions: map[string]RoleCondition{
+			"bad": {Expression: ".reason | test("},
+		},
+	}
+
+	if err := role.ValidateConditions(); err == nil {
+		t.Error("expected an invalid expression to fail validation")
+	}
+}
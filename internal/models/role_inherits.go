@@ -0,0 +1,211 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/thand-io/agent/internal/common"
+)
+
+// IsLocalInherit returns true if the inherited role refers to another
+// thand role rather than a provider role. Provider roles are always
+// prefixed with the provider name, for example aws:ReadOnlyAccess.
+func IsLocalInherit(inherit string) bool {
+	return !strings.Contains(inherit, ":")
+}
+
+// FlattenRoles resolves the local inheritance of every role and checks
+// their parameters and conditions are valid. Parents can be disabled roles, which lets a
+// base role be extended without being requestable itself.
+func FlattenRoles(roles map[string]Role) (map[string]Role, error) {
+
+	resolved := make(map[string]Role, len(roles))
+
+	for name := range roles {
+		if _, err := resolveRole(name, roles, resolved, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, role := range resolved {
+		if err := role.ValidateParameters(); err != nil {
+			return nil, err
+		}
+		if err := role.ValidateConditions(); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// ResolveRole flattens the local inheritance of a role that is not part of
+// the configured roles, such as a dynamically generated one.
+func ResolveRole(role *Role, roles map[string]Role) (*Role, error) {
+
+	if role == nil {
+		return nil, nil
+	}
+
+	effective, err := flattenRole(*role, roles, map[string]Role{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &effective, nil
+}
+
+// ValidateInherits checks the user could request every role that a role
+// outside the configured roles inherits from. Configured roles are trusted
+// to inherit from base roles, but a dynamically generated role must not
+// grant what its requester couldn't request directly.
+func ValidateInherits(role *Role, roles map[string]Role, user *User, authenticator string) error {
+
+	if role == nil {
+		return nil
+	}
+
+	for _, inherit := range role.Inherits {
+
+		if !IsLocalInherit(inherit) {
+			continue
+		}
+
+		parent, exists := roles[inherit]
+		if !exists {
+			return fmt.Errorf("role %s inherits from unknown role %s", role.Name, inherit)
+		}
+
+		if user == nil {
+			return fmt.Errorf("role %s can only inherit from %s for an authenticated user", role.Name, inherit)
+		}
+
+		if !parent.Enabled {
+			return fmt.Errorf("role %s cannot inherit from disabled role %s", role.Name, inherit)
+		}
+
+		if parent.BreakGlass {
+			return fmt.Errorf("role %s cannot inherit from break-glass role %s", role.Name, inherit)
+		}
+
+		if err := parent.ValidateApplies(user); err != nil {
+			return fmt.Errorf("role %s cannot inherit from %s: %w", role.Name, inherit, err)
+		}
+
+		if !parent.AllowsAuthenticator(authenticator) {
+			return fmt.Errorf("role %s cannot inherit from %s with authenticator %s", role.Name, inherit, authenticator)
+		}
+	}
+
+	return nil
+}
+
+func resolveRole(name string, roles map[string]Role, resolved map[string]Role, path []string) (Role, error) {
+
+	if role, exists := resolved[name]; exists {
+		return role, nil
+	}
+
+	if slices.Contains(path, name) {
+		return Role{}, fmt.Errorf("role inheritance cycle: %s", strings.Join(append(path, name), " -> "))
+	}
+
+	effective, err := flattenRole(roles[name], roles, resolved, append(path, name))
+	if err != nil {
+		return Role{}, err
+	}
+
+	resolved[name] = effective
+
+	return effective, nil
+}
+
+func flattenRole(role Role, roles map[string]Role, resolved map[string]Role, path []string) (Role, error) {
+
+	effective := role
+	effective.Inherits = []string{}
+
+	for _, inherit := range role.Inherits {
+
+		if !IsLocalInherit(inherit) {
+			effective.Inherits = appendUnique(effective.Inherits, inherit)
+			continue
+		}
+
+		if _, exists := roles[inherit]; !exists {
+			return Role{}, fmt.Errorf("role %s inherits from unknown role %s", role.Name, inherit)
+		}
+
+		parent, err := resolveRole(inherit, roles, resolved, path)
+		if err != nil {
+			return Role{}, err
+		}
+
+		effective.mergeParent(parent)
+	}
+
+	effective.applyDenies()
+
+	return effective, nil
+}
+
+// mergeParent adds the permissions, permission sets, resources, providers,
+// workflows, parameters, conditions, conflicts and provider roles of an
+// already flattened parent. Who can request the role
+// and how is never inherited.
+func (r *Role) mergeParent(parent Role) {
+	r.Permissions.Allow = appendUnique(r.Permissions.Allow, parent.Permissions.Allow...)
+	r.Permissions.Deny = appendUnique(r.Permissions.Deny, parent.Permissions.Deny...)
+	r.PermissionSets = appendUnique(r.PermissionSets, parent.PermissionSets...)
+	r.Resources.Allow = appendUnique(r.Resources.Allow, parent.Resources.Allow...)
+	r.Resources.Deny = appendUnique(r.Resources.Deny, parent.Resources.Deny...)
+	r.Providers = appendUnique(r.Providers, parent.Providers...)
+	// The role's own workflows come first so its primary workflow is kept
+	r.Workflows = appendUnique(r.Workflows, parent.Workflows...)
+	r.Inherits = appendUnique(r.Inherits, parent.Inherits...)
+	r.ConflictsWith = appendUnique(r.ConflictsWith, parent.ConflictsWith...)
+
+	// Parameters with the same name are overridden by the role
+	for name, parameter := range parent.Parameters {
+		if _, exists := r.Parameters[name]; exists {
+			continue
+		}
+		if r.Parameters == nil {
+			r.Parameters = map[string]RoleParameter{}
+		}
+		r.Parameters[name] = parameter
+	}
+
+	// Conditions with the same name are overridden by the role
+	for name, condition := range parent.Conditions {
+		if _, exists := r.Conditions[name]; exists {
+			continue
+		}
+		if r.Conditions == nil {
+			r.Conditions = map[string]RoleCondition{}
+		}
+		r.Conditions[name] = condition
+	}
+}
+
+// applyDenies drops allowed entries that a deny matches, so a deny in a
+// parent or child always takes precedence.
+func (r *Role) applyDenies() {
+	r.Permissions.Allow = slices.DeleteFunc(slices.Clone(r.Permissions.Allow), func(allow string) bool {
+		return common.MatchAnyGlob(r.Permissions.Deny, allow)
+	})
+	r.Resources.Allow = slices.DeleteFunc(slices.Clone(r.Resources.Allow), func(allow string) bool {
+		return common.MatchAnyGlob(r.Resources.Deny, allow)
+	})
+}
+
+func appendUnique(values []string, add ...string) []string {
+	result := slices.Clone(values)
+	for _, value := range add {
+		if !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,150 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFlattenRoles(t *testing.T) {
+
+	roles := map[string]Role{
+		"readonly": {
+			Name:      "readonly",
+			Workflows: []string{"auto_approve"},
+			Inherits:  []string{"aws:ReadOnlyAccess"},
+			Permissions: Permissions{
+				Allow: []string{"ec2:Describe*", "s3:GetObject"},
+				Deny:  []string{"s3:GetObjectAcl"},
+			},
+			Providers: []string{"aws-prod"},
+			Enabled:   false,
+		},
+		"operator": {
+			Name:      "operator",
+			Workflows: []string{"slack_approval"},
+			Inherits:  []string{"readonly"},
+			Permissions: Permissions{
+				Allow: []string{"ec2:RebootInstances", "s3:GetObjectAcl"},
+				Deny:  []string{"s3:GetObject"},
+			},
+			Providers: []string{"aws-dev"},
+			Enabled:   true,
+		},
+	}
+
+	effective, err := FlattenRoles(roles)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	operator := effective["operator"]
+
+	if !slices.Equal(operator.Permissions.Allow, []string{"ec2:RebootInstances", "ec2:Describe*"}) {
+		t.Errorf("unexpected allow: %v", operator.Permissions.Allow)
+	}
+	if !slices.Equal(operator.Permissions.Deny, []string{"s3:GetObject", "s3:GetObjectAcl"}) {
+		t.Errorf("unexpected deny: %v", operator.Permissions.Deny)
+	}
+	if !slices.Equal(operator.Providers, []string{"aws-dev", "aws-prod"}) {
+		t.Errorf("unexpected providers: %v", operator.Providers)
+	}
+	if !slices.Equal(operator.Workflows, []string{"slack_approval", "auto_approve"}) {
+		t.Errorf("unexpected workflows: %v", operator.Workflows)
+	}
+	if !slices.Equal(operator.Inherits, []string{"aws:ReadOnlyAccess"}) {
+		t.Errorf("unexpected inherits: %v", operator.Inherits)
+	}
+
+	// The declared roles must not be changed by flattening
+	if !slices.Equal(roles["operator"].Inherits, []string{"readonly"}) {
+		t.Errorf("declared role was modified: %v", roles["operator"].Inherits)
+	}
+}
+
+func TestFlattenRoles_Errors(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		roles map[string]Role
+		want  string
+	}{
+		{
+			name: "cycle",
+			roles: map[string]Role{
+				"a": {Name: "a", Inherits: []string{"b"}},
+				"b": {Name: "b", Inherits: []string{"a"}},
+			},
+			want: "cycle",
+		},
+		{
+			name: "missing parent",
+			roles: map[string]Role{
+				"a": {Name: "a", Inherits: []string{"missing"}},
+			},
+			want: "unknown role missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FlattenRoles(tt.roles)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestValidateInherits(t *testing.T) {
+
+	roles := map[string]Role{
+		"base": {
+			Name:    "base",
+			Enabled: false,
+		},
+		"admin": {
+			Name:    "admin",
+			Enabled: true,
+			Applies: &RoleApplies{Groups: []string{"admins"}},
+		},
+		"operator": {
+			Name:           "operator",
+			Enabled:        true,
+			Authenticators: []string{"google"},
+		},
+	}
+
+	user := &User{Email: "dev@example.com", Groups: []string{"developers"}}
+
+	tests := []struct {
+		name          string
+		inherits      []string
+		user          *User
+		authenticator string
+		want          string
+	}{
+		{name: "requestable parent", inherits: []string{"operator", "aws:ReadOnlyAccess"}, user: user, authenticator: "google"},
+		{name: "parent does not apply", inherits: []string{"admin"}, user: user, want: "cannot inherit from admin"},
+		{name: "disabled parent", inherits: []string{"base"}, user: user, want: "disabled role base"},
+		{name: "authenticator not allowed", inherits: []string{"operator"}, user: user, authenticator: "github", want: "with authenticator github"},
+		{name: "no user", inherits: []string{"operator"}, want: "authenticated user"},
+		{name: "unknown parent", inherits: []string{"missing"}, user: user, want: "unknown role missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := &Role{Name: "dynamic", Inherits: tt.inherits}
+			err := ValidateInherits(role, roles, tt.user, tt.authenticator)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
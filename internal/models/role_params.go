@@ -0,0 +1,203 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/thand-io/agent/internal/common"
+)
+
+type RoleParameterType string
+
+const (
+	RoleParameterTypeString  RoleParameterType = "string"
+	RoleParameterTypeInteger RoleParameterType = "integer"
+	RoleParameterTypeBoolean RoleParameterType = "boolean"
+)
+
+// RoleParameter declares a value that is filled in at request time. Roles
+// reference parameters as ${params.name} in their permissions, resources
+// and providers. A parameter without a default is required.
+//
+//	parameters:
+//	  project:
+//	    description: The GCP project to access
+//	    allowed: [billing-prod, billing-dev]
+//	permissions:
+//	  allow:
+//	    - projects/${params.project}/storage.objects.get
+type RoleParameter struct {
+	Type        RoleParameterType `json:"type,omitempty"` // Defaults to string
+	Description string            `json:"description,omitempty"`
+	Default     string            `json:"default,omitempty"`
+	Allowed     []string          `json:"allowed,omitempty"` // The only values that can be used
+	Pattern     string            `json:"pattern,omitempty"` // Regular expression the whole value must match
+}
+
+// Strings without allowed values or a pattern are limited to characters
+// that can't widen a permission, such as wildcards or path separators
+var roleParameterSafeValue = regexp.MustCompile(`^[A-Za-z0-9._@-]+$`)
+
+var roleParameterReference = regexp.MustCompile(`\$\{\s*params\.([A-Za-z0-9_]+)\s*\}`)
+
+// IsTemplate returns true if the role needs parameters before it can be used
+func (r *Role) IsTemplate() bool {
+	return len(r.Parameters) > 0
+}
+
+// IsTemplatedValue returns true if the value references a parameter
+func IsTemplatedValue(value string) bool {
+	return roleParameterReference.MatchString(value)
+}
+
+// GetParameterNames returns the role's parameter names in order
+func (r *Role) GetParameterNames() []string {
+	return slices.Sorted(maps.Keys(r.Parameters))
+}
+
+// ValidateParameters checks every parameter the role references is declared
+// and every declared pattern compiles
+func (r *Role) ValidateParameters() error {
+
+	for name, parameter := range r.Parameters {
+		if len(parameter.Pattern) > 0 {
+			if _, err := regexp.Compile(parameter.Pattern); err != nil {
+				return fmt.Errorf("role %s parameter %s has an invalid pattern: %w", r.Name, name, err)
+			}
+		}
+		switch parameter.Type {
+		case "", RoleParameterTypeString, RoleParameterTypeInteger, RoleParameterTypeBoolean:
+		default:
+			return fmt.Errorf("role %s parameter %s has an unknown type %s", r.Name, name, parameter.Type)
+		}
+	}
+
+	for _, value := range r.getTemplatedValues() {
+		for _, match := range roleParameterReference.FindAllStringSubmatch(value, -1) {
+			if _, exists := r.Parameters[match[1]]; !exists {
+				return fmt.Errorf("role %s references undeclared parameter %s", r.Name, match[1])
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateParameterValue checks a single value against the parameter
+func (p *RoleParameter) ValidateParameterValue(value string) error {
+
+	switch p.Type {
+	case RoleParameterTypeInteger:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s is not an integer", value)
+		}
+	case RoleParameterTypeBoolean:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s is not a boolean", value)
+		}
+	}
+
+	if len(p.Allowed) > 0 && !slices.Contains(p.Allowed, value) {
+		return fmt.Errorf("%s is not one of the allowed values: %s", value, strings.Join(p.Allowed, ", "))
+	}
+
+	if len(p.Pattern) > 0 {
+		pattern, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", p.Pattern))
+		if err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", p.Pattern, err)
+		}
+		if !pattern.MatchString(value) {
+			return fmt.Errorf("%s does not match the pattern %s", value, p.Pattern)
+		}
+	}
+
+	if len(p.Allowed) == 0 && len(p.Pattern) == 0 && !roleParameterSafeValue.MatchString(value) {
+		return fmt.Errorf("%s contains characters that are not allowed", value)
+	}
+
+	return nil
+}
+
+// ApplyParameters validates the values and returns a copy of the role with
+// every parameter reference replaced. The returned role is no longer a
+// template.
+func (r *Role) ApplyParameters(values map[string]string) (*Role, error) {
+
+	for name := range values {
+		if _, exists := r.Parameters[name]; !exists {
+			return nil, fmt.Errorf("role %s has no parameter %s", r.Name, name)
+		}
+	}
+
+	resolved := map[string]string{}
+
+	for _, name := range r.GetParameterNames() {
+
+		parameter := r.Parameters[name]
+
+		value, exists := values[name]
+		if !exists || len(value) == 0 {
+			value = parameter.Default
+		}
+
+		if len(value) == 0 {
+			return nil, fmt.Errorf("role %s requires parameter %s", r.Name, name)
+		}
+
+		if err := parameter.ValidateParameterValue(value); err != nil {
+			return nil, fmt.Errorf("invalid value for role %s parameter %s: %w", r.Name, name, err)
+		}
+
+		resolved[name] = value
+	}
+
+	substitute := func(templated []string) []string {
+		if templated == nil {
+			return nil
+		}
+		result := make([]string, 0, len(templated))
+		for _, value := range templated {
+			result = append(result, roleParameterReference.ReplaceAllStringFunc(value, func(reference string) string {
+				return resolved[roleParameterReference.FindStringSubmatch(reference)[1]]
+			}))
+		}
+		return result
+	}
+
+	role := *r
+	role.Permissions.Allow = substitute(r.Permissions.Allow)
+	role.Permissions.Deny = substitute(r.Permissions.Deny)
+	role.Resources.Allow = substitute(r.Resources.Allow)
+	role.Resources.Deny = substitute(r.Resources.Deny)
+	role.Providers = substitute(r.Providers)
+	role.Parameters = nil
+	role.applyDenies()
+
+	return &role, nil
+}
+
+// MatchesTemplatedProvider returns true if the provider could be one of the
+// role's providers once parameters are filled in
+func (r *Role) MatchesTemplatedProvider(provider string) bool {
+	for _, templated := range r.Providers {
+		pattern := roleParameterReference.ReplaceAllString(templated, "*")
+		if pattern == provider || (pattern != templated && common.MatchGlob(pattern, provider)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Role) getTemplatedValues() []string {
+	values := []string{}
+	values = append(values, r.Permissions.Allow...)
+	values = append(values, r.Permissions.Deny...)
+	values = append(values, r.Resources.Allow...)
+	values = append(values, r.Resources.Deny...)
+	values = append(values, r.Providers...)
+	return values
+}
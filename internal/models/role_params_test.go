@@ -0,0 +1,129 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRole_ApplyParameters(t *testing.T) {
+
+	role := &Role{
+		Name: "project-reader",
+		Parameters: map[string]RoleParameter{
+			"project": {
+				Description: "The project to read",
+				Allowed:     []string{"billing-prod", "billing-dev"},
+			},
+			"bucket": {
+				Default: "reports",
+			},
+		},
+		Permissions: Permissions{
+			Allow: []string{"projects/${params.project}/storage.objects.get"},
+		},
+		Resources: Resources{
+			Allow: []string{"buckets/${ params.bucket }"},
+		},
+		Providers: []string{"gcp-${params.project}"},
+	}
+
+	if err := role.ValidateParameters(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	applied, err := role.ApplyParameters(map[string]string{"project": "billing-prod"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(applied.Permissions.Allow, []string{"projects/billing-prod/storage.objects.get"}) {
+		t.Errorf("unexpected permissions: %v", applied.Permissions.Allow)
+	}
+	if !slices.Equal(applied.Resources.Allow, []string{"buckets/reports"}) {
+		t.Errorf("unexpected resources: %v", applied.Resources.Allow)
+	}
+	if !slices.Equal(applied.Providers, []string{"gcp-billing-prod"}) {
+		t.Errorf("unexpected providers: %v", applied.Providers)
+	}
+	if applied.IsTemplate() {
+		t.Error("expected the applied role to no longer be a template")
+	}
+
+	// The template must not be changed
+	if role.Permissions.Allow[0] != "projects/${params.project}/storage.objects.get" {
+		t.Errorf("template was modified: %v", role.Permissions.Allow)
+	}
+
+	if !role.MatchesTemplatedProvider("gcp-billing-dev") || role.MatchesTemplatedProvider("aws-prod") {
+		t.Error("unexpected templated provider match")
+	}
+}
+
+func TestRole_ApplyParameters_Errors(t *testing.T) {
+
+	role := &Role{
+		Name: "project-reader",
+		Parameters: map[string]RoleParameter{
+			"project": {Allowed: []string{"billing-prod"}},
+			"dataset": {},
+			"count":   {Type: RoleParameterTypeInteger, Default: "1"},
+		},
+		Permissions: Permissions{
+			Allow: []string{"projects/${params.project}/datasets/${params.dataset}"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		want   string
+	}{
+		{
+			name:   "missing",
+			values: map[string]string{"project": "billing-prod"},
+			want:   "requires parameter dataset",
+		},
+		{
+			name:   "not allowed",
+			values: map[string]string{"project": "billing-dev", "dataset": "sales"},
+			want:   "not one of the allowed values",
+		},
+		{
+			name:   "unsafe",
+			values: map[string]string{"project": "billing-prod", "dataset": "*"},
+			want:   "characters that are not allowed",
+		},
+		{
+			name:   "not an integer",
+			values: map[string]string{"project": "billing-prod", "dataset": "sales", "count": "many"},
+			want:   "not an integer",
+		},
+		{
+			name:   "unknown",
+			values: map[string]string{"project": "billing-prod", "dataset": "sales", "region": "eu"},
+			want:   "has no parameter region",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := role.ApplyParameters(tt.values)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRole_ValidateParameters_Undeclared(t *testing.T) {
+
+	role := &Role{
+		Name:        "broken",
+		Permissions: Permissions{Allow: []string{"projects/${params.project}/read"}},
+	}
+
+	if err := role.ValidateParameters(); err == nil {
+		t.Error("expected an undeclared parameter to fail validation")
+	}
+}
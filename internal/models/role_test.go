@@ -0,0 +1,89 @@
+package models
+
+import "testing"
+
+func TestRole_ValidateApplies(t *testing.T) {
+
+	role := &Role{
+		Name: "prod-admin",
+		Applies: &RoleApplies{
+			Users:  []string{"oncall-*@example.com"},
+			Groups: []string{"sre", "platform-*"},
+			Deny: &RoleAppliesMatcher{
+				Users:  []string{"contractor@example.com"},
+				Groups: []string{"interns"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		user    *User
+		applies bool
+	}{
+		{
+			name:    "user glob",
+			user:    &User{Email: "oncall-alice@example.com"},
+			applies: true,
+		},
+		{
+			name:    "group glob",
+			user:    &User{Email: "bob@example.com", Groups: []string{"Platform-Eng"}},
+			applies: true,
+		},
+		{
+			name:    "not allowed",
+			user:    &User{Email: "carol@example.com", Groups: []string{"marketing"}},
+			applies: false,
+		},
+		{
+			name:    "denied group wins over allowed group",
+			user:    &User{Email: "dave@example.com", Groups: []string{"sre", "interns"}},
+			applies: false,
+		},
+		{
+			name:    "denied user",
+			user:    &User{Email: "contractor@example.com", Groups: []string{"sre"}},
+			applies: false,
+		},
+		{
+			name:    "display name is not matched",
+			user:    &User{Email: "eve@example.com", Name: "oncall-eve@example.com"},
+			applies: false,
+		},
+		{
+			name:    "no user",
+			user:    nil,
+			applies: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := role.ValidateApplies(tt.user)
+			if (err == nil) != tt.applies {
+				t.Errorf("ValidateApplies() error = %v, expected applies %v", err, tt.applies)
+			}
+		})
+	}
+}
+
+func TestRole_ValidateApplies_DefaultsToEveryone(t *testing.T) {
+
+	user := &User{Email: "alice@example.com", Groups: []string{"interns"}}
+
+	if err := (&Role{Name: "viewer"}).ValidateApplies(user); err != nil {
+		t.Errorf("expected role without applies to apply, got %v", err)
+	}
+
+	denyOnly := &Role{
+		Name: "viewer",
+		Applies: &RoleApplies{
+			Deny: &RoleAppliesMatcher{Groups: []string{"interns"}},
+		},
+	}
+
+	if err := denyOnly.ValidateApplies(user); err == nil {
+		t.Error("expected deny list to apply without an allow list")
+	}
+}
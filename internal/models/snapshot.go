@@ -0,0 +1,103 @@
+package models
+
+import (
+	"github.com/thand-io/agent/internal/common"
+)
+
+// SnapshotsMetadataKey is the AuthorizeRole metadata key providers
+// store entitlement snapshots under
+const SnapshotsMetadataKey = "snapshots"
+
+// EntitlementSnapshot records the state of an entitlement before thand
+// granted it. Providers return snapshots from AuthorizeRole so that
+// RevokeRole only undoes what thand added and never removes access the
+// identity already held.
+type EntitlementSnapshot struct {
+	Resource    string `json:"resource"`              // The provider specific entitlement, e.g. a role binding or team
+	PreExisting bool   `json:"pre_existing"`          // The identity already held the entitlement
+	PriorState  string `json:"prior_state,omitempty"` // Provider specific state to restore, e.g. a previous role
+}
+
+// ShouldRevoke returns true if thand created the entitlement outright
+// and revocation should remove it
+func (s *EntitlementSnapshot) ShouldRevoke() bool {
+	return !s.PreExisting && len(s.PriorState) == 0
+}
+
+// ShouldRestore returns true if thand changed an existing entitlement
+// and revocation should put the prior state back
+func (s *EntitlementSnapshot) ShouldRestore() bool {
+	return !s.PreExisting && len(s.PriorState) > 0
+}
+
+// EntitlementSnapshots is the set of snapshots for a single grant
+// keyed by resource
+type EntitlementSnapshots map[string]EntitlementSnapshot
+
+func NewEntitlementSnapshots() EntitlementSnapshots {
+	return EntitlementSnapshots{}
+}
+
+// Add records the state of a resource before it was granted
+func (s EntitlementSnapshots) Add(resource string, preExisting bool, priorState string) {
+	s[resource] = EntitlementSnapshot{
+		Resource:    resource,
+		PreExisting: preExisting,
+		PriorState:  priorState,
+	}
+}
+
+// Get returns the snapshot for a resource. Grants made before snapshots
+// were recorded have none, in which case the entitlement is revoked.
+func (s EntitlementSnapshots) Get(resource string) EntitlementSnapshot {
+	if snapshot, ok := s[resource]; ok {
+		return snapshot
+	}
+	return EntitlementSnapshot{
+		Resource: resource,
+	}
+}
+
+// AddToMetadata stores the snapshots in AuthorizeRole metadata, creating
+// the metadata if needed. The snapshots are stored as plain JSON types
+// so they survive being persisted in the workflow context.
+func (s EntitlementSnapshots) AddToMetadata(metadata map[string]any) map[string]any {
+
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
+	snapshots := []EntitlementSnapshot{}
+	for _, snapshot := range s {
+		snapshots = append(snapshots, snapshot)
+	}
+
+	var converted []any
+	if err := common.ConvertInterfaceToInterface(snapshots, &converted); err == nil {
+		metadata[SnapshotsMetadataKey] = converted
+	}
+
+	return metadata
+}
+
+// GetEntitlementSnapshots reads the snapshots recorded by AuthorizeRole
+func GetEntitlementSnapshots(metadata map[string]any) EntitlementSnapshots {
+
+	result := NewEntitlementSnapshots()
+
+	found, ok := metadata[SnapshotsMetadataKey]
+	if !ok {
+		return result
+	}
+
+	var snapshots []EntitlementSnapshot
+	if err := common.ConvertInterfaceToInterface(found, &snapshots); err != nil {
+		return result
+	}
+
+	for _, snapshot := range snapshots {
+		result[snapshot.Resource] = snapshot
+	}
+
+	return result
+}
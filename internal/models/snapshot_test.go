@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEntitlementSnapshots_RoundTrip(t *testing.T) {
+
+	snapshots := NewEntitlementSnapshots()
+	snapshots.Add("org:acme", true, "")
+	snapshots.Add("team:acme/platform", false, "member")
+	snapshots.Add("repo:acme/api", false, "")
+
+	metadata := snapshots.AddToMetadata(map[string]any{"member": "user:alice@example.com"})
+
+	// Metadata is persisted as JSON in the workflow context
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		t.Fatalf("failed to marshal metadata: %v", err)
+	}
+
+	var persisted map[string]any
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		t.Fatalf("failed to unmarshal metadata: %v", err)
+	}
+
+	restored := GetEntitlementSnapshots(persisted)
+
+	tests := []struct {
+		resource      string
+		shouldRevoke  bool
+		shouldRestore bool
+	}{
+		{resource: "org:acme", shouldRevoke: false, shouldRestore: false},
+		{resource: "team:acme/platform", shouldRevoke: false, shouldRestore: true},
+		{resource: "repo:acme/api", shouldRevoke: true, shouldRestore: false},
+		// Grants made before snapshots were recorded are revoked
+		{resource: "repo:acme/legacy", shouldRevoke: true, shouldRestore: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			snapshot := restored.Get(tt.resource)
+			if got := snapshot.ShouldRevoke(); got != tt.shouldRevoke {
+				t.Errorf("ShouldRevoke() = %v, expected %v", got, tt.shouldRevoke)
+			}
+			if got := snapshot.ShouldRestore(); got != tt.shouldRestore {
+				t.Errorf("ShouldRestore() = %v, expected %v", got, tt.shouldRestore)
+			}
+		})
+	}
+
+	if persisted["member"] != "user:alice@example.com" {
+		t.Errorf("expected existing metadata to be kept, got %v", persisted)
+	}
+}
@@ -11,12 +11,17 @@ import (
 var TemporalEmptyRunId = ""
 
 var TemporalExecuteElevationWorkflowName = "ExecuteElevationWorkflow"
+var TemporalRunWorkflowName = "RunWorkflow"
 
 var TemporalCleanupActivityName = "cleanup"
 var TemporalHttpActivityName = "http"
 var TemporalGrpcActivityName = "grpc"
 var TemporalAsyncionActivityName = "asyncio"
 var TemporalOpenAPIActivityName = "openapi"
+var TemporalScriptActivityName = "script"
+var TemporalContainerActivityName = "container"
+var TemporalEmitActivityName = "emit"
+var TemporalSecretsActivityName = "secrets"
 
 var TemporalResumeSignalName = "resume"
 var TemporalEventSignalName = "event"
@@ -27,14 +32,18 @@ var TemporalGetWorkflowTaskQueryName = "getWorkflowTask"
 
 var TypedSearchAttributeStatus = temporal.NewSearchAttributeKeyKeyword("status")
 var TypedSearchAttributeTask = temporal.NewSearchAttributeKeyString("task")
-var TypedSearchAttributeUser = temporal.NewSearchAttributeKeyString(VarsContextUser)
-var TypedSearchAttributeRole = temporal.NewSearchAttributeKeyString(VarsContextRole)
-var TypedSearchAttributeWorkflow = temporal.NewSearchAttributeKeyString(VarsContextWorkflow)
+
+// The user, role and workflow are keywords so queries match them exactly
+var TypedSearchAttributeUser = temporal.NewSearchAttributeKeyKeyword(VarsContextUser)
+var TypedSearchAttributeRole = temporal.NewSearchAttributeKeyKeyword(VarsContextRole)
+var TypedSearchAttributeWorkflow = temporal.NewSearchAttributeKeyKeyword(VarsContextWorkflow)
 var TypedSearchAttributeProviders = temporal.NewSearchAttributeKeyKeywordList(VarsContextProviders)
 var TypedSearchAttributeReason = temporal.NewSearchAttributeKeyString("reason")
 var TypedSearchAttributeDuration = temporal.NewSearchAttributeKeyInt64("duration")
 var TypedSearchAttributeIdentities = temporal.NewSearchAttributeKeyKeywordList("identities")
 var TypedSearchAttributeApproved = temporal.NewSearchAttributeKeyBool(VarsContextApproved)
+var TypedSearchAttributeBreakGlass = temporal.NewSearchAttributeKeyBool("break_glass")
+var TypedSearchAttributeReviewDueAt = temporal.NewSearchAttributeKeyTime("review_due_at")
 
 type TemporalConfig struct {
 	Host      string `mapstructure:"host" default:"localhost"`
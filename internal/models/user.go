@@ -26,6 +26,19 @@ func (u *User) GetName() string {
 	return "Unknown"
 }
 
+// GetIdentity returns the email if known, otherwise the username
+func (u *User) GetIdentity() string {
+	if len(u.Email) > 0 {
+		return u.Email
+	}
+	return u.Username
+}
+
+// MatchesFilters returns true if the user's id, names or email contain every filter
+func (u *User) MatchesFilters(filters ...string) bool {
+	return matchesFilters([]string{u.ID, u.Username, u.Email, u.Name}, filters...)
+}
+
 func (u *User) AsMap() map[string]any {
 	// Convert User struct to a map[string]any
 	var mapUser map[string]any
@@ -79,6 +79,10 @@ type WorkflowExecutionInfo struct {
 	Approved   *bool    `json:"approved"`           // nil = pending approval, true = approved, false = denied
 	Identities []string `json:"identities,omitempty"`
 
+	// Break-glass elevations skip approval and must be reviewed afterwards
+	BreakGlass  bool       `json:"break_glass,omitempty"`
+	ReviewDueAt *time.Time `json:"review_due_at,omitempty"`
+
 	// Context
 	Input   any `json:"input,omitempty"`
 	Output  any `json:"output,omitempty"`
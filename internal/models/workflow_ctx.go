@@ -56,11 +56,13 @@ func (ctx *WorkflowTask) Clone() swctx.WorkflowContext {
 		Context:          utils.DeepCloneValue(ctx.Context),
 		state:            ctx.cloneState(),
 		localExprVars:    utils.DeepClone(ctx.localExprVars),
+		secrets:          ctx.secrets,
 		StatusPhase:      append([]swctx.StatusPhaseLog(nil), ctx.StatusPhase...),
 		TasksStatusPhase: ctx.cloneTasksStatusPhase(),
 
 		// Copy read-only/shared fields
 		WorkflowID:      ctx.WorkflowID,
+		Callers:         ctx.Callers,
 		Workflow:        ctx.Workflow,
 		internalContext: ctx.internalContext,
 	}
@@ -128,6 +130,23 @@ func (ctx *WorkflowTask) SetLocalExprVars(vars map[string]any) {
 	ctx.localExprVars = vars
 }
 
+// SetSecrets sets the workflow's masked secrets, exposed to expressions
+// as $secrets. They are never serialized.
+func (ctx *WorkflowTask) SetSecrets(secrets map[string]any) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.secrets = secrets
+}
+
+func (ctx *WorkflowTask) GetSecrets() map[string]any {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.secrets == nil {
+		return map[string]any{}
+	}
+	return ctx.secrets
+}
+
 // GetVars returns all available variables for expression evaluation
 func (ctx *WorkflowTask) GetVars() map[string]any {
 	workflow := ctx.getWorkflowDefAsMap()
@@ -145,6 +164,7 @@ func (ctx *WorkflowTask) GetVars() map[string]any {
 			"name":    runtimeName,
 			"version": runtimeVersion,
 		},
+		varsSecrets: ctx.GetSecrets(),
 	}
 
 	ctx.mu.Lock()
@@ -171,6 +191,7 @@ func (ctx *WorkflowTask) SetStatus(status swctx.StatusPhase) {
 		ctx.StatusPhase = []swctx.StatusPhaseLog{}
 	}
 	ctx.StatusPhase = append(ctx.StatusPhase, swctx.NewStatusPhaseLog(status))
+	ctx.Status = status
 }
 
 // SetInstanceCtx safely sets the `$context` value
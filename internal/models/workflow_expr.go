@@ -145,6 +145,23 @@ func (t *WorkflowTask) mergeContextInVars(variables map[string]any) error {
 	return nil
 }
 
+// EvaluateWithSecrets evaluates a runtime expression that can only
+// reference $secrets. Anything else is returned as it is.
+func EvaluateWithSecrets(value string, secrets map[string]any) (any, error) {
+
+	value = strings.TrimSpace(value)
+
+	if !model.IsStrictExpr(value) {
+		return value, nil
+	}
+
+	task := &WorkflowTask{}
+
+	return task.evaluateJQExpression(model.SanitizeExpr(value), nil, map[string]any{
+		varsSecrets: secrets,
+	})
+}
+
 func (t *WorkflowTask) TraverseAndEvaluateObj(runtimeExpr *model.ObjectOrRuntimeExpr, input any, taskName string) (output any, err error) {
 	if runtimeExpr == nil {
 		return input, nil
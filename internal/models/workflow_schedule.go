@@ -0,0 +1,153 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/thand-io/agent/internal/common"
+)
+
+type WorkflowScheduleType string
+
+const (
+	WorkflowScheduleEvery WorkflowScheduleType = "every" // Start at a fixed interval
+	WorkflowScheduleCron  WorkflowScheduleType = "cron"  // Start on a cron expression
+	WorkflowScheduleAfter WorkflowScheduleType = "after" // Start again a delay after the last run completes
+	WorkflowScheduleOn    WorkflowScheduleType = "on"    // Start when a matching event is received
+)
+
+const (
+	WorkflowScheduleBackendTemporal  = "temporal"
+	WorkflowScheduleBackendScheduler = "scheduler"
+	WorkflowScheduleBackendEvents    = "events"
+)
+
+// WorkflowSchedule is a configured workflow that starts on its own rather
+// than from an elevation request
+type WorkflowSchedule struct {
+	Name    string               `json:"name"` // The configured workflow name
+	Type    WorkflowScheduleType `json:"type"`
+	Spec    string               `json:"spec"`    // The interval, cron expression or event types
+	Backend string               `json:"backend"` // temporal, scheduler or events
+	Paused  bool                 `json:"paused"`
+	LastRun *time.Time           `json:"last_run,omitempty"`
+	NextRun *time.Time           `json:"next_run,omitempty"`
+
+	Interval time.Duration                   `json:"-"` // For every and after schedules
+	Events   *model.EventConsumptionStrategy `json:"-"` // For on schedules
+}
+
+// NewWorkflowSchedule converts a workflow's DSL schedule. Only one of
+// every, cron, after or on can be set.
+func NewWorkflowSchedule(name string, schedule *model.Schedule) (*WorkflowSchedule, error) {
+
+	if schedule == nil {
+		return nil, fmt.Errorf("workflow %s has no schedule", name)
+	}
+
+	workflowSchedules := []*WorkflowSchedule{}
+
+	if schedule.Every != nil {
+
+		interval, err := common.ParseWorkflowDuration(schedule.Every)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule.every for workflow %s: %w", name, err)
+		}
+
+		workflowSchedules = append(workflowSchedules, &WorkflowSchedule{
+			Type:     WorkflowScheduleEvery,
+			Spec:     interval.String(),
+			Interval: interval,
+		})
+	}
+
+	if len(schedule.Cron) > 0 {
+		workflowSchedules = append(workflowSchedules, &WorkflowSchedule{
+			Type: WorkflowScheduleCron,
+			Spec: schedule.Cron,
+		})
+	}
+
+	if schedule.After != nil {
+
+		interval, err := common.ParseWorkflowDuration(schedule.After)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule.after for workflow %s: %w", name, err)
+		}
+
+		workflowSchedules = append(workflowSchedules, &WorkflowSchedule{
+			Type:     WorkflowScheduleAfter,
+			Spec:     interval.String(),
+			Interval: interval,
+		})
+	}
+
+	if schedule.On != nil {
+		workflowSchedules = append(workflowSchedules, &WorkflowSchedule{
+			Type:   WorkflowScheduleOn,
+			Spec:   strings.Join(getEventTypes(schedule.On), ","),
+			Events: schedule.On,
+		})
+	}
+
+	if len(workflowSchedules) != 1 {
+		return nil, fmt.Errorf("workflow %s schedule must set exactly one of every, cron, after or on", name)
+	}
+
+	workflowSchedule := workflowSchedules[0]
+	workflowSchedule.Name = name
+
+	hasInterval := workflowSchedule.Type == WorkflowScheduleEvery || workflowSchedule.Type == WorkflowScheduleAfter
+
+	if hasInterval && workflowSchedule.Interval <= 0 {
+		return nil, fmt.Errorf("workflow %s schedule interval must be positive", name)
+	}
+
+	return workflowSchedule, nil
+}
+
+// GetCronSpec returns the schedule as a cron expression, where intervals
+// use the @every descriptor
+func (s *WorkflowSchedule) GetCronSpec() string {
+	if s.Type == WorkflowScheduleEvery {
+		return fmt.Sprintf("@every %s", s.Interval)
+	}
+	return s.Spec
+}
+
+// IsTimed returns whether the schedule starts the workflow on a timer
+// rather than an event
+func (s *WorkflowSchedule) IsTimed() bool {
+	return s.Type != WorkflowScheduleOn
+}
+
+// getEventTypes returns the event types a consumption strategy filters on
+func getEventTypes(strategy *model.EventConsumptionStrategy) []string {
+
+	var filters []*model.EventFilter
+
+	if strategy.One != nil {
+		filters = append(filters, strategy.One)
+	}
+
+	filters = append(filters, strategy.Any...)
+	filters = append(filters, strategy.All...)
+
+	types := []string{}
+
+	for _, filter := range filters {
+		if filter != nil && filter.With != nil && len(filter.With.Type) > 0 {
+			types = append(types, filter.With.Type)
+		}
+	}
+
+	if strategy.Until != nil && strategy.Until.Strategy != nil {
+		types = append(types, getEventTypes(strategy.Until.Strategy)...)
+	}
+
+	return types
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+)
+
+func TestNewWorkflowSchedule(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		schedule *model.Schedule
+		wantType WorkflowScheduleType
+		wantSpec string
+		wantCron string
+		wantErr  bool
+	}{
+		{
+			name:     "every inline",
+			schedule: &model.Schedule{Every: model.NewDurationExpr("PT1H")},
+			wantType: WorkflowScheduleEvery,
+			wantSpec: "1h0m0s",
+			wantCron: "@every 1h0m0s",
+		},
+		{
+			name: "every object",
+			schedule: &model.Schedule{Every: &model.Duration{Value: model.DurationInline{
+				Days: 7,
+			}}},
+			wantType: WorkflowScheduleEvery,
+			wantSpec: "168h0m0s",
+			wantCron: "@every 168h0m0s",
+		},
+		{
+			name:     "cron",
+			schedule: &model.Schedule{Cron: "0 9 * * MON"},
+			wantType: WorkflowScheduleCron,
+			wantSpec: "0 9 * * MON",
+			wantCron: "0 9 * * MON",
+		},
+		{
+			name:     "after",
+			schedule: &model.Schedule{After: model.NewDurationExpr("PT30M")},
+			wantType: WorkflowScheduleAfter,
+			wantSpec: "30m0s",
+		},
+		{
+			name: "on",
+			schedule: &model.Schedule{On: &model.EventConsumptionStrategy{
+				Any: []*model.EventFilter{
+					{With: &model.EventProperties{Type: "com.example.drift.detected"}},
+					{With: &model.EventProperties{Type: "com.example.user.offboarded"}},
+				},
+			}},
+			wantType: WorkflowScheduleOn,
+			wantSpec: "com.example.drift.detected,com.example.user.offboarded",
+		},
+		{
+			name:     "empty",
+			schedule: &model.Schedule{},
+			wantErr:  true,
+		},
+		{
+			name: "more than one",
+			schedule: &model.Schedule{
+				Every: model.NewDurationExpr("PT1H"),
+				Cron:  "0 9 * * MON",
+			},
+			wantErr: true,
+		},
+		{
+			name:     "zero interval",
+			schedule: &model.Schedule{Every: &model.Duration{Value: model.DurationInline{}}},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid interval",
+			schedule: &model.Schedule{After: model.NewDurationExpr("soon")},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			schedule, err := NewWorkflowSchedule("review", tt.schedule)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", schedule)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if schedule.Name != "review" {
+				t.Errorf("expected name review, got %s", schedule.Name)
+			}
+
+			if schedule.Type != tt.wantType {
+				t.Errorf("expected type %s, got %s", tt.wantType, schedule.Type)
+			}
+
+			if schedule.Spec != tt.wantSpec {
+				t.Errorf("expected spec %s, got %s", tt.wantSpec, schedule.Spec)
+			}
+
+			if len(tt.wantCron) > 0 && schedule.GetCronSpec() != tt.wantCron {
+				t.Errorf("expected cron spec %s, got %s", tt.wantCron, schedule.GetCronSpec())
+			}
+
+			if schedule.IsTimed() != (tt.wantType != WorkflowScheduleOn) {
+				t.Errorf("unexpected IsTimed for %s", tt.wantType)
+			}
+		})
+	}
+}
@@ -17,6 +17,8 @@ package models
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
@@ -38,6 +40,9 @@ const (
 	VarsContextRole      = "role"
 	VarsContextApproved  = "approved"
 
+	VarsContextAuthorizations = "authorizations"
+	VarsContextRevocations    = "revocations"
+
 	runnerCtxKey   ctxKey = "wfRunnerContext"
 	temporalCtxKey ctxKey = "wfTemporalContext"
 
@@ -47,6 +52,7 @@ const (
 	varsWorkflow = "$workflow"
 	varsRuntime  = "$runtime"
 	varsTask     = "$task"
+	varsSecrets  = "$secrets"
 
 	// TODO: script during the release to update this value programmatically
 	runtimeVersion = "v3.1.0"
@@ -59,18 +65,34 @@ type WorkflowTask struct {
 	internalContext context.Context    `json:"-"`
 	state           *WorkflowTaskState `json:"-"`
 	localExprVars   map[string]any     `json:"-"` // local variables for expressions
+	secrets         map[string]any     `json:"-"` // masked, filled in where a call is made
 
 	// Core workfork/task fields
 	WorkflowID   string `json:"id"`
 	WorkflowName string `json:"name"`
 	Signature    string `json:"signature"` // The signature of the task, used for validation
 
+	// Callers are the workflows that ran this one through run.workflow,
+	// outermost first. Used to stop workflows from calling themselves.
+	Callers []string `json:"callers,omitempty"`
+
 	// TaskSupport implementation fields
 	// Entrypoint is the current task name to start from
 	Entrypoint string          `json:"entrypoint,omitempty"` // The entrypoint of the workflow - allows for resumption
 	Status     ctx.StatusPhase `json:"status,omitempty"`
 	StartedAt  time.Time       `json:"started_at,omitempty"`
 
+	// WakeAt is when a stateless workflow suspended by a wait task resumes
+	WakeAt *time.Time `json:"wake_at,omitempty"`
+
+	// Deadlines are when timed out tasks of a stateless workflow expire,
+	// keyed by task reference. The workflow itself is keyed by "/".
+	Deadlines map[string]time.Time `json:"deadlines,omitempty"`
+
+	// Nested are the suspended nested workflows of a stateless workflow,
+	// keyed by the reference of the run.workflow task waiting on them
+	Nested map[string]*WorkflowTask `json:"nested,omitempty"`
+
 	// Never store the actual workflow workflow. We can just load it from the
 	// workflow engine
 	Workflow *model.Workflow `json:"-"` //  The workflow definition - no need to store this we can get it from the engine
@@ -318,6 +340,114 @@ func (ctx *WorkflowTask) HasEntrypoint() bool {
 	return len(ctx.Entrypoint) > 0
 }
 
+// Set when a suspended wait task should resume
+func (ctx *WorkflowTask) SetWakeAt(wakeAt *time.Time) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	ctx.WakeAt = wakeAt
+}
+
+// Get when a suspended wait task should resume
+func (ctx *WorkflowTask) GetWakeAt() *time.Time {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.WakeAt
+}
+
+// Set when a task, or the workflow for "/", times out
+func (ctx *WorkflowTask) SetDeadline(reference string, deadline time.Time) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.Deadlines == nil {
+		ctx.Deadlines = map[string]time.Time{}
+	}
+	ctx.Deadlines[reference] = deadline
+}
+
+// Get when a task, or the workflow for "/", times out
+func (ctx *WorkflowTask) GetDeadline(reference string) *time.Time {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	deadline, ok := ctx.Deadlines[reference]
+	if !ok {
+		return nil
+	}
+	return &deadline
+}
+
+func (ctx *WorkflowTask) ClearDeadline(reference string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	delete(ctx.Deadlines, reference)
+}
+
+// GetNextDeadline returns the soonest deadline, if any, including those
+// of suspended nested workflows
+func (ctx *WorkflowTask) GetNextDeadline() *time.Time {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+
+	var next *time.Time
+
+	for _, deadline := range ctx.Deadlines {
+		if next == nil || deadline.Before(*next) {
+			next = &deadline
+		}
+	}
+
+	for _, nested := range ctx.Nested {
+		if deadline := nested.GetNextDeadline(); deadline != nil {
+			if next == nil || deadline.Before(*next) {
+				next = deadline
+			}
+		}
+	}
+
+	return next
+}
+
+// Set the suspended nested workflow run by the task at the reference
+func (ctx *WorkflowTask) SetNested(reference string, nested *WorkflowTask) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.Nested == nil {
+		ctx.Nested = map[string]*WorkflowTask{}
+	}
+	ctx.Nested[reference] = nested
+}
+
+// Get the suspended nested workflow run by the task at the reference
+func (ctx *WorkflowTask) GetNested(reference string) *WorkflowTask {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	return ctx.Nested[reference]
+}
+
+func (ctx *WorkflowTask) ClearNested(reference string) {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	delete(ctx.Nested, reference)
+}
+
+// IsListening reports whether the suspended workflow, or a nested
+// workflow it is waiting on, is waiting at a listen task
+func (ctx *WorkflowTask) IsListening() bool {
+
+	if taskList := ctx.GetTaskList(); taskList != nil {
+		if _, taskItem := taskList.KeyAndIndex(ctx.GetEntrypoint()); taskItem != nil {
+			if _, ok := taskItem.Task.(*model.ListenTask); ok {
+				return true
+			}
+		}
+	}
+
+	ctx.mu.Lock()
+	nested := slices.Collect(maps.Values(ctx.Nested))
+	ctx.mu.Unlock()
+
+	return slices.ContainsFunc(nested, (*WorkflowTask).IsListening)
+}
+
 func (ctx *WorkflowTask) GetEntrypointIndex() (int, error) {
 	ctx.mu.Lock()
 	defer ctx.mu.Unlock()
@@ -340,6 +470,29 @@ func (ctx *WorkflowTask) GetEntrypointIndex() (int, error) {
 
 }
 
+// ApprovalEventType is the cloud event type sent when an approver responds
+const ApprovalEventType = "com.thand.approval"
+
+// ReservedEventTypePrefix is the prefix of the event types the agent sends
+// itself. Events of these types are never accepted from external sources.
+const ReservedEventTypePrefix = "com.thand."
+
+// GetApprovalEvent returns the approval event the task is being resumed
+// with, if there is one
+func (ctx *WorkflowTask) GetApprovalEvent() (map[string]any, bool) {
+
+	var event map[string]any
+	if err := common.ConvertInterfaceToInterface(ctx.GetInput(), &event); err != nil {
+		return nil, false
+	}
+
+	if eventType, _ := event["type"].(string); eventType != ApprovalEventType {
+		return nil, false
+	}
+
+	return event, true
+}
+
 func (ctx *WorkflowTask) IsApproved() *bool {
 
 	if context := ctx.GetContextAsMap(); len(context) > 0 {
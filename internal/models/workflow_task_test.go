@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/serverlessworkflow/sdk-go/v3/model"
@@ -537,3 +538,83 @@ func TestWorkflowTask_WorkflowExampleCompatibility(t *testing.T) {
 		t.Errorf("JSON round-trip failed. Got: %s, Expected: %s", unmarshalledResult["message"], expectedMessage)
 	}
 }
+
+func TestWorkflowTask_GetApprovalEvent(t *testing.T) {
+
+	task := &WorkflowTask{}
+
+	if _, ok := task.GetApprovalEvent(); ok {
+		t.Error("expected no approval event without input")
+	}
+
+	task.SetInput(map[string]any{
+		"specversion": "1.0",
+		"type":        ApprovalEventType,
+		"data": map[string]any{
+			"approved": true,
+		},
+	})
+
+	event, ok := task.GetApprovalEvent()
+	if !ok {
+		t.Fatal("expected an approval event")
+	}
+
+	if data, _ := event["data"].(map[string]any); data["approved"] != true {
+		t.Errorf("unexpected approval data: %v", event["data"])
+	}
+
+	task.SetInput(map[string]any{"type": "com.example.other"})
+
+	if _, ok := task.GetApprovalEvent(); ok {
+		t.Error("expected other events to be ignored")
+	}
+}
+
+func TestWorkflowTask_Secrets(t *testing.T) {
+
+	secrets := map[string]any{
+		"google_oauth2": map[string]any{"client_id": "abc"},
+	}
+
+	result, err := EvaluateWithSecrets(`${ $secrets.google_oauth2.client_id }`, secrets)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != "abc" {
+		t.Errorf("expected abc, got %v", result)
+	}
+
+	workflowTask := &WorkflowTask{
+		WorkflowID: "test-workflow",
+		Input:      make(map[string]any),
+		Output:     make(map[string]any),
+	}
+
+	workflowTask.SetSecrets(map[string]any{
+		"google_oauth2": map[string]any{"client_id": "[secret:google_oauth2.client_id]"},
+	})
+
+	result, err = workflowTask.TraverseAndEvaluate(`${ "id=" + $secrets.google_oauth2.client_id }`, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result != "id=[secret:google_oauth2.client_id]" {
+		t.Errorf("expected the masked secret, got %v", result)
+	}
+
+	if _, found := workflowTask.Clone().(*WorkflowTask).GetVars()[varsSecrets].(map[string]any)["google_oauth2"]; !found {
+		t.Error("expected clones to keep the secrets")
+	}
+
+	encoded, err := json.Marshal(workflowTask)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Contains(string(encoded), "google_oauth2") {
+		t.Errorf("secrets should never be serialized: %s", encoded)
+	}
+}
@@ -0,0 +1,188 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/identitystore"
+	identitystoretypes "github.com/aws/aws-sdk-go-v2/service/identitystore/types"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	"github.com/thand-io/agent/internal/models"
+)
+
+// getIdentityStoreId returns the identity store backing the Identity Center instance
+func (p *awsProvider) getIdentityStoreId(ctx context.Context) (*string, error) {
+
+	resp, err := p.ssoAdminService.ListInstances(ctx, &ssoadmin.ListInstancesInput{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list SSO instances: %w", err)
+	}
+
+	if len(resp.Instances) == 0 {
+		return nil, fmt.Errorf("no SSO instances found")
+	}
+
+	identityStoreId := resp.Instances[0].IdentityStoreId
+	if identityStoreId == nil {
+		return nil, fmt.Errorf("identity store ID not found in SSO instance")
+	}
+
+	return identityStoreId, nil
+}
+
+// GetUser finds a user in the Identity Store by username or email
+// and resolves their group memberships
+func (p *awsProvider) GetUser(ctx context.Context, user string) (*models.User, error) {
+
+	identityStoreId, err := p.getIdentityStoreId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := p.findIdentityCenterUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.identityStoreClient.DescribeUser(ctx, &identitystore.DescribeUserInput{
+		IdentityStoreId: identityStoreId,
+		UserId:          aws.String(userId),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe user: %w", err)
+	}
+
+	foundUser := convertIdentityStoreUser(identitystoretypes.User{
+		UserId:      resp.UserId,
+		UserName:    resp.UserName,
+		DisplayName: resp.DisplayName,
+		Emails:      resp.Emails,
+	})
+
+	groups, err := p.listGroupsForMember(ctx, identityStoreId, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	foundUser.Groups = groups
+
+	return &foundUser, nil
+}
+
+// ListUsers lists all users in the Identity Store
+func (p *awsProvider) ListUsers(ctx context.Context, filters ...string) ([]models.User, error) {
+
+	identityStoreId, err := p.getIdentityStoreId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var users []models.User
+
+	paginator := identitystore.NewListUsersPaginator(p.identityStoreClient, &identitystore.ListUsersInput{
+		IdentityStoreId: identityStoreId,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list users: %w", err)
+		}
+
+		for _, identityUser := range page.Users {
+			user := convertIdentityStoreUser(identityUser)
+			if user.MatchesFilters(filters...) {
+				users = append(users, user)
+			}
+		}
+	}
+
+	return users, nil
+}
+
+// ListGroups lists all groups in the Identity Store
+func (p *awsProvider) ListGroups(ctx context.Context, filters ...string) ([]models.Group, error) {
+
+	identityStoreId, err := p.getIdentityStoreId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []models.Group
+
+	paginator := identitystore.NewListGroupsPaginator(p.identityStoreClient, &identitystore.ListGroupsInput{
+		IdentityStoreId: identityStoreId,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list groups: %w", err)
+		}
+
+		for _, identityGroup := range page.Groups {
+			group := models.Group{
+				ID:   aws.ToString(identityGroup.GroupId),
+				Name: aws.ToString(identityGroup.DisplayName),
+			}
+			if group.MatchesFilters(filters...) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// listGroupsForMember returns the display names of the groups a user belongs to
+func (p *awsProvider) listGroupsForMember(ctx context.Context, identityStoreId *string, userId string) ([]string, error) {
+
+	var groups []string
+
+	paginator := identitystore.NewListGroupMembershipsForMemberPaginator(
+		p.identityStoreClient, &identitystore.ListGroupMembershipsForMemberInput{
+			IdentityStoreId: identityStoreId,
+			MemberId: &identitystoretypes.MemberIdMemberUserId{
+				Value: userId,
+			},
+		})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list group memberships: %w", err)
+		}
+
+		for _, membership := range page.GroupMemberships {
+			group, err := p.identityStoreClient.DescribeGroup(ctx, &identitystore.DescribeGroupInput{
+				IdentityStoreId: identityStoreId,
+				GroupId:         membership.GroupId,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to describe group: %w", err)
+			}
+			groups = append(groups, aws.ToString(group.DisplayName))
+		}
+	}
+
+	return groups, nil
+}
+
+func convertIdentityStoreUser(identityUser identitystoretypes.User) models.User {
+
+	user := models.User{
+		ID:       aws.ToString(identityUser.UserId),
+		Username: aws.ToString(identityUser.UserName),
+		Name:     aws.ToString(identityUser.DisplayName),
+		Source:   "identitystore",
+	}
+
+	for _, email := range identityUser.Emails {
+		if email.Primary || len(user.Email) == 0 {
+			user.Email = aws.ToString(email.Value)
+		}
+	}
+
+	return user
+}
@@ -40,6 +40,7 @@ func (p *awsProvider) Initialize(provider models.Provider) error {
 	p.BaseProvider = models.NewBaseProvider(
 		provider,
 		models.ProviderCapabilityRBAC,
+		models.ProviderCapabilityIdentity,
 	)
 
 	// Load EC2 Permissions. This loads from third_party/iam-dataset/aws/docs.json
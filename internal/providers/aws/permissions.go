@@ -15,15 +15,12 @@ import (
 )
 
 func (p *awsProvider) LoadPermissions() error {
-	var docs map[string]string
 
-	// Load EC2 Permissions
-	if err := json.Unmarshal(third_party.GetEc2Docs(), &docs); err != nil {
-		return fmt.Errorf("failed to unmarshal EC2 permissions: %w", err)
+	permissions, err := GetAwsPermissions()
+	if err != nil {
+		return err
 	}
 
-	var permissions []models.ProviderPermission
-
 	// Create in-memory Bleve index
 	mapping := bleve.NewIndexMapping()
 	index, err := bleve.NewMemOnly(mapping)
@@ -32,16 +29,10 @@ func (p *awsProvider) LoadPermissions() error {
 	}
 
 	// Index permissions
-	for name, description := range docs {
-		perm := models.ProviderPermission{
-			Name:        name,
-			Description: description,
-		}
-		permissions = append(permissions, perm)
-
+	for _, perm := range permissions {
 		// Index the permission for full-text search
-		if err := index.Index(name, perm); err != nil {
-			return fmt.Errorf("failed to index permission %s: %w", name, err)
+		if err := index.Index(perm.Name, perm); err != nil {
+			return fmt.Errorf("failed to index permission %s: %w", perm.Name, err)
 		}
 	}
 
@@ -55,6 +46,36 @@ func (p *awsProvider) LoadPermissions() error {
 	return nil
 }
 
+// GetAwsPermissions returns the permissions from the embedded IAM dataset
+func GetAwsPermissions() ([]models.ProviderPermission, error) {
+	var docs map[string]string
+
+	// Load EC2 Permissions
+	if err := json.Unmarshal(third_party.GetEc2Docs(), &docs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal EC2 permissions: %w", err)
+	}
+
+	var permissions []models.ProviderPermission
+
+	for name, description := range docs {
+		permissions = append(permissions, models.ProviderPermission{
+			Name:        name,
+			Description: description,
+		})
+	}
+
+	return permissions, nil
+}
+
+// GetPermissionWildcards matches actions ignoring case and keeps wildcards
+// in policies, which stops large expansions hitting the policy size limit
+func (p *awsProvider) GetPermissionWildcards() models.PermissionWildcards {
+	return models.PermissionWildcards{
+		CaseInsensitive: true,
+		Native:          true,
+	}
+}
+
 func (p *awsProvider) GetPermission(ctx context.Context, permission string) (*models.ProviderPermission, error) {
 	// loop over permissions and match by name
 	for _, p := range p.permissions {
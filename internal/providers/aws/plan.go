@@ -0,0 +1,157 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thand-io/agent/internal/common"
+	"github.com/thand-io/agent/internal/models"
+)
+
+// PlanRole describes the changes AuthorizeRole would make without applying them
+func (p *awsProvider) PlanRole(
+	ctx context.Context,
+	req *models.AuthorizeRoleRequest,
+) (*models.RolePlan, error) {
+
+	if !req.IsValid() {
+		return nil, fmt.Errorf("user and role must be provided to plan aws role")
+	}
+
+	plan := models.NewRolePlan(p, req)
+
+	permissions, err := models.ResolveRolePlanPermissions(p, req.GetRole())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+
+	plan.Permissions = *permissions
+
+	if p.shouldUseIdentityCenter(req.GetUser()) {
+		err = p.planRoleIdentityCenter(ctx, req, plan)
+	} else {
+		err = p.planRoleTraditionalIAM(ctx, req, plan)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// planRoleTraditionalIAM mirrors authorizeRoleTraditionalIAM
+func (p *awsProvider) planRoleTraditionalIAM(
+	ctx context.Context,
+	req *models.AuthorizeRoleRequest,
+	plan *models.RolePlan,
+) error {
+
+	user := req.GetUser()
+	role := req.GetRole()
+	roleName := role.GetSnakeCaseName()
+
+	roleAction := models.RolePlanActionUpdate
+
+	if _, err := p.getRole(ctx, role); err != nil {
+		roleAction = models.RolePlanActionCreate
+	}
+
+	if len(plan.Permissions.Allow) > 0 {
+		plan.PolicyDocuments = append(plan.PolicyDocuments, models.RolePlanPolicyDocument{
+			Action:   roleAction,
+			Name:     fmt.Sprintf("thand-%s-policy", common.ConvertToSnakeCase(roleName)),
+			Target:   fmt.Sprintf("arn:aws:iam::%s:role/%s", p.GetAccountID(), roleName),
+			Document: newPermissionsPolicyDocument(plan.Permissions.Allow),
+		})
+	}
+
+	username := p.getUsernameForIAM(user)
+
+	if len(username) == 0 {
+		return fmt.Errorf("failed to determine username for user")
+	}
+
+	userArn := fmt.Sprintf("arn:aws:iam::%s:user/%s", p.GetAccountID(), username)
+
+	plan.PolicyDocuments = append(plan.PolicyDocuments, models.RolePlanPolicyDocument{
+		Action:   models.RolePlanActionUpdate,
+		Name:     "AssumeRolePolicyDocument",
+		Target:   fmt.Sprintf("arn:aws:iam::%s:role/%s", p.GetAccountID(), roleName),
+		Document: newAssumeRolePolicyDocument(userArn),
+	})
+
+	plan.Bindings = append(plan.Bindings, models.RolePlanBinding{
+		Action:    models.RolePlanActionCreate,
+		Principal: userArn,
+		Role:      roleName,
+		Scope:     p.GetAccountID(),
+	})
+
+	return nil
+}
+
+// planRoleIdentityCenter mirrors authorizeRoleIdentityCenter
+func (p *awsProvider) planRoleIdentityCenter(
+	ctx context.Context,
+	req *models.AuthorizeRoleRequest,
+	plan *models.RolePlan,
+) error {
+
+	user := req.GetUser()
+	role := req.GetRole()
+	permissionSetName := role.GetSnakeCaseName()
+
+	instanceArn, err := p.getIdentityCenterInstance(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find Identity Center instance: %w", err)
+	}
+
+	permissionSet := models.RolePlanPermissionSet{
+		Action: models.RolePlanActionCreate,
+		Name:   permissionSetName,
+	}
+
+	permissionSetArn, err := p.findPermissionSetByName(ctx, instanceArn, permissionSetName)
+	if err == nil {
+		permissionSet.Action = models.RolePlanActionUpdate
+		permissionSet.Arn = permissionSetArn
+	}
+
+	for _, arnOrPolicy := range role.Inherits {
+		if strings.HasPrefix(arnOrPolicy, "arn:aws:iam::") {
+			if strings.Contains(arnOrPolicy, ":policy/") {
+				permissionSet.ManagedPolicies = append(permissionSet.ManagedPolicies, arnOrPolicy)
+			}
+			continue
+		}
+		permissionSet.ManagedPolicies = append(permissionSet.ManagedPolicies,
+			fmt.Sprintf("arn:aws:iam::aws:policy/%s", arnOrPolicy))
+	}
+
+	plan.PermissionSets = append(plan.PermissionSets, permissionSet)
+
+	if len(plan.Permissions.Allow) > 0 {
+		plan.PolicyDocuments = append(plan.PolicyDocuments, models.RolePlanPolicyDocument{
+			Action:   models.RolePlanActionUpdate,
+			Name:     "InlinePolicy",
+			Target:   permissionSetName,
+			Document: newPermissionsPolicyDocument(plan.Permissions.Allow),
+		})
+	}
+
+	principalId, err := p.findIdentityCenterUser(ctx, user.Email)
+	if err != nil {
+		return fmt.Errorf("failed to find user in Identity Center: %w", err)
+	}
+
+	plan.Bindings = append(plan.Bindings, models.RolePlanBinding{
+		Action:    models.RolePlanActionCreate,
+		Principal: principalId,
+		Role:      permissionSetName,
+		Scope:     p.GetAccountID(),
+	})
+
+	return nil
+}
@@ -220,3 +220,28 @@ func indexOf(s, substr string) int {
 	}
 	return -1
 }
+
+func TestIsPlaceholderStatement(t *testing.T) {
+
+	accountRoot := map[string]any{"AWS": "arn:aws:iam::123456789012:root"}
+
+	// Only the statements thand added are placeholders
+	assert.True(t, isPlaceholderStatement(Statement{
+		Sid:       placeholderStatementSid,
+		Effect:    "Allow",
+		Principal: accountRoot,
+		Action:    "sts:AssumeRole",
+	}))
+
+	assert.False(t, isPlaceholderStatement(Statement{
+		Effect:    "Allow",
+		Principal: accountRoot,
+		Action:    "sts:AssumeRole",
+	}))
+
+	assert.False(t, isPlaceholderStatement(Statement{
+		Effect:    "Deny",
+		Principal: map[string]any{"AWS": "*"},
+		Action:    "sts:AssumeRole",
+	}))
+}
@@ -48,13 +48,13 @@ func (p *awsProvider) RevokeRole(
 	useIdentityCenter := p.shouldUseIdentityCenter(user)
 
 	if useIdentityCenter {
-		err := p.revokeRoleIdentityCenter(ctx, user, role)
+		err := p.revokeRoleIdentityCenter(ctx, user, role, metadata)
 		if err != nil {
 			return nil, fmt.Errorf("failed to revoke Identity Center role: %w", err)
 		}
 		return nil, nil
 	} else {
-		return p.revokeRoleTraditionalIAM(ctx, user, role)
+		return p.revokeRoleTraditionalIAM(ctx, user, role, metadata)
 	}
 }
 
@@ -71,10 +71,45 @@ type PolicyDocument struct {
 	Statement []Statement `json:"Statement"`
 }
 
+// placeholderStatementSid marks the trust policy statements thand adds
+// when a role has no users bound, so only those are replaced on bind
+const placeholderStatementSid = "ThandPlaceholder"
+
 // Statement represents a policy statement
 type Statement struct {
+	Sid       string `json:"Sid,omitempty"`
 	Effect    string `json:"Effect"`
 	Action    any    `json:"Action,omitempty"`    // Can be string or []string
 	Resource  any    `json:"Resource,omitempty"`  // Can be string or []string
 	Principal any    `json:"Principal,omitempty"` // For assume role policies
 }
+
+// newPermissionsPolicyDocument creates the policy document granting the permissions
+func newPermissionsPolicyDocument(permissions []string) PolicyDocument {
+	return PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []Statement{
+			{
+				Effect:   "Allow",
+				Action:   permissions,
+				Resource: "*",
+			},
+		},
+	}
+}
+
+// newAssumeRolePolicyDocument creates the trust policy allowing the principal to assume a role
+func newAssumeRolePolicyDocument(principalArn string) PolicyDocument {
+	return PolicyDocument{
+		Version: "2012-10-17",
+		Statement: []Statement{
+			{
+				Effect: "Allow",
+				Principal: map[string]string{
+					"AWS": principalArn,
+				},
+				Action: "sts:AssumeRole",
+			},
+		},
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/iam"
 	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/common"
 	"github.com/thand-io/agent/internal/models"
 )
@@ -37,16 +39,26 @@ func (p *awsProvider) authorizeRoleTraditionalIAM(
 	}
 
 	// Bind the user to the role (assuming user will assume this role)
-	err = p.bindUserToRole(ctx, user, existingRole.RoleName)
+	preExisting, err := p.bindUserToRole(ctx, user, existingRole.RoleName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to bind user to role: %w", err)
 	}
 
-	return nil, nil
+	// Record whether the user could already assume the role so
+	// revocation doesn't remove trust thand didn't add
+	snapshots := models.NewEntitlementSnapshots()
+	snapshots.Add(aws.ToString(existingRole.Arn), preExisting, "")
+
+	return snapshots.AddToMetadata(nil), nil
 }
 
 // revokeRoleTraditionalIAM handles role revocation for traditional IAM users
-func (p *awsProvider) revokeRoleTraditionalIAM(ctx context.Context, user *models.User, role *models.Role) (map[string]any, error) {
+func (p *awsProvider) revokeRoleTraditionalIAM(
+	ctx context.Context,
+	user *models.User,
+	role *models.Role,
+	metadata map[string]any,
+) (map[string]any, error) {
 
 	// Check if the role exists
 	existingRole, err := p.getRole(ctx, role)
@@ -55,6 +67,16 @@ func (p *awsProvider) revokeRoleTraditionalIAM(ctx context.Context, user *models
 		return nil, fmt.Errorf("role not found: %w", err)
 	}
 
+	// Leave the trust in place if the user could assume the role before
+	snapshot := models.GetEntitlementSnapshots(metadata).Get(aws.ToString(existingRole.Arn))
+	if !snapshot.ShouldRevoke() {
+		logrus.WithFields(logrus.Fields{
+			"user": user.GetName(),
+			"role": aws.ToString(existingRole.RoleName),
+		}).Info("User could assume the role before authorization, leaving trust policy in place")
+		return nil, nil
+	}
+
 	// Unbind the user from the role by resetting the assume role policy to deny access
 	err = p.unbindUserFromRole(ctx, user, existingRole.RoleName)
 	if err != nil {
@@ -89,6 +111,7 @@ func (p *awsProvider) createRole(ctx context.Context, role *models.Role) (*types
 		Version: "2012-10-17",
 		Statement: []Statement{
 			{
+				Sid:    placeholderStatementSid,
 				Effect: "Allow",
 				Principal: map[string]string{
 					"AWS": fmt.Sprintf("arn:aws:iam::%s:root", accountID),
@@ -124,16 +147,7 @@ func (p *awsProvider) attachPoliciesToRole(ctx context.Context, roleName *string
 	}
 
 	// Create a policy document using proper structs
-	policyDocument := PolicyDocument{
-		Version: "2012-10-17",
-		Statement: []Statement{
-			{
-				Effect:   "Allow",
-				Action:   permissions,
-				Resource: "*",
-			},
-		},
-	}
+	policyDocument := newPermissionsPolicyDocument(permissions)
 
 	policyDocumentJSON, err := json.Marshal(policyDocument)
 	if err != nil {
@@ -156,38 +170,49 @@ func (p *awsProvider) attachPoliciesToRole(ctx context.Context, roleName *string
 	return nil
 }
 
-// bindUserToRole creates or updates the assume role policy to allow the user to assume the role
-func (p *awsProvider) bindUserToRole(ctx context.Context, user *models.User, roleName *string) error {
+// bindUserToRole adds the user to the assume role policy so they can assume the role.
+// Returns true if the user was already allowed to assume the role.
+func (p *awsProvider) bindUserToRole(ctx context.Context, user *models.User, roleName *string) (bool, error) {
 	// Use the cached account ID
 	accountID := p.GetAccountID()
 
-	// Create a basic assume role policy that allows the user to assume the role
-	var assumeRolePolicy PolicyDocument
-
 	// Determine the username to use for the IAM user ARN
 	username := p.getUsernameForIAM(user)
 
 	if len(username) == 0 {
-		return fmt.Errorf("failed to determine username for user")
+		return false, fmt.Errorf("failed to determine username for user")
 	}
 
-	// Create policy allowing specific user with proper account ID
-	assumeRolePolicy = PolicyDocument{
-		Version: "2012-10-17",
-		Statement: []Statement{
-			{
-				Effect: "Allow",
-				Principal: map[string]string{
-					"AWS": fmt.Sprintf("arn:aws:iam::%s:user/%s", accountID, username),
-				},
-				Action: "sts:AssumeRole",
-			},
-		},
+	userArn := fmt.Sprintf("arn:aws:iam::%s:user/%s", accountID, username)
+
+	// Get current assume role policy so other principals are kept
+	currentPolicy, err := p.getAssumeRolePolicy(ctx, roleName)
+	if err != nil {
+		return false, err
 	}
 
-	assumeRolePolicyJSON, err := json.Marshal(assumeRolePolicy)
+	var statements []Statement
+	for _, stmt := range currentPolicy.Statement {
+		if stmt.Effect == "Allow" && statementHasPrincipal(stmt, userArn) {
+			// Nothing to change, the user can already assume the role
+			return true, nil
+		}
+		// Drop the placeholders used when a role has no users bound
+		if isPlaceholderStatement(stmt) {
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+
+	// Allow the specific user with proper account ID
+	statements = append(statements, newAssumeRolePolicyDocument(userArn).Statement...)
+
+	assumeRolePolicyJSON, err := json.Marshal(PolicyDocument{
+		Version:   "2012-10-17",
+		Statement: statements,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to marshal assume role policy: %w", err)
+		return false, fmt.Errorf("failed to marshal assume role policy: %w", err)
 	}
 
 	// Update the role's assume role policy
@@ -198,33 +223,78 @@ func (p *awsProvider) bindUserToRole(ctx context.Context, user *models.User, rol
 
 	_, err = p.service.UpdateAssumeRolePolicy(ctx, updateInput)
 	if err != nil {
-		return fmt.Errorf("failed to update assume role policy: %w", err)
+		return false, fmt.Errorf("failed to update assume role policy: %w", err)
 	}
 
-	return nil
+	return false, nil
 }
 
-// unbindUserFromRole removes the user from the assume role policy
-func (p *awsProvider) unbindUserFromRole(ctx context.Context, user *models.User, roleName *string) error {
-	// Use the cached account ID
-	accountID := p.GetAccountID()
+// getAssumeRolePolicy returns the parsed assume role policy for a role
+func (p *awsProvider) getAssumeRolePolicy(ctx context.Context, roleName *string) (*PolicyDocument, error) {
 
-	// Get current assume role policy
 	roleOutput, err := p.service.GetRole(ctx, &iam.GetRoleInput{
 		RoleName: roleName,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to get role %s: %w", *roleName, err)
+		return nil, fmt.Errorf("failed to get role %s: %w", *roleName, err)
 	}
 
-	// Parse the current policy document
 	var currentPolicy PolicyDocument
 	if roleOutput.Role.AssumeRolePolicyDocument != nil {
-		if err := json.Unmarshal([]byte(*roleOutput.Role.AssumeRolePolicyDocument), &currentPolicy); err != nil {
-			return fmt.Errorf("failed to parse assume role policy: %w", err)
+		// IAM returns the policy document URL encoded
+		document, err := url.PathUnescape(*roleOutput.Role.AssumeRolePolicyDocument)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode assume role policy: %w", err)
+		}
+		if err := json.Unmarshal([]byte(document), &currentPolicy); err != nil {
+			return nil, fmt.Errorf("failed to parse assume role policy: %w", err)
+		}
+	}
+
+	return &currentPolicy, nil
+}
+
+// statementHasPrincipal returns true if the statement names the AWS principal
+func statementHasPrincipal(stmt Statement, principalArn string) bool {
+
+	principal, ok := stmt.Principal.(map[string]any)
+	if !ok {
+		return false
+	}
+
+	switch awsPrincipal := principal["AWS"].(type) {
+	case string:
+		return awsPrincipal == principalArn
+	case []any:
+		for _, value := range awsPrincipal {
+			if value == principalArn {
+				return true
+			}
 		}
 	}
 
+	return false
+}
+
+// isPlaceholderStatement returns true for the statements thand uses when a
+// role has no users: the account root from createRole and the deny-all
+// left behind by unbindUserFromRole. Statements added by anyone else are
+// kept, even if they name the same principals.
+func isPlaceholderStatement(stmt Statement) bool {
+	return stmt.Sid == placeholderStatementSid
+}
+
+// unbindUserFromRole removes the user from the assume role policy
+func (p *awsProvider) unbindUserFromRole(ctx context.Context, user *models.User, roleName *string) error {
+	// Use the cached account ID
+	accountID := p.GetAccountID()
+
+	// Get current assume role policy
+	currentPolicy, err := p.getAssumeRolePolicy(ctx, roleName)
+	if err != nil {
+		return err
+	}
+
 	// Extract username from email
 	username := p.getUsernameForIAM(user)
 	if len(username) == 0 {
@@ -237,14 +307,9 @@ func (p *awsProvider) unbindUserFromRole(ctx context.Context, user *models.User,
 	// Remove statements that reference this user
 	var newStatements []Statement
 	for _, stmt := range currentPolicy.Statement {
-		// Check if this statement references our user
-		if principal, ok := stmt.Principal.(map[string]interface{}); ok {
-			if awsPrincipal, exists := principal["AWS"]; exists {
-				if awsStr, ok := awsPrincipal.(string); ok && awsStr == userArn {
-					// Skip this statement - we're removing the user
-					continue
-				}
-			}
+		// Skip statements that reference our user - we're removing the user
+		if statementHasPrincipal(stmt, userArn) {
+			continue
 		}
 		newStatements = append(newStatements, stmt)
 	}
@@ -253,6 +318,7 @@ func (p *awsProvider) unbindUserFromRole(ctx context.Context, user *models.User,
 	if len(newStatements) == 0 {
 		newStatements = []Statement{
 			{
+				Sid:    placeholderStatementSid,
 				Effect: "Deny",
 				Principal: map[string]string{
 					"AWS": "*",
@@ -42,18 +42,29 @@ func (p *awsProvider) authorizeRoleIdentityCenter(
 		return nil, fmt.Errorf("failed to find user in Identity Center: %w", err)
 	}
 
-	// 4. Create an Account Assignment
-	err = p.createAccountAssignment(ctx, instanceArn, permissionSetArn, principalId)
+	// 4. Check if the user already has the assignment so we only revoke what we add
+	preExisting, err := p.hasAccountAssignment(ctx, instanceArn, permissionSetArn, principalId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create account assignment: %w", err)
+		return nil, fmt.Errorf("failed to check account assignments: %w", err)
 	}
 
-	return map[string]any{
+	// 5. Create an Account Assignment
+	if !preExisting {
+		err = p.createAccountAssignment(ctx, instanceArn, permissionSetArn, principalId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create account assignment: %w", err)
+		}
+	}
+
+	snapshots := models.NewEntitlementSnapshots()
+	snapshots.Add(permissionSetArn, preExisting, "")
+
+	return snapshots.AddToMetadata(map[string]any{
 		"instanceArn":      instanceArn,
 		"permissionSetArn": permissionSetArn,
 		"principalId":      principalId,
 		"accountId":        p.GetAccountID(),
-	}, nil
+	}), nil
 }
 
 // getIdentityCenterInstance finds the Identity Center instance ARN
@@ -151,16 +162,7 @@ func (p *awsProvider) findOrCreatePermissionSet(ctx context.Context, instanceArn
 // attachPermissionsToPermissionSet creates an inline policy for the permission set
 func (p *awsProvider) attachPermissionsToPermissionSet(ctx context.Context, instanceArn, permissionSetArn string, permissions []string) error {
 	// Create a policy document
-	policyDocument := PolicyDocument{
-		Version: "2012-10-17",
-		Statement: []Statement{
-			{
-				Effect:   "Allow",
-				Action:   permissions,
-				Resource: "*",
-			},
-		},
-	}
+	policyDocument := newPermissionsPolicyDocument(permissions)
 
 	policyDocumentJSON, err := json.Marshal(policyDocument)
 	if err != nil {
@@ -343,18 +345,9 @@ func (p *awsProvider) isCustomerManagedPolicyAttached(ctx context.Context, insta
 func (p *awsProvider) findIdentityCenterUser(ctx context.Context, email string) (string, error) {
 
 	// First, get the identity store ID from the SSO instance
-	resp, err := p.ssoAdminService.ListInstances(ctx, &ssoadmin.ListInstancesInput{})
+	identityStoreId, err := p.getIdentityStoreId(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to list SSO instances: %w", err)
-	}
-
-	if len(resp.Instances) == 0 {
-		return "", fmt.Errorf("no SSO instances found")
-	}
-
-	identityStoreId := resp.Instances[0].IdentityStoreId
-	if identityStoreId == nil {
-		return "", fmt.Errorf("identity store ID not found in SSO instance")
+		return "", err
 	}
 
 	// Search for user by email
@@ -393,6 +386,32 @@ func (p *awsProvider) findIdentityCenterUser(ctx context.Context, email string)
 	return *usersResp.Users[0].UserId, nil
 }
 
+// hasAccountAssignment checks if the permission set is already assigned to the user for the current account
+func (p *awsProvider) hasAccountAssignment(ctx context.Context, instanceArn, permissionSetArn, principalId string) (bool, error) {
+
+	paginator := ssoadmin.NewListAccountAssignmentsPaginator(p.ssoAdminService, &ssoadmin.ListAccountAssignmentsInput{
+		InstanceArn:      aws.String(instanceArn),
+		PermissionSetArn: aws.String(permissionSetArn),
+		AccountId:        aws.String(p.GetAccountID()),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to list account assignments: %w", err)
+		}
+
+		for _, assignment := range page.AccountAssignments {
+			if assignment.PrincipalType == types.PrincipalTypeUser &&
+				aws.ToString(assignment.PrincipalId) == principalId {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
 // createAccountAssignment assigns a permission set to a user for the current account
 func (p *awsProvider) createAccountAssignment(ctx context.Context, instanceArn, permissionSetArn, principalId string) error {
 
@@ -421,7 +440,12 @@ func (p *awsProvider) createAccountAssignment(ctx context.Context, instanceArn,
 }
 
 // revokeRoleIdentityCenter removes role authorization for Identity Center users
-func (p *awsProvider) revokeRoleIdentityCenter(ctx context.Context, user *models.User, role *models.Role) error {
+func (p *awsProvider) revokeRoleIdentityCenter(
+	ctx context.Context,
+	user *models.User,
+	role *models.Role,
+	metadata map[string]any,
+) error {
 	// 1. Find the Identity Center instance
 	instanceArn, err := p.getIdentityCenterInstance(ctx)
 	if err != nil {
@@ -434,13 +458,22 @@ func (p *awsProvider) revokeRoleIdentityCenter(ctx context.Context, user *models
 		return fmt.Errorf("failed to find permission set: %w in region: %s", err, p.GetRegion())
 	}
 
-	// 3. Find the user in Identity Center
+	// 3. Leave the assignment in place if the user had it before authorization
+	if snapshot := models.GetEntitlementSnapshots(metadata).Get(permissionSetArn); !snapshot.ShouldRevoke() {
+		logrus.WithFields(logrus.Fields{
+			"user":             user.Email,
+			"permissionSetArn": permissionSetArn,
+		}).Info("User had the account assignment before authorization, leaving it in place")
+		return nil
+	}
+
+	// 4. Find the user in Identity Center
 	principalId, err := p.findIdentityCenterUser(ctx, user.Email)
 	if err != nil {
 		return fmt.Errorf("failed to find user in Identity Center: %w in region: %s", err, p.GetRegion())
 	}
 
-	// 4. Delete the Account Assignment
+	// 5. Delete the Account Assignment
 	_, err = p.ssoAdminService.DeleteAccountAssignment(ctx, &ssoadmin.DeleteAccountAssignmentInput{
 		InstanceArn:      aws.String(instanceArn),
 		PermissionSetArn: aws.String(permissionSetArn),
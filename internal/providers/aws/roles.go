@@ -23,12 +23,11 @@ type awsManagedPolicy struct {
 }
 
 func (p *awsProvider) LoadRoles() error {
-	var docs awsManagedPolicies
-	if err := json.Unmarshal(third_party.GetEc2Roles(), &docs); err != nil {
-		return fmt.Errorf("failed to unmarshal EC2 roles: %w", err)
-	}
 
-	var roles []models.ProviderRole
+	roles, err := GetAwsRoles()
+	if err != nil {
+		return err
+	}
 
 	// Create in-memory Bleve index for roles
 	mapping := bleve.NewIndexMapping()
@@ -38,15 +37,10 @@ func (p *awsProvider) LoadRoles() error {
 	}
 
 	// Index roles
-	for _, policy := range docs.Policies {
-		role := models.ProviderRole{
-			Name: policy.Name,
-		}
-		roles = append(roles, role)
-
+	for _, role := range roles {
 		// Index the role for full-text search
-		if err := rolesIndex.Index(policy.Name, role); err != nil {
-			return fmt.Errorf("failed to index role %s: %w", policy.Name, err)
+		if err := rolesIndex.Index(role.Name, role); err != nil {
+			return fmt.Errorf("failed to index role %s: %w", role.Name, err)
 		}
 	}
 
@@ -60,6 +54,24 @@ func (p *awsProvider) LoadRoles() error {
 	return nil
 }
 
+// GetAwsRoles returns the managed policies from the embedded IAM dataset
+func GetAwsRoles() ([]models.ProviderRole, error) {
+	var docs awsManagedPolicies
+	if err := json.Unmarshal(third_party.GetEc2Roles(), &docs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal EC2 roles: %w", err)
+	}
+
+	var roles []models.ProviderRole
+
+	for _, policy := range docs.Policies {
+		roles = append(roles, models.ProviderRole{
+			Name: policy.Name,
+		})
+	}
+
+	return roles, nil
+}
+
 func (p *awsProvider) GetRole(ctx context.Context, role string) (*models.ProviderRole, error) {
 
 	// loop over and match role by name
@@ -0,0 +1,184 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/go-resty/resty/v2"
+	"github.com/thand-io/agent/internal/models"
+)
+
+const (
+	graphEndpoint = "https://graph.microsoft.com/v1.0"
+	graphScope    = "https://graph.microsoft.com/.default"
+)
+
+// graphUser represents a Microsoft Graph user
+type graphUser struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	Mail              string `json:"mail"`
+	UserPrincipalName string `json:"userPrincipalName"`
+}
+
+// graphGroup represents a Microsoft Graph group
+type graphGroup struct {
+	ODataType   string `json:"@odata.type"`
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Mail        string `json:"mail"`
+}
+
+// graphPage is a single page of a Microsoft Graph collection
+type graphPage[T any] struct {
+	Value    []T    `json:"value"`
+	NextLink string `json:"@odata.nextLink"`
+}
+
+// GetUser looks up an Azure AD user by object ID or user principal
+// name and resolves the groups they are a member of
+func (p *azureProvider) GetUser(ctx context.Context, user string) (*models.User, error) {
+
+	var found graphUser
+
+	err := p.graphGet(ctx, fmt.Sprintf("%s/users/%s", graphEndpoint, url.PathEscape(user)), &found)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	foundUser := convertGraphUser(found)
+
+	memberOf, err := listGraphPages[graphGroup](ctx, p,
+		fmt.Sprintf("%s/users/%s/memberOf", graphEndpoint, url.PathEscape(found.ID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups for user: %w", err)
+	}
+
+	for _, group := range memberOf {
+		// memberOf also returns directory roles and administrative units
+		if group.ODataType == "#microsoft.graph.group" {
+			foundUser.Groups = append(foundUser.Groups, group.DisplayName)
+		}
+	}
+
+	return &foundUser, nil
+}
+
+// ListUsers lists the users in the Azure AD tenant
+func (p *azureProvider) ListUsers(ctx context.Context, filters ...string) ([]models.User, error) {
+
+	graphUsers, err := listGraphPages[graphUser](ctx, p,
+		graphEndpoint+"/users?$select=id,displayName,mail,userPrincipalName&$top=999")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	var users []models.User
+
+	for _, graphUser := range graphUsers {
+		user := convertGraphUser(graphUser)
+		if user.MatchesFilters(filters...) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// ListGroups lists the groups in the Azure AD tenant
+func (p *azureProvider) ListGroups(ctx context.Context, filters ...string) ([]models.Group, error) {
+
+	graphGroups, err := listGraphPages[graphGroup](ctx, p,
+		graphEndpoint+"/groups?$select=id,displayName,mail&$top=999")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	var groups []models.Group
+
+	for _, graphGroup := range graphGroups {
+		group := models.Group{
+			ID:    graphGroup.ID,
+			Name:  graphGroup.DisplayName,
+			Email: graphGroup.Mail,
+		}
+		if group.MatchesFilters(filters...) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
+// listGraphPages follows @odata.nextLink until every page has been read
+func listGraphPages[T any](ctx context.Context, p *azureProvider, requestUrl string) ([]T, error) {
+
+	var items []T
+
+	for len(requestUrl) > 0 {
+
+		var page graphPage[T]
+
+		if err := p.graphGet(ctx, requestUrl, &page); err != nil {
+			return nil, err
+		}
+
+		items = append(items, page.Value...)
+		requestUrl = page.NextLink
+	}
+
+	return items, nil
+}
+
+// graphGet performs an authenticated GET against Microsoft Graph
+func (p *azureProvider) graphGet(ctx context.Context, requestUrl string, result any) error {
+
+	token, err := p.cred.Token.GetToken(ctx, policy.TokenRequestOptions{
+		Scopes: []string{graphScope},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get Microsoft Graph token: %w", err)
+	}
+
+	client := resty.New()
+	client.SetTimeout(10 * time.Second)
+
+	resp, err := client.R().
+		SetContext(ctx).
+		SetAuthToken(token.Token).
+		SetHeader("Accept", "application/json").
+		SetResult(result).
+		Get(requestUrl)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return fmt.Errorf("microsoft Graph API error: %s", resp.Status())
+	}
+
+	return nil
+}
+
+func convertGraphUser(graphUser graphUser) models.User {
+
+	user := models.User{
+		ID:       graphUser.ID,
+		Name:     graphUser.DisplayName,
+		Email:    graphUser.Mail,
+		Username: strings.Split(graphUser.UserPrincipalName, "@")[0],
+		Source:   "azuread",
+	}
+
+	if len(user.Email) == 0 {
+		user.Email = graphUser.UserPrincipalName
+	}
+
+	return user
+}
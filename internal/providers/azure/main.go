@@ -38,6 +38,7 @@ func (p *azureProvider) Initialize(provider models.Provider) error {
 	p.BaseProvider = models.NewBaseProvider(
 		provider,
 		models.ProviderCapabilityRBAC,
+		models.ProviderCapabilityIdentity,
 	)
 
 	// Load Azure Permissions from third_party/iam-dataset/azure/provider-operations.json
@@ -25,6 +25,15 @@ func (p *azureProvider) GetPermission(ctx context.Context, permission string) (*
 	return nil, fmt.Errorf("permission '%s' not found", permission)
 }
 
+// GetPermissionWildcards matches operations ignoring case and keeps
+// wildcards, which role definition actions support
+func (p *azureProvider) GetPermissionWildcards() models.PermissionWildcards {
+	return models.PermissionWildcards{
+		CaseInsensitive: true,
+		Native:          true,
+	}
+}
+
 // ListPermissions returns all available permissions
 func (p *azureProvider) ListPermissions(ctx context.Context, filters ...string) ([]models.ProviderPermission, error) {
 
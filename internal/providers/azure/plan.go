@@ -0,0 +1,109 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization"
+	"github.com/thand-io/agent/internal/models"
+)
+
+// PlanRole describes the changes AuthorizeRole would make without applying them
+func (p *azureProvider) PlanRole(
+	ctx context.Context,
+	req *models.AuthorizeRoleRequest,
+) (*models.RolePlan, error) {
+
+	if !req.IsValid() {
+		return nil, fmt.Errorf("user and role must be provided to plan azure role")
+	}
+
+	user := req.GetUser()
+	role := req.GetRole()
+	scope := p.getScope()
+
+	plan := models.NewRolePlan(p, req)
+
+	permissions, err := models.ResolveRolePlanPermissions(p, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+
+	plan.Permissions = *permissions
+
+	// Custom role definitions are only created if they don't already exist
+	roleAction := models.RolePlanActionCreate
+	roleDefinitionID := ""
+
+	if existingRole, err := p.getRoleDefinition(ctx, role.Name); err == nil {
+		roleAction = models.RolePlanActionNone
+		roleDefinitionID = *existingRole.ID
+	}
+
+	plan.PolicyDocuments = append(plan.PolicyDocuments, models.RolePlanPolicyDocument{
+		Action: roleAction,
+		Name:   role.Name,
+		Target: scope,
+		Document: map[string]any{
+			"roleName":         role.Name,
+			"description":      role.Description,
+			"assignableScopes": []string{scope},
+			"permissions": []map[string]any{
+				{
+					"actions":    plan.Permissions.Allow,
+					"notActions": []string{},
+				},
+			},
+		},
+	})
+
+	principalID, err := p.getUserPrincipalID(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user principal ID: %w", err)
+	}
+
+	assignmentAction := models.RolePlanActionCreate
+
+	if len(roleDefinitionID) > 0 {
+		exists, err := p.hasRoleAssignment(ctx, principalID, roleDefinitionID)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			assignmentAction = models.RolePlanActionNone
+		}
+	}
+
+	plan.Bindings = append(plan.Bindings, models.RolePlanBinding{
+		Action:    assignmentAction,
+		Principal: principalID,
+		Role:      role.Name,
+		Scope:     scope,
+	})
+
+	return plan, nil
+}
+
+// hasRoleAssignment checks if the principal is already assigned the role definition
+func (p *azureProvider) hasRoleAssignment(ctx context.Context, principalID, roleDefinitionID string) (bool, error) {
+	pager := p.authClient.NewListForScopePager(p.getScope(), &armauthorization.RoleAssignmentsClientListForScopeOptions{
+		Filter: &[]string{fmt.Sprintf("principalId eq '%s'", principalID)}[0],
+	})
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to list role assignments: %w", err)
+		}
+
+		for _, assignment := range page.Value {
+			if assignment.Properties != nil &&
+				assignment.Properties.RoleDefinitionID != nil &&
+				*assignment.Properties.RoleDefinitionID == roleDefinitionID {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/models"
 )
 
@@ -31,12 +32,19 @@ func (p *azureProvider) AuthorizeRole(
 	}
 
 	// Create role assignment for the user
-	err = p.createRoleAssignment(ctx, user, *existingRole.ID)
+	roleAssignmentName, preExisting, err := p.createRoleAssignment(ctx, user, *existingRole.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create role assignment: %w", err)
 	}
 
-	return nil, nil
+	// Record whether the user already held the role so revocation
+	// only removes the assignment thand created
+	snapshots := models.NewEntitlementSnapshots()
+	snapshots.Add(*existingRole.ID, preExisting, "")
+
+	return snapshots.AddToMetadata(map[string]any{
+		"role_assignment": roleAssignmentName,
+	}), nil
 }
 
 // Revoke removes access for a user from a role
@@ -52,6 +60,24 @@ func (p *azureProvider) RevokeRole(
 		return nil, fmt.Errorf("failed to get role definition: %w", err)
 	}
 
+	// Leave the assignment in place if the user held the role before
+	if snapshot := models.GetEntitlementSnapshots(metadata).Get(*roleDefinition.ID); !snapshot.ShouldRevoke() {
+		logrus.WithFields(logrus.Fields{
+			"user": user.Email,
+			"role": role.Name,
+		}).Info("User held the role before authorization, leaving role assignment in place")
+		return nil, nil
+	}
+
+	// Prefer deleting the exact assignment created on authorization
+	if roleAssignmentName, ok := metadata["role_assignment"].(string); ok && len(roleAssignmentName) > 0 {
+		_, err = p.authClient.Delete(ctx, p.getScope(), roleAssignmentName, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete role assignment: %w", err)
+		}
+		return nil, nil
+	}
+
 	// Find and delete role assignments for this user and role
 	err = p.deleteRoleAssignment(ctx, user, *roleDefinition.ID)
 	if err != nil {
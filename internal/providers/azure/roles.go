@@ -74,14 +74,25 @@ func (p *azureProvider) createRoleDefinition(ctx context.Context, roleName, desc
 	return &result.RoleDefinition, nil
 }
 
-// createRoleAssignment assigns a role to a user
-func (p *azureProvider) createRoleAssignment(ctx context.Context, user *models.User, roleDefinitionID string) error {
+// createRoleAssignment assigns a role to a user. Returns the name of the
+// role assignment and whether the user already had it.
+func (p *azureProvider) createRoleAssignment(ctx context.Context, user *models.User, roleDefinitionID string) (string, bool, error) {
 	scope := p.getScope()
 
 	// Get the principal ID for the user
 	principalID, err := p.getUserPrincipalID(ctx, user)
 	if err != nil {
-		return fmt.Errorf("failed to get user principal ID: %w", err)
+		return "", false, fmt.Errorf("failed to get user principal ID: %w", err)
+	}
+
+	// Don't create a duplicate if the user already holds the role
+	existing, err := p.findRoleAssignment(ctx, principalID, roleDefinitionID)
+	if err != nil {
+		return "", false, err
+	}
+
+	if existing != nil {
+		return *existing.Name, true, nil
 	}
 
 	roleAssignmentID := uuid.New().String()
@@ -94,10 +105,36 @@ func (p *azureProvider) createRoleAssignment(ctx context.Context, user *models.U
 
 	_, err = p.authClient.Create(ctx, scope, roleAssignmentID, roleAssignment, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create role assignment: %w", err)
+		return "", false, fmt.Errorf("failed to create role assignment: %w", err)
 	}
 
-	return nil
+	return roleAssignmentID, false, nil
+}
+
+// findRoleAssignment finds the role assignment for a principal and role at the current scope
+func (p *azureProvider) findRoleAssignment(ctx context.Context, principalID, roleDefinitionID string) (*armauthorization.RoleAssignment, error) {
+	scope := p.getScope()
+
+	pager := p.authClient.NewListForScopePager(scope, &armauthorization.RoleAssignmentsClientListForScopeOptions{
+		Filter: &[]string{fmt.Sprintf("principalId eq '%s'", principalID)}[0],
+	})
+
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list role assignments: %w", err)
+		}
+
+		for _, assignment := range page.Value {
+			if assignment.Properties != nil &&
+				assignment.Properties.RoleDefinitionID != nil &&
+				strings.EqualFold(*assignment.Properties.RoleDefinitionID, roleDefinitionID) {
+				return assignment, nil
+			}
+		}
+	}
+
+	return nil, nil
 }
 
 // deleteRoleAssignment removes a role assignment for a user
@@ -143,25 +180,19 @@ func (p *azureProvider) getUserPrincipalID(ctx context.Context, user *models.Use
 		return "", fmt.Errorf("user email is required for Azure role assignments")
 	}
 
-	// For this implementation, we'll use the user's email to lookup the Azure AD object ID
-	// In a production implementation, you would query Microsoft Graph API to get the object ID
-	// based on the user's email or UPN
-
-	// For now, we'll use the user's ID field if it contains an Azure object ID (GUID format)
-	// or derive it from email in a simplified way
+	// Use the user's ID field if it already contains an Azure object ID (GUID format)
 	if len(user.ID) > 0 && len(user.ID) >= 32 {
 		// Assume ID is already an Azure object ID if it looks like a GUID
 		return user.ID, nil
 	}
 
-	// TODO: In production, implement Microsoft Graph API lookup:
-	// 1. Use Microsoft Graph SDK to query users by email
-	// 2. Get the user's object ID from the response
-	// Example: GET https://graph.microsoft.com/v1.0/users/{email}
+	// Otherwise resolve the object ID from Microsoft Graph
+	found, err := p.GetUser(ctx, user.Email)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve Azure AD object ID for '%s': %w", user.Email, err)
+	}
 
-	// For development/testing, return error with instruction
-	return "", fmt.Errorf("azure object ID not found. User ID should contain the Azure AD object ID. "+
-		"In production, implement Microsoft Graph API lookup to resolve email '%s' to object ID", user.Email)
+	return found.ID, nil
 }
 
 // getScope returns the scope for role operations
@@ -0,0 +1,170 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thand-io/agent/internal/models"
+
+	admin "google.golang.org/api/admin/directory/v1"
+)
+
+const serviceAccountDomain = ".iam.gserviceaccount.com"
+
+// ResolveIdentity resolves users, groups and service accounts into IAM members
+func (p *gcpProvider) ResolveIdentity(ctx context.Context, identity string) (*models.Identity, error) {
+
+	resolved, err := models.ParseIdentity(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service accounts can be requested by email without the type prefix
+	if resolved.IsUser() && strings.HasSuffix(resolved.User.Email, serviceAccountDomain) {
+		resolved.Type = models.IdentityTypeServiceAccount
+	}
+
+	if _, err := getMember(resolved); err != nil {
+		return nil, err
+	}
+
+	return resolved, nil
+}
+
+// getMember converts an identity into an IAM policy member string
+func getMember(identity *models.Identity) (string, error) {
+
+	switch identity.Type {
+	case models.IdentityTypeGroup:
+		if identity.Group == nil || len(identity.Group.Email) == 0 {
+			return "", fmt.Errorf("group email is required for GCP IAM binding")
+		}
+		return "group:" + identity.Group.Email, nil
+	case models.IdentityTypeServiceAccount:
+		if identity.User == nil || len(identity.User.Email) == 0 {
+			return "", fmt.Errorf("service account email is required for GCP IAM binding")
+		}
+		return "serviceAccount:" + identity.User.Email, nil
+	default:
+		if identity.User == nil || len(identity.User.Email) == 0 {
+			return "", fmt.Errorf("user email is required for GCP IAM binding")
+		}
+		return "user:" + identity.User.Email, nil
+	}
+}
+
+// GetUser looks up a Google Workspace user by email or ID and
+// resolves the groups they are a member of
+func (p *gcpProvider) GetUser(ctx context.Context, user string) (*models.User, error) {
+
+	if p.directoryClient == nil {
+		return nil, fmt.Errorf("google workspace directory is not configured")
+	}
+
+	directoryUser, err := p.directoryClient.Users.Get(user).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	foundUser := convertDirectoryUser(directoryUser)
+
+	err = p.directoryClient.Groups.List().
+		UserKey(directoryUser.PrimaryEmail).
+		Pages(ctx, func(groups *admin.Groups) error {
+			for _, group := range groups.Groups {
+				foundUser.Groups = append(foundUser.Groups, group.Email)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups for user: %w", err)
+	}
+
+	return &foundUser, nil
+}
+
+// ListUsers lists the users in the Google Workspace directory
+func (p *gcpProvider) ListUsers(ctx context.Context, filters ...string) ([]models.User, error) {
+
+	if p.directoryClient == nil {
+		return nil, fmt.Errorf("google workspace directory is not configured")
+	}
+
+	var users []models.User
+
+	call := p.directoryClient.Users.List().MaxResults(500)
+	call = scopeDirectoryCall(p, call.Customer, call.Domain)
+
+	err := call.Pages(ctx, func(page *admin.Users) error {
+		for _, directoryUser := range page.Users {
+			user := convertDirectoryUser(directoryUser)
+			if user.MatchesFilters(filters...) {
+				users = append(users, user)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	return users, nil
+}
+
+// ListGroups lists the groups in the Google Workspace directory
+func (p *gcpProvider) ListGroups(ctx context.Context, filters ...string) ([]models.Group, error) {
+
+	if p.directoryClient == nil {
+		return nil, fmt.Errorf("google workspace directory is not configured")
+	}
+
+	var groups []models.Group
+
+	call := p.directoryClient.Groups.List()
+	call = scopeDirectoryCall(p, call.Customer, call.Domain)
+
+	err := call.Pages(ctx, func(page *admin.Groups) error {
+		for _, directoryGroup := range page.Groups {
+			group := models.Group{
+				ID:    directoryGroup.Id,
+				Name:  directoryGroup.Name,
+				Email: directoryGroup.Email,
+			}
+			if group.MatchesFilters(filters...) {
+				groups = append(groups, group)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// scopeDirectoryCall restricts a directory list call to the configured
+// domain, otherwise to the configured customer
+func scopeDirectoryCall[T any](p *gcpProvider, customer func(string) T, domain func(string) T) T {
+	if len(p.directoryDomain) > 0 {
+		return domain(p.directoryDomain)
+	}
+	return customer(p.directoryCustomer)
+}
+
+func convertDirectoryUser(directoryUser *admin.User) models.User {
+
+	user := models.User{
+		ID:       directoryUser.Id,
+		Email:    directoryUser.PrimaryEmail,
+		Username: strings.Split(directoryUser.PrimaryEmail, "@")[0],
+		Source:   "google",
+	}
+
+	if directoryUser.Name != nil {
+		user.Name = directoryUser.Name.FullName
+	}
+
+	return user
+}
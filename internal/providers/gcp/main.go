@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"slices"
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/sirupsen/logrus"
@@ -12,6 +13,7 @@ import (
 	"github.com/thand-io/agent/internal/models"
 	"github.com/thand-io/agent/internal/providers"
 
+	admin "google.golang.org/api/admin/directory/v1"
 	"google.golang.org/api/cloudresourcemanager/v1"
 	iam "google.golang.org/api/iam/v1"
 	"google.golang.org/api/option"
@@ -23,13 +25,16 @@ var DefaultStage = "GA"
 type gcpProvider struct {
 	*models.BaseProvider
 
-	client           *GcpConfigurationProvider
-	iamClient        *iam.Service
-	crmClient        *cloudresourcemanager.Service
-	permissions      []models.ProviderPermission
-	permissionsIndex bleve.Index
-	roles            []models.ProviderRole
-	rolesIndex       bleve.Index
+	client            *GcpConfigurationProvider
+	iamClient         *iam.Service
+	crmClient         *cloudresourcemanager.Service
+	directoryClient   *admin.Service
+	directoryCustomer string
+	directoryDomain   string
+	permissions       []models.ProviderPermission
+	permissionsIndex  bleve.Index
+	roles             []models.ProviderRole
+	rolesIndex        bleve.Index
 }
 
 func (p *gcpProvider) Initialize(provider models.Provider) error {
@@ -77,6 +82,34 @@ func (p *gcpProvider) Initialize(provider models.Provider) error {
 	}
 	p.crmClient = crmService
 
+	// Google Workspace is optional, only expose identities when a
+	// customer or domain has been configured for the directory
+	customer, foundCustomer := gcpConfig.GetString("customer")
+	domain, foundDomain := gcpConfig.GetString("domain")
+
+	if foundCustomer || foundDomain {
+
+		directoryOptions := append(slices.Clone(clientOptions), option.WithScopes(
+			admin.AdminDirectoryUserReadonlyScope,
+			admin.AdminDirectoryGroupReadonlyScope,
+		))
+
+		directoryService, err := admin.NewService(ctx, directoryOptions...)
+		if err != nil {
+			return fmt.Errorf("failed to create Directory client: %w", err)
+		}
+
+		p.directoryClient = directoryService
+		p.directoryCustomer = customer
+		p.directoryDomain = domain
+
+		if len(p.directoryCustomer) == 0 {
+			p.directoryCustomer = "my_customer"
+		}
+
+		p.EnableCapability(models.ProviderCapabilityIdentity)
+	}
+
 	return nil
 }
 
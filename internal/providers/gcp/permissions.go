@@ -42,15 +42,12 @@ type gcpPermissionMap []struct {
 }
 
 func (p *gcpProvider) LoadPermissions(stage string) error {
-	var permissionMap gcpPermissionMap
 
-	// Load GCP Permissions
-	if err := json.Unmarshal(GetGcpPermissions(), &permissionMap); err != nil {
-		return fmt.Errorf("failed to unmarshal GCP permissions: %w", err)
+	permissions, err := GetGcpPermissionsForStage(stage)
+	if err != nil {
+		return err
 	}
 
-	var permissions []models.ProviderPermission
-
 	// Create in-memory Bleve index
 	mapping := bleve.NewIndexMapping()
 	index, err := bleve.NewMemOnly(mapping)
@@ -58,6 +55,28 @@ func (p *gcpProvider) LoadPermissions(stage string) error {
 		return fmt.Errorf("failed to create search index: %w", err)
 	}
 
+	p.permissions = permissions
+	p.permissionsIndex = index
+
+	logrus.WithFields(logrus.Fields{
+		"permissions": len(permissions),
+	}).Debug("Loaded GCP permissions")
+
+	return nil
+}
+
+// GetGcpPermissionsForStage returns the embedded permissions that can be
+// granted at the given launch stage, defaulting to GA
+func GetGcpPermissionsForStage(stage string) ([]models.ProviderPermission, error) {
+	var permissionMap gcpPermissionMap
+
+	// Load GCP Permissions
+	if err := json.Unmarshal(GetGcpPermissions(), &permissionMap); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal GCP permissions: %w", err)
+	}
+
+	var permissions []models.ProviderPermission
+
 	if len(stage) == 0 {
 		stage = DefaultStage
 	}
@@ -79,14 +98,7 @@ func (p *gcpProvider) LoadPermissions(stage string) error {
 		})
 	}
 
-	p.permissions = permissions
-	p.permissionsIndex = index
-
-	logrus.WithFields(logrus.Fields{
-		"permissions": len(permissions),
-	}).Debug("Loaded GCP permissions")
-
-	return nil
+	return permissions, nil
 }
 
 func (p *gcpProvider) GetPermission(ctx context.Context, permission string) (*models.ProviderPermission, error) {
@@ -0,0 +1,83 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/thand-io/agent/internal/models"
+	"google.golang.org/api/cloudresourcemanager/v1"
+)
+
+// PlanRole describes the changes AuthorizeRole would make without applying them
+func (p *gcpProvider) PlanRole(
+	ctx context.Context,
+	req *models.AuthorizeRoleRequest,
+) (*models.RolePlan, error) {
+
+	if req.GetRole() == nil || (req.GetUser() == nil && req.Identity == nil) {
+		return nil, fmt.Errorf("identity and role must be provided to plan gcp role")
+	}
+
+	role := req.GetRole()
+
+	member, err := getMember(req.GetIdentity())
+	if err != nil {
+		return nil, err
+	}
+
+	plan := models.NewRolePlan(p, req)
+
+	permissions, err := models.ResolveRolePlanPermissions(p, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve permissions: %w", err)
+	}
+
+	plan.Permissions = *permissions
+
+	projectId := p.GetProjectId()
+	roleName := fmt.Sprintf("projects/%s/roles/%s", projectId, role.GetSnakeCaseName())
+
+	// Custom roles are only created if they don't already exist
+	roleAction := models.RolePlanActionCreate
+
+	if existingRole, err := p.getRole(projectId, role.GetSnakeCaseName()); err == nil {
+		roleAction = models.RolePlanActionNone
+		roleName = existingRole.Name
+	}
+
+	plan.PolicyDocuments = append(plan.PolicyDocuments, models.RolePlanPolicyDocument{
+		Action: roleAction,
+		Name:   roleName,
+		Target: "projects/" + projectId,
+		Document: map[string]any{
+			"title":               role.GetName(),
+			"description":         role.GetDescription(),
+			"stage":               p.GetConfig().GetStringWithDefault("stage", DefaultStage),
+			"includedPermissions": plan.Permissions.Allow,
+		},
+	})
+
+	bindingAction := models.RolePlanActionCreate
+
+	policy, err := p.crmClient.Projects.GetIamPolicy(projectId, &cloudresourcemanager.GetIamPolicyRequest{}).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IAM policy: %w", err)
+	}
+
+	for _, binding := range policy.Bindings {
+		if binding.Role == roleName && slices.Contains(binding.Members, member) {
+			bindingAction = models.RolePlanActionNone
+			break
+		}
+	}
+
+	plan.Bindings = append(plan.Bindings, models.RolePlanBinding{
+		Action:    bindingAction,
+		Principal: member,
+		Role:      roleName,
+		Scope:     "projects/" + projectId,
+	})
+
+	return plan, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 
+	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/models"
 	"google.golang.org/api/cloudresourcemanager/v1"
 	iam "google.golang.org/api/iam/v1"
@@ -16,13 +17,17 @@ func (p *gcpProvider) AuthorizeRole(
 	req *models.AuthorizeRoleRequest,
 ) (map[string]any, error) {
 
-	if !req.IsValid() {
-		return nil, fmt.Errorf("user and role must be provided to authorize gcp role")
+	if req.GetRole() == nil || (req.GetUser() == nil && req.Identity == nil) {
+		return nil, fmt.Errorf("identity and role must be provided to authorize gcp role")
 	}
 
-	user := req.GetUser()
 	role := req.GetRole()
 
+	member, err := getMember(req.GetIdentity())
+	if err != nil {
+		return nil, err
+	}
+
 	config := p.GetConfig()
 	projectId := p.GetProjectId()
 
@@ -45,13 +50,21 @@ func (p *gcpProvider) AuthorizeRole(
 		}
 	}
 
-	// Bind the user to the role via IAM policy
-	err = p.bindUserToRole(projectId, user, existingRole)
+	// Bind the member to the role via IAM policy
+	preExisting, err := p.bindMemberToRole(projectId, member, existingRole)
 	if err != nil {
 		return nil, fmt.Errorf("failed to bind user to role: %w", err)
 	}
 
-	return nil, nil
+	// Record whether the member was already bound so revocation
+	// doesn't remove a binding thand didn't add
+	snapshots := models.NewEntitlementSnapshots()
+	snapshots.Add(existingRole.Name, preExisting, "")
+
+	// Store the member so revocation removes the same binding
+	return snapshots.AddToMetadata(map[string]any{
+		"member": member,
+	}), nil
 }
 
 // Revoke removes access for a user from a role
@@ -62,10 +75,36 @@ func (p *gcpProvider) RevokeRole(
 	metadata map[string]any,
 ) (map[string]any, error) {
 
-	if user == nil || role == nil {
-		return nil, fmt.Errorf("user and role must be provided to revoke gcp role")
+	if role == nil {
+		return nil, fmt.Errorf("role must be provided to revoke gcp role")
 	}
 
+	// Prefer the member recorded when the role was authorized
+	member, ok := metadata["member"].(string)
+
+	if !ok || len(member) == 0 {
+
+		if user == nil {
+			return nil, fmt.Errorf("user must be provided to revoke gcp role")
+		}
+
+		var err error
+		member, err = getMember(models.NewUserIdentity(user))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p.revokeMember(member, role, models.GetEntitlementSnapshots(metadata))
+}
+
+// revokeMember removes the member from the custom role binding
+func (p *gcpProvider) revokeMember(
+	member string,
+	role *models.Role,
+	snapshots models.EntitlementSnapshots,
+) (map[string]any, error) {
+
 	projectId := p.GetProjectId()
 
 	// Check if the role exists
@@ -74,8 +113,17 @@ func (p *gcpProvider) RevokeRole(
 		return nil, fmt.Errorf("failed to get role: %w", err)
 	}
 
-	// Remove the user from the role via IAM policy
-	err = p.unbindUserFromRole(projectId, user, existingRole)
+	// Leave bindings the member held before the role was authorized
+	if snapshot := snapshots.Get(existingRole.Name); !snapshot.ShouldRevoke() {
+		logrus.WithFields(logrus.Fields{
+			"member": member,
+			"role":   existingRole.Name,
+		}).Info("Member was bound to the role before authorization, leaving binding in place")
+		return nil, nil
+	}
+
+	// Remove the member from the role via IAM policy
+	err = p.unbindMemberFromRole(projectId, member, existingRole)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unbind user from role: %w", err)
 	}
@@ -115,21 +163,15 @@ func (p *gcpProvider) getRole(projectID, roleName string) (*iam.Role, error) {
 	return role, nil
 }
 
-func (p *gcpProvider) bindUserToRole(projectID string, user *models.User, iamRole *iam.Role) error {
+// bindMemberToRole adds the member to the role binding, returning
+// true if the member was already bound
+func (p *gcpProvider) bindMemberToRole(projectID string, member string, iamRole *iam.Role) (bool, error) {
 	crmService := p.crmClient
 
 	// Get current IAM policy
 	policy, err := crmService.Projects.GetIamPolicy(projectID, &cloudresourcemanager.GetIamPolicyRequest{}).Do()
 	if err != nil {
-		return fmt.Errorf("failed to get IAM policy: %w", err)
-	}
-
-	// Create member string based on user type
-	var member string
-	if len(user.Email) > 0 {
-		member = "user:" + user.Email
-	} else {
-		return fmt.Errorf("user email is required for GCP IAM binding")
+		return false, fmt.Errorf("failed to get IAM policy: %w", err)
 	}
 
 	// Check if binding already exists
@@ -137,13 +179,12 @@ func (p *gcpProvider) bindUserToRole(projectID string, user *models.User, iamRol
 	for _, binding := range policy.Bindings {
 		if binding.Role == iamRole.Name {
 			if slices.Contains(binding.Members, member) {
-				bindingExists = true
-			}
-			if !bindingExists {
-				// Add member to existing binding
-				binding.Members = append(binding.Members, member)
-				bindingExists = true
+				// Nothing to change, the member already holds the role
+				return true, nil
 			}
+			// Add member to existing binding
+			binding.Members = append(binding.Members, member)
+			bindingExists = true
 			break
 		}
 	}
@@ -162,13 +203,13 @@ func (p *gcpProvider) bindUserToRole(projectID string, user *models.User, iamRol
 		Policy: policy,
 	}).Do()
 	if err != nil {
-		return fmt.Errorf("failed to set IAM policy: %w", err)
+		return false, fmt.Errorf("failed to set IAM policy: %w", err)
 	}
 
-	return nil
+	return false, nil
 }
 
-func (p *gcpProvider) unbindUserFromRole(projectID string, user *models.User, iamRole *iam.Role) error {
+func (p *gcpProvider) unbindMemberFromRole(projectID string, member string, iamRole *iam.Role) error {
 	crmService := p.crmClient
 
 	// Get current IAM policy
@@ -177,14 +218,6 @@ func (p *gcpProvider) unbindUserFromRole(projectID string, user *models.User, ia
 		return fmt.Errorf("failed to get IAM policy: %w", err)
 	}
 
-	// Create member string based on user type
-	var member string
-	if len(user.Email) > 0 {
-		member = "user:" + user.Email
-	} else {
-		return fmt.Errorf("user email is required for GCP IAM binding")
-	}
-
 	// Find and remove the user from the role binding
 	bindingFound := false
 	for i, binding := range policy.Bindings {
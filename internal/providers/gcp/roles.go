@@ -16,13 +16,11 @@ import (
 
 func (p *gcpProvider) LoadRoles(stage string) error {
 
-	var predefinedRoles []gcpPredefinedRole
-	if err := json.Unmarshal(third_party.GetGcpRoles(), &predefinedRoles); err != nil {
-		return fmt.Errorf("failed to unmarshal GCP roles: %w", err)
+	roles, err := GetGcpRolesForStage(stage)
+	if err != nil {
+		return err
 	}
 
-	var roles []models.ProviderRole
-
 	// Create in-memory Bleve index for roles
 	mapping := bleve.NewIndexMapping()
 	rolesIndex, err := bleve.NewMemOnly(mapping)
@@ -30,6 +28,27 @@ func (p *gcpProvider) LoadRoles(stage string) error {
 		return fmt.Errorf("failed to create roles search index: %w", err)
 	}
 
+	p.roles = roles
+	p.rolesIndex = rolesIndex
+
+	logrus.WithFields(logrus.Fields{
+		"roles": len(roles),
+	}).Debug("Loaded GCP roles")
+
+	return nil
+}
+
+// GetGcpRolesForStage returns the embedded predefined roles at the given
+// launch stage, defaulting to GA
+func GetGcpRolesForStage(stage string) ([]models.ProviderRole, error) {
+
+	var predefinedRoles []gcpPredefinedRole
+	if err := json.Unmarshal(third_party.GetGcpRoles(), &predefinedRoles); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal GCP roles: %w", err)
+	}
+
+	var roles []models.ProviderRole
+
 	if len(stage) == 0 {
 		stage = DefaultStage
 	}
@@ -47,14 +66,7 @@ func (p *gcpProvider) LoadRoles(stage string) error {
 		})
 	}
 
-	p.roles = roles
-	p.rolesIndex = rolesIndex
-
-	logrus.WithFields(logrus.Fields{
-		"roles": len(roles),
-	}).Debug("Loaded GCP roles")
-
-	return nil
+	return roles, nil
 }
 
 func (p *gcpProvider) GetRole(ctx context.Context, role string) (*models.ProviderRole, error) {
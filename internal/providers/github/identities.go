@@ -0,0 +1,181 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	abs "github.com/microsoft/kiota-abstractions-go"
+	"github.com/octokit/go-sdk/pkg/github/orgs"
+	"github.com/thand-io/agent/internal/models"
+)
+
+const identitiesPageSize int32 = 100
+
+// GetUser finds a member of the configured organization by login
+// and resolves the teams they belong to
+func (p *githubProvider) GetUser(ctx context.Context, user string) (*models.User, error) {
+
+	users, err := p.ListUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range users {
+		if strings.EqualFold(member.Username, user) || strings.EqualFold(member.Email, user) {
+
+			teams, err := p.listTeamsForMember(ctx, member.Username)
+			if err != nil {
+				return nil, err
+			}
+
+			member.Groups = teams
+
+			return &member, nil
+		}
+	}
+
+	return nil, fmt.Errorf("user %s is not a member of organization %s", user, p.organization)
+}
+
+// ListUsers lists the members of the configured organization
+func (p *githubProvider) ListUsers(ctx context.Context, filters ...string) ([]models.User, error) {
+
+	if len(p.organization) == 0 {
+		return nil, fmt.Errorf("organization must be configured to list GitHub users")
+	}
+
+	var users []models.User
+
+	for page := int32(1); ; page++ {
+
+		perPage := identitiesPageSize
+		currentPage := page
+
+		members, err := p.client.Orgs().ByOrg(p.organization).Members().Get(ctx,
+			&abs.RequestConfiguration[orgs.ItemMembersRequestBuilderGetQueryParameters]{
+				QueryParameters: &orgs.ItemMembersRequestBuilderGetQueryParameters{
+					Page:     &currentPage,
+					Per_page: &perPage,
+				},
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization members: %w", err)
+		}
+
+		for _, member := range members {
+
+			user := models.User{
+				ID:       strconv.FormatInt(derefInt64(member.GetId()), 10),
+				Username: derefString(member.GetLogin()),
+				Name:     derefString(member.GetName()),
+				Email:    derefString(member.GetEmail()),
+				Source:   ProviderName,
+			}
+
+			if len(user.Name) == 0 {
+				user.Name = user.Username
+			}
+
+			if user.MatchesFilters(filters...) {
+				users = append(users, user)
+			}
+		}
+
+		if int32(len(members)) < perPage {
+			break
+		}
+	}
+
+	return users, nil
+}
+
+// ListGroups lists the teams in the configured organization
+func (p *githubProvider) ListGroups(ctx context.Context, filters ...string) ([]models.Group, error) {
+
+	if len(p.organization) == 0 {
+		return nil, fmt.Errorf("organization must be configured to list GitHub teams")
+	}
+
+	var groups []models.Group
+
+	for page := int32(1); ; page++ {
+
+		perPage := identitiesPageSize
+		currentPage := page
+
+		teams, err := p.client.Orgs().ByOrg(p.organization).Teams().Get(ctx,
+			&abs.RequestConfiguration[orgs.ItemTeamsRequestBuilderGetQueryParameters]{
+				QueryParameters: &orgs.ItemTeamsRequestBuilderGetQueryParameters{
+					Page:     &currentPage,
+					Per_page: &perPage,
+				},
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list organization teams: %w", err)
+		}
+
+		for _, team := range teams {
+
+			group := models.Group{
+				ID:   derefString(team.GetSlug()),
+				Name: derefString(team.GetName()),
+			}
+
+			if group.MatchesFilters(filters...) {
+				groups = append(groups, group)
+			}
+		}
+
+		if int32(len(teams)) < perPage {
+			break
+		}
+	}
+
+	return groups, nil
+}
+
+// listTeamsForMember returns the slugs of the teams the user is an active member of
+func (p *githubProvider) listTeamsForMember(ctx context.Context, username string) ([]string, error) {
+
+	groups, err := p.ListGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var teams []string
+
+	for _, group := range groups {
+
+		membership, err := p.client.Orgs().ByOrg(p.organization).
+			Teams().ByTeam_slug(group.ID).
+			Memberships().ByUsername(username).
+			Get(ctx, nil)
+
+		// A missing membership is returned as a 404
+		if err != nil || membership == nil || membership.GetState() == nil {
+			continue
+		}
+
+		if membership.GetState().String() == "active" {
+			teams = append(teams, group.ID)
+		}
+	}
+
+	return teams, nil
+}
+
+func derefString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func derefInt64(value *int64) int64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
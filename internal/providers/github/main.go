@@ -16,10 +16,11 @@ var ProviderName = "github"
 // githubProvider implements the ProviderImpl interface for GitHub
 type githubProvider struct {
 	*models.BaseProvider
-	client      *pkg.Client
-	oauthClient *oauth2.Config
-	permissions []models.ProviderPermission
-	roles       []models.ProviderRole
+	client       *pkg.Client
+	oauthClient  *oauth2.Config
+	organization string
+	permissions  []models.ProviderPermission
+	roles        []models.ProviderRole
 }
 
 // GitHubUser represents the GitHub user response
@@ -94,6 +95,12 @@ func (p *githubProvider) Initialize(provider models.Provider) error {
 		p.oauthClient = conf
 	}
 
+	// Listing users and teams requires an organization to scope them to
+	if organization, found := githubConfig.GetString("organization"); found && len(organization) > 0 {
+		p.organization = organization
+		p.EnableCapability(models.ProviderCapabilityIdentity)
+	}
+
 	p.permissions = GitHubPermissions
 	p.roles = GitHubRoles
 
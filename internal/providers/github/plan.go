@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thand-io/agent/internal/models"
+)
+
+// PlanRole describes the changes AuthorizeRole would make without applying them
+func (p *githubProvider) PlanRole(
+	ctx context.Context,
+	req *models.AuthorizeRoleRequest,
+) (*models.RolePlan, error) {
+
+	if !req.IsValid() {
+		return nil, fmt.Errorf("user and role must be provided to plan github role")
+	}
+
+	user := req.GetUser()
+	role := req.GetRole()
+
+	username := user.Name
+
+	plan := models.NewRolePlan(p, req)
+
+	for _, resource := range role.Resources.Allow {
+
+		resourceType, resourcePath, err := parseResource(resource)
+		if err != nil {
+			return nil, err
+		}
+
+		switch resourceType {
+		case "org":
+			plan.GroupMemberships = append(plan.GroupMemberships, models.RolePlanGroupMembership{
+				Action: models.RolePlanActionCreate,
+				Group:  resourcePath,
+				Member: username,
+				Role:   p.mapRoleToMembership(role.Name),
+			})
+		case "team":
+			if len(strings.Split(resourcePath, "/")) != 2 {
+				return nil, fmt.Errorf("invalid team path format, expected 'org/team': %s", resourcePath)
+			}
+			plan.GroupMemberships = append(plan.GroupMemberships, models.RolePlanGroupMembership{
+				Action: models.RolePlanActionCreate,
+				Group:  resourcePath,
+				Member: username,
+				Role:   "member",
+			})
+		case "repo":
+			if len(strings.Split(resourcePath, "/")) != 2 {
+				return nil, fmt.Errorf("invalid repo path format, expected 'owner/repo': %s", resourcePath)
+			}
+			plan.Bindings = append(plan.Bindings, models.RolePlanBinding{
+				Action:    models.RolePlanActionCreate,
+				Principal: username,
+				Role:      p.mapRoleToPermission(role.Name),
+				Scope:     resourcePath,
+			})
+		default:
+			return nil, fmt.Errorf("unsupported resource type: %s", resourceType)
+		}
+	}
+
+	return plan, nil
+}
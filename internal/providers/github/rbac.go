@@ -2,14 +2,20 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/go-resty/resty/v2"
+	abs "github.com/microsoft/kiota-abstractions-go"
+	"github.com/octokit/go-sdk/pkg/github/orgs"
+	"github.com/octokit/go-sdk/pkg/github/repos"
+	"github.com/octokit/go-sdk/pkg/github/repos/item/item/collaborators"
+	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/models"
 )
 
@@ -28,14 +34,19 @@ func (p *githubProvider) AuthorizeRole(
 
 	username := user.Name
 
+	// Record what the user held before so revocation only undoes our changes
+	snapshots := models.NewEntitlementSnapshots()
+
 	// Process each resource in the role
 	for _, resource := range role.Resources.Allow {
-		if err := p.authorizeResource(ctx, username, resource, role); err != nil {
+		preExisting, priorState, err := p.authorizeResource(ctx, username, resource, role)
+		if err != nil {
 			return nil, fmt.Errorf("failed to authorize resource %s: %w", resource, err)
 		}
+		snapshots.Add(resource, preExisting, priorState)
 	}
 
-	return nil, nil
+	return snapshots.AddToMetadata(nil), nil
 }
 
 // Revoke removes access for a user from a role
@@ -47,9 +58,11 @@ func (p *githubProvider) RevokeRole(
 ) (map[string]any, error) {
 	username := user.Name
 
+	snapshots := models.GetEntitlementSnapshots(metadata)
+
 	// Process each resource in the role
 	for _, resource := range role.Resources.Allow {
-		if err := p.revokeResource(ctx, username, resource); err != nil {
+		if err := p.revokeResource(ctx, username, resource, snapshots.Get(resource)); err != nil {
 			return nil, fmt.Errorf("failed to revoke resource %s: %w", resource, err)
 		}
 	}
@@ -57,24 +70,20 @@ func (p *githubProvider) RevokeRole(
 	return nil, nil
 }
 
-// authorizeResource handles authorization for a single resource
-func (p *githubProvider) authorizeResource(ctx context.Context, username, resource string, role *models.Role) error {
+// authorizeResource handles authorization for a single resource. Returns
+// whether the user already had the access and any prior state to restore.
+func (p *githubProvider) authorizeResource(ctx context.Context, username, resource string, role *models.Role) (bool, string, error) {
 	// Parse resource format to determine what type of GitHub entity it is
 	// Expected formats:
 	// - "org:myorg" or "github:org:myorg" -> organization membership
 	// - "team:myorg/myteam" or "github:team:myorg/myteam" -> team membership
 	// - "repo:owner/repo" or "github:repo:owner/repo" -> repository collaborator
 
-	resource = strings.TrimPrefix(resource, "github:")
-
-	parts := strings.Split(resource, ":")
-	if len(parts) < 2 {
-		return fmt.Errorf("invalid resource format: %s", resource)
+	resourceType, resourcePath, err := parseResource(resource)
+	if err != nil {
+		return false, "", err
 	}
 
-	resourceType := parts[0]
-	resourcePath := parts[1]
-
 	switch resourceType {
 	case "org":
 		return p.authorizeOrgMembership(ctx, username, resourcePath, role)
@@ -83,68 +92,126 @@ func (p *githubProvider) authorizeResource(ctx context.Context, username, resour
 	case "repo":
 		return p.authorizeRepoCollaboration(ctx, username, resourcePath, role)
 	default:
-		return fmt.Errorf("unsupported resource type: %s", resourceType)
+		return false, "", fmt.Errorf("unsupported resource type: %s", resourceType)
 	}
 }
 
 // revokeResource handles revocation for a single resource
-func (p *githubProvider) revokeResource(ctx context.Context, username, resource string) error {
-	resource = strings.TrimPrefix(resource, "github:")
-
-	parts := strings.Split(resource, ":")
-	if len(parts) < 2 {
-		return fmt.Errorf("invalid resource format: %s", resource)
+func (p *githubProvider) revokeResource(ctx context.Context, username, resource string, snapshot models.EntitlementSnapshot) error {
+	resourceType, resourcePath, err := parseResource(resource)
+	if err != nil {
+		return err
 	}
 
-	resourceType := parts[0]
-	resourcePath := parts[1]
+	// Leave access the user held before the role was authorized
+	if snapshot.PreExisting {
+		logrus.WithFields(logrus.Fields{
+			"user":     username,
+			"resource": resource,
+		}).Info("User had access before authorization, leaving it in place")
+		return nil
+	}
 
 	switch resourceType {
 	case "org":
-		return p.revokeOrgMembership(ctx, username, resourcePath)
+		return p.revokeOrgMembership(ctx, username, resourcePath, snapshot)
 	case "team":
 		return p.revokeTeamMembership(ctx, username, resourcePath)
 	case "repo":
-		return p.revokeRepoCollaboration(ctx, username, resourcePath)
+		return p.revokeRepoCollaboration(ctx, username, resourcePath, snapshot)
 	default:
 		return fmt.Errorf("unsupported resource type: %s", resourceType)
 	}
 }
 
 // Organization membership methods
-func (p *githubProvider) authorizeOrgMembership(ctx context.Context, username, orgName string, role *models.Role) error {
+func (p *githubProvider) authorizeOrgMembership(ctx context.Context, username, orgName string, role *models.Role) (bool, string, error) {
 	// Determine organization role from role name
-	membershipRole := "member" // default
-	roleName := strings.ToLower(role.Name)
+	membershipRole := p.mapRoleToMembership(role.Name)
 
-	if strings.Contains(roleName, "admin") || strings.Contains(roleName, "owner") {
-		membershipRole = "admin"
+	membership, err := p.client.Orgs().ByOrg(orgName).Memberships().ByUsername(username).Get(ctx, nil)
+	if err != nil && !isNotFound(err) {
+		return false, "", fmt.Errorf("failed to get organization membership: %w", err)
+	}
+
+	priorRole := ""
+	if err == nil && membership != nil && membership.GetRole() != nil {
+		priorRole = membership.GetRole().String()
 	}
 
-	// TODO: Use correct GitHub SDK API for organization membership
-	log.Printf("Would add user %s to org %s with role %s", username, orgName, membershipRole)
-	return fmt.Errorf("GitHub API integration not yet implemented for org membership")
+	// Admins already have everything a member has
+	if priorRole == membershipRole || priorRole == "admin" {
+		return true, "", nil
+	}
+
+	if err := p.setOrgMembership(ctx, username, orgName, membershipRole); err != nil {
+		return false, "", err
+	}
+
+	return false, priorRole, nil
 }
 
-func (p *githubProvider) revokeOrgMembership(ctx context.Context, username, orgName string) error {
-	// TODO: Use correct GitHub SDK API for organization membership
-	log.Printf("Would remove user %s from org %s", username, orgName)
-	return fmt.Errorf("GitHub API integration not yet implemented for org membership")
+func (p *githubProvider) revokeOrgMembership(ctx context.Context, username, orgName string, snapshot models.EntitlementSnapshot) error {
+
+	// The user was a member before, put their previous role back
+	if snapshot.ShouldRestore() {
+		return p.setOrgMembership(ctx, username, orgName, snapshot.PriorState)
+	}
+
+	err := p.client.Orgs().ByOrg(orgName).Memberships().ByUsername(username).Delete(ctx, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove organization membership: %w", err)
+	}
+
+	return nil
+}
+
+func (p *githubProvider) setOrgMembership(ctx context.Context, username, orgName, membershipRole string) error {
+
+	body := orgs.NewItemMembershipsItemWithUsernamePutRequestBody()
+	body.SetAdditionalData(map[string]any{
+		"role": membershipRole,
+	})
+
+	_, err := p.client.Orgs().ByOrg(orgName).Memberships().ByUsername(username).Put(ctx, body, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set organization membership: %w", err)
+	}
+
+	return nil
 }
 
 // Team membership methods
-func (p *githubProvider) authorizeTeamMembership(ctx context.Context, username, teamPath string) error {
+func (p *githubProvider) authorizeTeamMembership(ctx context.Context, username, teamPath string) (bool, string, error) {
 	// Parse team path: "myorg/myteam"
 	parts := strings.Split(teamPath, "/")
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid team path format, expected 'org/team': %s", teamPath)
+		return false, "", fmt.Errorf("invalid team path format, expected 'org/team': %s", teamPath)
 	}
 
 	orgName, teamSlug := parts[0], parts[1]
 
-	// TODO: Use correct GitHub SDK API for team membership
-	log.Printf("Would add user %s to team %s/%s", username, orgName, teamSlug)
-	return fmt.Errorf("GitHub API integration not yet implemented for team membership")
+	memberships := p.client.Orgs().ByOrg(orgName).Teams().ByTeam_slug(teamSlug).Memberships().ByUsername(username)
+
+	// Members and maintainers both already have the team's access
+	membership, err := memberships.Get(ctx, nil)
+	if err == nil && membership != nil {
+		return true, "", nil
+	} else if err != nil && !isNotFound(err) {
+		return false, "", fmt.Errorf("failed to get team membership: %w", err)
+	}
+
+	body := orgs.NewItemTeamsItemMembershipsItemWithUsernamePutRequestBody()
+	body.SetAdditionalData(map[string]any{
+		"role": "member",
+	})
+
+	_, err = memberships.Put(ctx, body, nil)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to add team membership: %w", err)
+	}
+
+	return false, "", nil
 }
 
 func (p *githubProvider) revokeTeamMembership(ctx context.Context, username, teamPath string) error {
@@ -155,28 +222,81 @@ func (p *githubProvider) revokeTeamMembership(ctx context.Context, username, tea
 
 	orgName, teamSlug := parts[0], parts[1]
 
-	// TODO: Use correct GitHub SDK API for team membership
-	log.Printf("Would remove user %s from team %s/%s", username, orgName, teamSlug)
-	return fmt.Errorf("GitHub API integration not yet implemented for team membership")
+	err := p.client.Orgs().ByOrg(orgName).Teams().ByTeam_slug(teamSlug).Memberships().ByUsername(username).Delete(ctx, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove team membership: %w", err)
+	}
+
+	return nil
 }
 
 // Repository collaboration methods
-func (p *githubProvider) authorizeRepoCollaboration(ctx context.Context, username, repoPath string, role *models.Role) error {
+func (p *githubProvider) authorizeRepoCollaboration(ctx context.Context, username, repoPath string, role *models.Role) (bool, string, error) {
 	// Parse repo path: "owner/repository"
 	parts := strings.Split(repoPath, "/")
 	if len(parts) != 2 {
-		return fmt.Errorf("invalid repo path format, expected 'owner/repo': %s", repoPath)
+		return false, "", fmt.Errorf("invalid repo path format, expected 'owner/repo': %s", repoPath)
 	}
 
 	owner, repo := parts[0], parts[1]
 	permission := p.mapRoleToPermission(role.Name)
 
-	// TODO: Use correct GitHub SDK API for repository collaboration
-	log.Printf("Would add user %s to repo %s/%s with permission %s", username, owner, repo, permission)
-	return fmt.Errorf("GitHub API integration not yet implemented for repo collaboration")
+	// Only a direct grant is restored on revoke, access through
+	// teams or the organization is left untouched either way
+	priorPermission, err := p.getDirectCollaboratorPermission(ctx, username, owner, repo)
+	if err != nil {
+		return false, "", err
+	}
+
+	if len(priorPermission) > 0 && repoPermissionRank(priorPermission) >= repoPermissionRank(permission) {
+		return true, "", nil
+	}
+
+	if err := p.setRepoCollaborator(ctx, username, owner, repo, permission); err != nil {
+		return false, "", err
+	}
+
+	return false, priorPermission, nil
+}
+
+// getDirectCollaboratorPermission returns the permission the user was
+// granted on the repository directly, or an empty string if they have
+// none. Checking the collaborator itself would also match access through
+// a team or the organization.
+func (p *githubProvider) getDirectCollaboratorPermission(ctx context.Context, username, owner, repo string) (string, error) {
+
+	affiliation := collaborators.DIRECT_GETAFFILIATIONQUERYPARAMETERTYPE
+
+	for page := int32(1); ; page++ {
+
+		perPage := identitiesPageSize
+		currentPage := page
+
+		direct, err := p.client.Repos().ByOwnerId(owner).ByRepoId(repo).Collaborators().Get(ctx,
+			&abs.RequestConfiguration[repos.ItemItemCollaboratorsRequestBuilderGetQueryParameters]{
+				QueryParameters: &repos.ItemItemCollaboratorsRequestBuilderGetQueryParameters{
+					Affiliation: &affiliation,
+					Page:        &currentPage,
+					Per_page:    &perPage,
+				},
+			})
+		if err != nil {
+			return "", fmt.Errorf("failed to list direct repository collaborators: %w", err)
+		}
+
+		for _, collaborator := range direct {
+			if strings.EqualFold(derefString(collaborator.GetLogin()), username) {
+				return normalizeRepoPermission(derefString(collaborator.GetRoleName())), nil
+			}
+		}
+
+		if int32(len(direct)) < perPage {
+			return "", nil
+		}
+	}
 }
 
-func (p *githubProvider) revokeRepoCollaboration(ctx context.Context, username, repoPath string) error {
+func (p *githubProvider) revokeRepoCollaboration(ctx context.Context, username, repoPath string, snapshot models.EntitlementSnapshot) error {
 	parts := strings.Split(repoPath, "/")
 	if len(parts) != 2 {
 		return fmt.Errorf("invalid repo path format, expected 'owner/repo': %s", repoPath)
@@ -184,9 +304,76 @@ func (p *githubProvider) revokeRepoCollaboration(ctx context.Context, username,
 
 	owner, repo := parts[0], parts[1]
 
-	// TODO: Use correct GitHub SDK API for repository collaboration
-	log.Printf("Would remove user %s from repo %s/%s", username, owner, repo)
-	return fmt.Errorf("GitHub API integration not yet implemented for repo collaboration")
+	// The user was a collaborator before, put their previous permission back
+	if snapshot.ShouldRestore() {
+		return p.setRepoCollaborator(ctx, username, owner, repo, snapshot.PriorState)
+	}
+
+	err := p.client.Repos().ByOwnerId(owner).ByRepoId(repo).Collaborators().ByUsername(username).Delete(ctx, nil)
+	if err != nil && !isNotFound(err) {
+		return fmt.Errorf("failed to remove repository collaborator: %w", err)
+	}
+
+	return nil
+}
+
+func (p *githubProvider) setRepoCollaborator(ctx context.Context, username, owner, repo, permission string) error {
+
+	body := repos.NewItemItemCollaboratorsItemWithUsernamePutRequestBody()
+	body.SetPermission(&permission)
+
+	_, err := p.client.Repos().ByOwnerId(owner).ByRepoId(repo).Collaborators().ByUsername(username).Put(ctx, body, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set repository collaborator: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeRepoPermission converts the permission names returned by the
+// API into the names accepted when adding a collaborator
+func normalizeRepoPermission(permission string) string {
+	switch permission {
+	case "read":
+		return "pull"
+	case "write":
+		return "push"
+	}
+	return permission
+}
+
+// repoPermissionRank orders repository permissions from least to most access
+func repoPermissionRank(permission string) int {
+	return slices.Index([]string{"pull", "triage", "push", "maintain", "admin"}, permission)
+}
+
+// isNotFound returns true if the GitHub API responded with a 404
+func isNotFound(err error) bool {
+	var apiErr interface{ GetStatusCode() int }
+	return errors.As(err, &apiErr) && apiErr.GetStatusCode() == http.StatusNotFound
+}
+
+// parseResource splits a resource into its type and path
+func parseResource(resource string) (string, string, error) {
+	resource = strings.TrimPrefix(resource, "github:")
+
+	parts := strings.Split(resource, ":")
+	if len(parts) < 2 {
+		return "", "", fmt.Errorf("invalid resource format: %s", resource)
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// Helper function to map role names to GitHub organization membership roles
+func (p *githubProvider) mapRoleToMembership(roleName string) string {
+	roleName = strings.ToLower(roleName)
+
+	if strings.Contains(roleName, "admin") || strings.Contains(roleName, "owner") {
+		return "admin"
+	}
+
+	return "member"
 }
 
 // Helper function to map role names to GitHub permissions
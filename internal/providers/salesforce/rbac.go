@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/models"
 )
 
@@ -54,14 +55,23 @@ func (p *salesForceProvider) AuthorizeRole(
 		return nil, fmt.Errorf("failed to get role profile: %w", err)
 	}
 
+	// Record the prior profile so revocation can restore it. If the user
+	// already has the target profile there is nothing to undo.
+	snapshots := models.NewEntitlementSnapshots()
+	if currentProfileId == profileReesult.Id {
+		snapshots.Add(salesforceProfileResource, true, "")
+	} else {
+		snapshots.Add(salesforceProfileResource, false, currentProfileId)
+	}
+
 	// We need to store the old profile Id so we can revert it on revoke
-	salesforceProfile := map[string]any{
+	salesforceProfile := snapshots.AddToMetadata(map[string]any{
 		"salesforce": map[string]any{
 			"id":              salesforceUserId,
 			"current_profile": profileReesult.Id,
 			"prior_profile":   currentProfileId,
 		},
-	}
+	})
 
 	// Check if user already has the target profile
 	if currentProfileId == profileReesult.Id {
@@ -104,6 +114,11 @@ func (p *salesForceProvider) RevokeRole(
 	salesforceUserId := userResult.Records[0].StringField("Id")
 	currentProfileId := userResult.Records[0].StringField("ProfileId")
 
+	// Restore the profile recorded when the role was authorized
+	if snapshot, ok := models.GetEntitlementSnapshots(metadata)[salesforceProfileResource]; ok {
+		return p.restoreProfile(salesforceUserId, currentProfileId, snapshot, metadata)
+	}
+
 	// Check if the user currently has the role profile that we want to revoke
 	roleProfileQuery := "SELECT Id FROM Profile WHERE Name = ?"
 	roleProfileResult, err := p.queryWithParams(roleProfileQuery, role.Name)
@@ -156,3 +171,43 @@ func (p *salesForceProvider) RevokeRole(
 
 	return nil, nil
 }
+
+// salesforceProfileResource is the snapshot resource for the user's profile
+const salesforceProfileResource = "profile"
+
+// restoreProfile puts back the profile the user had before authorization.
+// Nothing is changed if the user already had the profile, or if their
+// profile has been changed by someone else since.
+func (p *salesForceProvider) restoreProfile(
+	salesforceUserId string,
+	currentProfileId string,
+	snapshot models.EntitlementSnapshot,
+	metadata map[string]any,
+) (map[string]any, error) {
+
+	if !snapshot.ShouldRestore() {
+		logrus.WithField("user", salesforceUserId).Info("User had the profile before authorization, leaving it in place")
+		return nil, nil
+	}
+
+	grantedProfileId := ""
+	if salesforceMetadata, ok := metadata["salesforce"].(map[string]any); ok {
+		grantedProfileId, _ = salesforceMetadata["current_profile"].(string)
+	}
+
+	if len(grantedProfileId) > 0 && currentProfileId != grantedProfileId {
+		logrus.WithField("user", salesforceUserId).Warn("User profile changed since authorization, not restoring prior profile")
+		return nil, nil
+	}
+
+	userObj := p.client.SObject("User")
+	userObj.Set("Id", salesforceUserId)
+	userObj.Set("ProfileId", snapshot.PriorState)
+
+	result := userObj.Update()
+	if result == nil {
+		return nil, fmt.Errorf("failed to restore prior user profile")
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,276 @@
+package durable
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	pendingExtension = ".json"
+	claimedExtension = ".claimed"
+)
+
+var ErrorSuspensionClaimed = errors.New("suspension has already been claimed")
+
+// Suspension is a stateless workflow that is waiting for a timer or an
+// event before it can continue
+type Suspension struct {
+	ID        string     `json:"id"`                 // Unique per workflow and what it is waiting for
+	Workflow  string     `json:"workflow"`           // The workflow ID
+	Task      string     `json:"task"`               // The task the workflow resumes from
+	State     string     `json:"state"`              // The encrypted workflow task
+	WakeAt    *time.Time `json:"wake_at,omitempty"`  // When the workflow should be resumed
+	Listening bool       `json:"listening"`          // Whether a matching event resumes the workflow
+	Attempts  int        `json:"attempts,omitempty"` // How many times resuming it has failed
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsDue returns whether the suspension's timer has expired
+func (s *Suspension) IsDue(now time.Time) bool {
+	return s.WakeAt != nil && !now.Before(*s.WakeAt)
+}
+
+// Store persists suspended workflows so they survive a restart.
+//
+// A suspension is claimed before it is resumed so that only one resumer
+// gets it. Once resumed it is released, or requeued if resuming failed.
+// Claims that were never released, because the server stopped mid-resume,
+// are returned by Recover.
+type Store interface {
+	Save(suspension Suspension) error
+	List() ([]Suspension, error)
+	Claim(id string) (*Suspension, error)
+	Release(id string) error
+	Requeue(suspension Suspension) error
+	Delete(id string) error
+	Recover() (int, error)
+}
+
+// FileStore keeps each suspension as a JSON file in a directory
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) (*FileStore, error) {
+
+	if len(path) == 0 {
+		return nil, fmt.Errorf("durable store path is required")
+	}
+
+	if err := os.MkdirAll(path, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create durable store: %w", err)
+	}
+
+	return &FileStore{path: path}, nil
+}
+
+// filename encodes the ID so any workflow ID is a safe file name
+func (f *FileStore) filename(id string, extension string) string {
+	return filepath.Join(f.path, base64.RawURLEncoding.EncodeToString([]byte(id))+extension)
+}
+
+// Save writes the suspension, replacing any pending one with the same ID
+func (f *FileStore) Save(suspension Suspension) error {
+
+	if len(suspension.ID) == 0 {
+		return fmt.Errorf("suspension id is required")
+	}
+
+	if suspension.CreatedAt.IsZero() {
+		suspension.CreatedAt = time.Now().UTC()
+	}
+
+	data, err := json.Marshal(suspension)
+	if err != nil {
+		return fmt.Errorf("failed to encode suspension: %w", err)
+	}
+
+	// Write then rename so a crash never leaves a partial file
+	tmp, err := os.CreateTemp(f.path, ".suspension-*")
+	if err != nil {
+		return fmt.Errorf("failed to save suspension: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save suspension: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save suspension: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save suspension: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.filename(suspension.ID, pendingExtension)); err != nil {
+		return fmt.Errorf("failed to save suspension: %w", err)
+	}
+
+	return nil
+}
+
+// List returns the pending suspensions, soonest timer first
+func (f *FileStore) List() ([]Suspension, error) {
+
+	entries, err := os.ReadDir(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list suspensions: %w", err)
+	}
+
+	suspensions := []Suspension{}
+
+	for _, entry := range entries {
+
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), pendingExtension) {
+			continue
+		}
+
+		suspension, err := readSuspension(filepath.Join(f.path, entry.Name()))
+		if err != nil {
+			// Claimed between listing and reading
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			logrus.WithError(err).WithField("file", entry.Name()).Warn("Skipping unreadable suspension")
+			continue
+		}
+
+		suspensions = append(suspensions, *suspension)
+	}
+
+	slices.SortFunc(suspensions, func(a, b Suspension) int {
+		switch {
+		case a.WakeAt == nil && b.WakeAt == nil:
+			return a.CreatedAt.Compare(b.CreatedAt)
+		case a.WakeAt == nil:
+			return 1
+		case b.WakeAt == nil:
+			return -1
+		default:
+			return a.WakeAt.Compare(*b.WakeAt)
+		}
+	})
+
+	return suspensions, nil
+}
+
+// Claim takes the suspension out of the pending set. Renames are atomic
+// so only one claim can succeed.
+func (f *FileStore) Claim(id string) (*Suspension, error) {
+
+	claimed := f.filename(id, claimedExtension)
+
+	if err := os.Rename(f.filename(id, pendingExtension), claimed); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrorSuspensionClaimed
+		}
+		return nil, fmt.Errorf("failed to claim suspension: %w", err)
+	}
+
+	return readSuspension(claimed)
+}
+
+// Release removes a claimed suspension once it has been resumed
+func (f *FileStore) Release(id string) error {
+
+	err := os.Remove(f.filename(id, claimedExtension))
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to release suspension: %w", err)
+	}
+
+	return nil
+}
+
+// Requeue returns a claimed suspension to the pending set, such as when
+// resuming it failed. A newer suspension saved while it was claimed is
+// kept instead.
+func (f *FileStore) Requeue(suspension Suspension) error {
+
+	if _, err := os.Stat(f.filename(suspension.ID, pendingExtension)); err == nil {
+		return f.Release(suspension.ID)
+	}
+
+	if err := f.Save(suspension); err != nil {
+		return fmt.Errorf("failed to requeue suspension: %w", err)
+	}
+
+	return f.Release(suspension.ID)
+}
+
+// Delete removes a pending suspension, such as when the workflow was
+// resumed by other means
+func (f *FileStore) Delete(id string) error {
+
+	err := os.Remove(f.filename(id, pendingExtension))
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete suspension: %w", err)
+	}
+
+	return nil
+}
+
+// Recover returns claimed suspensions to the pending set so that
+// workflows interrupted mid-resume are resumed again
+func (f *FileStore) Recover() (int, error) {
+
+	entries, err := os.ReadDir(f.path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to recover suspensions: %w", err)
+	}
+
+	recovered := 0
+
+	for _, entry := range entries {
+
+		name, found := strings.CutSuffix(entry.Name(), claimedExtension)
+		if entry.IsDir() || !found {
+			continue
+		}
+
+		pending := filepath.Join(f.path, name+pendingExtension)
+
+		// A newer suspension was saved before the claim was released
+		if _, err := os.Stat(pending); err == nil {
+			os.Remove(filepath.Join(f.path, entry.Name()))
+			continue
+		}
+
+		if err := os.Rename(filepath.Join(f.path, entry.Name()), pending); err != nil {
+			return recovered, fmt.Errorf("failed to recover suspension: %w", err)
+		}
+
+		recovered++
+	}
+
+	return recovered, nil
+}
+
+func readSuspension(path string) (*Suspension, error) {
+
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var suspension Suspension
+	if err := json.Unmarshal(data, &suspension); err != nil {
+		return nil, fmt.Errorf("failed to decode suspension: %w", err)
+	}
+
+	return &suspension, nil
+}
@@ -0,0 +1,131 @@
+package durable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+
+	store, err := NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Minute)
+
+	require.NoError(t, store.Save(Suspension{ID: "wf/1", Workflow: "wf/1", Task: "approval", Listening: true}))
+	require.NoError(t, store.Save(Suspension{ID: "wf-2", Workflow: "wf-2", Task: "pause", WakeAt: &later}))
+	require.NoError(t, store.Save(Suspension{ID: "wf-3-revoke", Workflow: "wf-3", Task: "revoke", WakeAt: &earlier}))
+
+	suspensions, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, suspensions, 3)
+
+	// Soonest timer first, listeners last
+	assert.Equal(t, "wf-3-revoke", suspensions[0].ID)
+	assert.Equal(t, "wf-2", suspensions[1].ID)
+	assert.Equal(t, "wf/1", suspensions[2].ID)
+
+	assert.True(t, suspensions[0].IsDue(now))
+	assert.False(t, suspensions[1].IsDue(now))
+	assert.False(t, suspensions[2].IsDue(now))
+
+	t.Run("claim", func(t *testing.T) {
+
+		claimed, err := store.Claim("wf-3-revoke")
+		require.NoError(t, err)
+		assert.Equal(t, "revoke", claimed.Task)
+
+		_, err = store.Claim("wf-3-revoke")
+		assert.ErrorIs(t, err, ErrorSuspensionClaimed)
+
+		suspensions, err := store.List()
+		require.NoError(t, err)
+		assert.Len(t, suspensions, 2)
+	})
+
+	t.Run("recover", func(t *testing.T) {
+
+		recovered, err := store.Recover()
+		require.NoError(t, err)
+		assert.Equal(t, 1, recovered)
+
+		suspensions, err := store.List()
+		require.NoError(t, err)
+		assert.Len(t, suspensions, 3)
+	})
+
+	t.Run("release", func(t *testing.T) {
+
+		_, err := store.Claim("wf/1")
+		require.NoError(t, err)
+		require.NoError(t, store.Release("wf/1"))
+
+		recovered, err := store.Recover()
+		require.NoError(t, err)
+		assert.Equal(t, 0, recovered)
+
+		suspensions, err := store.List()
+		require.NoError(t, err)
+		assert.Len(t, suspensions, 2)
+	})
+
+	t.Run("requeue", func(t *testing.T) {
+
+		claimed, err := store.Claim("wf-3-revoke")
+		require.NoError(t, err)
+
+		retryAt := now.Add(time.Minute)
+		claimed.WakeAt = &retryAt
+		claimed.Attempts++
+
+		require.NoError(t, store.Requeue(*claimed))
+
+		recovered, err := store.Recover()
+		require.NoError(t, err)
+		assert.Equal(t, 0, recovered)
+
+		requeued, err := store.Claim("wf-3-revoke")
+		require.NoError(t, err)
+		assert.Equal(t, 1, requeued.Attempts)
+		assert.False(t, requeued.IsDue(now))
+
+		// A suspension saved while it was claimed is kept
+		require.NoError(t, store.Save(Suspension{ID: "wf-3-revoke", Workflow: "wf-3", Task: "cleanup"}))
+		require.NoError(t, store.Requeue(*requeued))
+
+		pending, err := store.Claim("wf-3-revoke")
+		require.NoError(t, err)
+		assert.Equal(t, "cleanup", pending.Task)
+		require.NoError(t, store.Requeue(*pending))
+	})
+
+	t.Run("suspended again while claimed", func(t *testing.T) {
+
+		_, err := store.Claim("wf-2")
+		require.NoError(t, err)
+
+		require.NoError(t, store.Save(Suspension{ID: "wf-2", Workflow: "wf-2", Task: "approval", Listening: true}))
+
+		recovered, err := store.Recover()
+		require.NoError(t, err)
+		assert.Equal(t, 0, recovered)
+
+		claimed, err := store.Claim("wf-2")
+		require.NoError(t, err)
+		assert.Equal(t, "approval", claimed.Task)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+
+		require.NoError(t, store.Delete("wf-3-revoke"))
+		require.NoError(t, store.Delete("missing"))
+
+		_, err := store.Claim("wf-3-revoke")
+		assert.ErrorIs(t, err, ErrorSuspensionClaimed)
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/thand-io/agent/internal/common"
 	"github.com/thand-io/agent/internal/config"
 	"github.com/thand-io/agent/internal/models"
+	"github.com/thand-io/agent/internal/workflows/durable"
 	"github.com/thand-io/agent/internal/workflows/functions"
 )
 
@@ -146,30 +147,31 @@ func (t *authorizeFunction) executeAuthorization(
 		"duration":  duration,
 	}).Info("Executing authorization logic")
 
-	// First lets call the provider to execute the role request.
-	primaryProvider := elevateRequest.Providers[0]
+	validations := map[string]any{}
 
-	providerCall, err := t.config.GetProviderByName(primaryProvider)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get provider: %w", err)
-	}
+	// Validate the role against every provider before granting anything
+	for _, providerName := range elevateRequest.Providers {
 
-	modelOutput, err := t.validateRoleAndBuildOutput(providerCall, *elevateRequest)
-	if err != nil {
-		return nil, err
-	}
+		providerCall, err := t.config.GetProviderByName(providerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get provider: %w", err)
+		}
 
-	authorizedAt := time.Now().UTC()
-	revocationDate := authorizedAt.Add(duration)
+		validateOut, err := t.validateRoleAndBuildOutput(providerCall, *elevateRequest)
+		if err != nil {
+			return nil, err
+		}
 
-	maps.Copy(modelOutput, map[string]any{
-		"authorized_at": authorizedAt.Format(time.RFC3339),
-		"revocation_at": revocationDate.Format(time.RFC3339),
-	})
+		if len(validateOut) > 0 {
+			validations[providerName] = validateOut
+		}
+	}
 
-	if err := t.scheduleRevocation(workflowTask, *authRequest, revocationDate); err != nil {
-		logrus.WithError(err).Error("Failed to schedule revocation")
-		return nil, fmt.Errorf("failed to schedule revocation: %w", err)
+	// Conflicts were checked before approval, but another conflicting
+	// elevation may have been granted since
+	if err := t.config.ValidateRoleConflicts(
+		workflowTask.GetContext(), elevateRequest, workflowTask.WorkflowID); err != nil {
+		return nil, err
 	}
 
 	durationParsed, err := common.ValidateDuration(elevateRequest.Duration)
@@ -178,24 +180,60 @@ func (t *authorizeFunction) executeAuthorization(
 		return nil, fmt.Errorf("invalid duration format: %w", err)
 	}
 
-	authOut, err := providerCall.GetClient().AuthorizeRole(
-		workflowTask.GetContext(), &models.AuthorizeRoleRequest{
-			User:     elevateRequest.User,
-			Role:     elevateRequest.Role,
-			Duration: &durationParsed,
-		},
+	// Authorize across all providers. If any provider fails the
+	// providers already granted are rolled back.
+	authorizations, err := models.AuthorizeProviders(
+		workflowTask.GetContext(),
+		t.config.GetProviderByName,
+		elevateRequest,
+		durationParsed,
 	)
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to authorize user: %w", err)
 	}
 
-	maps.Copy(modelOutput, authOut)
+	authorizedAt := time.Now().UTC()
+	revocationDate := authorizedAt.Add(duration)
+
+	if err := t.scheduleRevocation(workflowTask, *authRequest, revocationDate); err != nil {
+		logrus.WithError(err).Error("Failed to schedule revocation")
+
+		// Without a scheduled revocation the grants would never expire
+		rollbackErr := models.RollbackProviders(
+			workflowTask.GetContext(),
+			t.config.GetProviderByName,
+			elevateRequest,
+			authorizations,
+		)
+
+		return nil, errors.Join(
+			fmt.Errorf("failed to schedule revocation: %w", err),
+			rollbackErr,
+		)
+	}
+
+	// The output is evaluated by jq so it must be plain JSON types
+	authorizationsOut := map[string]any{}
+	if err := common.ConvertInterfaceToInterface(authorizations, &authorizationsOut); err != nil {
+		return nil, fmt.Errorf("failed to convert authorizations: %w", err)
+	}
+
+	// Provider output is kept under each provider's authorization rather
+	// than merged, so providers returning the same keys don't collide
+	modelOutput := map[string]any{
+		"authorized_at":                  authorizedAt.Format(time.RFC3339),
+		"revocation_at":                  revocationDate.Format(time.RFC3339),
+		models.VarsContextAuthorizations: authorizationsOut,
+		models.VarsContextApproved:       true,
+	}
 
-	maps.Copy(modelOutput, map[string]any{
-		models.VarsContextApproved: true,
-	})
+	if len(validations) > 0 {
+		modelOutput["validations"] = validations
+	}
 
 	logrus.WithFields(logrus.Fields{
+		"providers":     elevateRequest.Providers,
 		"authorized_at": authorizedAt.Format(time.RFC3339),
 		"revocation_at": revocationDate.Format(time.RFC3339),
 	}).Info("Scheduled revocation")
@@ -290,6 +328,33 @@ func (t *authorizeFunction) scheduleRevocation(
 			"url":  t.config.GetResumeCallbackUrl(newTask),
 		}).Info("Scheduled revocation via Temporal")
 
+	} else if durableConfig := t.config.GetWorkflows().Durable; durableConfig.Enabled && len(revocationTask) > 0 {
+
+		// Persist the revocation so it still happens if the server restarts
+		// before it is due. The server's durable timers resume it.
+		store, err := durable.NewFileStore(durableConfig.Path)
+
+		if err != nil {
+			return fmt.Errorf("failed to schedule revocation: %w", err)
+		}
+
+		err = store.Save(durable.Suspension{
+			ID:       fmt.Sprintf("%s-%s", workflowTask.WorkflowID, revocationTask),
+			Workflow: workflowTask.WorkflowID,
+			Task:     revocationTask,
+			State:    newTask.GetEncodedTask(serviceClient.GetEncryption()),
+			WakeAt:   &revocationAt,
+		})
+
+		if err != nil {
+			return fmt.Errorf("failed to schedule revocation: %w", err)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"task":          revocationTask,
+			"revocation_at": revocationAt,
+		}).Info("Scheduled durable revocation")
+
 	} else if t.config.GetServices().HasScheduler() {
 
 		err := t.config.GetServices().GetScheduler().AddJob(
@@ -3,6 +3,7 @@ package thand
 import (
 	"errors"
 	"fmt"
+	"html"
 	"strings"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -149,9 +150,16 @@ func (t *notifyFunction) Execute(
 
 	var notificationPayload models.NotificationRequest
 
+	// Approvers should see exactly what will change before approving
+	planSummary := ""
+
+	if notificationReq.Approvals {
+		planSummary = t.getPlanSummary(workflowTask, elevationReq)
+	}
+
 	switch providerConfig.Provider {
 	case "slack":
-		blocks := t.createSlackBlocks(workflowTask, elevationReq, &notificationReq)
+		blocks := t.createSlackBlocks(workflowTask, elevationReq, &notificationReq, planSummary)
 
 		slackReq := slackProvider.SlackNotificationRequest{
 			To: notificationReq.To,
@@ -170,12 +178,24 @@ func (t *notifyFunction) Execute(
 			return nil, fmt.Errorf("failed to convert slack request: %w", err)
 		}
 	case "email":
+		// The message and summaries can hold requester supplied values
+		// such as the reason, so they are escaped in the HTML body
+		textMessage := notificationReq.Message
+		htmlMessage := html.EscapeString(notificationReq.Message)
+
+		// Approvers see how risky the request is alongside the message
+		if elevationReq.Risk != nil {
+			riskSummary := elevationReq.Risk.Summary()
+			textMessage = fmt.Sprintf("%s\n\n%s", textMessage, riskSummary)
+			htmlMessage = fmt.Sprintf("%s<br/><pre>%s</pre>", htmlMessage, html.EscapeString(riskSummary))
+		}
+
 		emailReq := emailProvider.EmailNotificationRequest{
 			To:      notificationReq.To,
 			Subject: "Workflow Notification",
 			Body: emailProvider.EmailNotificationBody{
-				Text: notificationReq.Message,
-				HTML: notificationReq.Message,
+				Text: t.appendPlanSummary(textMessage, planSummary, "\n\n"),
+				HTML: t.appendPlanSummary(htmlMessage, html.EscapeString(planSummary), "<br/><pre>", "</pre>"),
 			},
 		}
 		err = common.ConvertInterfaceToInterface(emailReq, &notificationPayload)
@@ -204,6 +224,7 @@ func (t *notifyFunction) createSlackBlocks(
 	workflowTask *models.WorkflowTask,
 	elevateRequest *models.ElevateRequestInternal,
 	notificationReq *NotifierRequest,
+	planSummary string,
 ) []slack.Block {
 	blocks := []slack.Block{}
 
@@ -216,6 +237,9 @@ func (t *notifyFunction) createSlackBlocks(
 	// Add request details section
 	t.addRequestDetailsSection(&blocks, elevateRequest)
 
+	// Add risk section
+	t.addRiskSection(&blocks, elevateRequest)
+
 	// Add identities section
 	t.addIdentitiesSection(&blocks, elevateRequest)
 
@@ -231,6 +255,9 @@ func (t *notifyFunction) createSlackBlocks(
 	// Add user information section
 	t.addUserInfoSection(&blocks, elevateRequest)
 
+	// Add planned changes section
+	t.addPlanSection(&blocks, planSummary)
+
 	// Add divider before action section
 	blocks = append(blocks, slack.NewDividerBlock())
 
@@ -292,6 +319,31 @@ func (t *notifyFunction) addRequestDetailsSection(blocks *[]slack.Block, elevate
 	))
 }
 
+// addRiskSection adds the risk assessment if the request was scored
+func (t *notifyFunction) addRiskSection(blocks *[]slack.Block, elevateRequest *models.ElevateRequestInternal) {
+	if elevateRequest.Risk == nil {
+		return
+	}
+
+	var riskText strings.Builder
+	riskText.WriteString(fmt.Sprintf("*Risk:* %s (%d/100)\n", elevateRequest.Risk.Level, elevateRequest.Risk.Score))
+
+	for _, factor := range elevateRequest.Risk.Factors {
+		riskText.WriteString(fmt.Sprintf("• +%d %s\n", factor.Score, factor.Detail))
+	}
+
+	*blocks = append(*blocks, slack.NewSectionBlock(
+		slack.NewTextBlockObject(
+			slack.MarkdownType,
+			riskText.String(),
+			false,
+			false,
+		),
+		nil,
+		nil,
+	))
+}
+
 // addInheritedRolesSection adds inherited roles section if available
 func (t *notifyFunction) addInheritedRolesSection(blocks *[]slack.Block, elevateRequest *models.ElevateRequestInternal) {
 	if elevateRequest.Role != nil && len(elevateRequest.Role.Inherits) > 0 {
@@ -404,6 +456,79 @@ func (t *notifyFunction) addUserInfoSection(blocks *[]slack.Block, elevateReques
 	}
 }
 
+// addPlanSection adds the planned provider changes if available
+func (t *notifyFunction) addPlanSection(blocks *[]slack.Block, planSummary string) {
+	if len(planSummary) > 0 {
+		*blocks = append(*blocks, slack.NewSectionBlock(
+			slack.NewTextBlockObject(
+				slack.MarkdownType,
+				fmt.Sprintf("*Planned Changes:*\n```%s```", planSummary),
+				false,
+				false,
+			),
+			nil,
+			nil,
+		))
+	}
+}
+
+// getPlanSummary builds a summary of the changes each provider would make.
+// Planning is best effort and should never block a notification
+func (t *notifyFunction) getPlanSummary(
+	workflowTask *models.WorkflowTask,
+	elevateRequest *models.ElevateRequestInternal,
+) string {
+
+	var summary strings.Builder
+
+	for _, providerName := range elevateRequest.Providers {
+
+		provider, err := t.config.GetProviderByName(providerName)
+
+		if err != nil {
+			logrus.WithError(err).WithField("provider", providerName).Warn("Failed to get provider for plan summary")
+			continue
+		}
+
+		plans, err := models.PlanRole(workflowTask.GetContext(), provider.GetClient(), *elevateRequest)
+
+		if err != nil {
+			logrus.WithError(err).WithField("provider", providerName).Warn("Failed to plan role for notification")
+			continue
+		}
+
+		// One plan for each requested identity
+		for _, plan := range plans {
+			summary.WriteString(plan.Summary())
+		}
+	}
+
+	return summary.String()
+}
+
+// appendPlanSummary appends the plan summary to a message using the given wrapping
+func (t *notifyFunction) appendPlanSummary(message string, planSummary string, wrap ...string) string {
+	if len(planSummary) == 0 {
+		return message
+	}
+
+	var out strings.Builder
+	out.WriteString(message)
+
+	if len(wrap) > 0 {
+		out.WriteString(wrap[0])
+	}
+
+	out.WriteString("Planned changes:\n")
+	out.WriteString(planSummary)
+
+	if len(wrap) > 1 {
+		out.WriteString(wrap[1])
+	}
+
+	return out.String()
+}
+
 // addIdentitiesSection adds identities section if available
 func (t *notifyFunction) addIdentitiesSection(blocks *[]slack.Block, elevateRequest *models.ElevateRequestInternal) {
 	if len(elevateRequest.Identities) > 0 {
@@ -486,7 +611,7 @@ func (t *notifyFunction) createCallbackUrl(
 	// Create an Event.
 	event := cloudevents.NewEvent()
 	event.SetSource("thand/agent")
-	event.SetType("com.thand.approval")
+	event.SetType(models.ApprovalEventType)
 	event.SetData(cloudevents.ApplicationJSON, map[string]any{
 		"approved": approve,
 		"user":     "",
@@ -3,7 +3,6 @@ package thand
 import (
 	"errors"
 	"fmt"
-	"maps"
 
 	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/sirupsen/logrus"
@@ -100,30 +99,30 @@ func (t *revokeFunction) Execute(
 		"role":     role,
 		"provider": providers,
 		"duration": duration,
-	}).Info("Executing authorization logic")
-
-	// First lets call the provider to execute the role request.
-	primaryProvider := elevateRequest.Providers[0]
-
-	providerCall, err := t.config.GetProviderByName(primaryProvider)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get provider: %w", err)
+	}).Info("Executing revocation logic")
+
+	// Walk the same set of providers that were authorized
+	revocations, revokeErr := models.RevokeProviders(
+		workflowTask.GetContext(),
+		t.config.GetProviderByName,
+		&elevateRequest,
+		req,
+	)
+
+	revocationsOut := map[string]any{}
+	if err := common.ConvertInterfaceToInterface(revocations, &revocationsOut); err != nil {
+		return nil, fmt.Errorf("failed to convert revocations: %w", err)
 	}
 
-	modelOutput := map[string]any{
-		"revoked": true,
+	if revokeErr != nil {
+		// Keep the providers that were revoked so a retry skips them
+		workflowTask.SetContextKeyValue(models.VarsContextRevocations, revocationsOut)
+		return nil, fmt.Errorf("failed to revoke user: %w", revokeErr)
 	}
 
-	revokeOut, err := providerCall.GetClient().RevokeRole(
-		workflowTask.GetContext(), user, role, req)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to revoke user: %w", err)
-	}
-
-	// If the revoke returned any output, merge it into modelOutput
-	if len(revokeOut) > 0 {
-		maps.Copy(modelOutput, revokeOut)
+	modelOutput := map[string]any{
+		"revoked":                     true,
+		models.VarsContextRevocations: revocationsOut,
 	}
 
 	return &modelOutput, nil
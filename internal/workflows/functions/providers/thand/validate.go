@@ -138,32 +138,80 @@ func (t *validateFunction) Execute(
 		return nil, errors.New("no providers specified in elevate request")
 	}
 
-	primaryProvider := elevateRequest.Providers[0]
+	if elevateRequest.Role == nil {
+		return nil, errors.New("no role specified in elevate request")
+	}
 
-	providerCall, err := t.config.GetProviderByName(primaryProvider)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get provider: %w", err)
+	// The role must apply to the requester, including through groups
+	// their session didn't carry
+	t.config.ResolveUserGroups(ctx, elevateRequest.User)
+
+	if err := elevateRequest.Role.ValidateApplies(elevateRequest.User); err != nil {
+		return nil, err
 	}
 
-	responseOut := map[string]any{}
+	if len(elevateRequest.Authenticator) > 0 &&
+		!elevateRequest.Role.AllowsAuthenticator(elevateRequest.Authenticator) {
+		return nil, fmt.Errorf("authenticator %s is not allowed for role %s",
+			elevateRequest.Authenticator, elevateRequest.Role.Name)
+	}
+
+	// The break-glass cap and review deadline apply however the
+	// workflow was started
+	if err := t.config.ValidateBreakGlass(&elevateRequest); err != nil {
+		return nil, err
+	}
 
-	// Validate role
-	validateOut, err := models.ValidateRole(providerCall.GetClient(), elevateRequest)
+	// Every role condition must pass before anything is granted
+	if err := elevateRequest.Role.EvaluateConditions(&elevateRequest); err != nil {
+		return nil, err
+	}
 
-	if err != nil {
+	// Separation of duties against the active elevations
+	if err := t.config.ValidateRoleConflicts(
+		ctx, &elevateRequest, workflowTask.WorkflowID); err != nil {
 		return nil, err
 	}
 
-	// If the validation returned any output, merge it into responseOut
-	if len(validateOut) > 0 {
-		maps.Copy(responseOut, validateOut)
+	// Only roles that allow delegation can target other identities
+	if err := models.ValidateDelegation(
+		elevateRequest.Role, elevateRequest.User, elevateRequest.Identities); err != nil {
+		return nil, err
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"role":      elevateRequest.Role,
-		"providers": elevateRequest.Providers,
-		"output":    validateOut,
-	}).Info("Role validated successfully")
+	responseOut := map[string]any{}
+
+	// Validate the role against every provider in the request
+	for _, providerName := range elevateRequest.Providers {
+
+		providerCall, err := t.config.GetProviderByName(providerName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get provider: %w", err)
+		}
+
+		// Identity types the provider can't grant are refused now rather
+		// than after the request has been approved
+		if _, err := models.ResolveIdentities(ctx, providerCall.GetClient(), &elevateRequest); err != nil {
+			return nil, fmt.Errorf("provider %s cannot grant the requested identities: %w", providerName, err)
+		}
+
+		validateOut, err := models.ValidateRole(providerCall.GetClient(), elevateRequest)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate role for provider %s: %w", providerName, err)
+		}
+
+		// If the validation returned any output, merge it into responseOut
+		if len(validateOut) > 0 {
+			maps.Copy(responseOut, validateOut)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"role":     elevateRequest.Role,
+			"provider": providerName,
+			"output":   validateOut,
+		}).Info("Role validated successfully")
+	}
 
 	// TODO: Do something with the output for static validation
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/sirupsen/logrus"
 	models "github.com/thand-io/agent/internal/models"
@@ -114,34 +115,36 @@ func (m *WorkflowManager) registerActivities() error {
 		}
 
 		providers := elevateRequest.Providers
-		user := elevateRequest.User
-		role := elevateRequest.Role
 
 		if len(providers) == 0 {
 			log.Info("No providers found in elevate request, skipping cleanup")
 			return nil, fmt.Errorf("no providers found in elevate request")
 		}
 
-		primaryProvider := elevateRequest.Providers[0]
-
-		providerHandler, err := m.config.GetProviderByName(primaryProvider)
-
-		if err != nil {
-			log.Info("No valid provider found, skipping cleanup")
-			return nil, fmt.Errorf("no valid provider found: %w", err)
+		// A retried attempt skips the providers the last attempt revoked
+		if activity.HasHeartbeatDetails(ctx) {
+			var revoked map[string]*models.ProviderAuthorization
+			if err := activity.GetHeartbeatDetails(ctx, &revoked); err == nil {
+				elevateRequest.Revocations = revoked
+			}
 		}
 
-		output, err := providerHandler.GetClient().RevokeRole(
-			ctx, user, role, workflowTask.GetContextAsMap())
+		revocations, err := models.RevokeProviders(
+			ctx,
+			m.config.GetProviderByName,
+			elevateRequest,
+			workflowTask.GetContextAsMap(),
+		)
 
 		if err != nil {
+			activity.RecordHeartbeat(ctx, revocations)
 			return nil, fmt.Errorf("failed to revoke role: %w", err)
 		}
 
 		// Perform any necessary cleanup here
 		log.Info("Cleanup activity completed successfully", "cleanupID", workflowTask.WorkflowID)
 
-		return output, nil
+		return revocations, nil
 
 	}, activity.RegisterOptions{
 		Name: models.TemporalCleanupActivityName,
@@ -154,6 +157,7 @@ func (m *WorkflowManager) registerActivities() error {
 		ctx context.Context,
 		httpCall model.HTTPArguments,
 		finalURL string,
+		secretNames []string,
 	) (any, error) {
 
 		logrus.WithFields(logrus.Fields{
@@ -162,7 +166,12 @@ func (m *WorkflowManager) registerActivities() error {
 			"method":   httpCall.Method,
 		}).Info("Executing HTTP activity")
 
-		return runner.MakeHttpRequest(httpCall, finalURL)
+		secrets, err := m.loadActivitySecrets(secretNames)
+		if err != nil {
+			return nil, err
+		}
+
+		return runner.MakeHttpRequestWithSecrets(ctx, httpCall, finalURL, secrets)
 
 	}, activity.RegisterOptions{
 		Name: models.TemporalHttpActivityName,
@@ -194,15 +203,27 @@ func (m *WorkflowManager) registerActivities() error {
 	*/
 	worker.RegisterActivityWithOptions(func(
 		ctx context.Context,
-		asyncIOCall model.AsyncAPIArguments,
-		input any,
+		workflowTask *models.WorkflowTask,
+		asyncAPIRequest runner.AsyncAPIRequest,
+		secretNames []string,
 	) (any, error) {
 
 		logrus.WithFields(logrus.Fields{
-			"activity": models.TemporalAsyncionActivityName,
+			"activity":  models.TemporalAsyncionActivityName,
+			"operation": asyncAPIRequest.Arguments.Operation,
+			"channel":   asyncAPIRequest.Arguments.Channel,
 		}).Info("Executing AsyncIO activity")
 
-		return nil, fmt.Errorf("asyncIO activity not implemented yet")
+		secrets, err := m.loadActivitySecrets(secretNames)
+		if err != nil {
+			return nil, err
+		}
+
+		// The subscription's expressions see the placeholders as the
+		// workflow does
+		workflowTask.SetSecrets(runner.MaskSecrets(secrets))
+
+		return runner.MakeAsyncAPIRequestWithSecrets(ctx, workflowTask, asyncAPIRequest, secrets)
 
 	}, activity.RegisterOptions{
 		Name: models.TemporalAsyncionActivityName,
@@ -227,5 +248,134 @@ func (m *WorkflowManager) registerActivities() error {
 		Name: models.TemporalOpenAPIActivityName,
 	})
 
+	/*
+		Script Activity
+	*/
+	worker.RegisterActivityWithOptions(func(
+		ctx context.Context,
+		script model.Script,
+		arguments map[string]any,
+		input any,
+		options runner.ScriptOptions,
+		secretNames []string,
+	) (any, error) {
+
+		logrus.WithFields(logrus.Fields{
+			"activity": models.TemporalScriptActivityName,
+			"language": script.Language,
+		}).Info("Executing script activity")
+
+		secrets, err := m.loadActivitySecrets(secretNames)
+		if err != nil {
+			return nil, err
+		}
+
+		return runner.RunScriptWithSecrets(ctx, script, arguments, input, options, secrets)
+
+	}, activity.RegisterOptions{
+		Name: models.TemporalScriptActivityName,
+	})
+
+	/*
+		Container Activity
+	*/
+	worker.RegisterActivityWithOptions(func(
+		ctx context.Context,
+		workflowTask *models.WorkflowTask,
+		taskName string,
+		container model.Container,
+		input any,
+		options runner.ContainerOptions,
+		secretNames []string,
+	) (any, error) {
+
+		logrus.WithFields(logrus.Fields{
+			"activity": models.TemporalContainerActivityName,
+			"image":    container.Image,
+		}).Info("Executing container activity")
+
+		secrets, err := m.loadActivitySecrets(secretNames)
+		if err != nil {
+			return nil, err
+		}
+
+		// The command and environment see the placeholders as the
+		// workflow does, they are filled in just before the run
+		workflowTask.SetSecrets(runner.MaskSecrets(secrets))
+
+		evaluated, err := runner.EvaluateContainer(workflowTask, &container, input)
+		if err != nil {
+			return nil, err
+		}
+
+		return runner.RunContainerWithSecrets(ctx, taskName, *evaluated, options, secrets)
+
+	}, activity.RegisterOptions{
+		Name: models.TemporalContainerActivityName,
+	})
+
+	/*
+		Secrets Activity
+	*/
+	worker.RegisterActivityWithOptions(func(
+		ctx context.Context,
+		secretNames []string,
+	) (map[string]any, error) {
+
+		logrus.WithFields(logrus.Fields{
+			"activity": models.TemporalSecretsActivityName,
+			"secrets":  secretNames,
+		}).Info("Executing secrets activity")
+
+		secrets, err := m.loadActivitySecrets(secretNames)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only the placeholders are returned so the values are never
+		// recorded in the workflow history
+		return runner.MaskSecrets(secrets), nil
+
+	}, activity.RegisterOptions{
+		Name: models.TemporalSecretsActivityName,
+	})
+
+	/*
+		Emit Activity
+	*/
+	worker.RegisterActivityWithOptions(func(
+		ctx context.Context,
+		event cloudevents.Event,
+	) error {
+
+		logrus.WithFields(logrus.Fields{
+			"activity":  models.TemporalEmitActivityName,
+			"eventType": event.Type(),
+		}).Info("Executing emit activity")
+
+		events := m.config.GetWorkflows().Events
+
+		return runner.PublishEvent(ctx, events.GetSinksForEvent(event.Type()), event)
+
+	}, activity.RegisterOptions{
+		Name: models.TemporalEmitActivityName,
+	})
+
 	return nil
 }
+
+// loadActivitySecrets reads the named secrets for an activity. Secrets are
+// read here rather than passed in so they are never recorded in the
+// workflow history.
+func (m *WorkflowManager) loadActivitySecrets(secretNames []string) (map[string]any, error) {
+
+	if len(secretNames) == 0 {
+		return map[string]any{}, nil
+	}
+
+	if !m.config.HasVault() {
+		return nil, fmt.Errorf("workflow uses secrets but no vault is configured")
+	}
+
+	return runner.LoadSecrets(m.config.GetVault(), secretNames)
+}
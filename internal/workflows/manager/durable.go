@@ -0,0 +1,310 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
+	"github.com/sirupsen/logrus"
+	models "github.com/thand-io/agent/internal/models"
+	"github.com/thand-io/agent/internal/workflows/durable"
+)
+
+// How many times a suspended workflow is resumed before it is given up on.
+// With the backoff this retries for around a day.
+const maxResumeAttempts = 30
+
+// HasDurableStore returns whether stateless workflows are persisted
+// while they wait
+func (m *WorkflowManager) HasDurableStore() bool {
+	return m.suspensions != nil
+}
+
+// suspendWorkflow persists a stateless workflow that is waiting on a
+// timer or an event, or removes its suspension once it has moved on
+func (m *WorkflowManager) suspendWorkflow(workflowTask *models.WorkflowTask) error {
+
+	if !m.HasDurableStore() || workflowTask.HasTemporalContext() {
+		return nil
+	}
+
+	if workflowTask.GetStatus() != swctx.WaitingStatus {
+		return m.suspensions.Delete(workflowTask.WorkflowID)
+	}
+
+	suspension := durable.Suspension{
+		ID:       workflowTask.WorkflowID,
+		Workflow: workflowTask.WorkflowID,
+		Task:     workflowTask.GetEntrypoint(),
+		State:    workflowTask.GetEncodedTask(m.config.GetServices().GetEncryption()),
+		WakeAt:   workflowTask.GetWakeAt(),
+	}
+
+	// Wake the workflow when a timeout expires so unanswered listens fail
+	if deadline := workflowTask.GetNextDeadline(); deadline != nil {
+		if suspension.WakeAt == nil || deadline.Before(*suspension.WakeAt) {
+			suspension.WakeAt = deadline
+		}
+	}
+
+	suspension.Listening = workflowTask.IsListening()
+
+	if err := m.suspensions.Save(suspension); err != nil {
+		return fmt.Errorf("failed to suspend workflow: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"workflow_id": suspension.Workflow,
+		"task":        suspension.Task,
+		"wake_at":     suspension.WakeAt,
+		"listening":   suspension.Listening,
+	}).Info("Suspended workflow")
+
+	return nil
+}
+
+// StartDurableTimers resumes suspended workflows as their timers expire,
+// until the context is cancelled. Workflows that were being resumed when
+// the server last stopped are resumed again.
+func (m *WorkflowManager) StartDurableTimers(ctx context.Context) error {
+
+	if !m.HasDurableStore() {
+		return fmt.Errorf("durable workflows are not enabled")
+	}
+
+	recovered, err := m.suspensions.Recover()
+	if err != nil {
+		return err
+	}
+
+	durableConfig := m.config.GetWorkflows().Durable
+
+	pollInterval := durableConfig.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 10 * time.Second
+	}
+
+	concurrency := durableConfig.Concurrency
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+
+	m.resumeSlots = make(chan struct{}, concurrency)
+
+	logrus.WithFields(logrus.Fields{
+		"recovered":     recovered,
+		"poll_interval": pollInterval,
+		"concurrency":   concurrency,
+	}).Info("Starting durable workflow timers")
+
+	go func() {
+
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			// Timers that expired while the server was down fire straight away
+			m.resumeDueSuspensions(time.Now().UTC())
+
+			select {
+			case <-ctx.Done():
+				logrus.Info("Stopping durable workflow timers")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+// resumeDueSuspensions resumes the workflows whose timers have expired in
+// the background, so one slow workflow doesn't hold up the rest. Only so
+// many are resumed at once, any left over are resumed on a later poll.
+// Workflows still being resumed from an earlier poll are claimed, so are
+// skipped.
+func (m *WorkflowManager) resumeDueSuspensions(now time.Time) {
+
+	suspensions, err := m.suspensions.List()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list suspended workflows")
+		return
+	}
+
+	for _, suspension := range suspensions {
+
+		// Suspensions are sorted by timer so the rest aren't due either
+		if !suspension.IsDue(now) {
+			break
+		}
+
+		select {
+		case m.resumeSlots <- struct{}{}:
+		default:
+			logrus.Debug("Every durable resume slot is busy, waiting for the next poll")
+			return
+		}
+
+		go func() {
+
+			defer func() { <-m.resumeSlots }()
+
+			err := m.resumeSuspension(suspension.ID, nil)
+
+			if err != nil && !errors.Is(err, durable.ErrorSuspensionClaimed) {
+				logrus.WithError(err).WithField("workflow_id", suspension.Workflow).Error("Failed to resume suspended workflow")
+			}
+		}()
+	}
+}
+
+// resumeSuspension claims a suspended workflow and resumes it from the
+// task it was suspended on, with the event that woke it as input. A
+// workflow that waits again is saved as a new pending suspension, so the
+// claim is released. If resuming fails the suspension is requeued to be
+// retried later, so work such as a scheduled revocation isn't lost.
+func (m *WorkflowManager) resumeSuspension(id string, event *cloudevents.Event) error {
+
+	suspension, err := m.suspensions.Claim(id)
+	if err != nil {
+		return err
+	}
+
+	err = m.runSuspension(suspension, event)
+
+	if err == nil {
+		if err := m.suspensions.Release(id); err != nil {
+			logrus.WithError(err).WithField("workflow_id", suspension.Workflow).Warn("Failed to release suspension")
+		}
+		return nil
+	}
+
+	if suspension.Attempts+1 >= maxResumeAttempts {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"workflow_id": suspension.Workflow,
+			"attempts":    suspension.Attempts + 1,
+		}).Error("Giving up resuming suspended workflow")
+
+		if err := m.suspensions.Release(id); err != nil {
+			logrus.WithError(err).WithField("workflow_id", suspension.Workflow).Warn("Failed to release suspension")
+		}
+		return err
+	}
+
+	retryAt := time.Now().UTC().Add(resumeBackoff(suspension.Attempts))
+	suspension.WakeAt = &retryAt
+	suspension.Attempts++
+
+	if requeueErr := m.suspensions.Requeue(*suspension); requeueErr != nil {
+		return errors.Join(err, requeueErr)
+	}
+
+	logrus.WithError(err).WithFields(logrus.Fields{
+		"workflow_id": suspension.Workflow,
+		"attempts":    suspension.Attempts,
+		"retry_at":    retryAt,
+	}).Warn("Failed to resume suspended workflow, it will be retried")
+
+	return err
+}
+
+// resumeBackoff returns how long to wait before resuming a suspension
+// again, doubling from a minute for each failed attempt up to an hour
+func resumeBackoff(attempts int) time.Duration {
+
+	backoff := time.Minute << min(attempts, 6)
+
+	return min(backoff, time.Hour)
+}
+
+// runSuspension resumes the claimed suspension's workflow
+func (m *WorkflowManager) runSuspension(suspension *durable.Suspension, event *cloudevents.Event) error {
+
+	workflowTask, err := m.decodeSuspension(suspension)
+	if err != nil {
+		return err
+	}
+
+	workflowTask.SetEntrypoint(suspension.Task)
+
+	if event != nil {
+		workflowTask.SetInput(event)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"workflow_id": suspension.Workflow,
+		"task":        suspension.Task,
+	}).Info("Resuming suspended workflow")
+
+	_, err = m.ResumeWorkflowTask(workflowTask)
+
+	return err
+}
+
+// correlateSuspendedEvent resumes the suspended workflows whose listen
+// task the event matches
+func (m *WorkflowManager) correlateSuspendedEvent(event cloudevents.Event) ([]string, error) {
+
+	suspensions, err := m.suspensions.List()
+	if err != nil {
+		return nil, err
+	}
+
+	resumed := []string{}
+
+	for _, suspension := range suspensions {
+
+		if !suspension.Listening {
+			continue
+		}
+
+		workflowTask, err := m.decodeSuspension(&suspension)
+		if err != nil {
+			logrus.WithError(err).WithField("workflow_id", suspension.Workflow).Warn("Failed to load suspended workflow")
+			continue
+		}
+
+		workflowRunner, err := m.createCustomRunner(workflowTask)
+		if err != nil || !workflowRunner.MatchesListenTask(suspension.Task, event) {
+			continue
+		}
+
+		err = m.resumeSuspension(suspension.ID, &event)
+
+		if errors.Is(err, durable.ErrorSuspensionClaimed) {
+			continue
+		} else if err != nil {
+			logrus.WithError(err).WithField("workflow_id", suspension.Workflow).Error("Failed to resume suspended workflow")
+			continue
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"workflowId": suspension.Workflow,
+			"eventId":    event.ID(),
+			"eventType":  event.Type(),
+		}).Info("Resumed suspended workflow with event")
+
+		resumed = append(resumed, suspension.Workflow)
+	}
+
+	return resumed, nil
+}
+
+func (m *WorkflowManager) decodeSuspension(suspension *durable.Suspension) (*models.WorkflowTask, error) {
+
+	workflowTask, err := CreateWorkflowFromEncodedTask(
+		m.config.GetServices().GetEncryption(), suspension.State)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Hydrate(workflowTask); err != nil {
+		return nil, fmt.Errorf("failed to hydrate suspended workflow: %w", err)
+	}
+
+	return workflowTask, nil
+}
@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thand-io/agent/internal/config"
+	"github.com/thand-io/agent/internal/workflows/durable"
+)
+
+func TestResumeSuspension_Failed(t *testing.T) {
+
+	store, err := durable.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+
+	manager := &WorkflowManager{
+		config:      config.DefaultConfig(),
+		suspensions: store,
+	}
+
+	now := time.Now().UTC()
+
+	// The state can't be decoded, so resuming fails
+	require.NoError(t, store.Save(durable.Suspension{
+		ID:       "wf-1-revoke",
+		Workflow: "wf-1",
+		Task:     "revoke",
+		State:    "not a workflow",
+		WakeAt:   &now,
+	}))
+
+	err = manager.resumeSuspension("wf-1-revoke", nil)
+	require.Error(t, err)
+
+	// The suspension is still pending and retried after a backoff
+	suspensions, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, suspensions, 1)
+
+	assert.Equal(t, "wf-1-revoke", suspensions[0].ID)
+	assert.Equal(t, 1, suspensions[0].Attempts)
+	require.NotNil(t, suspensions[0].WakeAt)
+	assert.WithinDuration(t, now.Add(resumeBackoff(0)), *suspensions[0].WakeAt, 5*time.Second)
+}
+
+func TestResumeBackoff(t *testing.T) {
+	assert.Equal(t, time.Minute, resumeBackoff(0))
+	assert.Equal(t, 4*time.Minute, resumeBackoff(2))
+	assert.Equal(t, time.Hour, resumeBackoff(6))
+	assert.Equal(t, time.Hour, resumeBackoff(40))
+}
@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
+	"github.com/sirupsen/logrus"
+	models "github.com/thand-io/agent/internal/models"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+)
+
+var ErrorCorrelationUnsupported = errors.New("correlating events requires temporal or durable workflows")
+
+// CorrelateEvent starts the workflows with an on schedule the event
+// matches and signals it to every workflow waiting on a listen task the
+// event matches, including the task's correlate keys. It returns the IDs
+// of the workflows that were started or signalled.
+func (m *WorkflowManager) CorrelateEvent(ctx context.Context, event cloudevents.Event) ([]string, error) {
+
+	started := m.startEventSchedules(ctx, event)
+
+	signalled, err := m.correlateWaitingWorkflows(ctx, event)
+
+	// Events can still start workflows when waiting ones can't be found
+	if errors.Is(err, ErrorCorrelationUnsupported) && len(m.getSchedules(models.WorkflowScheduleOn)) > 0 {
+		return started, nil
+	}
+
+	return append(started, signalled...), err
+}
+
+// correlateWaitingWorkflows signals the event to the waiting workflows
+// whose listen task it matches
+func (m *WorkflowManager) correlateWaitingWorkflows(ctx context.Context, event cloudevents.Event) ([]string, error) {
+
+	services := m.config.GetServices()
+
+	if !services.HasTemporal() || !services.GetTemporal().HasClient() {
+
+		// Stateless workflows can only be found if they were persisted
+		if m.HasDurableStore() {
+			return m.correlateSuspendedEvent(event)
+		}
+
+		return nil, ErrorCorrelationUnsupported
+	}
+
+	temporalService := services.GetTemporal()
+	temporalClient := temporalService.GetClient()
+
+	query := fmt.Sprintf("TaskQueue='%s' AND ExecutionStatus='Running' AND status='%s'",
+		temporalService.GetTaskQueue(), swctx.WaitingStatus)
+
+	signalled := []string{}
+
+	var nextPageToken []byte
+
+	for {
+
+		resp, err := temporalClient.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+			Namespace:     temporalService.GetNamespace(),
+			PageSize:      100,
+			Query:         query,
+			NextPageToken: nextPageToken,
+		})
+
+		if err != nil {
+			return signalled, fmt.Errorf("failed to list waiting workflows: %w", err)
+		}
+
+		for _, execution := range resp.GetExecutions() {
+
+			workflowID := execution.GetExecution().GetWorkflowId()
+
+			if !m.isWaitingForEvent(ctx, temporalClient, execution, event) {
+				continue
+			}
+
+			err := temporalClient.SignalWorkflow(
+				ctx,
+				workflowID,
+				models.TemporalEmptyRunId,
+				models.TemporalEventSignalName,
+				event,
+			)
+
+			if err != nil {
+				logrus.WithError(err).WithField("workflowId", workflowID).Error("Failed to signal workflow")
+				continue
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"workflowId": workflowID,
+				"eventId":    event.ID(),
+				"eventType":  event.Type(),
+			}).Info("Signalled workflow with event")
+
+			signalled = append(signalled, workflowID)
+		}
+
+		nextPageToken = resp.GetNextPageToken()
+
+		if len(nextPageToken) == 0 {
+			break
+		}
+	}
+
+	return signalled, nil
+}
+
+// isWaitingForEvent queries the workflow's state and checks the event
+// against the listen task it is waiting on
+func (m *WorkflowManager) isWaitingForEvent(
+	ctx context.Context,
+	temporalClient client.Client,
+	execution *workflow.WorkflowExecutionInfo,
+	event cloudevents.Event,
+) bool {
+
+	workflowID := execution.GetExecution().GetWorkflowId()
+
+	var taskName string
+
+	taskAttr, exists := execution.GetSearchAttributes().GetIndexedFields()["task"]
+	if !exists || converter.GetDefaultDataConverter().FromPayload(taskAttr, &taskName) != nil {
+		return false
+	}
+
+	// Don't let one unresponsive workflow hold up the rest
+	queryCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	queryResponse, err := temporalClient.QueryWorkflowWithOptions(queryCtx, &client.QueryWorkflowWithOptionsRequest{
+		WorkflowID: workflowID,
+		RunID:      execution.GetExecution().GetRunId(),
+		QueryType:  models.TemporalGetWorkflowTaskQueryName,
+	})
+
+	if err != nil {
+		logrus.WithError(err).WithField("workflowId", workflowID).Warn("Failed to query waiting workflow")
+		return false
+	}
+
+	var workflowTask models.WorkflowTask
+	if err := queryResponse.QueryResult.Get(&workflowTask); err != nil {
+		logrus.WithError(err).WithField("workflowId", workflowID).Warn("Failed to get workflow state")
+		return false
+	}
+
+	if err := m.Hydrate(&workflowTask); err != nil {
+		logrus.WithError(err).WithField("workflowId", workflowID).Warn("Failed to load workflow definition")
+		return false
+	}
+
+	workflowRunner, err := m.createCustomRunner(&workflowTask)
+	if err != nil {
+		return false
+	}
+
+	return workflowRunner.MatchesListenTask(taskName, event)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
@@ -12,6 +13,7 @@ import (
 	"github.com/thand-io/agent/internal/common"
 	"github.com/thand-io/agent/internal/config"
 	models "github.com/thand-io/agent/internal/models"
+	"github.com/thand-io/agent/internal/workflows/durable"
 	"github.com/thand-io/agent/internal/workflows/functions"
 	"github.com/thand-io/agent/internal/workflows/functions/providers/aws"
 	"github.com/thand-io/agent/internal/workflows/functions/providers/gcp"
@@ -26,6 +28,16 @@ import (
 type WorkflowManager struct {
 	config    *config.Config
 	functions *functions.FunctionRegistry
+
+	// Persists waiting stateless workflows, only when durable is enabled
+	suspensions durable.Store
+
+	// Limits how many expired suspensions are resumed at once
+	resumeSlots chan struct{}
+
+	// Workflows that start on a timer or event
+	schedules   map[string]*models.WorkflowSchedule
+	schedulesMu sync.Mutex
 }
 
 // NewWorkflowManager creates a new workflow manager
@@ -34,6 +46,7 @@ func NewWorkflowManager(cfg *config.Config) *WorkflowManager {
 	wm := WorkflowManager{
 		config:    cfg,
 		functions: functions.NewFunctionRegistry(cfg),
+		schedules: map[string]*models.WorkflowSchedule{},
 	}
 
 	for _, provider := range []functions.FunctionCollection{
@@ -46,6 +59,17 @@ func NewWorkflowManager(cfg *config.Config) *WorkflowManager {
 		provider.RegisterFunctions(wm.functions)
 	}
 
+	// Without temporal, waiting workflows are persisted by us instead
+	if durableConfig := cfg.GetWorkflows().Durable; durableConfig.Enabled && !cfg.GetServices().HasTemporal() {
+
+		store, err := durable.NewFileStore(durableConfig.Path)
+		if err != nil {
+			logrus.WithError(err).Error("Failed to create durable workflow store")
+		} else {
+			wm.suspensions = store
+		}
+	}
+
 	// If we have temporal configured, then we can register
 	// all the activities and workflows
 
@@ -310,6 +334,10 @@ func (m *WorkflowManager) ResumeWorkflowTask(
 		return nil, fmt.Errorf("failed to resume workflow: %w", err)
 	}
 
+	if err := m.suspendWorkflow(result); err != nil {
+		logrus.WithError(err).WithField("workflow_id", result.WorkflowID).Error("Failed to persist waiting workflow")
+	}
+
 	// Merge the output with the input based on any handlers
 
 	return result, err
@@ -373,21 +401,29 @@ func (m *WorkflowManager) createTemporalWorkflow(workflowTask *models.WorkflowTa
 
 	ctx := workflowTask.GetContext()
 
+	searchAttributes := []temporal.SearchAttributeUpdate{
+		models.TypedSearchAttributeUser.ValueSet(userEmail),
+		models.TypedSearchAttributeRole.ValueSet(roleName),
+		models.TypedSearchAttributeProviders.ValueSet(elevationRequest.Providers),
+		models.TypedSearchAttributeWorkflow.ValueSet(elevationRequest.Workflow),
+		models.TypedSearchAttributeStatus.ValueSet(strings.ToUpper(string(swctx.PendingStatus))),
+		// models.TypedSearchAttributeApproved.ValueSet(false),
+		models.TypedSearchAttributeDuration.ValueSet(int64(duration.Seconds())),
+		models.TypedSearchAttributeReason.ValueSet(elevationRequest.Reason),
+		models.TypedSearchAttributeIdentities.ValueSet(elevationRequest.Identities),
+		models.TypedSearchAttributeBreakGlass.ValueSet(elevationRequest.BreakGlass),
+	}
+
+	if elevationRequest.Metadata != nil && elevationRequest.Metadata.ReviewDueAt != nil {
+		searchAttributes = append(searchAttributes,
+			models.TypedSearchAttributeReviewDueAt.ValueSet(*elevationRequest.Metadata.ReviewDueAt))
+	}
+
 	// Create new workflow
 	we, err := temporalClient.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
-		ID:        workflowTask.WorkflowID,
-		TaskQueue: temporalService.GetTaskQueue(),
-		TypedSearchAttributes: temporal.NewSearchAttributes(
-			models.TypedSearchAttributeUser.ValueSet(userEmail),
-			models.TypedSearchAttributeRole.ValueSet(roleName),
-			models.TypedSearchAttributeProviders.ValueSet(elevationRequest.Providers),
-			models.TypedSearchAttributeWorkflow.ValueSet(elevationRequest.Workflow),
-			models.TypedSearchAttributeStatus.ValueSet(strings.ToUpper(string(swctx.PendingStatus))),
-			// models.TypedSearchAttributeApproved.ValueSet(false),
-			models.TypedSearchAttributeDuration.ValueSet(int64(duration.Seconds())),
-			models.TypedSearchAttributeReason.ValueSet(elevationRequest.Reason),
-			models.TypedSearchAttributeIdentities.ValueSet(elevationRequest.Identities),
-		),
+		ID:                    workflowTask.WorkflowID,
+		TaskQueue:             temporalService.GetTaskQueue(),
+		TypedSearchAttributes: temporal.NewSearchAttributes(searchAttributes...),
 	}, models.TemporalExecuteElevationWorkflowName, workflowTask)
 
 	if err != nil {
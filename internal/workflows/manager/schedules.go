@@ -0,0 +1,547 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/sirupsen/logrus"
+	models "github.com/thand-io/agent/internal/models"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+const temporalScheduleIDPrefix = "thand-schedule-"
+
+var ErrorScheduleNotFound = errors.New("schedule not found")
+
+/*
+StartSchedules starts the workflows that have a schedule. Interval and
+cron schedules use Temporal Schedules when temporal is configured and the
+scheduler service otherwise. After schedules start the workflow again a
+delay after it completes, and on schedules start it for matching events.
+
+	weekly_access_review:
+	  workflow:
+	    schedule:
+	      cron: 0 9 * * MON
+	    document:
+	      ...
+*/
+func (m *WorkflowManager) StartSchedules(ctx context.Context) error {
+
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	definitions := m.config.Workflows.GetDefinitions()
+
+	for _, name := range slices.Sorted(maps.Keys(definitions)) {
+
+		definition := definitions[name]
+		workflowDsl := definition.GetWorkflow()
+
+		if !definition.Enabled || workflowDsl == nil || workflowDsl.Schedule == nil {
+			continue
+		}
+
+		schedule, err := models.NewWorkflowSchedule(name, workflowDsl.Schedule)
+
+		if err != nil {
+			logrus.WithError(err).WithField("workflow", name).Error("Invalid workflow schedule")
+			continue
+		}
+
+		if err := m.registerSchedule(ctx, schedule); err != nil {
+			logrus.WithError(err).WithField("workflow", name).Error("Failed to schedule workflow")
+			continue
+		}
+
+		m.schedules[name] = schedule
+
+		logrus.WithFields(logrus.Fields{
+			"workflow": name,
+			"type":     schedule.Type,
+			"spec":     schedule.Spec,
+			"backend":  schedule.Backend,
+		}).Info("Scheduled workflow")
+	}
+
+	if m.hasTemporalClient() {
+		m.removeStaleTemporalSchedules(ctx)
+	}
+
+	return nil
+}
+
+func (m *WorkflowManager) registerSchedule(ctx context.Context, schedule *models.WorkflowSchedule) error {
+
+	switch {
+	case schedule.Type == models.WorkflowScheduleOn:
+
+		schedule.Backend = models.WorkflowScheduleBackendEvents
+		return nil
+
+	case schedule.Type != models.WorkflowScheduleAfter && m.hasTemporalClient():
+
+		schedule.Backend = models.WorkflowScheduleBackendTemporal
+		return m.createTemporalSchedule(ctx, schedule)
+	}
+
+	services := m.config.GetServices()
+
+	if !services.HasScheduler() {
+		return fmt.Errorf("no scheduler available to schedule workflow")
+	}
+
+	schedule.Backend = models.WorkflowScheduleBackendScheduler
+
+	if schedule.Type == models.WorkflowScheduleAfter {
+		return m.scheduleAfter(schedule)
+	}
+
+	return services.GetScheduler().AddJob(models.NewScheduledJob(schedule.GetCronSpec(), func() {
+		m.runSchedule(schedule.Name, false)
+	}))
+}
+
+// scheduleAfter starts the workflow once the schedule's delay has passed,
+// then schedules it again once that run has completed
+func (m *WorkflowManager) scheduleAfter(schedule *models.WorkflowSchedule) error {
+
+	nextRun := time.Now().UTC().Add(schedule.Interval)
+	schedule.NextRun = &nextRun
+
+	return m.config.GetServices().GetScheduler().AddJob(models.NewAtJob(nextRun, func() {
+
+		m.runSchedule(schedule.Name, false)
+
+		m.schedulesMu.Lock()
+		defer m.schedulesMu.Unlock()
+
+		if err := m.scheduleAfter(schedule); err != nil {
+			logrus.WithError(err).WithField("workflow", schedule.Name).Error("Failed to reschedule workflow")
+		}
+	}))
+}
+
+// runSchedule starts a timed workflow unless its schedule is paused
+func (m *WorkflowManager) runSchedule(name string, force bool) {
+
+	m.schedulesMu.Lock()
+	schedule, exists := m.schedules[name]
+	paused := exists && schedule.Paused
+	m.schedulesMu.Unlock()
+
+	if !exists || (paused && !force) {
+		return
+	}
+
+	// After schedules wait for the run to complete before the next one
+	wait := schedule.Type == models.WorkflowScheduleAfter
+
+	if _, err := m.startScheduledWorkflow(context.Background(), schedule, nil, wait); err != nil {
+		logrus.WithError(err).WithField("workflow", name).Error("Failed to start scheduled workflow")
+	}
+}
+
+// startScheduledWorkflow starts a new run of the workflow, as a temporal
+// workflow when available. The event that triggered it, if any, is the
+// workflow's input.
+func (m *WorkflowManager) startScheduledWorkflow(
+	ctx context.Context,
+	schedule *models.WorkflowSchedule,
+	event *cloudevents.Event,
+	wait bool,
+) (string, error) {
+
+	workflowTask, err := m.newScheduledWorkflowTask(schedule, event)
+
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+
+	m.schedulesMu.Lock()
+	schedule.LastRun = &now
+	m.schedulesMu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"workflow":    schedule.Name,
+		"workflow_id": workflowTask.WorkflowID,
+		"type":        schedule.Type,
+	}).Info("Starting scheduled workflow")
+
+	if m.hasTemporalClient() {
+
+		temporalService := m.config.GetServices().GetTemporal()
+
+		run, err := temporalService.GetClient().ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+			ID:        workflowTask.WorkflowID,
+			TaskQueue: temporalService.GetTaskQueue(),
+		}, models.TemporalRunWorkflowName, workflowTask)
+
+		if err != nil {
+			return "", fmt.Errorf("failed to start scheduled workflow: %w", err)
+		}
+
+		if wait {
+			if err := run.Get(ctx, nil); err != nil {
+				return run.GetID(), fmt.Errorf("scheduled workflow failed: %w", err)
+			}
+		}
+
+		return run.GetID(), nil
+	}
+
+	if !wait {
+		go func() {
+			if _, err := m.ResumeWorkflowTask(workflowTask); err != nil {
+				logrus.WithError(err).WithField("workflow", schedule.Name).Error("Scheduled workflow failed")
+			}
+		}()
+		return workflowTask.WorkflowID, nil
+	}
+
+	if _, err := m.ResumeWorkflowTask(workflowTask); err != nil {
+		return workflowTask.WorkflowID, fmt.Errorf("scheduled workflow failed: %w", err)
+	}
+
+	return workflowTask.WorkflowID, nil
+}
+
+// newScheduledWorkflowTask creates a run of the workflow. The schedule is
+// available to the workflow as $context.schedule.
+func (m *WorkflowManager) newScheduledWorkflowTask(
+	schedule *models.WorkflowSchedule,
+	event *cloudevents.Event,
+) (*models.WorkflowTask, error) {
+
+	definition, err := m.config.GetWorkflowByName(schedule.Name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	workflowTask, err := models.NewWorkflowContext(definition)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create workflow context: %w", err)
+	}
+
+	workflowTask.WorkflowName = schedule.Name
+	workflowTask.WorkflowID = fmt.Sprintf("%s-%s", schedule.Name, workflowTask.WorkflowID)
+
+	workflowTask.SetContext(map[string]any{
+		"schedule": map[string]any{
+			"name": schedule.Name,
+			"type": schedule.Type,
+			"spec": schedule.Spec,
+			"time": time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+
+	if event != nil {
+		workflowTask.SetInput(event)
+	} else {
+		workflowTask.SetInput(map[string]any{})
+	}
+
+	return workflowTask, nil
+}
+
+// startEventSchedules starts the workflows with an on schedule the event
+// matches and returns their IDs
+func (m *WorkflowManager) startEventSchedules(ctx context.Context, event cloudevents.Event) []string {
+
+	started := []string{}
+
+	for _, schedule := range m.getSchedules(models.WorkflowScheduleOn) {
+
+		m.schedulesMu.Lock()
+		paused := schedule.Paused
+		m.schedulesMu.Unlock()
+
+		if paused {
+			continue
+		}
+
+		workflowTask, err := m.newScheduledWorkflowTask(schedule, nil)
+
+		if err != nil {
+			logrus.WithError(err).WithField("workflow", schedule.Name).Warn("Failed to load scheduled workflow")
+			continue
+		}
+
+		workflowRunner, err := m.createCustomRunner(workflowTask)
+
+		if err != nil || !workflowRunner.MatchesEvents(schedule.Events, event) {
+			continue
+		}
+
+		workflowID, err := m.startScheduledWorkflow(ctx, schedule, &event, false)
+
+		if err != nil {
+			logrus.WithError(err).WithField("workflow", schedule.Name).Error("Failed to start workflow for event")
+			continue
+		}
+
+		started = append(started, workflowID)
+	}
+
+	return started
+}
+
+// getSchedules returns the schedules of a type, or all schedules
+func (m *WorkflowManager) getSchedules(scheduleType models.WorkflowScheduleType) []*models.WorkflowSchedule {
+
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	schedules := []*models.WorkflowSchedule{}
+
+	for _, name := range slices.Sorted(maps.Keys(m.schedules)) {
+		if len(scheduleType) == 0 || m.schedules[name].Type == scheduleType {
+			schedules = append(schedules, m.schedules[name])
+		}
+	}
+
+	return schedules
+}
+
+// ListSchedules returns the workflow schedules and their state
+func (m *WorkflowManager) ListSchedules(ctx context.Context) []models.WorkflowSchedule {
+
+	schedules := []models.WorkflowSchedule{}
+
+	for _, schedule := range m.getSchedules("") {
+
+		m.schedulesMu.Lock()
+		current := *schedule
+		m.schedulesMu.Unlock()
+
+		if current.Backend == models.WorkflowScheduleBackendTemporal {
+			m.describeTemporalSchedule(ctx, &current)
+		}
+
+		schedules = append(schedules, current)
+	}
+
+	return schedules
+}
+
+// PauseSchedule stops a schedule from starting its workflow
+func (m *WorkflowManager) PauseSchedule(ctx context.Context, name string) error {
+	return m.setSchedulePaused(ctx, name, true)
+}
+
+// UnpauseSchedule lets a paused schedule start its workflow again
+func (m *WorkflowManager) UnpauseSchedule(ctx context.Context, name string) error {
+	return m.setSchedulePaused(ctx, name, false)
+}
+
+func (m *WorkflowManager) setSchedulePaused(ctx context.Context, name string, paused bool) error {
+
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	schedule, exists := m.schedules[name]
+
+	if !exists {
+		return ErrorScheduleNotFound
+	}
+
+	if schedule.Backend == models.WorkflowScheduleBackendTemporal {
+
+		handle := m.config.GetServices().GetTemporal().GetClient().ScheduleClient().GetHandle(ctx, getTemporalScheduleID(name))
+
+		var err error
+
+		if paused {
+			err = handle.Pause(ctx, client.SchedulePauseOptions{Note: "Paused through the API"})
+		} else {
+			err = handle.Unpause(ctx, client.ScheduleUnpauseOptions{Note: "Unpaused through the API"})
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to update temporal schedule: %w", err)
+		}
+	}
+
+	schedule.Paused = paused
+
+	return nil
+}
+
+// TriggerSchedule starts the schedule's workflow straight away, even if
+// the schedule is paused. Event schedules can only be started by events.
+func (m *WorkflowManager) TriggerSchedule(ctx context.Context, name string) error {
+
+	m.schedulesMu.Lock()
+	schedule, exists := m.schedules[name]
+	m.schedulesMu.Unlock()
+
+	if !exists {
+		return ErrorScheduleNotFound
+	}
+
+	switch schedule.Backend {
+	case models.WorkflowScheduleBackendEvents:
+		return fmt.Errorf("workflow %s is started by events", name)
+
+	case models.WorkflowScheduleBackendTemporal:
+
+		handle := m.config.GetServices().GetTemporal().GetClient().ScheduleClient().GetHandle(ctx, getTemporalScheduleID(name))
+
+		if err := handle.Trigger(ctx, client.ScheduleTriggerOptions{}); err != nil {
+			return fmt.Errorf("failed to trigger temporal schedule: %w", err)
+		}
+
+		return nil
+	}
+
+	go m.runSchedule(name, true)
+
+	return nil
+}
+
+// createTemporalSchedule creates the Temporal Schedule for the workflow,
+// or updates the one created when the server last started
+func (m *WorkflowManager) createTemporalSchedule(ctx context.Context, schedule *models.WorkflowSchedule) error {
+
+	temporalService := m.config.GetServices().GetTemporal()
+	scheduleClient := temporalService.GetClient().ScheduleClient()
+
+	workflowTask, err := m.newScheduledWorkflowTask(schedule, nil)
+
+	if err != nil {
+		return err
+	}
+
+	spec := client.ScheduleSpec{}
+
+	if schedule.Type == models.WorkflowScheduleEvery {
+		spec.Intervals = []client.ScheduleIntervalSpec{{Every: schedule.Interval}}
+	} else {
+		spec.CronExpressions = []string{schedule.Spec}
+	}
+
+	// Every run is started with this task. The run sets its own
+	// workflow ID and schedule time when it starts.
+	action := &client.ScheduleWorkflowAction{
+		ID:        schedule.Name,
+		Workflow:  models.TemporalRunWorkflowName,
+		Args:      []any{workflowTask},
+		TaskQueue: temporalService.GetTaskQueue(),
+	}
+
+	scheduleID := getTemporalScheduleID(schedule.Name)
+
+	_, err = scheduleClient.Create(ctx, client.ScheduleOptions{
+		ID:     scheduleID,
+		Spec:   spec,
+		Action: action,
+	})
+
+	if !errors.Is(err, temporal.ErrScheduleAlreadyRunning) {
+		return err
+	}
+
+	// Keep its paused state but pick up any changes to the workflow
+	return scheduleClient.GetHandle(ctx, scheduleID).Update(ctx, client.ScheduleUpdateOptions{
+		DoUpdate: func(input client.ScheduleUpdateInput) (*client.ScheduleUpdate, error) {
+
+			updated := input.Description.Schedule
+			updated.Spec = &spec
+			updated.Action = action
+
+			if updated.State != nil {
+				schedule.Paused = updated.State.Paused
+			}
+
+			return &client.ScheduleUpdate{Schedule: &updated}, nil
+		},
+	})
+}
+
+// describeTemporalSchedule fills in the schedule's state from Temporal
+func (m *WorkflowManager) describeTemporalSchedule(ctx context.Context, schedule *models.WorkflowSchedule) {
+
+	handle := m.config.GetServices().GetTemporal().GetClient().ScheduleClient().GetHandle(ctx, getTemporalScheduleID(schedule.Name))
+
+	description, err := handle.Describe(ctx)
+
+	if err != nil {
+		logrus.WithError(err).WithField("workflow", schedule.Name).Warn("Failed to describe temporal schedule")
+		return
+	}
+
+	if description.Schedule.State != nil {
+		schedule.Paused = description.Schedule.State.Paused
+	}
+
+	if len(description.Info.NextActionTimes) > 0 {
+		nextRun := description.Info.NextActionTimes[0].UTC()
+		schedule.NextRun = &nextRun
+	}
+
+	if recent := description.Info.RecentActions; len(recent) > 0 {
+		lastRun := recent[len(recent)-1].ActualTime.UTC()
+		schedule.LastRun = &lastRun
+	}
+}
+
+// removeStaleTemporalSchedules deletes the Temporal Schedules of workflows
+// that no longer have a schedule
+func (m *WorkflowManager) removeStaleTemporalSchedules(ctx context.Context) {
+
+	scheduleClient := m.config.GetServices().GetTemporal().GetClient().ScheduleClient()
+
+	iterator, err := scheduleClient.List(ctx, client.ScheduleListOptions{})
+
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list temporal schedules")
+		return
+	}
+
+	for iterator.HasNext() {
+
+		entry, err := iterator.Next()
+
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to list temporal schedules")
+			return
+		}
+
+		name, found := strings.CutPrefix(entry.ID, temporalScheduleIDPrefix)
+
+		if !found {
+			continue
+		}
+
+		if schedule, exists := m.schedules[name]; exists && schedule.Backend == models.WorkflowScheduleBackendTemporal {
+			continue
+		}
+
+		if err := scheduleClient.GetHandle(ctx, entry.ID).Delete(ctx); err != nil {
+			logrus.WithError(err).WithField("schedule", entry.ID).Warn("Failed to delete stale temporal schedule")
+			continue
+		}
+
+		logrus.WithField("schedule", entry.ID).Info("Deleted stale temporal schedule")
+	}
+}
+
+func (m *WorkflowManager) hasTemporalClient() bool {
+	services := m.config.GetServices()
+	return services.HasTemporal() && services.GetTemporal().HasClient()
+}
+
+func getTemporalScheduleID(name string) string {
+	return temporalScheduleIDPrefix + name
+}
@@ -32,6 +32,14 @@ func CreateWorkflowFromEncodedTask(
 // Hydrate populates the workflow task with necessary data
 func (m *WorkflowManager) Hydrate(workflowTask *models.WorkflowTask) error {
 
+	// Scheduled and nested workflows aren't started by an elevation so
+	// are loaded by the name they are configured under
+	if workflowTask.GetWorkflowDef() == nil && len(workflowTask.WorkflowName) > 0 {
+		if definition, err := m.config.GetWorkflowByName(workflowTask.WorkflowName); err == nil {
+			workflowTask.SetWorkflowDsl(definition.GetWorkflow())
+		}
+	}
+
 	if workflowTask.GetWorkflowDef() == nil {
 
 		elevationRequest, err := workflowTask.GetContextAsElevationRequest()
@@ -37,6 +37,14 @@ func (m *WorkflowManager) registerWorkflows() error {
 		},
 	)
 
+	// Register the workflow used by run.workflow tasks
+	worker.RegisterWorkflowWithOptions(
+		m.createNestedWorkflowHandler(),
+		workflow.RegisterOptions{
+			Name: models.TemporalRunWorkflowName,
+		},
+	)
+
 	return nil
 }
 
@@ -99,6 +107,104 @@ func (m *WorkflowManager) createPrimaryWorkflowHandler(
 	}
 }
 
+// createNestedWorkflowHandler creates the child workflow handler for
+// run.workflow tasks, which also runs scheduled workflows. The nested
+// workflow runs straight away and, if it waits on an event, is resumed by
+// signalling the child workflow. Like primary workflows it can be queried
+// and terminated.
+func (m *WorkflowManager) createNestedWorkflowHandler() func(workflow.Context, *models.WorkflowTask) (*models.WorkflowTask, error) {
+	return func(rootCtx workflow.Context, workflowTask *models.WorkflowTask) (*models.WorkflowTask, error) {
+
+		info := workflow.GetInfo(rootCtx)
+
+		// Every run of a schedule is started with the same task, so take
+		// the ID and schedule time from this run
+		workflowTask.WorkflowID = info.WorkflowExecution.ID
+
+		if info.ParentWorkflowExecution == nil {
+			setScheduledTime(workflowTask, workflow.Now(rootCtx))
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"WorkflowID": workflowTask.WorkflowID,
+			"TaskName":   workflowTask.WorkflowName,
+			"Callers":    workflowTask.Callers,
+			"StartTime":  workflow.Now(rootCtx),
+		}).Info("Nested workflow started.")
+
+		nestedWorkflow, err := m.config.GetWorkflowByName(workflowTask.WorkflowName)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load nested workflow: %w", err)
+		}
+
+		ctx, cancelHandler := workflow.WithCancel(rootCtx)
+
+		// The task is replaced on each resume so the handlers read it
+		// through the closure rather than being passed it
+		err = workflow.SetQueryHandler(ctx, models.TemporalGetWorkflowTaskQueryName, func() (*models.WorkflowTask, error) {
+			return workflowTask, nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to set get workflow task query handler: %w", err)
+		}
+
+		err = workflow.SetQueryHandler(ctx, models.TemporalIsApprovedQueryName, func() (*bool, error) {
+			return workflowTask.IsApproved(), nil
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to set is approved query handler: %w", err)
+		}
+
+		resumeSignal, terminateSignal := m.setupSignalChannels(ctx)
+		m.setupTerminationHandler(rootCtx, terminateSignal, cancelHandler)
+
+		for {
+
+			// The definition is never serialized so set it on every run
+			workflowTask.SetWorkflowDsl(nestedWorkflow.GetWorkflow())
+
+			result, err := m.ResumeWorkflowTask(workflowTask.WithTemporalContext(ctx))
+
+			if err != nil {
+				return result, err
+			}
+
+			if result.GetStatus() != swctx.WaitingStatus {
+				return result, nil
+			}
+
+			logrus.WithFields(logrus.Fields{
+				"WorkflowID": workflowTask.WorkflowID,
+			}).Info("Nested workflow is waiting for a signal")
+
+			resumeSignal.Receive(ctx, &workflowTask)
+
+			if ctx.Err() != nil {
+				return workflowTask, ctx.Err()
+			}
+		}
+	}
+}
+
+// setScheduledTime sets when a scheduled workflow run started in its
+// $context.schedule. Workflows that weren't scheduled are left alone.
+func setScheduledTime(workflowTask *models.WorkflowTask, startedAt time.Time) {
+
+	contextMap := workflowTask.GetContextAsMap()
+
+	schedule, ok := contextMap["schedule"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	schedule["time"] = startedAt.UTC().Format(time.RFC3339)
+
+	workflowTask.SetContext(contextMap)
+}
+
 // runCleanup executes the cleanup activity and returns any cleanup-specific errors
 func (m *WorkflowManager) runCleanup(
 	rootCtx workflow.Context,
@@ -1,31 +1,919 @@
 package runner
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/go-resty/resty/v2"
 	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/common"
+	"github.com/thand-io/agent/internal/models"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	yamlSig "sigs.k8s.io/yaml"
 )
 
+// How long a subscription waits for messages when no duration is given
+const defaultAsyncAPISubscriptionTimeout = 5 * time.Minute
+
+// Where the correlation ID is set when the document doesn't say
+const defaultAsyncAPICorrelationLocation = "$message.header#/correlationId"
+
+// AsyncAPIRequest is an AsyncAPI call with its message already evaluated
+type AsyncAPIRequest struct {
+	Arguments     model.AsyncAPIArguments `json:"arguments"`
+	CorrelationID string                  `json:"correlation_id"`
+}
+
+// AsyncAPIExecutor handles AsyncAPI document loading and message exchange
+type AsyncAPIExecutor struct {
+	documents map[string]*asyncAPIDocument
+	mutex     sync.RWMutex
+}
+
+// NewAsyncAPIExecutor creates a new AsyncAPI executor
+func NewAsyncAPIExecutor() *AsyncAPIExecutor {
+	return &AsyncAPIExecutor{
+		documents: make(map[string]*asyncAPIDocument),
+	}
+}
+
+/*
+Publishes a message to a channel described by an AsyncAPI document and
+optionally waits for messages in reply.
+
+The operation, or channel, is looked up in the document to find the
+server, protocol and address. Messages are sent with a correlation ID
+unique to the workflow task, at the location the document's message
+declares or in a correlationId header. Received messages carrying a
+different correlation ID are ignored.
+
+Without a subscription the output is the published message. With one it
+is the list of messages consumed.
+
+	do:
+	  - requestChange:
+	      call: asyncapi
+	      with:
+	        document:
+	          endpoint: https://changes.example.com/asyncapi.yaml
+	        operation: requestChange
+	        message:
+	          payload:
+	            role: ${ $context.role.name }
+	            user: ${ $context.user.email }
+	        subscription:
+	          filter: ${ .payload.status != "pending" }
+	          consume:
+	            amount: 1
+	            for:
+	              minutes: 30
+*/
 func (r *ResumableWorkflowRunner) executeAsyncFunction(
 	taskName string,
 	call *model.CallAsyncAPI,
 	input any,
-) (map[string]any, error) {
+) (any, error) {
 
 	logrus.WithFields(logrus.Fields{
 		"task": taskName,
 		"call": call.Call,
 	}).Info("Executing AsyncAPI function call")
 
-	// Execute the function call
+	workflowTask := r.GetWorkflowTask()
+
+	if workflowTask == nil {
+		return nil, fmt.Errorf("workflow task is not set")
+	}
+
+	args := call.With
+
+	if args.Document == nil {
+		return nil, fmt.Errorf("asyncapi document is required")
+	}
+
+	if len(args.Operation) == 0 && len(args.Channel) == 0 {
+		return nil, fmt.Errorf("asyncapi call requires an operation or a channel")
+	}
+
+	// Evaluate the message and server variables before they leave the workflow
+	if args.Message != nil {
+
+		message, err := r.evaluateAsyncAPIMessage(args.Message, input)
+		if err != nil {
+			return nil, model.NewErrRuntime(err, taskName)
+		}
+
+		args.Message = message
+	}
+
+	if args.Server != nil && len(args.Server.Variables) > 0 {
+
+		variables, err := workflowTask.TraverseAndEvaluate(args.Server.Variables, input)
+		if err != nil {
+			return nil, model.NewErrRuntime(fmt.Errorf("failed to evaluate server variables: %w", err), taskName)
+		}
+
+		variablesMap, ok := variables.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("server variables must evaluate to a map/object")
+		}
+
+		args.Server = &model.AsyncAPIServer{
+			Name:      args.Server.Name,
+			Variables: variablesMap,
+		}
+	}
+
+	// Secrets are filled in where the call is made so they stay out of
+	// the workflow history
+	policy, err := r.getAuthenticationPolicy(args.Authentication)
+	if err != nil {
+		return nil, model.NewErrRuntime(err, taskName)
+	}
+
+	if policy != nil {
+		args.Authentication = &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy}
+	}
+
+	request := AsyncAPIRequest{
+		Arguments:     args,
+		CorrelationID: fmt.Sprintf("%s-%s", workflowTask.WorkflowID, taskName),
+	}
+
+	serviceClient := r.config.GetServices()
+
+	if workflowTask.HasTemporalContext() && serviceClient.HasTemporal() {
+
+		timeout, err := getAsyncAPISubscriptionTimeout(args.Subscription)
+		if err != nil {
+			return nil, err
+		}
+
+		// Publishing again would send a duplicate message so don't retry
+		activityOptions := workflow.ActivityOptions{
+			TaskQueue:           serviceClient.GetTemporal().GetTaskQueue(),
+			StartToCloseTimeout: timeout + time.Minute,
+			RetryPolicy: &temporal.RetryPolicy{
+				MaximumAttempts: 1,
+			},
+		}
+
+		ctx := workflow.WithActivityOptions(workflowTask.GetTemporalContext(), activityOptions)
+
+		fut := workflow.ExecuteActivity(
+			ctx,
+			models.TemporalAsyncionActivityName,
+			workflowTask,
+			request,
+			r.getSecretNames(),
+		)
+
+		var result any
+		if err := fut.Get(ctx, &result); err != nil {
+			return nil, fmt.Errorf("asyncapi activity failed: %w", err)
+		}
+
+		return result, nil
+	}
+
+	secrets, err := r.loadSecrets()
+	if err != nil {
+		return nil, model.NewErrRuntime(err, taskName)
+	}
+
+	result, err := MakeAsyncAPIRequestWithSecrets(r.GetContext(), workflowTask, request, secrets)
+	if err != nil {
+		return nil, model.NewErrRuntime(err, taskName)
+	}
+
+	return result, nil
+}
+
+// MakeAsyncAPIRequestWithSecrets fills in the request's secrets and
+// resolves its authentication before making it, then masks the secrets in
+// the result
+func MakeAsyncAPIRequestWithSecrets(
+	ctx context.Context,
+	workflowTask *models.WorkflowTask,
+	request AsyncAPIRequest,
+	secrets map[string]any,
+) (any, error) {
+
+	request, err := FillSecrets(request, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err = ResolveAsyncAPIAuthentication(ctx, request, secrets)
+	if err != nil {
+		return RedactSecrets(nil, err, secrets)
+	}
+
+	result, err := MakeAsyncAPIRequest(ctx, workflowTask, request)
+
+	return RedactSecrets(result, err, secrets)
+}
+
+// ResolveAsyncAPIAuthentication returns the request with its
+// authentication policy's secrets filled in
+func ResolveAsyncAPIAuthentication(ctx context.Context, request AsyncAPIRequest, secrets map[string]any) (AsyncAPIRequest, error) {
+
+	policy, err := ResolveAuthentication(ctx, request.Arguments.Authentication, secrets)
+
+	if err != nil || policy == nil {
+		return request, err
+	}
+
+	request.Arguments.Authentication = &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy}
+
+	return request, nil
+}
+
+func (r *ResumableWorkflowRunner) evaluateAsyncAPIMessage(
+	message *model.AsyncAPIOutboundMessage,
+	input any,
+) (*model.AsyncAPIOutboundMessage, error) {
+
+	workflowTask := r.GetWorkflowTask()
+
+	evaluated := &model.AsyncAPIOutboundMessage{}
+
+	if message.Payload != nil {
+
+		payload, err := workflowTask.TraverseAndEvaluate(message.Payload, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate message payload: %w", err)
+		}
+
+		payloadMap, ok := payload.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("message payload must evaluate to a map/object")
+		}
 
-	asyncCall := call.With
+		evaluated.Payload = payloadMap
+	}
+
+	if message.Headers != nil {
+
+		headers, err := workflowTask.TraverseAndEvaluate(message.Headers, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate message headers: %w", err)
+		}
+
+		headersMap, ok := headers.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("message headers must evaluate to a map/object")
+		}
+
+		evaluated.Headers = headersMap
+	}
+
+	return evaluated, nil
+}
+
+// MakeAsyncAPIRequest publishes the message and consumes any replies. The
+// workflow task is used to evaluate the subscription's expressions.
+func MakeAsyncAPIRequest(
+	ctx context.Context,
+	workflowTask *models.WorkflowTask,
+	request AsyncAPIRequest,
+) (any, error) {
+
+	executor := NewAsyncAPIExecutor()
+
+	args := request.Arguments
+
+	documentURL, err := resolveDocumentURL(args.Document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve document URL: %w", err)
+	}
+
+	doc, err := executor.loadAsyncAPIDocument(ctx, documentURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AsyncAPI document: %w", err)
+	}
+
+	target, err := doc.resolveTarget(args)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := GetAsyncAPITransport(target.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := resolveAsyncAPIAuthentication(args.Authentication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve authentication: %w", err)
+	}
+
+	endpoint := AsyncAPIEndpoint{
+		Protocol: target.Protocol,
+		Server:   target.Server,
+		Address:  target.Address,
+		Headers:  headers,
+		Bindings: target.Bindings,
+	}
+
+	timeout, err := getAsyncAPISubscriptionTimeout(args.Subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := transport.Connect(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", endpoint.GetURL(), err)
+	}
+	defer conn.Close()
+
+	// Replies on another channel are subscribed to before publishing so
+	// none are missed
+	replyConn := conn
+
+	_, inlineReplies := transport.(asyncAPIInlineReplies)
+
+	if args.Subscription != nil && !inlineReplies &&
+		len(target.ReplyAddress) > 0 && target.ReplyAddress != target.Address {
+
+		replyEndpoint := endpoint
+		replyEndpoint.Address = target.ReplyAddress
+
+		replyConn, err = transport.Connect(ctx, replyEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to %s: %w", replyEndpoint.GetURL(), err)
+		}
+		defer replyConn.Close()
+	}
+
+	correlation := newAsyncAPICorrelation(target.CorrelationLocation, request.CorrelationID)
+
+	var published *AsyncAPIMessage
+
+	if args.Message != nil {
+
+		message := AsyncAPIMessage{
+			Payload: maps.Clone(args.Message.Payload),
+			Headers: maps.Clone(args.Message.Headers),
+		}
+
+		correlation.apply(&message)
+
+		if err := conn.Publish(ctx, message); err != nil {
+			return nil, fmt.Errorf("failed to publish to %s: %w", endpoint.GetURL(), err)
+		}
+
+		published = &message
+
+		logrus.WithFields(logrus.Fields{
+			"protocol":      target.Protocol,
+			"channel":       target.Address,
+			"operation":     target.Operation,
+			"correlationId": correlation.id,
+		}).Info("Published AsyncAPI message")
+	}
+
+	if args.Subscription == nil {
+
+		if published == nil {
+			return nil, fmt.Errorf("asyncapi call requires a message or a subscription")
+		}
+
+		return map[string]any{
+			"channel":       target.Address,
+			"operation":     target.Operation,
+			"correlationId": correlation.id,
+			"payload":       published.Payload,
+			"headers":       published.Headers,
+		}, nil
+	}
+
+	return consumeAsyncAPIMessages(ctx, workflowTask, replyConn, args.Subscription, correlation)
+}
+
+// consumeAsyncAPIMessages reads messages until the consumption policy is met
+func consumeAsyncAPIMessages(
+	ctx context.Context,
+	workflowTask *models.WorkflowTask,
+	conn AsyncAPIConnection,
+	subscription *model.AsyncAPISubscription,
+	correlation asyncAPICorrelation,
+) ([]any, error) {
+
+	consume := subscription.Consume
+
+	if consume == nil {
+		return nil, fmt.Errorf("subscription requires a consumption policy")
+	}
+
+	evaluate := func(expression *model.RuntimeExpression, message map[string]any) (bool, error) {
+		if workflowTask == nil {
+			return false, fmt.Errorf("workflow task is required to evaluate subscription expressions")
+		}
+		return workflowTask.TraverseAndEvaluateBool(expression.Value, message)
+	}
+
+	messages := []any{}
+
+	for consume.Amount <= 0 || len(messages) < consume.Amount {
+
+		received, err := conn.Receive(ctx)
+
+		if err != nil {
+
+			// Consuming for a period ends when the period does
+			if consume.For != nil && consume.Amount <= 0 && errors.Is(err, context.DeadlineExceeded) {
+				break
+			}
+
+			return nil, fmt.Errorf("failed to receive message after %d message(s): %w", len(messages), err)
+		}
+
+		if !correlation.matches(received) {
+			logrus.WithField("correlationId", correlation.id).Debug("Ignoring message for another correlation")
+			continue
+		}
+
+		message := received.AsMap()
+
+		if subscription.Filter != nil {
+			keep, err := evaluate(subscription.Filter, message)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate subscription filter: %w", err)
+			}
+			if !keep {
+				continue
+			}
+		}
+
+		if consume.While != nil {
+			more, err := evaluate(consume.While, message)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate consume while: %w", err)
+			}
+			if !more {
+				break
+			}
+		}
+
+		messages = append(messages, message)
+
+		if consume.Until != nil {
+			done, err := evaluate(consume.Until, message)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate consume until: %w", err)
+			}
+			if done {
+				break
+			}
+		}
+	}
 
-	// For now, just log the async call details
 	logrus.WithFields(logrus.Fields{
-		"asyncCall": asyncCall,
-	}).Info("AsyncAPI call details")
+		"correlationId": correlation.id,
+		"messages":      len(messages),
+	}).Info("AsyncAPI subscription completed")
+
+	return messages, nil
+}
+
+func getAsyncAPISubscriptionTimeout(subscription *model.AsyncAPISubscription) (time.Duration, error) {
+
+	if subscription == nil || subscription.Consume == nil || subscription.Consume.For == nil {
+		return defaultAsyncAPISubscriptionTimeout, nil
+	}
+
+	timeout, err := common.ParseWorkflowDuration(subscription.Consume.For)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse consume duration: %w", err)
+	}
+
+	return timeout, nil
+}
+
+// resolveAsyncAPIAuthentication returns the headers for an authentication
+// policy whose secrets have been resolved
+func resolveAsyncAPIAuthentication(auth *model.ReferenceableAuthenticationPolicy) (map[string]string, error) {
+
+	headers := map[string]string{}
+
+	if auth == nil {
+		return headers, nil
+	}
+
+	if auth.AuthenticationPolicy == nil {
+		return nil, fmt.Errorf("authentication must be resolved before the call is made")
+	}
+
+	policy := auth.AuthenticationPolicy
+
+	switch {
+	case policy.Bearer != nil:
+		headers["Authorization"] = "Bearer " + policy.Bearer.Token
+	case policy.Basic != nil:
+		credentials := policy.Basic.Username + ":" + policy.Basic.Password
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	default:
+		return nil, fmt.Errorf("only bearer and basic authentication are supported for AsyncAPI calls")
+	}
+
+	return headers, nil
+}
+
+/*
+AsyncAPI documents. Only the parts needed to find where a message is sent
+are read. Both 2.x documents, where operations live on channels, and 3.x
+documents, with top level operations and channel addresses, are handled.
+*/
+
+type asyncAPIDocument struct {
+	AsyncAPI   string                       `json:"asyncapi"`
+	Servers    map[string]asyncAPIServer    `json:"servers"`
+	Channels   map[string]asyncAPIChannel   `json:"channels"`
+	Operations map[string]asyncAPIOperation `json:"operations"`
+	Components struct {
+		Messages map[string]asyncAPIMessageDef `json:"messages"`
+	} `json:"components"`
+}
+
+type asyncAPIServer struct {
+	URL       string                            `json:"url"`      // 2.x
+	Host      string                            `json:"host"`     // 3.x
+	Pathname  string                            `json:"pathname"` // 3.x
+	Protocol  string                            `json:"protocol"`
+	Variables map[string]asyncAPIServerVariable `json:"variables"`
+}
+
+type asyncAPIServerVariable struct {
+	Default string `json:"default"`
+}
+
+type asyncAPIChannel struct {
+	Address   *string                       `json:"address"`   // 3.x
+	Messages  map[string]asyncAPIMessageDef `json:"messages"`  // 3.x
+	Publish   *asyncAPIChannelOperation     `json:"publish"`   // 2.x
+	Subscribe *asyncAPIChannelOperation     `json:"subscribe"` // 2.x
+}
+
+type asyncAPIChannelOperation struct {
+	OperationID string              `json:"operationId"`
+	Message     *asyncAPIMessageDef `json:"message"`
+	Bindings    map[string]any      `json:"bindings"`
+}
+
+type asyncAPIOperation struct {
+	Action   string         `json:"action"`
+	Channel  asyncAPIRef    `json:"channel"`
+	Bindings map[string]any `json:"bindings"`
+	Reply    *struct {
+		Channel *asyncAPIRef `json:"channel"`
+	} `json:"reply"`
+}
+
+type asyncAPIRef struct {
+	Ref string `json:"$ref"`
+}
+
+type asyncAPIMessageDef struct {
+	Ref           string `json:"$ref"`
+	CorrelationID *struct {
+		Location string `json:"location"`
+	} `json:"correlationId"`
+}
+
+// asyncAPITarget is where a call's messages are sent and received
+type asyncAPITarget struct {
+	Operation           string
+	Protocol            string
+	Server              string
+	Address             string
+	ReplyAddress        string
+	CorrelationLocation string
+	Bindings            map[string]any
+}
+
+func (e *AsyncAPIExecutor) loadAsyncAPIDocument(ctx context.Context, documentURL string) (*asyncAPIDocument, error) {
+	e.mutex.RLock()
+	if doc, exists := e.documents[documentURL]; exists {
+		e.mutex.RUnlock()
+		return doc, nil
+	}
+	e.mutex.RUnlock()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	// Double-check after acquiring write lock
+	if doc, exists := e.documents[documentURL]; exists {
+		return doc, nil
+	}
+
+	res, err := resty.New().R().SetContext(ctx).Get(documentURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AsyncAPI document from %s: %w", documentURL, err)
+	}
+
+	if res.IsError() {
+		return nil, fmt.Errorf("failed to load AsyncAPI document from %s: %s", documentURL, res.Status())
+	}
+
+	// YAML is a superset of JSON so both formats are read the same way
+	var doc asyncAPIDocument
+	if err := yamlSig.Unmarshal(res.Body(), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse AsyncAPI document: %w", err)
+	}
+
+	if len(doc.AsyncAPI) == 0 {
+		return nil, fmt.Errorf("invalid AsyncAPI document: missing asyncapi version")
+	}
+
+	// Cache the document
+	e.documents[documentURL] = &doc
+	return &doc, nil
+}
+
+func (d *asyncAPIDocument) isV3() bool {
+	return strings.HasPrefix(d.AsyncAPI, "3.")
+}
+
+// resolveTarget finds the server, channel and reply channel for the call
+func (d *asyncAPIDocument) resolveTarget(args model.AsyncAPIArguments) (*asyncAPITarget, error) {
+
+	target := &asyncAPITarget{
+		Operation: args.Operation,
+	}
+
+	channelName := args.Channel
+	var messages []asyncAPIMessageDef
+
+	if len(args.Operation) > 0 {
+
+		if d.isV3() {
+
+			operation, exists := d.Operations[args.Operation]
+			if !exists {
+				return nil, fmt.Errorf("operation '%s' not found", args.Operation)
+			}
+
+			channelName = refName(operation.Channel.Ref)
+			target.Bindings = operation.Bindings
+
+			if operation.Reply != nil && operation.Reply.Channel != nil {
+				replyChannel, err := d.getChannel(refName(operation.Reply.Channel.Ref))
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve reply channel: %w", err)
+				}
+				target.ReplyAddress = replyChannel.getAddress(refName(operation.Reply.Channel.Ref))
+				messages = append(messages, slices.Collect(maps.Values(replyChannel.Messages))...)
+			}
+
+		} else {
+
+			found := false
+
+			for _, name := range slices.Sorted(maps.Keys(d.Channels)) {
+				channel := d.Channels[name]
+				for _, operation := range []*asyncAPIChannelOperation{channel.Publish, channel.Subscribe} {
+					if operation != nil && operation.OperationID == args.Operation {
+						channelName = name
+						target.Bindings = operation.Bindings
+						found = true
+					}
+				}
+			}
+
+			if !found {
+				return nil, fmt.Errorf("operation '%s' not found", args.Operation)
+			}
+		}
+	}
+
+	channel, err := d.getChannel(channelName)
+	if err != nil {
+		return nil, err
+	}
+
+	target.Address = channel.getAddress(channelName)
+
+	messages = append(channel.getMessages(), messages...)
+
+	for _, message := range messages {
+		if resolved := d.resolveMessage(message); resolved.CorrelationID != nil {
+			target.CorrelationLocation = resolved.CorrelationID.Location
+			break
+		}
+	}
+
+	if len(target.CorrelationLocation) == 0 {
+		target.CorrelationLocation = defaultAsyncAPICorrelationLocation
+	}
+
+	server, err := d.getServer(args.Server, args.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	target.Protocol = strings.ToLower(args.Protocol)
+	if len(target.Protocol) == 0 {
+		target.Protocol = strings.ToLower(server.Protocol)
+	}
+
+	if len(target.Protocol) == 0 {
+		return nil, fmt.Errorf("no protocol given for the call or its server")
+	}
+
+	var variables map[string]any
+	if args.Server != nil {
+		variables = args.Server.Variables
+	}
+
+	target.Server = server.getURL(target.Protocol, variables)
+
+	return target, nil
+}
+
+func (d *asyncAPIDocument) getChannel(name string) (*asyncAPIChannel, error) {
+	channel, exists := d.Channels[name]
+	if !exists {
+		return nil, fmt.Errorf("channel '%s' not found", name)
+	}
+	return &channel, nil
+}
+
+// getServer returns the named server, or the first that supports the protocol
+func (d *asyncAPIDocument) getServer(server *model.AsyncAPIServer, protocol string) (*asyncAPIServer, error) {
+
+	if server != nil && len(server.Name) > 0 {
+		found, exists := d.Servers[server.Name]
+		if !exists {
+			return nil, fmt.Errorf("server '%s' not found", server.Name)
+		}
+		return &found, nil
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(d.Servers)) {
+		found := d.Servers[name]
+		if len(protocol) == 0 || strings.EqualFold(found.Protocol, protocol) {
+			return &found, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no server found in the AsyncAPI document")
+}
+
+func (d *asyncAPIDocument) resolveMessage(message asyncAPIMessageDef) asyncAPIMessageDef {
+	if len(message.Ref) > 0 {
+		if resolved, exists := d.Components.Messages[refName(message.Ref)]; exists {
+			return resolved
+		}
+	}
+	return message
+}
+
+func (c *asyncAPIChannel) getAddress(name string) string {
+	if c.Address != nil {
+		return *c.Address
+	}
+	return name
+}
+
+func (c *asyncAPIChannel) getMessages() []asyncAPIMessageDef {
+
+	messages := []asyncAPIMessageDef{}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Messages)) {
+		messages = append(messages, c.Messages[name])
+	}
+
+	for _, operation := range []*asyncAPIChannelOperation{c.Publish, c.Subscribe} {
+		if operation != nil && operation.Message != nil {
+			messages = append(messages, *operation.Message)
+		}
+	}
+
+	return messages
+}
+
+// getURL returns the server's URL with its variables substituted
+func (s *asyncAPIServer) getURL(protocol string, variables map[string]any) string {
+
+	serverURL := s.URL
+	if len(serverURL) == 0 {
+		serverURL = s.Host + s.Pathname
+	}
+
+	for name, variable := range s.Variables {
+		value := variable.Default
+		if override, exists := variables[name]; exists {
+			value = fmt.Sprintf("%v", override)
+		}
+		serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", value)
+	}
+
+	if !strings.Contains(serverURL, "://") {
+		serverURL = protocol + "://" + serverURL
+	}
+
+	return serverURL
+}
+
+// refName returns the last part of a local reference like #/channels/name
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// asyncAPICorrelation places the correlation ID on published messages and
+// checks it on received ones. Locations are runtime expressions such as
+// $message.header#/correlationId or $message.payload#/meta/id.
+type asyncAPICorrelation struct {
+	id       string
+	inHeader bool
+	path     []string
+}
+
+func newAsyncAPICorrelation(location, id string) asyncAPICorrelation {
+
+	source, pointer, _ := strings.Cut(location, "#")
+
+	return asyncAPICorrelation{
+		id:       id,
+		inHeader: strings.EqualFold(source, "$message.header"),
+		path:     strings.Split(strings.Trim(pointer, "/"), "/"),
+	}
+}
+
+func (c *asyncAPICorrelation) target(message *AsyncAPIMessage) map[string]any {
+	if c.inHeader {
+		if message.Headers == nil {
+			message.Headers = map[string]any{}
+		}
+		return message.Headers
+	}
+	payload, ok := message.Payload.(map[string]any)
+	if !ok {
+		return nil
+	}
+	return payload
+}
+
+// apply sets the correlation ID unless the message already has one, in
+// which case that ID is used to match replies
+func (c *asyncAPICorrelation) apply(message *AsyncAPIMessage) {
+
+	if message.Payload == nil && !c.inHeader {
+		message.Payload = map[string]any{}
+	}
+
+	values := c.target(message)
+	if values == nil {
+		return
+	}
+
+	for _, key := range c.path[:len(c.path)-1] {
+		next, ok := values[key].(map[string]any)
+		if !ok {
+			next = map[string]any{}
+			values[key] = next
+		}
+		values = next
+	}
+
+	key := c.path[len(c.path)-1]
+
+	if existing, exists := values[key]; exists && existing != nil {
+		c.id = fmt.Sprintf("%v", existing)
+		return
+	}
+
+	values[key] = c.id
+}
+
+// matches returns false if the message is correlated to something else.
+// Messages without a correlation ID are accepted.
+func (c *asyncAPICorrelation) matches(message *AsyncAPIMessage) bool {
+
+	values := c.target(message)
+
+	var value any = values
+	for _, key := range c.path {
+		current, ok := value.(map[string]any)
+		if !ok {
+			return true
+		}
+		value = current[key]
+	}
 
-	return nil, fmt.Errorf("asyncapi call not implemented yet")
+	return value == nil || fmt.Sprintf("%v", value) == c.id
 }
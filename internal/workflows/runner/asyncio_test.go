@@ -0,0 +1,348 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thand-io/agent/internal/models"
+)
+
+// A 3.x document with a request/reply operation over HTTP
+const testAsyncAPIV3Doc = `
+asyncapi: 3.0.0
+info:
+  title: Change management
+  version: 1.0.0
+servers:
+  production:
+    host: %s
+    protocol: http
+    pathname: /{stage}
+    variables:
+      stage:
+        default: prod
+channels:
+  changeRequests:
+    address: changes
+    messages:
+      changeRequest:
+        $ref: '#/components/messages/changeRequest'
+  changeDecisions:
+    address: decisions
+operations:
+  requestChange:
+    action: send
+    channel:
+      $ref: '#/channels/changeRequests'
+    reply:
+      channel:
+        $ref: '#/channels/changeDecisions'
+components:
+  messages:
+    changeRequest:
+      correlationId:
+        location: $message.header#/correlationId
+`
+
+// A 2.x document over WebSocket that correlates on the payload
+const testAsyncAPIV2Doc = `{
+  "asyncapi": "2.6.0",
+  "info": {"title": "Change management", "version": "1.0.0"},
+  "servers": {
+    "socket": {"url": "%s", "protocol": "ws"}
+  },
+  "channels": {
+    "changes": {
+      "publish": {
+        "operationId": "requestChange",
+        "message": {
+          "correlationId": {"location": "$message.payload#/meta/correlationId"}
+        }
+      }
+    }
+  }
+}`
+
+func newTestAsyncAPICall(documentURL string, args model.AsyncAPIArguments) AsyncAPIRequest {
+	args.Document = &model.ExternalResource{
+		Endpoint: model.NewEndpoint(documentURL),
+	}
+	return AsyncAPIRequest{
+		Arguments:     args,
+		CorrelationID: "wf_1-requestChange",
+	}
+}
+
+func newTestAsyncAPIConsume(amount int) *model.AsyncAPISubscription {
+	return &model.AsyncAPISubscription{
+		Consume: &model.AsyncAPIMessageConsumptionPolicy{
+			Amount: amount,
+		},
+	}
+}
+
+func TestMakeAsyncAPIRequest_HTTP(t *testing.T) {
+
+	var received map[string]any
+	var correlationID string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/asyncapi.yaml":
+			fmt.Fprintf(w, testAsyncAPIV3Doc, r.Host)
+		case "/staging/changes":
+			correlationID = r.Header.Get("correlationId")
+			json.NewDecoder(r.Body).Decode(&received)
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"status": "approved"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	args := model.AsyncAPIArguments{
+		Operation: "requestChange",
+		Server: &model.AsyncAPIServer{
+			Name:      "production",
+			Variables: map[string]any{"stage": "staging"},
+		},
+		Message: &model.AsyncAPIOutboundMessage{
+			Payload: map[string]any{"role": "admin"},
+		},
+	}
+
+	t.Run("publish", func(t *testing.T) {
+
+		result, err := MakeAsyncAPIRequest(context.Background(), &models.WorkflowTask{},
+			newTestAsyncAPICall(server.URL+"/asyncapi.yaml", args))
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"role": "admin"}, received)
+		assert.Equal(t, "wf_1-requestChange", correlationID)
+
+		output := result.(map[string]any)
+		assert.Equal(t, "changes", output["channel"])
+		assert.Equal(t, "wf_1-requestChange", output["correlationId"])
+	})
+
+	t.Run("reply", func(t *testing.T) {
+
+		withReply := args
+		withReply.Subscription = newTestAsyncAPIConsume(1)
+
+		result, err := MakeAsyncAPIRequest(context.Background(), &models.WorkflowTask{},
+			newTestAsyncAPICall(server.URL+"/asyncapi.yaml", withReply))
+
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		assert.Equal(t, map[string]any{"status": "approved"}, result.([]any)[0].(map[string]any)["payload"])
+	})
+
+	t.Run("unknown operation", func(t *testing.T) {
+
+		unknown := args
+		unknown.Operation = "deleteChange"
+
+		_, err := MakeAsyncAPIRequest(context.Background(), &models.WorkflowTask{},
+			newTestAsyncAPICall(server.URL+"/asyncapi.yaml", unknown))
+
+		assert.ErrorContains(t, err, "operation 'deleteChange' not found")
+	})
+}
+
+func TestResolveAsyncAPIAuthentication(t *testing.T) {
+
+	reference := "changes"
+
+	workflow := &model.Workflow{
+		Document: model.Document{Name: "asyncapi-auth"},
+		Use: &model.Use{
+			Authentications: map[string]*model.AuthenticationPolicy{
+				"changes": {Bearer: &model.BearerAuthenticationPolicy{Use: "changes-token"}},
+			},
+			Secrets: []string{"changes-token"},
+		},
+	}
+
+	runner, err := NewDefaultRunner(workflow)
+	require.NoError(t, err)
+
+	policy, err := runner.getAuthenticationPolicy(&model.ReferenceableAuthenticationPolicy{Use: &reference})
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+
+	request, err := ResolveAsyncAPIAuthentication(context.Background(), AsyncAPIRequest{
+		Arguments: model.AsyncAPIArguments{
+			Authentication: &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy},
+		},
+	}, map[string]any{"changes-token": map[string]any{"token": "s3cret"}})
+	require.NoError(t, err)
+
+	headers, err := resolveAsyncAPIAuthentication(request.Arguments.Authentication)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer s3cret", headers["Authorization"])
+
+	// The workflow's policy is left untouched
+	assert.Empty(t, workflow.Use.Authentications["changes"].Bearer.Token)
+
+	t.Run("unresolved reference", func(t *testing.T) {
+		_, err := resolveAsyncAPIAuthentication(&model.ReferenceableAuthenticationPolicy{Use: &reference})
+		assert.Error(t, err)
+	})
+
+	t.Run("undefined reference", func(t *testing.T) {
+		missing := "missing"
+		_, err := runner.getAuthenticationPolicy(&model.ReferenceableAuthenticationPolicy{Use: &missing})
+		assert.ErrorContains(t, err, "not defined in use.authentications")
+	})
+}
+
+func TestMakeAsyncAPIRequest_WebSocket(t *testing.T) {
+
+	upgrader := websocket.Upgrader{}
+
+	var received map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Path == "/asyncapi.json" {
+			fmt.Fprintf(w, testAsyncAPIV2Doc, "ws://"+r.Host+"/socket")
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if err := conn.ReadJSON(&received); err != nil {
+			return
+		}
+
+		// A decision for another workflow arrives first and is ignored
+		conn.WriteJSON(map[string]any{"status": "denied", "meta": map[string]any{"correlationId": "wf_2-other"}})
+		conn.WriteJSON(map[string]any{"status": "pending", "meta": received["meta"]})
+		conn.WriteJSON(map[string]any{"status": "approved", "meta": received["meta"]})
+
+		// Keep the connection open until the client is done
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	until := model.NewExpr(`${ .payload.status == "approved" }`)
+
+	request := newTestAsyncAPICall(server.URL+"/asyncapi.json", model.AsyncAPIArguments{
+		Operation: "requestChange",
+		Message: &model.AsyncAPIOutboundMessage{
+			Payload: map[string]any{"role": "admin"},
+		},
+		Subscription: &model.AsyncAPISubscription{
+			Consume: &model.AsyncAPIMessageConsumptionPolicy{
+				For:   model.NewDurationExpr("PT10S"),
+				Until: until,
+			},
+		},
+	})
+
+	result, err := MakeAsyncAPIRequest(context.Background(), &models.WorkflowTask{}, request)
+
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{
+		"role": "admin",
+		"meta": map[string]any{"correlationId": "wf_1-requestChange"},
+	}, received)
+
+	messages := result.([]any)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "pending", messages[0].(map[string]any)["payload"].(map[string]any)["status"])
+	assert.Equal(t, "approved", messages[1].(map[string]any)["payload"].(map[string]any)["status"])
+}
+
+type mockAsyncAPIConnection struct {
+	endpoint  AsyncAPIEndpoint
+	published []AsyncAPIMessage
+}
+
+func (m *mockAsyncAPIConnection) Connect(ctx context.Context, endpoint AsyncAPIEndpoint) (AsyncAPIConnection, error) {
+	m.endpoint = endpoint
+	return m, nil
+}
+
+func (m *mockAsyncAPIConnection) Publish(ctx context.Context, message AsyncAPIMessage) error {
+	m.published = append(m.published, message)
+	return nil
+}
+
+func (m *mockAsyncAPIConnection) Receive(ctx context.Context) (*AsyncAPIMessage, error) {
+	return nil, errAsyncAPINoReply
+}
+
+func (m *mockAsyncAPIConnection) Close() error {
+	return nil
+}
+
+func TestRegisterAsyncAPITransport(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, strings.ReplaceAll(testAsyncAPIV3Doc, "protocol: http", "protocol: kafka"), "broker:9092")
+	}))
+	defer server.Close()
+
+	request := newTestAsyncAPICall(server.URL, model.AsyncAPIArguments{
+		Channel: "changeRequests",
+		Server:  &model.AsyncAPIServer{Name: "production"},
+		Message: &model.AsyncAPIOutboundMessage{
+			Payload: map[string]any{"role": "admin"},
+		},
+	})
+
+	_, err := MakeAsyncAPIRequest(context.Background(), nil, request)
+	assert.ErrorContains(t, err, "unsupported AsyncAPI protocol: kafka")
+
+	mock := &mockAsyncAPIConnection{}
+
+	RegisterAsyncAPITransport("kafka", mock)
+	t.Cleanup(func() {
+		asyncAPITransportsMu.Lock()
+		delete(asyncAPITransports, "kafka")
+		asyncAPITransportsMu.Unlock()
+	})
+
+	_, err = MakeAsyncAPIRequest(context.Background(), nil, request)
+	require.NoError(t, err)
+
+	assert.Equal(t, "changes", mock.endpoint.Address)
+	require.Len(t, mock.published, 1)
+	assert.Equal(t, "wf_1-requestChange", mock.published[0].Headers["correlationId"])
+}
+
+func TestExecuteAsyncFunction(t *testing.T) {
+
+	runner := &ResumableWorkflowRunner{}
+	runner.workflowTask = &models.WorkflowTask{}
+
+	call := &model.CallAsyncAPI{
+		Call: "asyncapi",
+		With: model.AsyncAPIArguments{
+			Document: &model.ExternalResource{
+				Endpoint: model.NewEndpoint("https://example.com/asyncapi.yaml"),
+			},
+			// Missing operation and channel
+		},
+	}
+
+	_, err := runner.executeAsyncFunction("test-task", call, nil)
+	assert.ErrorContains(t, err, "requires an operation or a channel")
+}
@@ -0,0 +1,272 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+var errAsyncAPINoReply = errors.New("no reply was returned")
+
+// AsyncAPIMessage is a message sent to or received from a channel
+type AsyncAPIMessage struct {
+	Payload any            `json:"payload"`
+	Headers map[string]any `json:"headers,omitempty"`
+}
+
+// AsyncAPIEndpoint is a channel on a server
+type AsyncAPIEndpoint struct {
+	Protocol string            `json:"protocol"`
+	Server   string            `json:"server"`  // The server URL with its variables substituted
+	Address  string            `json:"address"` // The channel address, such as a path, topic or subject
+	Headers  map[string]string `json:"headers,omitempty"`
+	Bindings map[string]any    `json:"bindings,omitempty"` // Protocol specific operation bindings
+}
+
+// AsyncAPITransport connects to a channel over a protocol. HTTP and
+// WebSocket are built in, brokers such as Kafka or NATS can be added
+// with RegisterAsyncAPITransport.
+type AsyncAPITransport interface {
+	Connect(ctx context.Context, endpoint AsyncAPIEndpoint) (AsyncAPIConnection, error)
+}
+
+// AsyncAPIConnection publishes and receives messages on a single channel
+type AsyncAPIConnection interface {
+	Publish(ctx context.Context, message AsyncAPIMessage) error
+	Receive(ctx context.Context) (*AsyncAPIMessage, error)
+	Close() error
+}
+
+// asyncAPIInlineReplies is implemented by request/response transports
+// whose replies come back on the connection the message was sent on
+type asyncAPIInlineReplies interface {
+	repliesInline()
+}
+
+var (
+	asyncAPITransportsMu sync.RWMutex
+	asyncAPITransports   = map[string]AsyncAPITransport{
+		"http":  &httpAsyncAPITransport{},
+		"https": &httpAsyncAPITransport{},
+		"ws":    &websocketAsyncAPITransport{},
+		"wss":   &websocketAsyncAPITransport{},
+	}
+)
+
+// RegisterAsyncAPITransport adds or replaces the transport for a protocol
+func RegisterAsyncAPITransport(protocol string, transport AsyncAPITransport) {
+
+	asyncAPITransportsMu.Lock()
+	defer asyncAPITransportsMu.Unlock()
+
+	asyncAPITransports[strings.ToLower(protocol)] = transport
+
+	logrus.WithField("protocol", protocol).Debugln("Registered AsyncAPI transport")
+}
+
+// GetAsyncAPITransport returns the transport for a protocol
+func GetAsyncAPITransport(protocol string) (AsyncAPITransport, error) {
+
+	asyncAPITransportsMu.RLock()
+	defer asyncAPITransportsMu.RUnlock()
+
+	transport, exists := asyncAPITransports[strings.ToLower(protocol)]
+	if !exists {
+		return nil, fmt.Errorf("unsupported AsyncAPI protocol: %s", protocol)
+	}
+
+	return transport, nil
+}
+
+// GetURL returns the server URL joined with the channel address
+func (e *AsyncAPIEndpoint) GetURL() string {
+	if len(e.Address) == 0 {
+		return e.Server
+	}
+	return strings.TrimSuffix(e.Server, "/") + "/" + strings.TrimPrefix(e.Address, "/")
+}
+
+// AsMap returns the message as it is seen by workflow expressions
+func (m *AsyncAPIMessage) AsMap() map[string]any {
+	headers := m.Headers
+	if headers == nil {
+		headers = map[string]any{}
+	}
+	return map[string]any{
+		"payload": m.Payload,
+		"headers": headers,
+	}
+}
+
+// decodeAsyncAPIPayload returns JSON payloads as values and anything else
+// as a string
+func decodeAsyncAPIPayload(data []byte) any {
+	var payload any
+	if err := json.Unmarshal(data, &payload); err == nil {
+		return payload
+	}
+	return string(data)
+}
+
+/*
+HTTP transport. Messages are sent as JSON request bodies with their
+headers as HTTP headers. The method defaults to POST and can be set with
+an http operation binding. A response body is the reply.
+*/
+
+type httpAsyncAPITransport struct{}
+
+type httpAsyncAPIConnection struct {
+	endpoint AsyncAPIEndpoint
+	client   *resty.Client
+	replies  []*AsyncAPIMessage
+}
+
+func (t *httpAsyncAPITransport) Connect(ctx context.Context, endpoint AsyncAPIEndpoint) (AsyncAPIConnection, error) {
+	return &httpAsyncAPIConnection{
+		endpoint: endpoint,
+		client:   resty.New(),
+	}, nil
+}
+
+func (t *httpAsyncAPITransport) repliesInline() {}
+
+func (c *httpAsyncAPIConnection) getMethod() string {
+	if binding, ok := c.endpoint.Bindings["http"].(map[string]any); ok {
+		if method, ok := binding["method"].(string); ok && len(method) > 0 {
+			return strings.ToUpper(method)
+		}
+	}
+	return http.MethodPost
+}
+
+func (c *httpAsyncAPIConnection) Publish(ctx context.Context, message AsyncAPIMessage) error {
+
+	req := c.client.R().
+		SetContext(ctx).
+		SetHeaders(c.endpoint.Headers).
+		SetHeader("Content-Type", "application/json").
+		SetHeader("Accept", "application/json").
+		SetBody(message.Payload)
+
+	for key, value := range message.Headers {
+		req.SetHeader(key, fmt.Sprintf("%v", value))
+	}
+
+	res, err := req.Execute(c.getMethod(), c.endpoint.GetURL())
+	if err != nil {
+		return err
+	}
+
+	if res.IsError() {
+		return fmt.Errorf("server responded with %s", res.Status())
+	}
+
+	if len(res.Body()) > 0 {
+
+		headers := map[string]any{}
+		for key := range res.Header() {
+			headers[key] = res.Header().Get(key)
+		}
+
+		c.replies = append(c.replies, &AsyncAPIMessage{
+			Payload: decodeAsyncAPIPayload(res.Body()),
+			Headers: headers,
+		})
+	}
+
+	return nil
+}
+
+// Receive returns the responses to published messages. HTTP can't wait
+// for further messages.
+func (c *httpAsyncAPIConnection) Receive(ctx context.Context) (*AsyncAPIMessage, error) {
+
+	if len(c.replies) == 0 {
+		return nil, errAsyncAPINoReply
+	}
+
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+
+	return reply, nil
+}
+
+func (c *httpAsyncAPIConnection) Close() error {
+	return nil
+}
+
+/*
+WebSocket transport. Each message is a JSON text frame of its payload.
+Headers can't be sent per message so only the authentication headers
+are sent, when connecting.
+*/
+
+type websocketAsyncAPITransport struct{}
+
+type websocketAsyncAPIConnection struct {
+	conn *websocket.Conn
+}
+
+func (t *websocketAsyncAPITransport) Connect(ctx context.Context, endpoint AsyncAPIEndpoint) (AsyncAPIConnection, error) {
+
+	header := http.Header{}
+	for key, value := range endpoint.Headers {
+		header.Set(key, value)
+	}
+
+	conn, res, err := websocket.DefaultDialer.DialContext(ctx, endpoint.GetURL(), header)
+	if err != nil {
+		if res != nil {
+			return nil, fmt.Errorf("%w: server responded with %s", err, res.Status)
+		}
+		return nil, err
+	}
+
+	return &websocketAsyncAPIConnection{conn: conn}, nil
+}
+
+func (c *websocketAsyncAPIConnection) Publish(ctx context.Context, message AsyncAPIMessage) error {
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetWriteDeadline(deadline)
+	}
+
+	return c.conn.WriteJSON(message.Payload)
+}
+
+func (c *websocketAsyncAPIConnection) Receive(ctx context.Context) (*AsyncAPIMessage, error) {
+
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetReadDeadline(deadline)
+	}
+
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// The read deadline is the context's so treat it the same way
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, context.DeadlineExceeded
+		}
+		return nil, err
+	}
+
+	return &AsyncAPIMessage{
+		Payload: decodeAsyncAPIPayload(data),
+	}, nil
+}
+
+func (c *websocketAsyncAPIConnection) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,663 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/google/uuid"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/common"
+	"github.com/thand-io/agent/internal/models"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	defaultContainerTimeout    = 120 * time.Second
+	defaultContainerPullPolicy = ContainerPullIfNotPresent
+
+	// How much of each output stream is kept, the rest is discarded
+	maxContainerOutput = 1024 * 1024
+)
+
+// Pull policies for container images
+const (
+	ContainerPullAlways       = "always"
+	ContainerPullIfNotPresent = "ifNotPresent"
+	ContainerPullNever        = "never"
+)
+
+// What a process task returns, set with run.return
+const (
+	ProcessReturnStdout = "stdout"
+	ProcessReturnStderr = "stderr"
+	ProcessReturnCode   = "code"
+	ProcessReturnAll    = "all"
+	ProcessReturnNone   = "none"
+)
+
+var processReturns = []string{
+	ProcessReturnStdout,
+	ProcessReturnStderr,
+	ProcessReturnCode,
+	ProcessReturnAll,
+	ProcessReturnNone,
+}
+
+var containerNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// ContainerOptions are the settings for a container beyond its image,
+// command, ports, volumes and environment
+type ContainerOptions struct {
+	Arguments   []string      `json:"arguments,omitempty"`
+	Return      string        `json:"return"`
+	PullPolicy  string        `json:"pull_policy"`
+	Timeout     time.Duration `json:"timeout"`
+	MemoryLimit int64         `json:"memory_limit"` // Bytes, 0 for no limit
+	CPUs        float64       `json:"cpus"`         // 0 for no limit
+	PidsLimit   int64         `json:"pids_limit"`   // 0 for no limit
+	Network     string        `json:"network"`
+	ReadOnly    bool          `json:"read_only"` // Read-only root filesystem and volumes
+}
+
+// containerMetadata is read from the task's metadata.container. The DSL
+// fields the workflow model doesn't carry, such as arguments, pull policy
+// and return, are set here along with the sandbox limits.
+type containerMetadata struct {
+	Arguments     []string `json:"arguments"`
+	Return        string   `json:"return"`
+	PullPolicy    string   `json:"pullPolicy"`
+	Timeout       string   `json:"timeout"`
+	MemoryLimitMB *int64   `json:"memoryLimitMB"`
+	CPUs          *float64 `json:"cpus"`
+	PidsLimit     *int64   `json:"pidsLimit"`
+	Network       string   `json:"network"`
+	ReadOnly      bool     `json:"readOnly"`
+}
+
+/*
+A task used to run a command in a container, such as a remediation
+script in a pinned image rather than on the host.
+
+The command is split into words as a shell would, honouring quotes, but
+isn't run by one. Containers are uniquely named, limited in memory, CPU
+and processes, and removed once they finish or time out. Only the first
+megabyte of each output stream is kept. Volumes are bind mounts of a host
+path to a container path, with :ro making the mount read-only.
+
+	do:
+	  - remediate:
+	      metadata:
+	        container:
+	          arguments: ["--instance", "${ .instance }"]
+	          pullPolicy: ifNotPresent
+	          return: all
+	          timeout: PT5M
+	          memoryLimitMB: 256
+	          network: none
+	          readOnly: true
+	      run:
+	        container:
+	          image: ghcr.io/acme/remediate@sha256:...
+	          command: remediate restart
+	          environment:
+	            REGION: ${ .region }
+	          volumes:
+	            /etc/acme: /config:ro
+*/
+func (r *ResumableWorkflowRunner) executeContainerProcess(
+	taskName string,
+	run *model.RunTask,
+	input any,
+) (any, error) {
+
+	if run == nil || run.Run.Container == nil {
+		return nil, fmt.Errorf("container process is nil")
+	}
+
+	workflowTask := r.GetWorkflowTask()
+
+	if workflowTask == nil {
+		return nil, fmt.Errorf("workflow task is not set")
+	}
+
+	options, err := r.getContainerOptions(run, input)
+	if err != nil {
+		return nil, model.NewErrRuntime(err, taskName)
+	}
+
+	serviceClient := r.config.GetServices()
+
+	if workflowTask.HasTemporalContext() && serviceClient.HasTemporal() {
+
+		// Containers may have side effects so they are not retried
+		activityOptions := workflow.ActivityOptions{
+			TaskQueue:           serviceClient.GetTemporal().GetTaskQueue(),
+			StartToCloseTimeout: options.Timeout + 5*time.Minute, // Allow time to pull the image
+			RetryPolicy: &temporal.RetryPolicy{
+				MaximumAttempts: 1,
+			},
+		}
+
+		// The command and environment are evaluated by the activity
+		fut := workflow.ExecuteActivity(
+			workflow.WithActivityOptions(workflowTask.GetTemporalContext(), activityOptions),
+			models.TemporalContainerActivityName,
+			workflowTask,
+			taskName,
+			*run.Run.Container,
+			input,
+			options,
+			r.getSecretNames(),
+		)
+
+		var result any
+		if err := fut.Get(workflowTask.GetTemporalContext(), &result); err != nil {
+			return nil, fmt.Errorf("container activity failed: %w", err)
+		}
+
+		return result, nil
+	}
+
+	container, err := EvaluateContainer(workflowTask, run.Run.Container, input)
+	if err != nil {
+		return nil, model.NewErrRuntime(err, taskName)
+	}
+
+	secrets, err := r.loadSecrets()
+	if err != nil {
+		return nil, model.NewErrRuntime(err, taskName)
+	}
+
+	return RunContainerWithSecrets(r.GetContext(), taskName, *container, options, secrets)
+}
+
+// RunContainerWithSecrets fills in the secrets in the container's command,
+// environment and arguments, then masks them in the result
+func RunContainerWithSecrets(
+	ctx context.Context,
+	taskName string,
+	container model.Container,
+	options ContainerOptions,
+	secrets map[string]any,
+) (any, error) {
+
+	container, err := FillSecrets(container, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err = FillSecrets(options, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := RunContainer(ctx, taskName, container, options)
+
+	return RedactSecrets(result, err, secrets)
+}
+
+// EvaluateContainer evaluates any expressions in the command and environment
+func EvaluateContainer(workflowTask *models.WorkflowTask, container *model.Container, input any) (*model.Container, error) {
+
+	evaluated := *container
+
+	command, err := workflowTask.TraverseAndEvaluate(container.Command, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate container command: %w", err)
+	}
+
+	evaluated.Command = fmt.Sprintf("%v", command)
+
+	if len(container.Environment) > 0 {
+
+		evaluated.Environment = make(map[string]string, len(container.Environment))
+
+		for key, value := range container.Environment {
+			result, err := workflowTask.TraverseAndEvaluate(value, input)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate container environment %s: %w", key, err)
+			}
+			evaluated.Environment[key] = fmt.Sprintf("%v", result)
+		}
+	}
+
+	return &evaluated, nil
+}
+
+// getContainerOptions combines the configured defaults with the task's
+// container metadata and timeout
+func (r *ResumableWorkflowRunner) getContainerOptions(run *model.RunTask, input any) (ContainerOptions, error) {
+
+	defaults := r.config.Workflows.Containers
+
+	options := ContainerOptions{
+		Return:      ProcessReturnStdout,
+		PullPolicy:  defaults.PullPolicy,
+		Timeout:     defaults.Timeout,
+		MemoryLimit: defaults.MemoryLimitMB * 1024 * 1024,
+		CPUs:        defaults.CPUs,
+		PidsLimit:   defaults.PidsLimit,
+		Network:     defaults.Network,
+	}
+
+	if len(options.PullPolicy) == 0 {
+		options.PullPolicy = defaultContainerPullPolicy
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = defaultContainerTimeout
+	}
+
+	if rawMetadata, found := run.Metadata["container"]; found {
+
+		evaluated, err := r.GetWorkflowTask().TraverseAndEvaluate(rawMetadata, input)
+		if err != nil {
+			return options, fmt.Errorf("failed to evaluate container metadata: %w", err)
+		}
+
+		var metadata containerMetadata
+		if err := common.ConvertInterfaceToInterface(evaluated, &metadata); err != nil {
+			return options, fmt.Errorf("failed to read container metadata: %w", err)
+		}
+
+		options.Arguments = metadata.Arguments
+		options.ReadOnly = metadata.ReadOnly
+
+		if len(metadata.Return) > 0 {
+			options.Return = metadata.Return
+		}
+
+		if len(metadata.PullPolicy) > 0 {
+			options.PullPolicy = metadata.PullPolicy
+		}
+
+		if len(metadata.Network) > 0 {
+			options.Network = metadata.Network
+		}
+
+		if metadata.MemoryLimitMB != nil {
+			options.MemoryLimit = *metadata.MemoryLimitMB * 1024 * 1024
+		}
+
+		if metadata.CPUs != nil {
+			options.CPUs = *metadata.CPUs
+		}
+
+		if metadata.PidsLimit != nil {
+			options.PidsLimit = *metadata.PidsLimit
+		}
+
+		if len(metadata.Timeout) > 0 {
+			timeout, err := common.ParseWorkflowDuration(model.NewDurationExpr(metadata.Timeout))
+			if err != nil {
+				return options, fmt.Errorf("failed to parse container timeout: %w", err)
+			}
+			options.Timeout = timeout
+		}
+	}
+
+	// An inline task timeout takes precedence
+	if run.Timeout != nil && run.Timeout.Timeout != nil && run.Timeout.Timeout.After != nil {
+		timeout, err := common.ParseWorkflowDuration(run.Timeout.Timeout.After)
+		if err != nil {
+			return options, fmt.Errorf("failed to parse task timeout: %w", err)
+		}
+		options.Timeout = timeout
+	}
+
+	if !slices.Contains(processReturns, options.Return) {
+		return options, fmt.Errorf("unsupported return %s. Supported values: %s",
+			options.Return, strings.Join(processReturns, ", "))
+	}
+
+	if !slices.Contains([]string{ContainerPullAlways, ContainerPullIfNotPresent, ContainerPullNever}, options.PullPolicy) {
+		return options, fmt.Errorf("unsupported pull policy: %s", options.PullPolicy)
+	}
+
+	return options, nil
+}
+
+// RunContainer runs the container to completion using the Docker Engine
+// API and returns its output according to the options' return
+func RunContainer(
+	ctx context.Context,
+	taskName string,
+	container model.Container,
+	options ContainerOptions,
+) (any, error) {
+
+	if len(container.Image) == 0 {
+		return nil, fmt.Errorf("container image is required")
+	}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+	defer cli.Close()
+
+	if err := pullContainerImage(ctx, cli, container.Image, options.PullPolicy); err != nil {
+		return nil, err
+	}
+
+	cfg, hostCfg, err := buildContainerConfig(container, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Names are unique so concurrent runs of the same task don't collide
+	containerName := fmt.Sprintf("thand-%s-%s",
+		strings.Trim(containerNameInvalidChars.ReplaceAllString(taskName, "-"), "-."),
+		uuid.NewString()[:8])
+
+	createResp, err := cli.ContainerCreate(ctx, cfg, hostCfg, nil, nil, containerName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create container: %w", err)
+	}
+
+	containerID := createResp.ID
+
+	// Always remove the container, even if the workflow was cancelled
+	defer func() {
+		err := cli.ContainerRemove(context.Background(), containerID, containerTypes.RemoveOptions{
+			Force:         true,
+			RemoveVolumes: true,
+		})
+		if err != nil {
+			logrus.WithError(err).WithField("id", containerID).Warn("Failed to remove container")
+		}
+	}()
+
+	// Only the executable is logged as the arguments may hold secrets
+	var executable string
+	if len(cfg.Cmd) > 0 {
+		executable = cfg.Cmd[0]
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"task":       taskName,
+		"image":      container.Image,
+		"name":       containerName,
+		"executable": executable,
+		"network":    hostCfg.NetworkMode,
+		"timeout":    options.Timeout,
+	}).Info("Starting container")
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+
+	if err := cli.ContainerStart(ctx, containerID, containerTypes.StartOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to start container: %w", err)
+	}
+
+	statusCh, errCh := cli.ContainerWait(ctx, containerID, containerTypes.WaitConditionNotRunning)
+
+	var exitCode int64 = -1
+
+	select {
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	case err := <-errCh:
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			_ = cli.ContainerKill(context.Background(), containerID, "KILL")
+			return nil, fmt.Errorf("container timed out after %s", options.Timeout)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for container: %w", err)
+		}
+	}
+
+	duration := time.Since(startedAt)
+
+	stdout := &limitedBuffer{limit: maxContainerOutput}
+	stderr := &limitedBuffer{limit: maxContainerOutput}
+
+	logs, err := cli.ContainerLogs(context.Background(), containerID, containerTypes.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+	})
+
+	if err == nil {
+		defer logs.Close()
+		// Without a TTY docker multiplexes stdout and stderr into one stream
+		if _, err := stdcopy.StdCopy(stdout, stderr, logs); err != nil {
+			logrus.WithError(err).Warn("Failed to read container logs")
+		}
+	} else {
+		logrus.WithError(err).Warn("Failed to fetch container logs")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"task":      taskName,
+		"name":      containerName,
+		"image":     container.Image,
+		"code":      exitCode,
+		"timeMs":    duration.Milliseconds(),
+		"stdoutLen": stdout.Len(),
+		"stderrLen": stderr.Len(),
+		"truncated": stdout.truncated || stderr.truncated,
+	}).Info("Container finished")
+
+	return getProcessReturn(options.Return, exitCode, stdout.String(), stderr.String())
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards
+// the rest, so a noisy container can't exhaust the agent's memory
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+
+	if remaining := b.limit - b.Len(); remaining < len(p) {
+		b.truncated = true
+		b.Buffer.Write(p[:max(remaining, 0)])
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
+
+// getProcessReturn shapes a finished process's output. A non-zero exit
+// code faults the task unless the code itself is being returned.
+func getProcessReturn(returns string, code int64, stdout, stderr string) (any, error) {
+
+	if code != 0 && returns != ProcessReturnCode && returns != ProcessReturnAll {
+		return nil, fmt.Errorf("process exited with code %d: %s", code, strings.TrimSpace(stderr))
+	}
+
+	switch returns {
+	case ProcessReturnStderr:
+		return stderr, nil
+	case ProcessReturnCode:
+		return code, nil
+	case ProcessReturnAll:
+		return map[string]any{
+			"code":   code,
+			"stdout": stdout,
+			"stderr": stderr,
+		}, nil
+	case ProcessReturnNone:
+		return nil, nil
+	default:
+		return stdout, nil
+	}
+}
+
+func pullContainerImage(ctx context.Context, cli *client.Client, imageName, pullPolicy string) error {
+
+	switch pullPolicy {
+	case ContainerPullNever:
+		return nil
+	case ContainerPullIfNotPresent:
+		if _, err := cli.ImageInspect(ctx, imageName); err == nil {
+			return nil
+		} else if !client.IsErrNotFound(err) {
+			return fmt.Errorf("failed to inspect image: %w", err)
+		}
+	}
+
+	logrus.WithField("image", imageName).Info("Pulling image")
+
+	rc, err := cli.ImagePull(ctx, imageName, image.PullOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
+	}
+	defer rc.Close()
+
+	// The pull only completes once the progress stream has been read
+	if _, err := io.Copy(io.Discard, rc); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", imageName, err)
+	}
+
+	return nil
+}
+
+func buildContainerConfig(
+	container model.Container,
+	options ContainerOptions,
+) (*containerTypes.Config, *containerTypes.HostConfig, error) {
+
+	cfg := &containerTypes.Config{
+		Image: container.Image,
+	}
+
+	// The command overrides the image's, arguments are passed to either
+	command, err := splitCommand(container.Command)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cmd := append(command, options.Arguments...); len(cmd) > 0 {
+		cfg.Cmd = cmd
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(container.Environment)) {
+		cfg.Env = append(cfg.Env, key+"="+container.Environment[key])
+	}
+
+	hostCfg := &containerTypes.HostConfig{
+		NetworkMode:    containerTypes.NetworkMode(options.Network),
+		ReadonlyRootfs: options.ReadOnly,
+		Resources: containerTypes.Resources{
+			Memory:   options.MemoryLimit,
+			NanoCPUs: int64(options.CPUs * 1e9),
+		},
+	}
+
+	if options.PidsLimit > 0 {
+		pidsLimit := options.PidsLimit
+		hostCfg.Resources.PidsLimit = &pidsLimit
+	}
+
+	for _, source := range slices.Sorted(maps.Keys(container.Volumes)) {
+
+		target, readOnly := strings.CutSuffix(fmt.Sprintf("%v", container.Volumes[source]), ":ro")
+
+		if !filepath.IsAbs(source) || !filepath.IsAbs(target) {
+			return nil, nil, fmt.Errorf("volume %s must map an absolute host path to an absolute container path", source)
+		}
+
+		hostCfg.Mounts = append(hostCfg.Mounts, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   source,
+			Target:   target,
+			ReadOnly: readOnly || options.ReadOnly,
+		})
+	}
+
+	if len(container.Ports) > 0 {
+
+		cfg.ExposedPorts = nat.PortSet{}
+		hostCfg.PortBindings = nat.PortMap{}
+
+		for hostPort, containerPort := range container.Ports {
+
+			port, err := nat.NewPort(nat.SplitProtoPort(fmt.Sprintf("%v", containerPort)))
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid container port %v: %w", containerPort, err)
+			}
+
+			cfg.ExposedPorts[port] = struct{}{}
+			hostCfg.PortBindings[port] = append(hostCfg.PortBindings[port], nat.PortBinding{
+				HostPort: hostPort,
+			})
+		}
+	}
+
+	return cfg, hostCfg, nil
+}
+
+// splitCommand splits a command into words the way a shell would, without
+// running one. Words can be quoted with single or double quotes and
+// characters escaped with a backslash. Nothing is expanded.
+func splitCommand(command string) ([]string, error) {
+
+	var words []string
+	var word strings.Builder
+
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, c := range command {
+
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("container command has an unterminated quote or escape")
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
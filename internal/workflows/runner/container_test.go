@@ -0,0 +1,260 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-connections/nat"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thand-io/agent/internal/config"
+	"github.com/thand-io/agent/internal/models"
+)
+
+func TestGetContainerOptions(t *testing.T) {
+
+	runner := &ResumableWorkflowRunner{
+		config: config.DefaultConfig(),
+	}
+	runner.workflowTask = &models.WorkflowTask{}
+	runner.config.Workflows.Containers.MemoryLimitMB = 512
+	runner.config.Workflows.Containers.Network = "bridge"
+
+	t.Run("defaults", func(t *testing.T) {
+
+		options, err := runner.getContainerOptions(&model.RunTask{}, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, ProcessReturnStdout, options.Return)
+		assert.Equal(t, ContainerPullIfNotPresent, options.PullPolicy)
+		assert.Equal(t, defaultContainerTimeout, options.Timeout)
+		assert.Equal(t, int64(512*1024*1024), options.MemoryLimit)
+		assert.Equal(t, "bridge", options.Network)
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+
+		run := &model.RunTask{
+			TaskBase: model.TaskBase{
+				Metadata: map[string]any{
+					"container": map[string]any{
+						"arguments":     []any{"--instance", "${ .instance }"},
+						"return":        "all",
+						"pullPolicy":    "never",
+						"timeout":       "PT5M",
+						"memoryLimitMB": 0,
+						"network":       "none",
+						"readOnly":      true,
+					},
+				},
+			},
+		}
+
+		options, err := runner.getContainerOptions(run, map[string]any{"instance": "i-123"})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"--instance", "i-123"}, options.Arguments)
+		assert.Equal(t, ProcessReturnAll, options.Return)
+		assert.Equal(t, ContainerPullNever, options.PullPolicy)
+		assert.Equal(t, 5*time.Minute, options.Timeout)
+		assert.Equal(t, int64(0), options.MemoryLimit)
+		assert.Equal(t, "none", options.Network)
+		assert.True(t, options.ReadOnly)
+	})
+
+	t.Run("task timeout", func(t *testing.T) {
+
+		run := &model.RunTask{
+			TaskBase: model.TaskBase{
+				Timeout: &model.TimeoutOrReference{
+					Timeout: &model.Timeout{
+						After: model.NewDurationExpr("PT30S"),
+					},
+				},
+			},
+		}
+
+		options, err := runner.getContainerOptions(run, nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, 30*time.Second, options.Timeout)
+	})
+
+	t.Run("invalid return", func(t *testing.T) {
+
+		run := &model.RunTask{
+			TaskBase: model.TaskBase{
+				Metadata: map[string]any{
+					"container": map[string]any{"return": "everything"},
+				},
+			},
+		}
+
+		_, err := runner.getContainerOptions(run, nil)
+		assert.ErrorContains(t, err, "unsupported return everything")
+	})
+}
+
+func TestGetProcessReturn(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		returns  string
+		code     int64
+		expected any
+		err      string
+	}{
+		{name: "stdout", returns: ProcessReturnStdout, expected: "out"},
+		{name: "stderr", returns: ProcessReturnStderr, expected: "err"},
+		{name: "code", returns: ProcessReturnCode, code: 3, expected: int64(3)},
+		{name: "all", returns: ProcessReturnAll, code: 1, expected: map[string]any{
+			"code":   int64(1),
+			"stdout": "out",
+			"stderr": "err",
+		}},
+		{name: "none", returns: ProcessReturnNone, expected: nil},
+		{name: "failure", returns: ProcessReturnStdout, code: 2, err: "process exited with code 2: err"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			result, err := getProcessReturn(tt.returns, tt.code, "out", "err")
+
+			if len(tt.err) > 0 {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestBuildContainerConfig(t *testing.T) {
+
+	container := model.Container{
+		Image:   "alpine:3.20",
+		Command: "echo hello",
+		Ports: map[string]interface{}{
+			"8080": "80",
+		},
+		Volumes: map[string]interface{}{
+			"/etc/acme": "/config:ro",
+			"/tmp/work": "/work",
+		},
+		Environment: map[string]string{
+			"REGION": "eu-west-1",
+			"MODE":   "dry-run",
+		},
+	}
+
+	options := ContainerOptions{
+		Arguments:   []string{"world"},
+		MemoryLimit: 256 * 1024 * 1024,
+		CPUs:        0.5,
+		PidsLimit:   64,
+		Network:     "none",
+	}
+
+	cfg, hostCfg, err := buildContainerConfig(container, options)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"echo", "hello", "world"}, []string(cfg.Cmd))
+	assert.Equal(t, []string{"MODE=dry-run", "REGION=eu-west-1"}, cfg.Env)
+
+	assert.Equal(t, int64(256*1024*1024), hostCfg.Memory)
+	assert.Equal(t, int64(500000000), hostCfg.NanoCPUs)
+	assert.Equal(t, int64(64), *hostCfg.PidsLimit)
+	assert.Equal(t, "none", string(hostCfg.NetworkMode))
+	assert.False(t, hostCfg.ReadonlyRootfs)
+
+	assert.Equal(t, []mount.Mount{
+		{Type: mount.TypeBind, Source: "/etc/acme", Target: "/config", ReadOnly: true},
+		{Type: mount.TypeBind, Source: "/tmp/work", Target: "/work"},
+	}, hostCfg.Mounts)
+
+	assert.Contains(t, cfg.ExposedPorts, nat.Port("80/tcp"))
+	assert.Equal(t, []nat.PortBinding{{HostPort: "8080"}}, hostCfg.PortBindings[nat.Port("80/tcp")])
+
+	t.Run("read only", func(t *testing.T) {
+
+		options.ReadOnly = true
+
+		_, hostCfg, err := buildContainerConfig(container, options)
+		require.NoError(t, err)
+
+		assert.True(t, hostCfg.ReadonlyRootfs)
+		for _, m := range hostCfg.Mounts {
+			assert.True(t, m.ReadOnly, m.Source)
+		}
+	})
+
+	t.Run("relative volume", func(t *testing.T) {
+
+		container.Volumes = map[string]interface{}{"data": "/data"}
+
+		_, _, err := buildContainerConfig(container, options)
+		assert.ErrorContains(t, err, "must map an absolute host path")
+	})
+}
+
+func TestSplitCommand(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		command  string
+		expected []string
+		err      bool
+	}{
+		{name: "words", command: "remediate  restart\tnow", expected: []string{"remediate", "restart", "now"}},
+		{name: "double quotes", command: `echo "hello world"`, expected: []string{"echo", "hello world"}},
+		{name: "single quotes", command: `echo 'it\s "quoted"'`, expected: []string{"echo", `it\s "quoted"`}},
+		{name: "escapes", command: `echo a\ b "c\"d"`, expected: []string{"echo", "a b", `c"d`}},
+		{name: "empty quotes", command: `echo ""`, expected: []string{"echo", ""}},
+		{name: "no expansion", command: "echo $HOME; rm -rf /", expected: []string{"echo", "$HOME;", "rm", "-rf", "/"}},
+		{name: "empty", command: "", expected: nil},
+		{name: "unterminated quote", command: `echo "hello`, err: true},
+		{name: "trailing escape", command: `echo \`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			words, err := splitCommand(tt.command)
+
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, words)
+		})
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+
+	buffer := &limitedBuffer{limit: 5}
+
+	n, err := buffer.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.False(t, buffer.truncated)
+
+	// Writes past the limit are accepted but dropped
+	n, err = buffer.Write([]byte("defgh"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	n, err = buffer.Write([]byte("ijk"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	assert.Equal(t, "abcde", buffer.String())
+	assert.True(t, buffer.truncated)
+}
@@ -0,0 +1,232 @@
+package runner
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/common"
+)
+
+/*
+MatchesListenTask returns whether the event is one the workflow's listen
+task is waiting for. The event has to match one of the task's filters,
+including their correlate keys, so external systems can resume workflows
+without knowing their IDs. Filters must correlate at least one key with
+an expected value, otherwise any event of the type would resume every
+waiting workflow. Listen tasks of suspended nested workflows are matched
+too.
+
+	do:
+	  - approval:
+	      listen:
+	        to:
+	          one:
+	            with:
+	              type: com.example.ticket.approved
+	            correlate:
+	              request:
+	                from: .data.request_id
+	                expect: ${ $workflow.id }
+*/
+func (r *ResumableWorkflowRunner) MatchesListenTask(taskName string, signal cloudevents.Event) bool {
+
+	taskList := r.GetTaskList()
+
+	if taskList == nil {
+		return false
+	}
+
+	_, taskItem := taskList.KeyAndIndex(taskName)
+
+	if taskItem == nil {
+		return false
+	}
+
+	listen, ok := taskItem.Task.(*model.ListenTask)
+
+	if !ok {
+		return r.matchesNestedListenTask(signal)
+	}
+
+	if listen.Listen.To == nil {
+		return false
+	}
+
+	for _, eventFilter := range getListenFilters(listen.Listen.To) {
+		if isCorrelated(eventFilter) && r.evaluateListenFilter(eventFilter, signal) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesNestedListenTask checks the event against the suspended nested
+// workflows the workflow is waiting on
+func (r *ResumableWorkflowRunner) matchesNestedListenTask(signal cloudevents.Event) bool {
+
+	for _, nested := range r.GetWorkflowTask().Nested {
+
+		if nested.GetWorkflowDef() == nil {
+
+			definition, err := r.config.GetWorkflowByName(nested.WorkflowName)
+			if err != nil {
+				continue
+			}
+
+			nested.SetWorkflowDsl(definition.GetWorkflow())
+		}
+
+		nestedRunner := NewResumableRunner(r.config, r.functions, nested)
+
+		if nestedRunner.MatchesListenTask(nested.GetEntrypoint(), signal) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isCorrelated returns whether the filter ties events to one workflow
+func isCorrelated(eventFilter *model.EventFilter) bool {
+
+	for _, correlation := range eventFilter.Correlate {
+		if len(correlation.Expect) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchesEvents returns whether the event matches one of the strategy's
+// filters, such as a workflow's schedule.on events
+func (r *ResumableWorkflowRunner) MatchesEvents(to *model.EventConsumptionStrategy, signal cloudevents.Event) bool {
+
+	if to == nil {
+		return false
+	}
+
+	for _, eventFilter := range getListenFilters(to) {
+		if r.evaluateListenFilter(eventFilter, signal) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getListenFilters returns every event filter a listen task consumes
+func getListenFilters(to *model.EventConsumptionStrategy) []*model.EventFilter {
+
+	var filters []*model.EventFilter
+
+	if to.One != nil {
+		filters = append(filters, to.One)
+	}
+
+	filters = append(filters, to.Any...)
+	filters = append(filters, to.All...)
+
+	if to.Until != nil && to.Until.Strategy != nil {
+		filters = append(filters, getListenFilters(to.Until.Strategy)...)
+	}
+
+	return filters
+}
+
+// evaluateCorrelation checks the event against the filter's correlate
+// keys. Each key's from expression is evaluated against the event and
+// compared to its expect value, which may be an expression against the
+// workflow. A key without an expect value only has to be present, and an
+// expect value that evaluates to null never matches.
+func (r *ResumableWorkflowRunner) evaluateCorrelation(
+	correlate map[string]model.Correlation,
+	signal cloudevents.Event,
+) bool {
+
+	if len(correlate) == 0 {
+		return true
+	}
+
+	var event map[string]any
+	if err := common.ConvertInterfaceToInterface(signal, &event); err != nil {
+		logrus.WithError(err).Warn("Failed to convert event for correlation")
+		return false
+	}
+
+	workflowTask := r.GetWorkflowTask()
+
+	for _, key := range slices.Sorted(maps.Keys(correlate)) {
+
+		correlation := correlate[key]
+
+		value, err := workflowTask.TraverseAndEvaluate(model.NormalizeExpr(correlation.From), event)
+		if err != nil || value == nil {
+			logrus.WithFields(logrus.Fields{
+				"key":  key,
+				"from": correlation.From,
+			}).WithError(err).Debug("Event does not have the correlation value")
+			return false
+		}
+
+		if len(correlation.Expect) == 0 {
+			continue
+		}
+
+		expected, err := workflowTask.TraverseAndEvaluate(correlation.Expect, workflowTask.GetInput())
+		if err != nil {
+			logrus.WithError(err).WithField("key", key).Warn("Failed to evaluate expected correlation value")
+			return false
+		}
+
+		if !correlationValuesEqual(value, expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// correlationValuesEqual compares correlation values by type. Numbers are
+// compared by value as events and contexts decode them differently.
+func correlationValuesEqual(value any, expected any) bool {
+
+	if value == nil || expected == nil {
+		return false
+	}
+
+	valueNumber, valueIsNumber := asCorrelationNumber(value)
+	expectedNumber, expectedIsNumber := asCorrelationNumber(expected)
+
+	if valueIsNumber || expectedIsNumber {
+		return valueIsNumber && expectedIsNumber && valueNumber == expectedNumber
+	}
+
+	return reflect.DeepEqual(value, expected)
+}
+
+func asCorrelationNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTicketEvent(t *testing.T, eventType string, data map[string]any) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID("event-1")
+	event.SetSource("https://tickets.example.com")
+	event.SetType(eventType)
+	require.NoError(t, event.SetData(cloudevents.ApplicationJSON, data))
+	return event
+}
+
+func TestMatchesListenTask(t *testing.T) {
+
+	runner, err := NewDefaultRunner(loadTestWorkflow(t, "./testdata/listen_correlate.yaml"))
+	require.NoError(t, err)
+
+	runner.GetWorkflowTask().WorkflowID = "req-42"
+
+	tests := []struct {
+		name     string
+		taskName string
+		event    cloudevents.Event
+		expected bool
+	}{
+		{
+			name:     "correlated",
+			taskName: "waitForTicket",
+			event:    newTestTicketEvent(t, "com.example.ticket.approved", map[string]any{"request_id": "req-42"}),
+			expected: true,
+		},
+		{
+			name:     "another request",
+			taskName: "waitForTicket",
+			event:    newTestTicketEvent(t, "com.example.ticket.approved", map[string]any{"request_id": "req-7"}),
+			expected: false,
+		},
+		{
+			name:     "missing correlation value",
+			taskName: "waitForTicket",
+			event:    newTestTicketEvent(t, "com.example.ticket.approved", map[string]any{}),
+			expected: false,
+		},
+		{
+			// Filters that don't tie the event to the workflow never match
+			name:     "present without expect",
+			taskName: "waitForTicket",
+			event:    newTestTicketEvent(t, "com.example.ticket.cancelled", map[string]any{"request_id": "req-42"}),
+			expected: false,
+		},
+		{
+			name:     "no correlate",
+			taskName: "waitForTicket",
+			event:    newTestTicketEvent(t, "com.example.ticket.commented", map[string]any{"request_id": "req-42"}),
+			expected: false,
+		},
+		{
+			name:     "another type",
+			taskName: "waitForTicket",
+			event:    newTestTicketEvent(t, "com.example.ticket.created", map[string]any{"request_id": "req-42"}),
+			expected: false,
+		},
+		{
+			name:     "not a listen task",
+			taskName: "prepare",
+			event:    newTestTicketEvent(t, "com.example.ticket.approved", map[string]any{"request_id": "req-42"}),
+			expected: false,
+		},
+		{
+			name:     "unknown task",
+			taskName: "missing",
+			event:    newTestTicketEvent(t, "com.example.ticket.approved", map[string]any{"request_id": "req-42"}),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, runner.MatchesListenTask(tt.taskName, tt.event))
+		})
+	}
+}
+
+func TestCorrelationValuesEqual(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		value    any
+		expected any
+		equal    bool
+	}{
+		{name: "strings", value: "req-42", expected: "req-42", equal: true},
+		{name: "different strings", value: "req-42", expected: "req-7", equal: false},
+		{name: "numbers", value: float64(42), expected: 42, equal: true},
+		{name: "number and string", value: float64(42), expected: "42", equal: false},
+		{name: "nil expect", value: "<nil>", expected: nil, equal: false},
+		{name: "nil value", value: nil, expected: nil, equal: false},
+		{name: "booleans", value: true, expected: true, equal: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, correlationValuesEqual(tt.value, tt.expected))
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -99,6 +100,14 @@ func (d *ResumableWorkflowRunner) resumeTasks(
 		}
 
 		if output, err = d.runTaskItem(currentTask, input); err != nil {
+
+			// Stateless workflows resume from the root task that suspended them
+			if errors.Is(err, ErrorAwaitSignal) && !taskSupport.HasTemporalContext() && taskList == d.GetTaskList() {
+				taskSupport.SetTaskStatus(currentTask.Key, swctx.WaitingStatus)
+				taskSupport.SetEntrypoint(currentTask.Key)
+				return output, err
+			}
+
 			taskSupport.SetTaskStatus(currentTask.Key, swctx.FaultedStatus)
 			return output, err
 		}
@@ -144,7 +153,20 @@ func (d *ResumableWorkflowRunner) runTaskItem(
 		}
 	}
 
-	output, err = d.executeTask(task, input)
+	timeout, err := d.getTimeout(task.GetBase().Timeout)
+
+	if err != nil {
+
+		logrus.WithFields(logrus.Fields{
+			"task": taskName,
+		}).WithError(err).Error("Failed to resolve task timeout")
+
+		return nil, err
+	}
+
+	output, err = d.runWithTimeout(taskSupport.GetTaskReference(), timeout, func() (any, error) {
+		return d.executeTask(task, input)
+	})
 
 	if err != nil {
 
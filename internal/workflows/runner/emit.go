@@ -3,17 +3,35 @@ package runner
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
 	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/models"
+	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
 )
 
-var ErrorEmitUnsupported = fmt.Errorf("emit task is only supported in temporal workflows")
-
-// executeEmitTask handles event emission according to the serverless workflow spec
+var ErrorEmitUnsupported = fmt.Errorf("emit task requires a temporal workflow or an event sink for the event type")
+
+/*
+Emit tasks publish a CloudEvent. In Temporal workflows the event is
+signalled to the current workflow so its listen tasks can consume it.
+In both modes the event is also published to every configured sink that
+routes its type, such as a SIEM or ticketing system.
+
+	workflows:
+	  events:
+	    sinks:
+	      - name: siem
+	        type: http
+	        endpoint: https://siem.example.com/events
+	        mode: structured
+	        types:
+	          - com.thand.*
+*/
 func (r *ResumableWorkflowRunner) executeEmitTask(
 	taskName string,
 	emit *model.EmitTask,
@@ -28,13 +46,15 @@ func (r *ResumableWorkflowRunner) executeEmitTask(
 		return nil, fmt.Errorf("workflow task is not set")
 	}
 
-	if workflowTask.HasTemporalContext() {
+	// Create the cloud event based on the emit task specification
+	event, err := r.createCloudEventFromEmit(emit, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cloud event: %w", err)
+	}
 
-		// Create the cloud event based on the emit task specification
-		event, err := r.createCloudEventFromEmit(emit, input)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create cloud event: %w", err)
-		}
+	sinks := r.config.Workflows.Events.GetSinksForEvent(event.Type())
+
+	if workflowTask.HasTemporalContext() {
 
 		// Get temporal context
 		ctx := workflowTask.GetTemporalContext()
@@ -42,6 +62,18 @@ func (r *ResumableWorkflowRunner) executeEmitTask(
 			return nil, fmt.Errorf("failed to get temporal context")
 		}
 
+		// The ID has to be the same when the workflow is replayed
+		if len(event.ID()) == 0 {
+			var eventID string
+			err := workflow.SideEffect(ctx, func(ctx workflow.Context) any {
+				return uuid.NewString()
+			}).Get(&eventID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create event id: %w", err)
+			}
+			event.SetID(eventID)
+		}
+
 		// Send the signal to the current workflow using the event signal channel
 		workflow.SignalExternalWorkflow(
 			ctx,
@@ -51,23 +83,68 @@ func (r *ResumableWorkflowRunner) executeEmitTask(
 			event,
 		)
 
+		serviceClient := r.config.GetServices()
+
+		if len(sinks) > 0 && serviceClient.HasTemporal() {
+
+			// Sinks can dedupe retried deliveries on the event ID
+			activityOptions := workflow.ActivityOptions{
+				TaskQueue:           serviceClient.GetTemporal().GetTaskQueue(),
+				StartToCloseTimeout: time.Duration(len(sinks)) * time.Minute,
+				RetryPolicy: &temporal.RetryPolicy{
+					MaximumAttempts: 3,
+				},
+			}
+
+			fut := workflow.ExecuteActivity(
+				workflow.WithActivityOptions(ctx, activityOptions),
+				models.TemporalEmitActivityName,
+				*event,
+			)
+
+			if err := fut.Get(ctx, nil); err != nil {
+				return nil, fmt.Errorf("emit activity failed: %w", err)
+			}
+		}
+
 		logrus.WithFields(logrus.Fields{
 			"taskName":    taskName,
 			"eventType":   event.Type(),
 			"eventSource": event.Source(),
+			"sinks":       len(sinks),
 		}).Info("Successfully emitted event")
 
 		// Return the event as output for potential use by next tasks
 		return event, nil
 
-	} else {
+	}
+
+	if len(sinks) == 0 {
 
 		logrus.WithFields(logrus.Fields{
-			"taskName": taskName,
-		}).Info("Not a Temporal workflow, emit task is not supported")
+			"taskName":  taskName,
+			"eventType": event.Type(),
+		}).Info("Not a Temporal workflow and no sink routes the event")
 
 		return nil, ErrorEmitUnsupported
 	}
+
+	if len(event.ID()) == 0 {
+		event.SetID(uuid.NewString())
+	}
+
+	if err := PublishEvent(r.GetContext(), sinks, *event); err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"taskName":    taskName,
+		"eventType":   event.Type(),
+		"eventSource": event.Source(),
+		"sinks":       len(sinks),
+	}).Info("Successfully emitted event")
+
+	return event, nil
 }
 
 // createCloudEventFromEmit creates a CloudEvent from the emit task specification
@@ -1,10 +1,23 @@
 package runner
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/thand-io/agent/internal/config"
 	"github.com/thand-io/agent/internal/models"
 	"github.com/thand-io/agent/internal/workflows/functions"
@@ -151,3 +164,287 @@ func TestCreateCloudEventFromEmit_MissingRequiredFields(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "type is required")
 }
+
+func newTestEmitTask(eventType string) *model.EmitTask {
+	return &model.EmitTask{
+		Emit: model.EmitTaskConfiguration{
+			Event: model.EmitEventDefinition{
+				With: &model.EventProperties{
+					Source: &model.URITemplateOrRuntimeExpr{
+						Value: "https://thand.io/workflows",
+					},
+					Type: eventType,
+				},
+			},
+		},
+	}
+}
+
+func TestExecuteEmitTask_Sinks(t *testing.T) {
+
+	var requests []*http.Request
+	var bodies []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.Workflows.Events.Sinks = []config.WorkflowEventSinkConfig{
+		{
+			Name:     "siem",
+			Type:     "http",
+			Endpoint: server.URL + "/siem",
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+			Types:    []string{"com.thand.*"},
+		},
+		{
+			Name:     "ticketing",
+			Endpoint: server.URL + "/tickets",
+			Mode:     EventModeStructured,
+			Types:    []string{"com.thand.elevation.approved"},
+		},
+	}
+
+	runner := &ResumableWorkflowRunner{
+		config:       cfg,
+		functions:    functions.NewFunctionRegistry(cfg),
+		workflowTask: &models.WorkflowTask{WorkflowID: "test-workflow"},
+	}
+
+	t.Run("routes by type", func(t *testing.T) {
+
+		requests, bodies = nil, nil
+
+		result, err := runner.executeEmitTask("notify", newTestEmitTask("com.thand.elevation.approved"),
+			map[string]any{"user": "alice"})
+
+		require.NoError(t, err)
+		require.Len(t, requests, 2)
+
+		event := result.(*cloudevents.Event)
+		assert.NotEmpty(t, event.ID())
+
+		// Binary mode has the attributes as headers and the data as the body
+		assert.Equal(t, "/siem", requests[0].URL.Path)
+		assert.Equal(t, "com.thand.elevation.approved", requests[0].Header.Get("ce-type"))
+		assert.Equal(t, event.ID(), requests[0].Header.Get("ce-id"))
+		assert.Equal(t, "Bearer token", requests[0].Header.Get("Authorization"))
+		assert.Equal(t, map[string]any{"user": "alice"}, bodies[0])
+
+		// Structured mode has the whole event as the body
+		assert.Equal(t, "/tickets", requests[1].URL.Path)
+		assert.Equal(t, "application/cloudevents+json", requests[1].Header.Get("Content-Type"))
+		assert.Equal(t, "com.thand.elevation.approved", bodies[1]["type"])
+		assert.Equal(t, map[string]any{"user": "alice"}, bodies[1]["data"])
+	})
+
+	t.Run("only matching sinks", func(t *testing.T) {
+
+		requests, bodies = nil, nil
+
+		_, err := runner.executeEmitTask("notify", newTestEmitTask("com.thand.elevation.revoked"), nil)
+
+		require.NoError(t, err)
+		require.Len(t, requests, 1)
+		assert.Equal(t, "/siem", requests[0].URL.Path)
+	})
+
+	t.Run("no matching sinks", func(t *testing.T) {
+
+		requests, bodies = nil, nil
+
+		_, err := runner.executeEmitTask("notify", newTestEmitTask("com.example.other"), nil)
+
+		assert.Equal(t, ErrorEmitUnsupported, err)
+		assert.Empty(t, requests)
+	})
+}
+
+type mockEventSink struct {
+	sinks  []config.WorkflowEventSinkConfig
+	events []cloudevents.Event
+}
+
+func (m *mockEventSink) Send(ctx context.Context, sink config.WorkflowEventSinkConfig, event cloudevents.Event) error {
+	m.sinks = append(m.sinks, sink)
+	m.events = append(m.events, event)
+	return nil
+}
+
+func TestPublishEvent_Brokers(t *testing.T) {
+
+	event := cloudevents.NewEvent()
+	event.SetID("event-1")
+	event.SetSource("https://thand.io/workflows")
+	event.SetType("com.thand.elevation.approved")
+
+	sinks := []config.WorkflowEventSinkConfig{
+		{Name: "stream", Type: "kafka", Endpoint: "localhost:9092", Topic: "thand.events"},
+	}
+
+	err := PublishEvent(context.Background(), sinks, event)
+	assert.ErrorContains(t, err, "sink stream: unsupported event sink type: kafka")
+
+	mock := &mockEventSink{}
+
+	RegisterEventSink("kafka", mock)
+	t.Cleanup(func() {
+		eventSinksMu.Lock()
+		delete(eventSinks, "kafka")
+		eventSinksMu.Unlock()
+	})
+
+	err = PublishEvent(context.Background(), sinks, event)
+	require.NoError(t, err)
+
+	require.Len(t, mock.events, 1)
+	assert.Equal(t, "event-1", mock.events[0].ID())
+	assert.Equal(t, "thand.events", mock.sinks[0].Topic)
+}
+
+// natsMessage is a message published to the fake NATS server
+type natsMessage struct {
+	subject string
+	headers string
+	data    string
+}
+
+// startNatsServer accepts one client and records what it publishes
+func startNatsServer(t *testing.T) (string, <-chan natsMessage) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan natsMessage, 1)
+
+	go func() {
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\",\"version\":\"2.10.0\",\"proto\":1,\"headers\":true,\"max_payload\":1048576}\r\n")
+
+		reader := bufio.NewReader(conn)
+
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) == 0 {
+				continue
+			}
+
+			switch strings.ToUpper(fields[0]) {
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "HPUB":
+				headerLen, _ := strconv.Atoi(fields[2])
+				totalLen, _ := strconv.Atoi(fields[3])
+
+				payload := make([]byte, totalLen+2)
+				if _, err := io.ReadFull(reader, payload); err != nil {
+					return
+				}
+
+				messages <- natsMessage{
+					subject: fields[1],
+					headers: string(payload[:headerLen]),
+					data:    string(payload[headerLen:totalLen]),
+				}
+			}
+		}
+	}()
+
+	return "nats://" + listener.Addr().String(), messages
+}
+
+func TestPublishEvent_Nats(t *testing.T) {
+
+	endpoint, messages := startNatsServer(t)
+
+	event := cloudevents.NewEvent()
+	event.SetID("event-1")
+	event.SetSource("https://thand.io/workflows")
+	event.SetType("com.thand.elevation.approved")
+	event.SetExtension("workflowid", "workflow-1")
+	require.NoError(t, event.SetData(cloudevents.ApplicationJSON, map[string]any{"user": "alice"}))
+
+	sinks := []config.WorkflowEventSinkConfig{
+		{Name: "stream", Type: "nats", Endpoint: endpoint, Topic: "thand.events", Headers: map[string]string{"X-Team": "security"}},
+	}
+
+	err := PublishEvent(context.Background(), sinks, event)
+	require.NoError(t, err)
+
+	select {
+	case msg := <-messages:
+		assert.Equal(t, "thand.events", msg.subject)
+		assert.Contains(t, msg.headers, "ce-id: event-1\r\n")
+		assert.Contains(t, msg.headers, "ce-type: com.thand.elevation.approved\r\n")
+		assert.Contains(t, msg.headers, "ce-workflowid: workflow-1\r\n")
+		assert.Contains(t, msg.headers, "content-type: application/json\r\n")
+		assert.Contains(t, msg.headers, "X-Team: security\r\n")
+		assert.JSONEq(t, `{"user":"alice"}`, msg.data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not published")
+	}
+}
+
+func TestNewNatsEventMessage_Structured(t *testing.T) {
+
+	event := cloudevents.NewEvent()
+	event.SetID("event-1")
+	event.SetSource("https://thand.io/workflows")
+	event.SetType("com.thand.elevation.approved")
+
+	sink := config.WorkflowEventSinkConfig{Type: "nats", Topic: "thand.events", Mode: EventModeStructured}
+
+	msg, err := newNatsEventMessage(sink, event)
+	require.NoError(t, err)
+
+	assert.Equal(t, "thand.events", msg.Subject)
+	assert.Equal(t, cloudevents.ApplicationCloudEventsJSON, msg.Header.Get("content-type"))
+	assert.Empty(t, msg.Header.Get("ce-id"))
+
+	var decoded cloudevents.Event
+	require.NoError(t, json.Unmarshal(msg.Data, &decoded))
+	assert.Equal(t, "event-1", decoded.ID())
+
+	sink.Mode = "unknown"
+	_, err = newNatsEventMessage(sink, event)
+	assert.ErrorContains(t, err, "unsupported content mode: unknown")
+}
+
+func TestWorkflowEventSinkConfig_Routes(t *testing.T) {
+
+	tests := []struct {
+		types     []string
+		eventType string
+		expected  bool
+	}{
+		{types: nil, eventType: "com.thand.approval", expected: true},
+		{types: []string{"*"}, eventType: "com.thand.approval", expected: true},
+		{types: []string{"com.thand.*"}, eventType: "com.thand.approval", expected: true},
+		{types: []string{"com.thand.*"}, eventType: "com.example.approval", expected: false},
+		{types: []string{"com.thand.approval"}, eventType: "com.thand.approval", expected: true},
+		{types: []string{"com.thand.approval"}, eventType: "com.thand.approval.v2", expected: false},
+	}
+
+	for _, tt := range tests {
+		sink := config.WorkflowEventSinkConfig{Types: tt.types}
+		assert.Equal(t, tt.expected, sink.Routes(tt.eventType), "%v routes %s", tt.types, tt.eventType)
+	}
+}
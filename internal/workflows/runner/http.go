@@ -1,6 +1,7 @@
 package runner
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"regexp"
@@ -85,14 +86,23 @@ func (r *ResumableWorkflowRunner) executeHttpFunction(
 
 	}
 
+	httpCall, err := r.getEndpointAuthentication(httpCall)
+
+	if err != nil {
+		return nil, err
+	}
+
 	if workflowTask.HasTemporalContext() {
 
-		// Execute the HTTP request within a Temporal activity
+		// Execute the HTTP request within a Temporal activity. Only the
+		// secret names are passed so the activity resolves them itself
+		// and they stay out of the workflow history.
 		fut := workflow.ExecuteActivity(
 			workflowTask.GetTemporalContext(),
 			models.TemporalHttpActivityName,
 			httpCall,
 			finalURL,
+			r.getSecretNames(),
 		)
 
 		var result any
@@ -104,20 +114,58 @@ func (r *ResumableWorkflowRunner) executeHttpFunction(
 
 	} else {
 
-		return MakeHttpRequest(httpCall, finalURL)
+		secrets, err := r.loadSecrets()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return MakeHttpRequestWithSecrets(r.GetContext(), httpCall, finalURL, secrets)
+
+	}
+
+}
+
+// MakeHttpRequestWithSecrets fills in the call's secrets and resolves its
+// authentication before making it, then masks the secrets in the result
+func MakeHttpRequestWithSecrets(
+	ctx context.Context,
+	httpCall model.HTTPArguments,
+	finalURL string,
+	secrets map[string]any,
+) (any, error) {
+
+	httpCall, err := FillSecrets(httpCall, secrets)
+	if err != nil {
+		return nil, err
+	}
 
+	finalURL, err = FillSecrets(finalURL, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	httpCall, err = ResolveHttpAuthentication(ctx, httpCall, secrets)
+	if err != nil {
+		return RedactSecrets(nil, err, secrets)
 	}
 
+	result, err := MakeHttpRequest(ctx, httpCall, finalURL)
+
+	return RedactSecrets(result, err, secrets)
 }
 
-func MakeHttpRequest(httpCall model.HTTPArguments, finalURL string) (any, error) {
+func MakeHttpRequest(ctx context.Context, httpCall model.HTTPArguments, finalURL string) (any, error) {
 
 	builder, err := common.CreateRequestBuilderFromEndpoint(&httpCall)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute HTTP call for %s: %w", httpCall, err)
+		// The call isn't included as it may hold resolved secrets
+		return nil, fmt.Errorf("failed to execute HTTP call for %s: %w", finalURL, err)
 	}
 
+	builder.SetContext(ctx)
+
 	res, err := common.MakeRequestFromBuilder(builder, httpCall.Method, finalURL)
 
 	if err != nil {
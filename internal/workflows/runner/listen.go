@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
+	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
 	"github.com/serverlessworkflow/sdk-go/v3/model"
 	"github.com/sirupsen/logrus"
 	"github.com/thand-io/agent/internal/common"
@@ -29,6 +30,16 @@ func (r *ResumableWorkflowRunner) executeListenTask(
 
 		cancelCtx := workflowTask.GetTemporalContext()
 
+		// Lets the events endpoint find workflows waiting on this task
+		err := r.updateTemporalSearchAttributes(&model.TaskItem{
+			Key:  taskName,
+			Task: listen,
+		}, swctx.WaitingStatus)
+
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to update temporal search attributes")
+		}
+
 		resumeChan := workflow.GetSignalChannel(cancelCtx, models.TemporalResumeSignalName)
 		signalChan := workflow.GetSignalChannel(cancelCtx, models.TemporalEventSignalName)
 
@@ -214,6 +225,18 @@ func (r *ResumableWorkflowRunner) handleListenTask(
 		return nil, fmt.Errorf("to in listener not defined")
 	}
 
+	// However the approval arrived, it must be from someone who can
+	// approve the request. Anything else is ignored.
+	if err := r.validateApprovalEvent(signal); err != nil {
+
+		logrus.WithFields(logrus.Fields{
+			"taskName": taskName,
+			"eventId":  signal.ID(),
+		}).WithError(err).Warn("Ignoring approval event")
+
+		return nil, nil
+	}
+
 	oneListener := listen.Listen.To.One
 	anyListener := listen.Listen.To.Any
 	untilListener := listen.Listen.To.Until
@@ -303,7 +326,8 @@ func (r *ResumableWorkflowRunner) evaluateListenFilter(eventFilter *model.EventF
 
 	if eventFilter.With != nil {
 
-		return r.evaluateListenEvent(eventFilter.With, signal)
+		return r.evaluateListenEvent(eventFilter.With, signal) &&
+			r.evaluateCorrelation(eventFilter.Correlate, signal)
 
 	}
 
@@ -335,3 +359,37 @@ func (r *ResumableWorkflowRunner) evaluateAllListener(allListener []*model.Event
 
 	return true
 }
+
+// validateApprovalEvent checks an approval was made by someone other than
+// the requester or the identities that receive the role. Approvals are
+// only checked by servers, as agents have no signed in approver.
+func (r *ResumableWorkflowRunner) validateApprovalEvent(signal cloudevents.Event) error {
+
+	if signal.Type() != models.ApprovalEventType || !r.config.IsServer() {
+		return nil
+	}
+
+	elevateRequest, err := r.GetWorkflowTask().GetContextAsElevationRequest()
+
+	// Only elevations have requesters to check
+	if err != nil || !elevateRequest.IsValid() {
+		return nil
+	}
+
+	var data map[string]any
+	if err := signal.DataAs(&data); err != nil {
+		return fmt.Errorf("failed to read approval: %w", err)
+	}
+
+	approver, _ := data["user"].(string)
+
+	if len(approver) == 0 {
+		return fmt.Errorf("approval does not say who approved it")
+	}
+
+	if elevateRequest.IsBeneficiary(approver) {
+		return fmt.Errorf("%s cannot approve a request they made or benefit from", approver)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thand-io/agent/internal/config"
+	"github.com/thand-io/agent/internal/models"
+)
+
+func TestValidateApprovalEvent(t *testing.T) {
+
+	runner, err := NewDefaultRunner(loadUnvalidatedTestWorkflow(t, "./testdata/timeout_listen.yaml"))
+	require.NoError(t, err)
+
+	runner.config.SetMode(config.ModeServer)
+
+	runner.GetWorkflowTask().SetContext(map[string]any{
+		"user":       map[string]any{"email": "alice@example.com", "name": "Alice"},
+		"role":       map[string]any{"name": "admin"},
+		"providers":  []any{"aws"},
+		"identities": []any{"bob@example.com"},
+		"reason":     "incident",
+		"duration":   "1h",
+	})
+
+	tests := []struct {
+		name  string
+		event string
+		user  string
+		err   string
+	}{
+		{name: "another approver", event: models.ApprovalEventType, user: "carol@example.com"},
+		{name: "requester", event: models.ApprovalEventType, user: "alice@example.com", err: "cannot approve"},
+		{name: "identity", event: models.ApprovalEventType, user: "BOB@example.com", err: "cannot approve"},
+		{name: "no approver", event: models.ApprovalEventType, err: "does not say who approved it"},
+		{name: "not an approval", event: "com.example.approval", user: "alice@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			event := newTestTicketEvent(t, tt.event, map[string]any{"approved": true, "user": tt.user})
+
+			err := runner.validateApprovalEvent(event)
+
+			if len(tt.err) > 0 {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
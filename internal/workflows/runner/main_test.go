@@ -0,0 +1,13 @@
+package runner
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Scripts re-execute the test binary as their sandbox
+	RunScriptSandbox()
+
+	os.Exit(m.Run())
+}
@@ -0,0 +1,244 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/thand-io/agent/internal/common"
+)
+
+/*
+OAuth2 and OpenID Connect authentication request a token with the client
+credentials or password grant and call the endpoint with it as a bearer
+token. The client id and secret are usually read from a secret:
+
+	use:
+	  secrets:
+	    - google_oauth2
+	  authentications:
+	    google_auth:
+	      oauth2:
+	        authority: https://accounts.google.com/o/oauth2
+	        endpoints:
+	          token: /token
+	        grant: client_credentials
+	        client:
+	          id: ${ $secrets.google_oauth2.client_id }
+	          secret: ${ $secrets.google_oauth2.client_secret }
+
+OpenID Connect finds the token endpoint in the authority's discovery
+document. A token is requested for each call.
+*/
+
+type oauth2TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type oidcDiscoveryDocument struct {
+	TokenEndpoint string `json:"token_endpoint"`
+}
+
+// requestOAuth2Token requests a token for an oauth2 policy
+func requestOAuth2Token(ctx context.Context, policy *model.OAuth2AuthenticationPolicy, secrets map[string]any) (string, error) {
+
+	properties, err := getOAuth2Properties(policy.Use, policy.Properties, secrets)
+	if err != nil {
+		return "", err
+	}
+
+	authority, err := getOAuth2Authority(properties, secrets)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := model.OAuth2DefaultTokenURI
+
+	if policy.Endpoints != nil && len(policy.Endpoints.Token) > 0 {
+		endpoint = policy.Endpoints.Token
+	}
+
+	return requestToken(ctx, resolveOAuth2URL(authority, endpoint), properties, secrets)
+}
+
+// requestOIDCToken requests a token for an openid connect policy
+func requestOIDCToken(ctx context.Context, policy *model.OpenIdConnectAuthenticationPolicy, secrets map[string]any) (string, error) {
+
+	properties, err := getOAuth2Properties(policy.Use, policy.Properties, secrets)
+	if err != nil {
+		return "", err
+	}
+
+	authority, err := getOAuth2Authority(properties, secrets)
+	if err != nil {
+		return "", err
+	}
+
+	discoveryURL := resolveOAuth2URL(authority, "/.well-known/openid-configuration")
+
+	var discovery oidcDiscoveryDocument
+
+	res, err := resty.New().R().
+		SetContext(ctx).
+		SetResult(&discovery).
+		Get(discoveryURL)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get discovery document from %s: %w", discoveryURL, err)
+	}
+
+	if res.IsError() || len(discovery.TokenEndpoint) == 0 {
+		return "", fmt.Errorf("discovery document from %s has no token endpoint: %s", discoveryURL, res.Status())
+	}
+
+	return requestToken(ctx, discovery.TokenEndpoint, properties, secrets)
+}
+
+// getOAuth2Properties returns the policy's properties, or reads them from
+// the secret the policy uses
+func getOAuth2Properties(
+	use string,
+	properties *model.OAuth2AuthenticationProperties,
+	secrets map[string]any,
+) (*model.OAuth2AuthenticationProperties, error) {
+
+	if len(use) == 0 {
+
+		if properties == nil {
+			return nil, fmt.Errorf("authority, grant and client are required")
+		}
+
+		return properties, nil
+	}
+
+	secret, found := secrets[use]
+	if !found {
+		return nil, fmt.Errorf("secret %s is not declared in use.secrets", use)
+	}
+
+	// The error isn't wrapped as it could quote the secret
+	var fromSecret model.OAuth2AuthenticationProperties
+	if err := common.ConvertInterfaceToInterface(secret, &fromSecret); err != nil {
+		return nil, fmt.Errorf("secret %s must hold the authority, grant and client", use)
+	}
+
+	return &fromSecret, nil
+}
+
+func getOAuth2Authority(properties *model.OAuth2AuthenticationProperties, secrets map[string]any) (string, error) {
+
+	if properties.Authority == nil || len(properties.Authority.String()) == 0 {
+		return "", fmt.Errorf("authority is required")
+	}
+
+	return evaluateSecretString(properties.Authority.String(), secrets)
+}
+
+// resolveOAuth2URL joins an endpoint to the authority unless it is absolute
+func resolveOAuth2URL(authority string, endpoint string) string {
+
+	if parsed, err := url.Parse(endpoint); err == nil && parsed.IsAbs() {
+		return endpoint
+	}
+
+	return strings.TrimSuffix(authority, "/") + "/" + strings.TrimPrefix(endpoint, "/")
+}
+
+// requestToken requests an access token from the token endpoint
+func requestToken(
+	ctx context.Context,
+	tokenURL string,
+	properties *model.OAuth2AuthenticationProperties,
+	secrets map[string]any,
+) (string, error) {
+
+	grant := properties.Grant
+
+	if len(grant) == 0 {
+		grant = model.ClientCredentialsGrant
+	}
+
+	params := map[string]string{
+		"grant_type": string(grant),
+	}
+
+	switch grant {
+	case model.ClientCredentialsGrant:
+
+		if properties.Client == nil {
+			return "", fmt.Errorf("client is required for the %s grant", grant)
+		}
+
+	case model.PasswordGrant:
+
+		username, password, err := resolveCredentials("", properties.Username, properties.Password, secrets)
+		if err != nil {
+			return "", err
+		}
+
+		params["username"] = username
+		params["password"] = password
+
+	default:
+		return "", fmt.Errorf("unsupported grant: %s, use client_credentials or password", grant)
+	}
+
+	if len(properties.Scopes) > 0 {
+		params["scope"] = strings.Join(properties.Scopes, " ")
+	}
+
+	if len(properties.Audiences) > 0 {
+		params["audience"] = strings.Join(properties.Audiences, " ")
+	}
+
+	request := resty.New().R().SetContext(ctx)
+
+	if properties.Client != nil {
+
+		clientID, clientSecret, err := resolveCredentials("", properties.Client.ID, properties.Client.Secret, secrets)
+		if err != nil {
+			return "", err
+		}
+
+		switch properties.Client.Authentication {
+		case model.OAuthClientAuthClientSecretPost, "":
+			params["client_id"] = clientID
+			params["client_secret"] = clientSecret
+		case model.OAuthClientAuthClientSecretBasic:
+			request.SetBasicAuth(clientID, clientSecret)
+		case model.OAuthClientAuthNone:
+			params["client_id"] = clientID
+		default:
+			return "", fmt.Errorf("unsupported client authentication: %s", properties.Client.Authentication)
+		}
+	}
+
+	if properties.Request != nil && properties.Request.Encoding == model.EncodingTypeApplicationJson {
+		request.SetHeader("Content-Type", string(model.EncodingTypeApplicationJson)).SetBody(params)
+	} else {
+		request.SetFormData(params)
+	}
+
+	var token oauth2TokenResponse
+
+	res, err := request.
+		SetResult(&token).
+		SetError(&token).
+		Post(tokenURL)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to request token from %s: %w", tokenURL, err)
+	}
+
+	if res.IsError() || len(token.AccessToken) == 0 {
+		return "", fmt.Errorf("token request to %s failed: %s %s %s",
+			tokenURL, res.Status(), token.Error, token.ErrorDescription)
+	}
+
+	return token.AccessToken, nil
+}
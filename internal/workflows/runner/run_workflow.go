@@ -0,0 +1,224 @@
+package runner
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
+	utils "github.com/serverlessworkflow/sdk-go/v3/impl/utils"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/sirupsen/logrus"
+	models "github.com/thand-io/agent/internal/models"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/workflow"
+)
+
+// How deep run.workflow tasks can nest before the run is stopped
+const maxNestedWorkflowDepth = 10
+
+/*
+A task used to run another configured workflow as a sub-flow. This lets
+common steps such as a two person approval be defined once and shared
+by many role workflows.
+
+The workflow is found by its document namespace, name and version, or
+by the name it is configured under. The evaluated input is passed to the
+nested workflow, or the task input if none is given, and its output
+becomes the task output. The nested workflow shares the parent's
+$context so it can see the user, role and request.
+
+Under Temporal the nested workflow runs as a child workflow with its own
+history and is cancelled with its parent. Otherwise it runs inline, and
+if it waits or listens the parent is suspended with it so both can be
+resumed from the durable store.
+
+	do:
+	  - approve:
+	      run:
+	        workflow:
+	          namespace: thand
+	          name: two-person-approval
+	          version: '1.0.0'
+	          input:
+	            approvers: ${ .approvers }
+*/
+func (r *ResumableWorkflowRunner) executeWorkflowProcess(
+	taskName string,
+	runWorkflow *model.RunWorkflow,
+	input any,
+) (any, error) {
+
+	if runWorkflow == nil {
+		return nil, fmt.Errorf("workflow process is nil")
+	}
+
+	workflowTask := r.GetWorkflowTask()
+
+	if workflowTask == nil {
+		return nil, fmt.Errorf("workflow task is not set")
+	}
+
+	workflowConfig := r.config.GetWorkflows()
+
+	workflowKey, nestedWorkflow, err := workflowConfig.GetWorkflowByReference(
+		runWorkflow.Namespace,
+		runWorkflow.Name,
+		runWorkflow.Version,
+	)
+
+	if err != nil {
+		return nil, model.NewErrRuntime(fmt.Errorf("failed to resolve nested workflow: %w", err), taskName)
+	}
+
+	// Stop workflows from calling themselves, directly or through others
+	callers := append(slices.Clone(workflowTask.Callers), workflowTask.WorkflowName)
+
+	if slices.Contains(callers, workflowKey) {
+		return nil, model.NewErrRuntime(fmt.Errorf(
+			"nested workflow %s is already running in %v", workflowKey, callers), taskName)
+	}
+
+	if len(callers) > maxNestedWorkflowDepth {
+		return nil, model.NewErrRuntime(fmt.Errorf(
+			"nested workflows are limited to a depth of %d", maxNestedWorkflowDepth), taskName)
+	}
+
+	nestedInput := input
+
+	if runWorkflow.Input != nil {
+
+		evaluated, err := workflowTask.TraverseAndEvaluate(runWorkflow.Input, input)
+		if err != nil {
+			return nil, model.NewErrRuntime(fmt.Errorf("failed to evaluate nested workflow input: %w", err), taskName)
+		}
+
+		nestedInput = evaluated
+	}
+
+	nestedTask, err := models.NewWorkflowContext(nestedWorkflow)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nested workflow context: %w", err)
+	}
+
+	nestedTask.WorkflowID, err = newNestedWorkflowID(workflowTask, taskName)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nested workflow ID: %w", err)
+	}
+
+	nestedTask.WorkflowName = workflowKey
+	nestedTask.Callers = callers
+	nestedTask.SetContext(utils.DeepCloneValue(workflowTask.Context))
+
+	logrus.WithFields(logrus.Fields{
+		"task":        taskName,
+		"workflow":    workflowKey,
+		"workflow_id": nestedTask.WorkflowID,
+		"depth":       len(callers),
+	}).Info("Running nested workflow")
+
+	serviceClient := r.config.GetServices()
+
+	if workflowTask.HasTemporalContext() && serviceClient.HasTemporal() {
+
+		nestedTask.SetInput(nestedInput)
+
+		// The child keeps its own history and is cancelled with the parent
+		childOptions := workflow.ChildWorkflowOptions{
+			WorkflowID:          nestedTask.WorkflowID,
+			TaskQueue:           serviceClient.GetTemporal().GetTaskQueue(),
+			ParentClosePolicy:   enumspb.PARENT_CLOSE_POLICY_REQUEST_CANCEL,
+			WaitForCancellation: true,
+		}
+
+		ctx := workflow.WithChildOptions(workflowTask.GetTemporalContext(), childOptions)
+
+		var result *models.WorkflowTask
+		err := workflow.ExecuteChildWorkflow(
+			ctx,
+			models.TemporalRunWorkflowName,
+			nestedTask,
+		).Get(ctx, &result)
+
+		if err != nil {
+			return nil, fmt.Errorf("nested workflow %s failed: %w", workflowKey, err)
+		}
+
+		if result == nil {
+			return nil, nil
+		}
+
+		return result.GetOutput(), nil
+	}
+
+	// A stateless nested workflow that waits suspends its parent. The
+	// nested workflow is kept with the parent and resumed with whatever
+	// the parent is resumed with.
+	reference := workflowTask.GetTaskReference()
+
+	if suspended := workflowTask.GetNested(reference); suspended != nil {
+
+		suspended.SetWorkflowDsl(nestedWorkflow.GetWorkflow())
+		if !suspended.HasState() {
+			suspended.ClearTaskContext()
+		}
+
+		workflowTask.SetWakeAt(nil)
+
+		nestedTask = suspended
+		nestedInput = input
+
+		logrus.WithFields(logrus.Fields{
+			"task":        taskName,
+			"workflow_id": nestedTask.WorkflowID,
+		}).Info("Resuming nested workflow")
+	}
+
+	nestedTask.SetInternalContext(workflowTask.GetContext())
+
+	output, err := NewResumableRunner(r.config, r.functions, nestedTask).Run(nestedInput)
+
+	if err != nil {
+		workflowTask.ClearNested(reference)
+		return nil, fmt.Errorf("nested workflow %s failed: %w", workflowKey, err)
+	}
+
+	if nestedTask.GetStatus() == swctx.WaitingStatus {
+
+		if workflowTask.HasTemporalContext() {
+			return nil, model.NewErrRuntime(fmt.Errorf(
+				"nested workflow %s is waiting for an event, which requires Temporal", workflowKey), taskName)
+		}
+
+		workflowTask.SetNested(reference, nestedTask)
+		workflowTask.SetWakeAt(nestedTask.GetWakeAt())
+
+		return nil, ErrorAwaitSignal
+	}
+
+	workflowTask.ClearNested(reference)
+
+	return output, nil
+}
+
+// newNestedWorkflowID returns a unique ID for a nested workflow. The same
+// task can run more than once, in a loop or on a retry, so the parent's ID
+// and task name are suffixed with a UUID. Under Temporal the UUID is a side
+// effect so replays reuse it.
+func newNestedWorkflowID(workflowTask *models.WorkflowTask, taskName string) (string, error) {
+
+	suffix := uuid.NewString()
+
+	if workflowTask.HasTemporalContext() {
+		encoded := workflow.SideEffect(workflowTask.GetTemporalContext(), func(ctx workflow.Context) any {
+			return uuid.NewString()
+		})
+		if err := encoded.Get(&suffix); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%s-%s-%s", workflowTask.WorkflowID, taskName, suffix), nil
+}
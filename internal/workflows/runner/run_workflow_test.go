@@ -0,0 +1,209 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/serverlessworkflow/sdk-go/v3/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thand-io/agent/internal/models"
+)
+
+func loadTestWorkflow(t *testing.T, workflowPath string) *model.Workflow {
+	yamlBytes, err := os.ReadFile(filepath.Clean(workflowPath))
+	require.NoError(t, err, "Failed to read workflow YAML file")
+
+	workflow, err := parser.FromYAMLSource(yamlBytes)
+	require.NoError(t, err, "Failed to parse workflow YAML")
+
+	return workflow
+}
+
+// newNestedWorkflowRunner creates a runner for the workflow with the
+// others configured so run.workflow tasks can find them
+func newNestedWorkflowRunner(t *testing.T, workflowPath string, definitions map[string]string) *ResumableWorkflowRunner {
+
+	runner, err := NewDefaultRunner(loadTestWorkflow(t, workflowPath))
+	require.NoError(t, err)
+
+	runner.config.Workflows.Definitions = map[string]models.Workflow{}
+
+	for name, path := range definitions {
+		runner.config.Workflows.Definitions[name] = models.Workflow{
+			Name:     name,
+			Workflow: loadTestWorkflow(t, path),
+			Enabled:  true,
+		}
+	}
+
+	return runner
+}
+
+func TestWorkflowRunner_RunWorkflow(t *testing.T) {
+
+	t.Run("runs the nested workflow inline", func(t *testing.T) {
+
+		runner := newNestedWorkflowRunner(t, "./testdata/run_workflow.yaml", map[string]string{
+			"approvals": "./testdata/run_workflow_child.yaml",
+		})
+
+		runner.GetWorkflowTask().SetContext(map[string]any{"role": "admin"})
+
+		output, err := runner.Run(map[string]any{
+			"approvers": []any{"alice", "bob", "carol"},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"message": "approved by alice and bob",
+		}, output)
+
+		// The parent's context is left untouched by the nested workflow
+		assert.Equal(t, map[string]any{"role": "admin"}, runner.GetWorkflowTask().Context)
+	})
+
+	t.Run("resolves by configured name", func(t *testing.T) {
+
+		runner := newNestedWorkflowRunner(t, "./testdata/run_workflow.yaml", map[string]string{
+			"two-person-approval": "./testdata/run_workflow_child.yaml",
+		})
+
+		// The document names no longer match so only the key can be used
+		definition := runner.config.Workflows.Definitions["two-person-approval"]
+		definition.Workflow.Document.Name = "approvals"
+
+		output, err := runner.Run(map[string]any{
+			"approvers": []any{"alice", "bob"},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"message": "approved by alice and bob",
+		}, output)
+	})
+
+	t.Run("configured name in another namespace", func(t *testing.T) {
+
+		runner := newNestedWorkflowRunner(t, "./testdata/run_workflow.yaml", map[string]string{
+			"two-person-approval": "./testdata/run_workflow_child.yaml",
+		})
+
+		definition := runner.config.Workflows.Definitions["two-person-approval"]
+		definition.Workflow.Document.Namespace = "shared"
+
+		_, err := runner.Run(map[string]any{"approvers": []any{"alice", "bob"}})
+
+		assert.ErrorContains(t, err, "workflow not found")
+	})
+
+	t.Run("workflow not found", func(t *testing.T) {
+
+		runner := newNestedWorkflowRunner(t, "./testdata/run_workflow.yaml", nil)
+
+		_, err := runner.Run(map[string]any{"approvers": []any{"alice"}})
+
+		assert.ErrorContains(t, err, "workflow not found")
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+
+		runner := newNestedWorkflowRunner(t, "./testdata/run_workflow.yaml", map[string]string{
+			"approvals": "./testdata/run_workflow_child.yaml",
+		})
+
+		definition := runner.config.Workflows.Definitions["approvals"]
+		definition.Workflow.Document.Version = "2.0.0"
+
+		_, err := runner.Run(map[string]any{"approvers": []any{"alice"}})
+
+		assert.ErrorContains(t, err, "workflow not found")
+	})
+
+	t.Run("recursion is stopped", func(t *testing.T) {
+
+		runner := newNestedWorkflowRunner(t, "./testdata/run_workflow_recursive.yaml", map[string]string{
+			"recursive": "./testdata/run_workflow_recursive.yaml",
+		})
+
+		_, err := runner.Run(map[string]any{})
+
+		assert.ErrorContains(t, err, "is already running")
+	})
+}
+
+func TestWorkflowRunner_RunWorkflowSuspended(t *testing.T) {
+
+	runner := newNestedWorkflowRunner(t, "./testdata/run_workflow_listen.yaml", map[string]string{
+		"await-approval": "./testdata/run_workflow_listen_child.yaml",
+	})
+
+	workflowTask := runner.GetWorkflowTask()
+
+	_, err := runner.Run(nil)
+	require.NoError(t, err)
+
+	// The parent waits with the nested workflow and wakes for its timeout
+	assert.Equal(t, swctx.WaitingStatus, workflowTask.GetStatus())
+	assert.Equal(t, "approve", workflowTask.GetEntrypoint())
+	assert.True(t, workflowTask.IsListening())
+
+	deadline := workflowTask.GetNextDeadline()
+	require.NotNil(t, deadline)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *deadline, time.Minute)
+
+	// Resume from the stored state as the durable store would
+	encoded, err := json.Marshal(workflowTask)
+	require.NoError(t, err)
+
+	var resumed models.WorkflowTask
+	require.NoError(t, json.Unmarshal(encoded, &resumed))
+
+	resumed.SetWorkflowDsl(workflowTask.GetWorkflowDef())
+	resumed.ClearTaskContext()
+	resumed.SetInternalContext(context.Background())
+
+	nested := resumed.GetNested("/do/0/approve")
+	require.NotNil(t, nested)
+
+	resumedRunner := NewResumableRunner(runner.config, runner.functions, &resumed)
+
+	// Events are matched against the nested workflow's listen task
+	other := newTestTicketEvent(t, "com.example.approval", map[string]any{"request_id": "req-7"})
+	assert.False(t, resumedRunner.MatchesListenTask(resumed.GetEntrypoint(), other))
+
+	// The nested workflow correlates on its own ID
+	approval := newTestTicketEvent(t, "com.example.approval", map[string]any{"request_id": nested.WorkflowID})
+	assert.True(t, resumedRunner.MatchesListenTask(resumed.GetEntrypoint(), approval))
+
+	output, err := resumedRunner.Run(approval)
+	require.NoError(t, err)
+
+	assert.Equal(t, swctx.CompletedStatus, resumed.GetStatus())
+	assert.Equal(t, map[string]any{"message": "request approved"}, output)
+	assert.Empty(t, resumed.Nested)
+	assert.Nil(t, resumed.GetNextDeadline())
+}
+
+func TestNewNestedWorkflowID(t *testing.T) {
+
+	workflowTask := &models.WorkflowTask{WorkflowID: "parent"}
+
+	first, err := newNestedWorkflowID(workflowTask, "approve")
+	require.NoError(t, err)
+
+	second, err := newNestedWorkflowID(workflowTask, "approve")
+	require.NoError(t, err)
+
+	// Running the same task again, such as in a loop, gets a new ID
+	assert.True(t, strings.HasPrefix(first, "parent-approve-"))
+	assert.NotEqual(t, first, second)
+}
@@ -109,6 +109,10 @@ func (wr *ResumableWorkflowRunner) Run(input any) (output any, err error) {
 
 	workflowTask.SetRawInput(input)
 
+	if err = wr.loadMaskedSecrets(); err != nil {
+		return nil, err
+	}
+
 	// Process input
 	if input, err = wr.processInput(input); err != nil {
 		return nil, err
@@ -137,11 +141,21 @@ func (wr *ResumableWorkflowRunner) Run(input any) (output any, err error) {
 
 	}
 
-	output, err = wr.resumeTaskList(
-		workflowTask.GetWorkflowDef().Do,
-		idx,
-		workflowTask.GetInput(),
-	)
+	timeout, err := wr.getTimeout(workflowTask.GetWorkflowDef().Timeout)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The workflow timeout covers every run of a stateless workflow
+	// from when it was first started
+	output, err = wr.runWithTimeout("/", timeout, func() (any, error) {
+		return wr.resumeTaskList(
+			workflowTask.GetWorkflowDef().Do,
+			idx,
+			workflowTask.GetInput(),
+		)
+	})
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,401 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/go-resty/resty/v2"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/models"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	defaultScriptTimeout       = 30 * time.Second
+	defaultScriptMemoryLimitMB = 64
+
+	// How often the heap is sampled to report scripts over the memory limit
+	scriptMemorySampleInterval = 10 * time.Millisecond
+)
+
+var scriptLanguages = []string{"js", "javascript"}
+
+var errScriptMemoryLimit = errors.New("script exceeded its memory limit")
+
+// ScriptOptions limits the sandbox a script executes in
+type ScriptOptions struct {
+	Timeout     time.Duration `json:"timeout"`
+	MemoryLimit uint64        `json:"memory_limit"` // Bytes the heap can grow by while the script runs
+}
+
+/*
+A task used to run JavaScript in an embedded sandbox.
+
+The code is the body of a function. The task input is available as
+input, the evaluated arguments as args and the environment as env. The
+returned value becomes the task output. Scripts have no filesystem,
+network, process or module access and are stopped once they exceed
+the configured timeout or memory limit.
+
+	do:
+	  - summarise:
+	      run:
+	        script:
+	          language: js
+	          arguments:
+	            threshold: ${ .threshold }
+	          code: |
+	            const risky = input.requests.filter(r => r.score > args.threshold);
+	            return { count: risky.length, names: risky.map(r => r.name) };
+*/
+func (r *ResumableWorkflowRunner) executeScriptProcess(
+	taskName string,
+	script *model.Script,
+	input any,
+) (any, error) {
+
+	if script == nil {
+		return nil, fmt.Errorf("script process is nil")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"task":     taskName,
+		"language": script.Language,
+	}).Info("Executing script")
+
+	workflowTask := r.GetWorkflowTask()
+
+	if workflowTask == nil {
+		return nil, fmt.Errorf("workflow task is not set")
+	}
+
+	arguments := map[string]any{}
+
+	if len(script.Arguments) > 0 {
+
+		evaluated, err := workflowTask.TraverseAndEvaluate(script.Arguments, input)
+		if err != nil {
+			return nil, model.NewErrRuntime(fmt.Errorf("failed to evaluate script arguments: %w", err), taskName)
+		}
+
+		evaluatedArguments, ok := evaluated.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("script arguments must evaluate to a map/object")
+		}
+
+		arguments = evaluatedArguments
+	}
+
+	options := r.getScriptOptions()
+
+	serviceClient := r.config.GetServices()
+
+	if workflowTask.HasTemporalContext() && serviceClient.HasTemporal() {
+
+		// Scripts fail the same way every time so they are not retried
+		activityOptions := workflow.ActivityOptions{
+			TaskQueue:           serviceClient.GetTemporal().GetTaskQueue(),
+			StartToCloseTimeout: options.Timeout + time.Minute,
+			RetryPolicy: &temporal.RetryPolicy{
+				MaximumAttempts: 1,
+			},
+		}
+
+		fut := workflow.ExecuteActivity(
+			workflow.WithActivityOptions(workflowTask.GetTemporalContext(), activityOptions),
+			models.TemporalScriptActivityName,
+			*script,
+			arguments,
+			input,
+			options,
+			r.getSecretNames(),
+		)
+
+		var result any
+		if err := fut.Get(workflowTask.GetTemporalContext(), &result); err != nil {
+			return nil, fmt.Errorf("script activity failed: %w", err)
+		}
+
+		return result, nil
+	}
+
+	secrets, err := r.loadSecrets()
+	if err != nil {
+		return nil, model.NewErrRuntime(err, taskName)
+	}
+
+	return RunScriptWithSecrets(r.GetContext(), *script, arguments, input, options, secrets)
+}
+
+// RunScriptWithSecrets fills in the secrets in the script's environment,
+// arguments and input, then masks them in the result and the script's logs
+func RunScriptWithSecrets(
+	ctx context.Context,
+	script model.Script,
+	arguments map[string]any,
+	input any,
+	options ScriptOptions,
+	secrets map[string]any,
+) (any, error) {
+
+	script, err := FillSecrets(script, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments, err = FillSecrets(arguments, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err = FillSecrets(input, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := RunScript(withSecretValues(ctx, secrets), script, arguments, input, options)
+
+	return RedactSecrets(result, err, secrets)
+}
+
+func (r *ResumableWorkflowRunner) getScriptOptions() ScriptOptions {
+
+	options := ScriptOptions{
+		Timeout:     defaultScriptTimeout,
+		MemoryLimit: defaultScriptMemoryLimitMB * 1024 * 1024,
+	}
+
+	scripts := r.config.Workflows.Scripts
+
+	if scripts.Timeout > 0 {
+		options.Timeout = scripts.Timeout
+	}
+
+	if scripts.MemoryLimitMB > 0 {
+		options.MemoryLimit = scripts.MemoryLimitMB * 1024 * 1024
+	}
+
+	return options
+}
+
+// RunScript executes a script in a new sandbox and returns its result.
+// The sandbox is a child process so the memory limit only counts what the
+// script allocates, and the script can't reach the workflow's own values.
+func RunScript(
+	ctx context.Context,
+	script model.Script,
+	arguments map[string]any,
+	input any,
+	options ScriptOptions,
+) (any, error) {
+
+	if !isScriptLanguageSupported(script.Language) {
+		return nil, fmt.Errorf("unsupported script language: %s. Supported languages: %s",
+			script.Language, strings.Join(scriptLanguages, ", "))
+	}
+
+	code, err := loadScriptCode(ctx, script)
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+
+	result, err := runScriptSandbox(ctx, scriptSandboxRequest{
+		Code:        code,
+		Environment: script.Environment,
+		Arguments:   arguments,
+		Input:       input,
+		Options:     options,
+	})
+
+	logFields := logrus.Fields{
+		"language": script.Language,
+		"timeMs":   time.Since(started).Milliseconds(),
+	}
+
+	if err != nil {
+		logrus.WithFields(logFields).WithError(errors.New(redactSecretText(ctx, err.Error()))).Warn("Script failed")
+		return nil, fmt.Errorf("script failed: %w", err)
+	}
+
+	logrus.WithFields(logFields).Info("Script finished successfully")
+
+	return result, nil
+}
+
+// evaluateScript runs the code in a goja runtime. It is only called in the
+// sandbox process, where the heap belongs to the script alone.
+func evaluateScript(ctx context.Context, request scriptSandboxRequest) (any, error) {
+
+	options := request.Options
+
+	vm := goja.New()
+	vm.SetFieldNameMapper(goja.TagFieldNameMapper("json", true))
+	vm.SetMaxCallStackSize(1024)
+
+	globals := map[string]any{
+		"input":   request.Input,
+		"args":    request.Arguments,
+		"env":     request.Environment,
+		"console": newScriptConsole(),
+	}
+
+	for name, value := range globals {
+		if err := vm.Set(name, value); err != nil {
+			return nil, fmt.Errorf("failed to set script global %s: %w", name, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, options.Timeout)
+	defer cancel()
+
+	// Stop the script when it runs out of time or memory
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			vm.Interrupt(ctx.Err())
+		case <-stop:
+		}
+	}()
+
+	if options.MemoryLimit > 0 {
+		go watchScriptMemory(vm, options.MemoryLimit, stop)
+	}
+
+	// Wrapping the code in a function lets scripts return their output
+	value, err := vm.RunString(fmt.Sprintf("(function() {\n%s\n})()", request.Code))
+
+	if err != nil {
+
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			if cause, ok := interrupted.Value().(error); ok {
+				err = cause
+			}
+		}
+
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("script timed out after %s", options.Timeout)
+		}
+
+		return nil, err
+	}
+
+	return exportScriptValue(value)
+}
+
+func isScriptLanguageSupported(language string) bool {
+	for _, supported := range scriptLanguages {
+		if strings.EqualFold(language, supported) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadScriptCode returns the inline code or fetches it from the source.
+// Fetching happens outside the sandbox so the script itself never has
+// network access.
+func loadScriptCode(ctx context.Context, script model.Script) (string, error) {
+
+	if script.InlineCode != nil {
+		return *script.InlineCode, nil
+	}
+
+	if script.External == nil || script.External.Endpoint == nil {
+		return "", fmt.Errorf("script must have either code or a source")
+	}
+
+	sourceURL := script.External.Endpoint.String()
+
+	res, err := resty.New().R().SetContext(ctx).Get(sourceURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch script from %s: %w", sourceURL, err)
+	}
+
+	if res.IsError() {
+		return "", fmt.Errorf("failed to fetch script from %s: %s", sourceURL, res.Status())
+	}
+
+	return res.String(), nil
+}
+
+// watchScriptMemory interrupts the script once the heap has grown by more
+// than the limit, so the script fails with a memory limit error. The
+// sandbox process only runs the script, so the growth is the script's own.
+// Allocations made between samples are held to the limit by the cap
+// limitScriptMemory sets.
+func watchScriptMemory(vm *goja.Runtime, limit uint64, stop <-chan struct{}) {
+
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+
+	metrics.Read(sample)
+	baseline := sample[0].Value.Uint64()
+
+	ticker := time.NewTicker(scriptMemorySampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			metrics.Read(sample)
+			if used := sample[0].Value.Uint64(); used > baseline && used-baseline > limit {
+				vm.Interrupt(errScriptMemoryLimit)
+				return
+			}
+		}
+	}
+}
+
+// exportScriptValue converts the script's result into plain JSON types so
+// it can be evaluated by jq and stored in the workflow history
+func exportScriptValue(value goja.Value) (any, error) {
+
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(value.Export())
+	if err != nil {
+		return nil, fmt.Errorf("script returned a value that is not JSON: %w", err)
+	}
+
+	var result any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to convert script result: %w", err)
+	}
+
+	return result, nil
+}
+
+// newScriptConsole sends console output from scripts to the log
+func newScriptConsole() map[string]any {
+
+	logger := func(level logrus.Level) func(...any) {
+		return func(args ...any) {
+			logrus.WithField("source", "script").Log(level, args...)
+		}
+	}
+
+	return map[string]any{
+		"log":   logger(logrus.InfoLevel),
+		"info":  logger(logrus.InfoLevel),
+		"debug": logger(logrus.DebugLevel),
+		"warn":  logger(logrus.WarnLevel),
+		"error": logger(logrus.ErrorLevel),
+	}
+}
@@ -0,0 +1,39 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// limitScriptAddressSpace caps the sandbox's virtual memory at what it has
+// mapped so far plus the limit, so any allocation past it fails
+func limitScriptAddressSpace(limit uint64) error {
+
+	statm, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return fmt.Errorf("failed to read the sandbox's memory usage: %w", err)
+	}
+
+	fields := strings.Fields(string(statm))
+	if len(fields) == 0 {
+		return fmt.Errorf("failed to read the sandbox's memory usage")
+	}
+
+	pages, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to read the sandbox's memory usage: %w", err)
+	}
+
+	capacity := pages*uint64(os.Getpagesize()) + limit
+
+	if err := syscall.Setrlimit(syscall.RLIMIT_AS, &syscall.Rlimit{Cur: capacity, Max: capacity}); err != nil {
+		return fmt.Errorf("failed to limit the sandbox's memory: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package runner
+
+// limitScriptAddressSpace is only supported on Linux. Elsewhere the
+// runtime's memory limit and the heap sampler apply.
+func limitScriptAddressSpace(limit uint64) error {
+	return nil
+}
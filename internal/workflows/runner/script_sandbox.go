@@ -0,0 +1,214 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// scriptSandboxEnv marks a process started to run a single script
+const scriptSandboxEnv = "THAND_SCRIPT_SANDBOX"
+
+// How long the sandbox has to report its own timeout before it is killed
+const scriptSandboxGrace = 5 * time.Second
+
+// Address space the sandbox can map beyond the script's memory limit, for
+// the runtime's own stacks and garbage collector
+const scriptSandboxHeadroom = 128 * 1024 * 1024
+
+// What the Go runtime writes to stderr when the sandbox runs out of memory
+const scriptOutOfMemoryMessage = "out of memory"
+
+// scriptSandboxRequest is sent to the sandbox process on stdin
+type scriptSandboxRequest struct {
+	Code        string            `json:"code"`
+	Environment map[string]string `json:"environment,omitempty"`
+	Arguments   map[string]any    `json:"arguments,omitempty"`
+	Input       any               `json:"input,omitempty"`
+	Options     ScriptOptions     `json:"options"`
+}
+
+// scriptSandboxResponse is written by the sandbox process to stdout
+type scriptSandboxResponse struct {
+	Result              any    `json:"result,omitempty"`
+	Error               string `json:"error,omitempty"`
+	MemoryLimitExceeded bool   `json:"memory_limit_exceeded,omitempty"`
+}
+
+// RunScriptSandbox runs the script sent on stdin and exits if this process
+// was started as a script sandbox. It must be called before anything else
+// in main.
+func RunScriptSandbox() {
+
+	if os.Getenv(scriptSandboxEnv) != "1" {
+		return
+	}
+
+	// Console output is passed back to the parent as JSON log lines
+	logrus.SetOutput(os.Stderr)
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+	logrus.SetLevel(logrus.DebugLevel)
+
+	var request scriptSandboxRequest
+	var response scriptSandboxResponse
+
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		response.Error = fmt.Sprintf("failed to read script: %v", err)
+	} else if err := limitScriptMemory(request.Options.MemoryLimit); err != nil {
+		response.Error = err.Error()
+	} else {
+		result, err := evaluateScript(context.Background(), request)
+		if err != nil {
+			response.Error = err.Error()
+			response.MemoryLimitExceeded = errors.Is(err, errScriptMemoryLimit)
+		} else {
+			response.Result = result
+		}
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(response); err != nil {
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// limitScriptMemory holds the sandbox to the script's memory limit. The heap
+// sampler stops a script that grows past the limit and reports it, but a
+// single large allocation can get past it between samples. So the runtime
+// is told the limit and, where supported, the address space is capped too.
+// A script that reaches the cap kills the sandbox with an out of memory
+// error.
+func limitScriptMemory(limit uint64) error {
+
+	if limit == 0 {
+		return nil
+	}
+
+	sample := []metrics.Sample{{Name: "/memory/classes/total:bytes"}}
+	metrics.Read(sample)
+
+	debug.SetMemoryLimit(int64(sample[0].Value.Uint64() + limit))
+
+	return limitScriptAddressSpace(limit + scriptSandboxHeadroom)
+}
+
+// runScriptSandbox runs the script in a new sandbox process. The process
+// is given none of the agent's environment.
+func runScriptSandbox(ctx context.Context, request scriptSandboxRequest) (any, error) {
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the script sandbox executable: %w", err)
+	}
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode script: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, request.Options.Timeout+scriptSandboxGrace)
+	defer cancel()
+
+	var stdout bytes.Buffer
+
+	stderr, stderrWriter := io.Pipe()
+	defer stderr.Close()
+
+	cmd := exec.CommandContext(ctx, executable)
+	cmd.Env = []string{scriptSandboxEnv + "=1"}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = stderrWriter
+
+	outOfMemory := make(chan bool, 1)
+
+	go func() {
+		outOfMemory <- relayScriptLogs(ctx, stderr)
+	}()
+
+	err = cmd.Run()
+	stderrWriter.Close()
+
+	// The sandbox is killed by the runtime if it reaches its memory cap
+	if err != nil && <-outOfMemory {
+		return nil, errScriptMemoryLimit
+	}
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("script timed out after %s", request.Options.Timeout)
+	}
+
+	var response scriptSandboxResponse
+	if decodeErr := json.Unmarshal(stdout.Bytes(), &response); decodeErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("script sandbox exited: %w", err)
+		}
+		return nil, fmt.Errorf("failed to read script result: %w", decodeErr)
+	}
+
+	if response.MemoryLimitExceeded {
+		return nil, errScriptMemoryLimit
+	}
+
+	if len(response.Error) > 0 {
+		return nil, errors.New(response.Error)
+	}
+
+	return response.Result, nil
+}
+
+// relayScriptLogs logs the sandbox's console output at the level it was
+// written with, masking any secrets. It returns whether the sandbox ran
+// out of memory.
+func relayScriptLogs(ctx context.Context, stderr io.Reader) bool {
+
+	scanner := bufio.NewScanner(stderr)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	// Keep draining if a line is too long so the sandbox never blocks
+	defer io.Copy(io.Discard, stderr)
+
+	for scanner.Scan() {
+
+		var entry struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+
+		logger := logrus.WithField("source", "script")
+
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+
+			// The runtime's stack dump that follows isn't logged
+			if line := scanner.Text(); strings.HasPrefix(line, "fatal error:") {
+				logger.Error(line)
+				return strings.Contains(line, scriptOutOfMemoryMessage)
+			}
+
+			logger.Warn(redactSecretText(ctx, scanner.Text()))
+			continue
+		}
+
+		level, err := logrus.ParseLevel(entry.Level)
+		if err != nil {
+			level = logrus.InfoLevel
+		}
+
+		logger.Log(level, redactSecretText(ctx, entry.Msg))
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestScript(code string) model.Script {
+	return model.Script{
+		Language:    "js",
+		InlineCode:  &code,
+		Environment: map[string]string{"STAGE": "prod"},
+	}
+}
+
+func TestRunScript(t *testing.T) {
+
+	options := ScriptOptions{Timeout: 5 * time.Second}
+
+	t.Run("returns output from input and arguments", func(t *testing.T) {
+
+		script := newTestScript(`
+			const risky = input.requests.filter(r => r.score > args.threshold);
+			return { count: risky.length, names: risky.map(r => r.name), stage: env.STAGE };
+		`)
+
+		input := map[string]any{
+			"requests": []any{
+				map[string]any{"name": "admin", "score": 80},
+				map[string]any{"name": "reader", "score": 10},
+			},
+		}
+
+		result, err := RunScript(context.Background(), script, map[string]any{"threshold": 50}, input, options)
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"count": float64(1),
+			"names": []any{"admin"},
+			"stage": "prod",
+		}, result)
+	})
+
+	t.Run("no return value", func(t *testing.T) {
+		result, err := RunScript(context.Background(), newTestScript(`console.log("hello")`), nil, nil, options)
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("thrown errors fail the task", func(t *testing.T) {
+		_, err := RunScript(context.Background(), newTestScript(`throw new Error("denied")`), nil, nil, options)
+		assert.ErrorContains(t, err, "denied")
+	})
+
+	t.Run("no module or process access", func(t *testing.T) {
+		_, err := RunScript(context.Background(), newTestScript(`return require("fs")`), nil, nil, options)
+		assert.ErrorContains(t, err, "require is not defined")
+
+		_, err = RunScript(context.Background(), newTestScript(`return process.env`), nil, nil, options)
+		assert.ErrorContains(t, err, "process is not defined")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := RunScript(context.Background(), newTestScript(`while (true) {}`), nil, nil,
+			ScriptOptions{Timeout: 100 * time.Millisecond})
+		assert.ErrorContains(t, err, "timed out")
+	})
+
+	t.Run("memory limit", func(t *testing.T) {
+		_, err := RunScript(context.Background(), newTestScript(`
+			const data = [];
+			while (true) { data.push("x".repeat(1024)); }
+		`), nil, nil, ScriptOptions{Timeout: 10 * time.Second, MemoryLimit: 16 * 1024 * 1024})
+		assert.ErrorIs(t, err, errScriptMemoryLimit)
+	})
+
+	t.Run("single large allocation", func(t *testing.T) {
+		_, err := RunScript(context.Background(), newTestScript(`
+			const data = new ArrayBuffer(1024 * 1024 * 1024);
+			return data.byteLength;
+		`), nil, nil, ScriptOptions{Timeout: 10 * time.Second, MemoryLimit: 16 * 1024 * 1024})
+		assert.ErrorIs(t, err, errScriptMemoryLimit)
+	})
+
+	t.Run("unsupported language", func(t *testing.T) {
+		script := newTestScript(`print("hello")`)
+		script.Language = "python"
+		_, err := RunScript(context.Background(), script, nil, nil, options)
+		assert.ErrorContains(t, err, "unsupported script language")
+	})
+}
+
+func TestWorkflowRunner_RunScript(t *testing.T) {
+
+	input := map[string]any{
+		"threshold": 50,
+		"requests": []any{
+			map[string]any{"name": "admin", "score": 80},
+			map[string]any{"name": "owner", "score": 95},
+			map[string]any{"name": "reader", "score": 10},
+		},
+	}
+
+	runWorkflowTest(t, "./testdata/run_script.yaml", input, map[string]any{
+		"message": "2 risky request(s): admin, owner",
+	})
+}
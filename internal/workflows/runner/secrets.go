@@ -0,0 +1,572 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/models"
+	"go.temporal.io/sdk/workflow"
+)
+
+// getSecretNames returns the secrets the workflow declares in use.secrets
+func (wr *ResumableWorkflowRunner) getSecretNames() []string {
+
+	workflowDef := wr.GetWorkflow()
+
+	if workflowDef == nil || workflowDef.Use == nil {
+		return nil
+	}
+
+	return workflowDef.Use.Secrets
+}
+
+// loadSecrets reads the workflow's declared secrets from the vault when it
+// runs outside Temporal. Temporal workflows pass the secret names to their
+// activities instead, which read them so they never reach the workflow
+// code or its history.
+func (wr *ResumableWorkflowRunner) loadSecrets() (map[string]any, error) {
+
+	names := wr.getSecretNames()
+
+	if len(names) == 0 {
+		return map[string]any{}, nil
+	}
+
+	if !wr.config.HasVault() {
+		return nil, fmt.Errorf("workflow uses secrets but no vault is configured")
+	}
+
+	return LoadSecrets(wr.config.GetVault(), names)
+}
+
+// loadMaskedSecrets exposes the workflow's secrets to expressions as
+// $secrets. Expressions only see placeholders, the values are filled in
+// where a call is made so the workflow state, its history and the logs
+// never hold them.
+func (wr *ResumableWorkflowRunner) loadMaskedSecrets() error {
+
+	names := wr.getSecretNames()
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	workflowTask := wr.GetWorkflowTask()
+	serviceClient := wr.config.GetServices()
+
+	if workflowTask.HasTemporalContext() && serviceClient.HasTemporal() {
+
+		activityOptions := workflow.ActivityOptions{
+			TaskQueue:           serviceClient.GetTemporal().GetTaskQueue(),
+			StartToCloseTimeout: time.Minute,
+		}
+
+		ctx := workflow.WithActivityOptions(workflowTask.GetTemporalContext(), activityOptions)
+
+		// The activity only returns the placeholders
+		var masked map[string]any
+		if err := workflow.ExecuteActivity(ctx, models.TemporalSecretsActivityName, names).Get(ctx, &masked); err != nil {
+			return fmt.Errorf("secrets activity failed: %w", err)
+		}
+
+		workflowTask.SetSecrets(masked)
+
+		return nil
+	}
+
+	secrets, err := wr.loadSecrets()
+	if err != nil {
+		return err
+	}
+
+	workflowTask.SetSecrets(MaskSecrets(secrets))
+
+	return nil
+}
+
+// LoadSecrets reads the named secrets from the vault. Secrets holding
+// JSON objects can be traversed in expressions, anything else is a string.
+func LoadSecrets(vault models.VaultImpl, names []string) (map[string]any, error) {
+
+	secrets := make(map[string]any, len(names))
+
+	for _, name := range names {
+
+		value, err := vault.GetSecret(name)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to load secret %s: %w", name, err)
+		}
+
+		var decoded any
+		if err := json.Unmarshal(value, &decoded); err == nil {
+			secrets[name] = decoded
+		} else {
+			secrets[name] = string(value)
+		}
+	}
+
+	logrus.WithField("secrets", names).Debug("Loaded workflow secrets")
+
+	return secrets, nil
+}
+
+/*
+Secrets are masked everywhere but where a call is made. Expressions see a
+placeholder such as [secret:google_oauth2.client_id] for each value, so
+anything built from a secret holds the placeholder in the workflow state,
+its history and the logs. The placeholders are replaced with the values
+just before the call, and the values are masked again in whatever the
+call returns. Expressions can pass secrets around and concatenate them
+but can't transform them, as they only ever see the placeholder.
+*/
+
+// secretMinLength is the shortest value masked in call results, so short
+// values such as a port or a region don't mask unrelated output
+const secretMinLength = 4
+
+// secretValue is one value within a secret and its placeholder
+type secretValue struct {
+	placeholder string
+	value       any
+}
+
+func secretPlaceholder(path string) string {
+	return fmt.Sprintf("[secret:%s]", path)
+}
+
+// MaskSecrets returns the secrets with each value replaced by its
+// placeholder, keeping their structure so expressions can traverse them
+func MaskSecrets(secrets map[string]any) map[string]any {
+
+	masked := make(map[string]any, len(secrets))
+
+	for name, value := range secrets {
+		masked[name] = maskSecretValue(name, value)
+	}
+
+	return masked
+}
+
+func maskSecretValue(path string, value any) any {
+
+	switch v := value.(type) {
+	case map[string]any:
+
+		masked := make(map[string]any, len(v))
+		for key, item := range v {
+			masked[key] = maskSecretValue(path+"."+key, item)
+		}
+		return masked
+
+	case []any:
+
+		masked := make([]any, len(v))
+		for i, item := range v {
+			masked[i] = maskSecretValue(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return masked
+
+	default:
+		return secretPlaceholder(path)
+	}
+}
+
+// getSecretValues flattens the secrets into their values, longest first
+// so a value is replaced before any value it contains
+func getSecretValues(secrets map[string]any) []secretValue {
+
+	var values []secretValue
+
+	var collect func(path string, value any)
+	collect = func(path string, value any) {
+		switch v := value.(type) {
+		case map[string]any:
+			for key, item := range v {
+				collect(path+"."+key, item)
+			}
+		case []any:
+			for i, item := range v {
+				collect(fmt.Sprintf("%s[%d]", path, i), item)
+			}
+		default:
+			values = append(values, secretValue{placeholder: secretPlaceholder(path), value: v})
+		}
+	}
+
+	for name, value := range secrets {
+		collect(name, value)
+	}
+
+	sort.Slice(values, func(i, j int) bool {
+		return len(fmt.Sprint(values[i].value)) > len(fmt.Sprint(values[j].value))
+	})
+
+	return values
+}
+
+// FillSecrets returns the value with the placeholders replaced by the
+// secrets' values. A string that is only a placeholder takes the value's
+// type, placeholders within a string are replaced by the value as text.
+func FillSecrets[T any](value T, secrets map[string]any) (T, error) {
+
+	encoded, err := encodeSecretJSON(value)
+	if err != nil {
+		return value, fmt.Errorf("failed to fill in secrets: %w", err)
+	}
+
+	if !bytes.Contains(encoded, []byte("[secret:")) {
+		return value, nil
+	}
+
+	for _, secret := range getSecretValues(secrets) {
+
+		placeholder, _ := encodeSecretJSON(secret.placeholder)
+
+		whole, err := encodeSecretJSON(secret.value)
+		if err != nil {
+			return value, fmt.Errorf("failed to fill in secrets: %w", err)
+		}
+
+		text, _ := encodeSecretJSON(fmt.Sprint(secret.value))
+
+		encoded = bytes.ReplaceAll(encoded, placeholder, whole)
+		encoded = bytes.ReplaceAll(encoded, trimJSONQuotes(placeholder), trimJSONQuotes(text))
+	}
+
+	var filled T
+	if err := json.Unmarshal(encoded, &filled); err != nil {
+		return value, fmt.Errorf("failed to fill in secrets: %w", err)
+	}
+
+	return filled, nil
+}
+
+// RedactSecrets masks the secrets' values in a call's result and error so
+// they aren't recorded in the workflow state, its history or the logs
+func RedactSecrets(result any, err error, secrets map[string]any) (any, error) {
+
+	values := getSecretValues(secrets)
+
+	if len(values) == 0 {
+		return result, err
+	}
+
+	if err != nil {
+
+		if message := redactSecretValues(values, err.Error()); message != err.Error() {
+			err = errors.New(message)
+		}
+
+		return nil, err
+	}
+
+	if result == nil {
+		return nil, nil
+	}
+
+	encoded, encodeErr := encodeSecretJSON(result)
+	if encodeErr != nil {
+		return nil, fmt.Errorf("failed to redact secrets: %w", encodeErr)
+	}
+
+	redacted := encoded
+
+	for _, secret := range values {
+
+		text, isString := secret.value.(string)
+		if !isString || len(text) < secretMinLength {
+			continue
+		}
+
+		value, _ := encodeSecretJSON(text)
+		placeholder, _ := encodeSecretJSON(secret.placeholder)
+
+		redacted = bytes.ReplaceAll(redacted, trimJSONQuotes(value), trimJSONQuotes(placeholder))
+	}
+
+	if bytes.Equal(redacted, encoded) {
+		return result, nil
+	}
+
+	var masked any
+	if err := json.Unmarshal(redacted, &masked); err != nil {
+		return nil, fmt.Errorf("failed to redact secrets: %w", err)
+	}
+
+	return masked, nil
+}
+
+func redactSecretValues(values []secretValue, text string) string {
+
+	for _, secret := range values {
+		if value, isString := secret.value.(string); isString && len(value) >= secretMinLength {
+			text = strings.ReplaceAll(text, value, secret.placeholder)
+		}
+	}
+
+	return text
+}
+
+type secretValuesKey struct{}
+
+// withSecretValues returns a context holding the secrets so what a call
+// logs can be masked with redactSecretText
+func withSecretValues(ctx context.Context, secrets map[string]any) context.Context {
+	return context.WithValue(ctx, secretValuesKey{}, getSecretValues(secrets))
+}
+
+// redactSecretText masks the values of the context's secrets in text
+func redactSecretText(ctx context.Context, text string) string {
+
+	values, _ := ctx.Value(secretValuesKey{}).([]secretValue)
+
+	return redactSecretValues(values, text)
+}
+
+// encodeSecretJSON encodes without escaping HTML so placeholders and
+// values are encoded the same wherever they appear
+func encodeSecretJSON(value any) ([]byte, error) {
+
+	var buffer bytes.Buffer
+
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+func trimJSONQuotes(encoded []byte) []byte {
+	return bytes.TrimSuffix(bytes.TrimPrefix(encoded, []byte(`"`)), []byte(`"`))
+}
+
+// getEndpointAuthentication replaces a reference to one of the workflow's
+// use.authentications with the policy itself. Secrets are not resolved so
+// the call can be passed to an activity.
+func (wr *ResumableWorkflowRunner) getEndpointAuthentication(httpCall model.HTTPArguments) (model.HTTPArguments, error) {
+
+	if httpCall.Endpoint == nil || httpCall.Endpoint.EndpointConfig == nil ||
+		httpCall.Endpoint.EndpointConfig.Authentication == nil {
+		return httpCall, nil
+	}
+
+	policy, err := wr.getAuthenticationPolicy(httpCall.Endpoint.EndpointConfig.Authentication)
+
+	if err != nil || policy == nil {
+		return httpCall, err
+	}
+
+	return withAuthenticationPolicy(httpCall, policy), nil
+}
+
+// getAuthenticationPolicy returns the policy the authentication uses,
+// looking up references to the workflow's use.authentications. It returns
+// nil if the authentication is inline.
+func (wr *ResumableWorkflowRunner) getAuthenticationPolicy(
+	authentication *model.ReferenceableAuthenticationPolicy,
+) (*model.AuthenticationPolicy, error) {
+
+	if authentication == nil || authentication.Use == nil {
+		return nil, nil
+	}
+
+	workflowDef := wr.GetWorkflow()
+
+	var policy *model.AuthenticationPolicy
+
+	if workflowDef.Use != nil {
+		policy = workflowDef.Use.Authentications[*authentication.Use]
+	}
+
+	if policy == nil {
+		return nil, fmt.Errorf("authentication %s is not defined in use.authentications", *authentication.Use)
+	}
+
+	return policy, nil
+}
+
+// ResolveHttpAuthentication returns the call with its authentication
+// policy's secrets filled in. The workflow definition is left untouched.
+func ResolveHttpAuthentication(ctx context.Context, httpCall model.HTTPArguments, secrets map[string]any) (model.HTTPArguments, error) {
+
+	if httpCall.Endpoint == nil || httpCall.Endpoint.EndpointConfig == nil {
+		return httpCall, nil
+	}
+
+	policy, err := ResolveAuthentication(ctx, httpCall.Endpoint.EndpointConfig.Authentication, secrets)
+
+	if err != nil || policy == nil {
+		return httpCall, err
+	}
+
+	return withAuthenticationPolicy(httpCall, policy), nil
+}
+
+// ResolveAuthentication returns a copy of the authentication policy with
+// its secrets filled in, or nil if there is nothing to resolve. A policy
+// can use a secret by name, holding the username and password, the token
+// or the oauth2 properties, or reference $secrets in its fields. OAuth2
+// and OpenID Connect policies are resolved to a bearer token.
+func ResolveAuthentication(
+	ctx context.Context,
+	authentication *model.ReferenceableAuthenticationPolicy,
+	secrets map[string]any,
+) (*model.AuthenticationPolicy, error) {
+
+	if authentication == nil || authentication.AuthenticationPolicy == nil {
+		return nil, nil
+	}
+
+	policy := authentication.AuthenticationPolicy
+	resolved := &model.AuthenticationPolicy{}
+
+	switch {
+	case policy.OAuth2 != nil:
+
+		token, err := requestOAuth2Token(ctx, policy.OAuth2, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve oauth2 authentication: %w", err)
+		}
+
+		resolved.Bearer = &model.BearerAuthenticationPolicy{Token: token}
+
+	case policy.OIDC != nil:
+
+		token, err := requestOIDCToken(ctx, policy.OIDC, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve oidc authentication: %w", err)
+		}
+
+		resolved.Bearer = &model.BearerAuthenticationPolicy{Token: token}
+
+	case policy.Basic != nil:
+
+		username, password, err := resolveCredentials(policy.Basic.Use, policy.Basic.Username, policy.Basic.Password, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve basic authentication: %w", err)
+		}
+
+		resolved.Basic = &model.BasicAuthenticationPolicy{Username: username, Password: password}
+
+	case policy.Digest != nil:
+
+		username, password, err := resolveCredentials(policy.Digest.Use, policy.Digest.Username, policy.Digest.Password, secrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve digest authentication: %w", err)
+		}
+
+		resolved.Digest = &model.DigestAuthenticationPolicy{Username: username, Password: password}
+
+	case policy.Bearer != nil:
+
+		token := policy.Bearer.Token
+
+		if len(policy.Bearer.Use) > 0 {
+
+			secret, found := secrets[policy.Bearer.Use]
+			if !found {
+				return nil, fmt.Errorf("secret %s is not declared in use.secrets", policy.Bearer.Use)
+			}
+
+			if fields, ok := secret.(map[string]any); ok {
+				token, _ = fields["token"].(string)
+			} else {
+				token, _ = secret.(string)
+			}
+
+		} else {
+
+			evaluated, err := evaluateSecretString(token, secrets)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve bearer authentication: %w", err)
+			}
+
+			token = evaluated
+		}
+
+		resolved.Bearer = &model.BearerAuthenticationPolicy{Token: token}
+
+	default:
+		return nil, nil
+	}
+
+	return resolved, nil
+}
+
+// resolveCredentials returns the username and password from the secret
+// the policy uses, or evaluates them when they are set inline
+func resolveCredentials(use string, username string, password string, secrets map[string]any) (string, string, error) {
+
+	if len(use) > 0 {
+
+		secret, found := secrets[use]
+		if !found {
+			return "", "", fmt.Errorf("secret %s is not declared in use.secrets", use)
+		}
+
+		fields, ok := secret.(map[string]any)
+		if !ok {
+			return "", "", fmt.Errorf("secret %s must hold a username and password", use)
+		}
+
+		username, _ = fields["username"].(string)
+		password, _ = fields["password"].(string)
+
+		return username, password, nil
+	}
+
+	username, err := evaluateSecretString(username, secrets)
+	if err != nil {
+		return "", "", err
+	}
+
+	password, err = evaluateSecretString(password, secrets)
+	if err != nil {
+		return "", "", err
+	}
+
+	return username, password, nil
+}
+
+func evaluateSecretString(value string, secrets map[string]any) (string, error) {
+
+	evaluated, err := models.EvaluateWithSecrets(value, secrets)
+	if err != nil {
+		return "", err
+	}
+
+	result, ok := evaluated.(string)
+	if !ok {
+		return "", fmt.Errorf("expression must evaluate to a string, got %T", evaluated)
+	}
+
+	return result, nil
+}
+
+// withAuthenticationPolicy copies the call's endpoint with a new policy
+func withAuthenticationPolicy(httpCall model.HTTPArguments, policy *model.AuthenticationPolicy) model.HTTPArguments {
+
+	endpoint := *httpCall.Endpoint
+	endpointConfig := *endpoint.EndpointConfig
+
+	endpointConfig.Authentication = &model.ReferenceableAuthenticationPolicy{
+		AuthenticationPolicy: policy,
+	}
+
+	endpoint.EndpointConfig = &endpointConfig
+	httpCall.Endpoint = &endpoint
+
+	return httpCall
+}
@@ -0,0 +1,367 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testVault struct {
+	secrets map[string]string
+}
+
+func (v *testVault) Initialize() error { return nil }
+func (v *testVault) Shutdown() error   { return nil }
+
+func (v *testVault) GetSecret(key string) ([]byte, error) {
+	if value, found := v.secrets[key]; found {
+		return []byte(value), nil
+	}
+	return nil, fmt.Errorf("secret %s not found", key)
+}
+
+func (v *testVault) StoreSecret(key string, value []byte) error {
+	v.secrets[key] = string(value)
+	return nil
+}
+
+func TestLoadSecrets(t *testing.T) {
+
+	vault := &testVault{secrets: map[string]string{
+		"google_oauth2": `{"client_id": "abc", "client_secret": "xyz"}`,
+		"slack_token":   "xoxb-123",
+	}}
+
+	secrets, err := LoadSecrets(vault, []string{"google_oauth2", "slack_token"})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]any{"client_id": "abc", "client_secret": "xyz"}, secrets["google_oauth2"])
+	assert.Equal(t, "xoxb-123", secrets["slack_token"])
+
+	_, err = LoadSecrets(vault, []string{"missing"})
+	assert.ErrorContains(t, err, "failed to load secret missing")
+}
+
+func TestResolveHttpAuthentication(t *testing.T) {
+
+	secrets := map[string]any{
+		"api": map[string]any{"username": "thand", "password": "s3cret"},
+		"ci":  map[string]any{"token": "abc123"},
+	}
+
+	newCall := func(policy *model.AuthenticationPolicy) model.HTTPArguments {
+		return model.HTTPArguments{
+			Method: "get",
+			Endpoint: &model.Endpoint{
+				EndpointConfig: &model.EndpointConfiguration{
+					URI: &model.LiteralUri{Value: "https://example.com"},
+					Authentication: &model.ReferenceableAuthenticationPolicy{
+						AuthenticationPolicy: policy,
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("basic secret", func(t *testing.T) {
+
+		policy := &model.AuthenticationPolicy{Basic: &model.BasicAuthenticationPolicy{Use: "api"}}
+
+		resolved, err := ResolveHttpAuthentication(context.Background(), newCall(policy), secrets)
+		require.NoError(t, err)
+
+		basic := resolved.Endpoint.EndpointConfig.Authentication.AuthenticationPolicy.Basic
+		assert.Equal(t, "thand", basic.Username)
+		assert.Equal(t, "s3cret", basic.Password)
+
+		// The workflow definition keeps the reference
+		assert.Equal(t, "api", policy.Basic.Use)
+		assert.Empty(t, policy.Basic.Password)
+	})
+
+	t.Run("bearer expression", func(t *testing.T) {
+
+		policy := &model.AuthenticationPolicy{Bearer: &model.BearerAuthenticationPolicy{
+			Token: "${ $secrets.ci.token }",
+		}}
+
+		resolved, err := ResolveHttpAuthentication(context.Background(), newCall(policy), secrets)
+		require.NoError(t, err)
+
+		assert.Equal(t, "abc123", resolved.Endpoint.EndpointConfig.Authentication.AuthenticationPolicy.Bearer.Token)
+		assert.Equal(t, "${ $secrets.ci.token }", policy.Bearer.Token)
+	})
+
+	t.Run("inline", func(t *testing.T) {
+
+		policy := &model.AuthenticationPolicy{Digest: &model.DigestAuthenticationPolicy{
+			Username: "user",
+			Password: "pass",
+		}}
+
+		resolved, err := ResolveHttpAuthentication(context.Background(), newCall(policy), secrets)
+		require.NoError(t, err)
+
+		digest := resolved.Endpoint.EndpointConfig.Authentication.AuthenticationPolicy.Digest
+		assert.Equal(t, "user", digest.Username)
+		assert.Equal(t, "pass", digest.Password)
+	})
+
+	t.Run("undeclared secret", func(t *testing.T) {
+
+		policy := &model.AuthenticationPolicy{Bearer: &model.BearerAuthenticationPolicy{Use: "missing"}}
+
+		_, err := ResolveHttpAuthentication(context.Background(), newCall(policy), secrets)
+		assert.ErrorContains(t, err, "secret missing is not declared in use.secrets")
+	})
+
+	t.Run("oauth2 without properties", func(t *testing.T) {
+
+		policy := &model.AuthenticationPolicy{OAuth2: &model.OAuth2AuthenticationPolicy{}}
+
+		_, err := ResolveHttpAuthentication(context.Background(), newCall(policy), secrets)
+		assert.ErrorContains(t, err, "failed to resolve oauth2 authentication: authority, grant and client are required")
+	})
+}
+
+// newTokenServer returns a token endpoint that grants a token to the client
+func newTokenServer(t *testing.T, clientID string, clientSecret string) *httptest.Server {
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"token_endpoint": "http://%s/oidc/token"}`, r.Host)
+	})
+
+	token := func(w http.ResponseWriter, r *http.Request) {
+
+		require.NoError(t, r.ParseForm())
+
+		id, secret, ok := r.BasicAuth()
+		if !ok {
+			id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.PostForm.Get("grant_type") != "client_credentials" || id != clientID || secret != clientSecret {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client", "error_description": "unknown client"}`)
+			return
+		}
+
+		fmt.Fprintf(w, `{"access_token": "token-%s", "token_type": "Bearer"}`, r.PostForm.Get("scope"))
+	}
+
+	mux.HandleFunc("/o/oauth2/token", token)
+	mux.HandleFunc("/oidc/token", token)
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestResolveAuthentication_OAuth2(t *testing.T) {
+
+	server := newTokenServer(t, "abc", "xyz")
+
+	secrets := map[string]any{
+		"google_oauth2": map[string]any{"client_id": "abc", "client_secret": "xyz"},
+	}
+
+	newPolicy := func(client *model.OAuth2AutenthicationDataClient) *model.AuthenticationPolicy {
+		return &model.AuthenticationPolicy{OAuth2: &model.OAuth2AuthenticationPolicy{
+			Properties: &model.OAuth2AuthenticationProperties{
+				Authority: &model.LiteralUri{Value: server.URL + "/o/oauth2"},
+				Grant:     model.ClientCredentialsGrant,
+				Client:    client,
+				Scopes:    []string{"email"},
+			},
+			Endpoints: &model.OAuth2Endpoints{Token: "/token"},
+		}}
+	}
+
+	t.Run("client secret post", func(t *testing.T) {
+
+		policy := newPolicy(&model.OAuth2AutenthicationDataClient{
+			ID:     "${ $secrets.google_oauth2.client_id }",
+			Secret: "${ $secrets.google_oauth2.client_secret }",
+		})
+
+		resolved, err := ResolveAuthentication(context.Background(), &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy}, secrets)
+		require.NoError(t, err)
+
+		require.NotNil(t, resolved.Bearer)
+		assert.Equal(t, "token-email", resolved.Bearer.Token)
+		assert.Nil(t, resolved.OAuth2)
+	})
+
+	t.Run("client secret basic", func(t *testing.T) {
+
+		policy := newPolicy(&model.OAuth2AutenthicationDataClient{
+			ID:             "abc",
+			Secret:         "xyz",
+			Authentication: model.OAuthClientAuthClientSecretBasic,
+		})
+
+		resolved, err := ResolveAuthentication(context.Background(), &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy}, secrets)
+		require.NoError(t, err)
+		assert.Equal(t, "token-email", resolved.Bearer.Token)
+	})
+
+	t.Run("rejected client", func(t *testing.T) {
+
+		policy := newPolicy(&model.OAuth2AutenthicationDataClient{ID: "abc", Secret: "wrong"})
+
+		_, err := ResolveAuthentication(context.Background(), &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy}, secrets)
+		assert.ErrorContains(t, err, "401 Unauthorized invalid_client unknown client")
+	})
+
+	t.Run("unsupported grant", func(t *testing.T) {
+
+		policy := newPolicy(&model.OAuth2AutenthicationDataClient{ID: "abc", Secret: "xyz"})
+		policy.OAuth2.Properties.Grant = model.AuthorizationCodeGrant
+
+		_, err := ResolveAuthentication(context.Background(), &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy}, secrets)
+		assert.ErrorContains(t, err, "unsupported grant: authorization_code")
+	})
+
+	t.Run("oidc discovery", func(t *testing.T) {
+
+		policy := &model.AuthenticationPolicy{OIDC: &model.OpenIdConnectAuthenticationPolicy{
+			Properties: &model.OAuth2AuthenticationProperties{
+				Authority: &model.LiteralUri{Value: server.URL},
+				Grant:     model.ClientCredentialsGrant,
+				Client:    &model.OAuth2AutenthicationDataClient{ID: "abc", Secret: "xyz"},
+			},
+		}}
+
+		resolved, err := ResolveAuthentication(context.Background(), &model.ReferenceableAuthenticationPolicy{AuthenticationPolicy: policy}, secrets)
+		require.NoError(t, err)
+		assert.Equal(t, "token-", resolved.Bearer.Token)
+	})
+}
+
+func TestMaskSecrets(t *testing.T) {
+
+	secrets := map[string]any{
+		"google_oauth2": map[string]any{"client_id": "abc123", "client_secret": "xyz789", "ports": []any{443.0}},
+		"slack_token":   "xoxb-123",
+	}
+
+	masked := MaskSecrets(secrets)
+
+	assert.Equal(t, map[string]any{
+		"google_oauth2": map[string]any{
+			"client_id":     "[secret:google_oauth2.client_id]",
+			"client_secret": "[secret:google_oauth2.client_secret]",
+			"ports":         []any{"[secret:google_oauth2.ports[0]]"},
+		},
+		"slack_token": "[secret:slack_token]",
+	}, masked)
+
+	t.Run("fill", func(t *testing.T) {
+
+		value := map[string]any{
+			"header": "Bearer [secret:slack_token]",
+			"id":     "[secret:google_oauth2.client_id]",
+			"port":   "[secret:google_oauth2.ports[0]]",
+			"other":  "[secret:unknown]",
+		}
+
+		filled, err := FillSecrets(value, secrets)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]any{
+			"header": "Bearer xoxb-123",
+			"id":     "abc123",
+			"port":   443.0,
+			"other":  "[secret:unknown]",
+		}, filled)
+
+		// The value passed in keeps the placeholders
+		assert.Equal(t, "Bearer [secret:slack_token]", value["header"])
+
+		url, err := FillSecrets("https://example.com/?key=[secret:google_oauth2.client_secret]", secrets)
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/?key=xyz789", url)
+	})
+
+	t.Run("redact", func(t *testing.T) {
+
+		result, err := RedactSecrets(map[string]any{
+			"echo":  "token xoxb-123 for abc123",
+			"count": 443.0,
+		}, nil, secrets)
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]any{
+			"echo":  "token [secret:slack_token] for [secret:google_oauth2.client_id]",
+			"count": 443.0,
+		}, result)
+
+		_, err = RedactSecrets(nil, errors.New("request to https://example.com/?key=xyz789 failed"), secrets)
+		assert.EqualError(t, err, "request to https://example.com/?key=[secret:google_oauth2.client_secret] failed")
+
+		ctx := withSecretValues(context.Background(), secrets)
+		assert.Equal(t, "sent [secret:slack_token]", redactSecretText(ctx, "sent xoxb-123"))
+		assert.Equal(t, "sent xoxb-123", redactSecretText(context.Background(), "sent xoxb-123"))
+	})
+}
+
+func TestMakeHttpRequestWithSecrets(t *testing.T) {
+
+	tokenServer := newTokenServer(t, "abc", "xyz")
+
+	var authorization string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"key": %q}`, r.URL.Query().Get("key"))
+	}))
+	t.Cleanup(server.Close)
+
+	secrets := map[string]any{
+		"google_oauth2": map[string]any{"client_id": "abc", "client_secret": "xyz"},
+		"api_key":       "k3y-value",
+	}
+
+	httpCall := model.HTTPArguments{
+		Method: "get",
+		Endpoint: &model.Endpoint{
+			EndpointConfig: &model.EndpointConfiguration{
+				URI: &model.LiteralUri{Value: server.URL},
+				Authentication: &model.ReferenceableAuthenticationPolicy{
+					AuthenticationPolicy: &model.AuthenticationPolicy{OAuth2: &model.OAuth2AuthenticationPolicy{
+						Properties: &model.OAuth2AuthenticationProperties{
+							Authority: &model.LiteralUri{Value: tokenServer.URL + "/o/oauth2"},
+							Grant:     model.ClientCredentialsGrant,
+							Client: &model.OAuth2AutenthicationDataClient{
+								ID:     "${ $secrets.google_oauth2.client_id }",
+								Secret: "${ $secrets.google_oauth2.client_secret }",
+							},
+						},
+						Endpoints: &model.OAuth2Endpoints{Token: "/token"},
+					}},
+				},
+			},
+		},
+	}
+
+	// The URL was built by an expression so it holds the placeholder
+	result, err := MakeHttpRequestWithSecrets(context.Background(), httpCall, server.URL+"/?key=[secret:api_key]", secrets)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer token-", authorization)
+	assert.Equal(t, map[string]any{"key": "[secret:api_key]"}, result)
+}
@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/sirupsen/logrus"
+	"github.com/thand-io/agent/internal/common"
+	"go.temporal.io/sdk/workflow"
+)
+
+// getTimeout resolves an inline timeout or a reference to one of the
+// workflow's reusable timeouts. A zero duration means no timeout.
+func (r *ResumableWorkflowRunner) getTimeout(timeout *model.TimeoutOrReference) (time.Duration, error) {
+
+	if timeout == nil {
+		return 0, nil
+	}
+
+	var after *model.Duration
+
+	if timeout.Timeout != nil {
+
+		after = timeout.Timeout.After
+
+	} else if timeout.Reference != nil {
+
+		workflowDef := r.GetWorkflow()
+
+		if workflowDef.Use == nil || workflowDef.Use.Timeouts == nil {
+			return 0, fmt.Errorf("timeout %s is not defined in use.timeouts", *timeout.Reference)
+		}
+
+		reusable, found := workflowDef.Use.Timeouts[*timeout.Reference]
+
+		if !found || reusable == nil {
+			return 0, fmt.Errorf("timeout %s is not defined in use.timeouts", *timeout.Reference)
+		}
+
+		after = reusable.After
+	}
+
+	if after == nil {
+		return 0, fmt.Errorf("timeout requires 'after'")
+	}
+
+	duration, err := common.ParseWorkflowDuration(after)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse timeout: %w", err)
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("timeout must be positive")
+	}
+
+	return duration, nil
+}
+
+// runWithTimeout runs the task at the reference, or the workflow for "/",
+// failing it with a timeout error if it takes longer than the timeout.
+//
+// Temporal workflows cancel the task's context on a durable timer, which
+// stops its activities, waits and listens. Stateless workflows cancel the
+// task's context when the deadline passes and record the deadline so it
+// still applies when a suspended workflow is resumed. A listen that is
+// resumed after its deadline fails without processing the event.
+func (r *ResumableWorkflowRunner) runWithTimeout(
+	reference string,
+	timeout time.Duration,
+	run func() (any, error),
+) (any, error) {
+
+	if timeout <= 0 {
+		return run()
+	}
+
+	workflowTask := r.GetWorkflowTask()
+
+	if workflowTask.HasTemporalContext() {
+		return r.runWithTemporalTimeout(reference, timeout, run)
+	}
+
+	deadline := workflowTask.GetDeadline(reference)
+
+	if deadline == nil {
+		expires := time.Now().UTC().Add(timeout)
+		workflowTask.SetDeadline(reference, expires)
+		deadline = &expires
+	}
+
+	if !time.Now().Before(*deadline) {
+		workflowTask.ClearDeadline(reference)
+		return nil, newTimeoutError(reference, timeout)
+	}
+
+	parentCtx := workflowTask.GetContext()
+
+	timeoutCtx, cancel := context.WithDeadline(parentCtx, *deadline)
+	defer cancel()
+
+	workflowTask.SetInternalContext(timeoutCtx)
+	output, err := run()
+	workflowTask.SetInternalContext(parentCtx)
+
+	// Keep the deadline while the workflow is suspended so it is
+	// enforced when the workflow resumes
+	if errors.Is(err, ErrorAwaitSignal) && timeoutCtx.Err() == nil {
+		return output, err
+	}
+
+	workflowTask.ClearDeadline(reference)
+
+	if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		return nil, newTimeoutError(reference, timeout)
+	}
+
+	return output, err
+}
+
+func (r *ResumableWorkflowRunner) runWithTemporalTimeout(
+	reference string,
+	timeout time.Duration,
+	run func() (any, error),
+) (any, error) {
+
+	workflowTask := r.GetWorkflowTask()
+
+	internalCtx := workflowTask.GetContext()
+
+	timeoutCtx, cancel := workflow.WithCancel(workflowTask.GetTemporalContext())
+	defer cancel()
+
+	timedOut := false
+
+	workflow.Go(timeoutCtx, func(ctx workflow.Context) {
+
+		// The timer is cancelled along with the context once the task finishes
+		if err := workflow.NewTimer(ctx, timeout).Get(ctx, nil); err == nil {
+			timedOut = true
+			cancel()
+		}
+	})
+
+	workflowTask.WithTemporalContext(timeoutCtx)
+	output, err := run()
+	workflowTask.SetInternalContext(internalCtx)
+
+	if timedOut {
+		return nil, newTimeoutError(reference, timeout)
+	}
+
+	return output, err
+}
+
+func newTimeoutError(reference string, timeout time.Duration) *model.Error {
+
+	logrus.WithFields(logrus.Fields{
+		"task":    reference,
+		"timeout": timeout,
+	}).Warn("Task timed out")
+
+	return model.NewErrTimeout(fmt.Errorf("timed out after %s", timeout), reference)
+}
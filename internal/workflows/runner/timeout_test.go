@@ -0,0 +1,110 @@
+package runner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
+	"github.com/serverlessworkflow/sdk-go/v3/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yamlSig "sigs.k8s.io/yaml"
+)
+
+// loadUnvalidatedTestWorkflow loads a workflow the way configured workflows
+// are loaded. The SDK's validator rejects timeout references.
+func loadUnvalidatedTestWorkflow(t *testing.T, workflowPath string) *model.Workflow {
+	yamlBytes, err := os.ReadFile(filepath.Clean(workflowPath))
+	require.NoError(t, err, "Failed to read workflow YAML file")
+
+	var workflow model.Workflow
+	require.NoError(t, yamlSig.Unmarshal(yamlBytes, &workflow), "Failed to parse workflow YAML")
+
+	return &workflow
+}
+
+func TestTaskTimeoutCaught(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(5 * time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	runner, err := NewDefaultRunner(loadTestWorkflow(t, "./testdata/timeout_task.yaml"))
+	require.NoError(t, err)
+
+	started := time.Now()
+
+	output, err := runner.Run(map[string]any{"endpoint": server.URL})
+	require.NoError(t, err)
+
+	assert.Less(t, time.Since(started), 5*time.Second)
+	assert.Equal(t, map[string]any{"status": "timed out"}, output)
+	assert.Empty(t, runner.GetWorkflowTask().Deadlines)
+}
+
+func TestListenTimeout(t *testing.T) {
+
+	approval := newTestTicketEvent(t, "com.example.approval", map[string]any{"approved": true})
+
+	t.Run("answered in time", func(t *testing.T) {
+
+		runner, err := NewDefaultRunner(loadUnvalidatedTestWorkflow(t, "./testdata/timeout_listen.yaml"))
+		require.NoError(t, err)
+
+		workflowTask := runner.GetWorkflowTask()
+
+		_, err = runner.Run(nil)
+		require.NoError(t, err)
+		assert.Equal(t, swctx.WaitingStatus, workflowTask.GetStatus())
+
+		// The reusable timeout is recorded against the listen
+		deadline := workflowTask.GetDeadline("/do/0/approval")
+		require.NotNil(t, deadline)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *deadline, time.Minute)
+		assert.Equal(t, deadline, workflowTask.GetNextDeadline())
+
+		output, err := runner.Run(approval)
+		require.NoError(t, err)
+		assert.Equal(t, swctx.CompletedStatus, workflowTask.GetStatus())
+		assert.Equal(t, map[string]any{"status": "approved"}, output)
+		assert.Nil(t, workflowTask.GetNextDeadline())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+
+		runner, err := NewDefaultRunner(loadUnvalidatedTestWorkflow(t, "./testdata/timeout_listen.yaml"))
+		require.NoError(t, err)
+
+		workflowTask := runner.GetWorkflowTask()
+
+		_, err = runner.Run(nil)
+		require.NoError(t, err)
+
+		workflowTask.SetDeadline("/do/0/approval", time.Now().Add(-time.Second))
+
+		// An approval that arrives after the deadline is not processed
+		_, err = runner.Run(approval)
+		require.Error(t, err)
+		assert.True(t, model.IsErrTimeout(err))
+		assert.Equal(t, swctx.FaultedStatus, workflowTask.GetStatus())
+		assert.Nil(t, workflowTask.GetNextDeadline())
+	})
+
+	t.Run("undefined reference", func(t *testing.T) {
+
+		runner, err := NewDefaultRunner(loadUnvalidatedTestWorkflow(t, "./testdata/timeout_undefined.yaml"))
+		require.NoError(t, err)
+
+		_, err = runner.Run(nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timeout missing is not defined in use.timeouts")
+	})
+}
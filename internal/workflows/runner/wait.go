@@ -60,6 +60,16 @@ func (r *ResumableWorkflowRunner) executeWaitTask(
 
 		duration = du
 
+	case *model.Duration:
+
+		du, err := common.ValidateDuration(v.AsExpression())
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse wait duration: %w", err)
+		}
+
+		duration = du
+
 	case model.Duration:
 
 		du, err := common.ValidateDuration(v.AsExpression())
@@ -86,6 +96,31 @@ func (r *ResumableWorkflowRunner) executeWaitTask(
 			return nil, fmt.Errorf("failed to sleep workflow: %w", err)
 		}
 
+	} else if durable := r.config.GetWorkflows().Durable; durable.Enabled && duration >= durable.MinWait {
+
+		// Long waits suspend the workflow so the wait survives a restart.
+		// The workflow is resumed from this task once the timer expires.
+		wakeAt := workflowTask.GetWakeAt()
+
+		if wakeAt == nil {
+
+			resumeAt := time.Now().UTC().Add(duration)
+			workflowTask.SetWakeAt(&resumeAt)
+
+			logrus.WithFields(logrus.Fields{
+				"task":    taskName,
+				"wake_at": resumeAt,
+			}).Info("Suspending workflow for durable wait")
+
+			return nil, ErrorAwaitSignal
+
+		} else if time.Now().Before(*wakeAt) {
+
+			return nil, ErrorAwaitSignal
+		}
+
+		workflowTask.SetWakeAt(nil)
+
 	} else {
 
 		logrus.WithFields(logrus.Fields{
@@ -97,7 +132,12 @@ func (r *ResumableWorkflowRunner) executeWaitTask(
 			return nil, fmt.Errorf("cannot wait for more than 1 minute in ephemeral mode")
 		}
 
-		time.Sleep(duration)
+		// Stop waiting if the task times out
+		select {
+		case <-time.After(duration):
+		case <-r.GetContext().Done():
+			return nil, r.GetContext().Err()
+		}
 	}
 
 	logrus.WithFields(logrus.Fields{
@@ -0,0 +1,49 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	swctx "github.com/serverlessworkflow/sdk-go/v3/impl/ctx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurableWait(t *testing.T) {
+
+	runner, err := NewDefaultRunner(loadTestWorkflow(t, "./testdata/wait_durable.yaml"))
+	require.NoError(t, err)
+
+	runner.config.Workflows.Durable.Enabled = true
+	runner.config.Workflows.Durable.MinWait = time.Minute
+
+	workflowTask := runner.GetWorkflowTask()
+
+	output, err := runner.Run(map[string]any{})
+	require.NoError(t, err)
+	assert.Nil(t, output)
+
+	// The workflow is suspended on the wait rather than sleeping
+	assert.Equal(t, swctx.WaitingStatus, workflowTask.GetStatus())
+	assert.Equal(t, "pause", workflowTask.GetEntrypoint())
+
+	wakeAt := workflowTask.GetWakeAt()
+	require.NotNil(t, wakeAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *wakeAt, time.Minute)
+
+	// Resuming before the timer expires suspends it again
+	_, err = runner.Run(map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, swctx.WaitingStatus, workflowTask.GetStatus())
+	assert.Equal(t, wakeAt, workflowTask.GetWakeAt())
+
+	// Once it has expired the workflow carries on from the wait
+	expired := time.Now().Add(-time.Second)
+	workflowTask.SetWakeAt(&expired)
+
+	output, err = runner.Run(map[string]any{})
+	require.NoError(t, err)
+	assert.Equal(t, swctx.CompletedStatus, workflowTask.GetStatus())
+	assert.Equal(t, map[string]any{"step": "finished"}, output)
+	assert.Nil(t, workflowTask.GetWakeAt())
+}
@@ -5,9 +5,13 @@ import (
 	"os"
 
 	"github.com/thand-io/agent/cmd/cli"
+	"github.com/thand-io/agent/internal/workflows/runner"
 )
 
 func main() {
+	// Scripts are run by re-executing the binary as a sandbox
+	runner.RunScriptSandbox()
+
 	if err := cli.GetCommandOptions().Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)